@@ -65,6 +65,20 @@ resource "aws_dynamodb_table" "mfl_free_agents" {
     projection_type = "ALL" # Include all attributes in the index
   }
 
+  attribute {
+    name = "DefSnapPctSlope3"
+    type = "N"
+  }
+
+  # Available defenders at a position ordered by snap trend (sparse: only players
+  # matched to a players-table item carry DefSnapPctSlope3)
+  global_secondary_index {
+    name            = "PositionBySlope3"
+    hash_key        = "position"
+    range_key       = "DefSnapPctSlope3"
+    projection_type = "ALL"
+  }
+
   # Tags for resource identification
   tags = {
     Environment = "Dev"
@@ -72,6 +86,32 @@ resource "aws_dynamodb_table" "mfl_free_agents" {
   }
 }
 
+# MFL -> PFR player crosswalk. Rows with Method = "override" are manual and never
+# rewritten by the crosswalk job; unmatched rows carry a Reason and no PFRID.
+resource "aws_dynamodb_table" "mfl_pfr_crosswalk" {
+  name         = "mfl_pfr_crosswalk"
+  billing_mode = "PAY_PER_REQUEST"
+  hash_key     = "MFLID"
+
+  attribute {
+    name = "MFLID"
+    type = "S"
+  }
+  attribute {
+    name = "PFRID"
+    type = "S"
+  }
+
+  # Reverse lookup (sparse: matched rows only)
+  global_secondary_index {
+    name            = "PFRIDIndex"
+    hash_key        = "PFRID"
+    projection_type = "ALL"
+  }
+
+  tags = { app = "pfr-weekly" }
+}
+
 resource "aws_dynamodb_table" "nfl_roster_rows" {
   name         = "nfl_roster_rows"
   billing_mode = "PAY_PER_REQUEST"
@@ -111,6 +151,10 @@ resource "aws_dynamodb_table" "defensive_snaps_by_game" {
   hash_key  = "SeasonTeamWeek"
   range_key = "PlayerID"
 
+  # Feeds the snaps-stream Lambda, which recomputes trends for changed players
+  stream_enabled   = true
+  stream_view_type = "NEW_AND_OLD_IMAGES"
+
   attribute {
     name = "SeasonTeamWeek"
     type = "S"
@@ -157,4 +201,102 @@ resource "aws_dynamodb_table" "defensive_starters_allgames" {
   }
 
   tags = { Project = "fantasy-football-backends" }
-}
\ No newline at end of file
+}
+# Writes that stayed unprocessed after BatchWriter retries (store.TableDeadLetter)
+resource "aws_dynamodb_table" "write_dead_letters" {
+  name         = "write_dead_letters"
+  billing_mode = "PAY_PER_REQUEST"
+  hash_key     = "SourceTable"
+  range_key    = "FailedKey" # "<unix>#<key attrs>#<hash>"
+
+  attribute {
+    name = "SourceTable"
+    type = "S"
+  }
+  attribute {
+    name = "FailedKey"
+    type = "S"
+  }
+
+  ttl {
+    attribute_name = "ExpiresAt"
+    enabled        = true
+  }
+
+  tags = { Project = "fantasy-football-backends" }
+}
+
+# Per-team progress for long Lambda runs (store.TableCheckpoints)
+resource "aws_dynamodb_table" "pipeline_checkpoints" {
+  name         = "pipeline_checkpoints"
+  billing_mode = "PAY_PER_REQUEST"
+  hash_key     = "Job"
+
+  attribute {
+    name = "Job"
+    type = "S"
+  }
+
+  ttl {
+    attribute_name = "ExpiresAt"
+    enabled        = true
+  }
+
+  tags = { Project = "fantasy-football-backends" }
+}
+
+# Role-change flags (internal/alerts) per player and week, read by the waiver workflow
+resource "aws_dynamodb_table" "defensive_role_alerts" {
+  name         = "defensive_role_alerts"
+  billing_mode = "PAY_PER_REQUEST"
+  hash_key     = "Season"
+  range_key    = "AlertKey" # "07#AdauJa00#SEA#starter_jump"
+
+  attribute {
+    name = "Season"
+    type = "S"
+  }
+  attribute {
+    name = "AlertKey"
+    type = "S"
+  }
+  attribute {
+    name = "PlayerID"
+    type = "S"
+  }
+
+  global_secondary_index {
+    name            = "PlayerAlerts"
+    hash_key        = "PlayerID"
+    range_key       = "AlertKey"
+    projection_type = "ALL"
+  }
+
+  tags = { Project = "fantasy-football-backends" }
+}
+
+# Weekly snap and IDP point projections with intervals (pfr-snaps mode project_idp)
+resource "aws_dynamodb_table" "defensive_projections" {
+  name         = "defensive_projections"
+  billing_mode = "PAY_PER_REQUEST"
+  hash_key     = "SeasonWeek" # "2024#05"
+  range_key    = "PlayerID"
+
+  attribute {
+    name = "SeasonWeek"
+    type = "S"
+  }
+  attribute {
+    name = "PlayerID"
+    type = "S"
+  }
+
+  global_secondary_index {
+    name            = "PlayerProjections"
+    hash_key        = "PlayerID"
+    range_key       = "SeasonWeek"
+    projection_type = "ALL"
+  }
+
+  tags = { Project = "fantasy-football-backends" }
+}
@@ -11,10 +11,43 @@
 #   arn  = aws_lambda_function.player_scraper.arn
 # }
 
-# resource "aws_cloudwatch_event_target" "free_agent_scraper_lambda_target" {
-#   rule = aws_cloudwatch_event_rule.nightly_trigger.name
-#   arn  = aws_lambda_function.free_agent_scraper.arn
-# }
+# Free-agent pool: daily, after waivers typically process
+resource "aws_cloudwatch_event_rule" "mfl_free_agents" {
+  name                = "mfl-free-agents-daily"
+  schedule_expression = "cron(0 12 * * ? *)"
+}
+resource "aws_cloudwatch_event_target" "mfl_free_agents_target" {
+  rule      = aws_cloudwatch_event_rule.mfl_free_agents.name
+  target_id = "mfl-free-agents"
+  arn       = aws_lambda_function.mfl_free_agents.arn
+}
+resource "aws_lambda_permission" "mfl_free_agents_invoke" {
+  statement_id  = "AllowFreeAgentsInvoke"
+  action        = "lambda:InvokeFunction"
+  function_name = aws_lambda_function.mfl_free_agents.function_name
+  principal     = "events.amazonaws.com"
+  source_arn    = aws_cloudwatch_event_rule.mfl_free_agents.arn
+}
+
+# Full crosswalk rebuild weekly; the daily free-agent run only matches new players
+resource "aws_cloudwatch_event_rule" "mfl_crosswalk" {
+  name                = "mfl-crosswalk-weekly"
+  schedule_expression = "cron(0 11 ? * MON *)"
+}
+resource "aws_cloudwatch_event_target" "mfl_crosswalk_target" {
+  rule      = aws_cloudwatch_event_rule.mfl_crosswalk.name
+  target_id = "mfl-crosswalk"
+  arn       = aws_lambda_function.mfl_free_agents.arn
+  input     = jsonencode({ mode = "crosswalk" })
+}
+resource "aws_lambda_permission" "mfl_crosswalk_invoke" {
+  statement_id  = "AllowCrosswalkInvoke"
+  action        = "lambda:InvokeFunction"
+  function_name = aws_lambda_function.mfl_free_agents.function_name
+  principal     = "events.amazonaws.com"
+  source_arn    = aws_cloudwatch_event_rule.mfl_crosswalk.arn
+}
+
 # EventBridge schedule -> Fetcher
 resource "aws_cloudwatch_event_rule" "weekly" {
   name                = "pfr-weekly-${var.season}"
@@ -57,16 +90,104 @@ resource "aws_cloudwatch_event_target" "snaps_chunk1_target" {
   input     = jsonencode({ mode = "ingest_snaps_by_game", season = "2024", team_chunk_total = 2, team_chunk_index = 1 })
 }
 
-resource "aws_cloudwatch_event_rule" "snaps_trends" {
-  name                = "snaps-trends-weekly"
-  schedule_expression = "cron(25 15 ? * TUE *)"
+# IDP points after both snap chunks; set SCORING_RULES(_FILE) on the Lambda for league rules.
+resource "aws_cloudwatch_event_rule" "score_idp" {
+  name                = "score-idp-weekly"
+  schedule_expression = "cron(30 15 ? * TUE *)"
+}
+resource "aws_cloudwatch_event_target" "score_idp_target" {
+  rule      = aws_cloudwatch_event_rule.score_idp.name
+  target_id = "score-idp"
+  arn       = aws_lambda_function.pfr_snaps_2024.arn
+  input     = jsonencode({ mode = "score_idp", season = "2024" })
+}
+resource "aws_lambda_permission" "score_idp_invoke" {
+  statement_id  = "AllowScoreIDPInvoke"
+  action        = "lambda:InvokeFunction"
+  function_name = aws_lambda_function.pfr_snaps_2024.function_name
+  principal     = "events.amazonaws.com"
+  source_arn    = aws_cloudwatch_event_rule.score_idp.arn
+}
+
+# Next week's projections once points are scored
+resource "aws_cloudwatch_event_rule" "project_idp" {
+  name                = "project-idp-weekly"
+  schedule_expression = "cron(45 15 ? * TUE *)"
+}
+resource "aws_cloudwatch_event_target" "project_idp_target" {
+  rule      = aws_cloudwatch_event_rule.project_idp.name
+  target_id = "project-idp"
+  arn       = aws_lambda_function.pfr_snaps_2024.arn
+  input     = jsonencode({ mode = "project_idp", season = "2024" })
+}
+resource "aws_lambda_permission" "project_idp_invoke" {
+  statement_id  = "AllowProjectIDPInvoke"
+  action        = "lambda:InvokeFunction"
+  function_name = aws_lambda_function.pfr_snaps_2024.function_name
+  principal     = "events.amazonaws.com"
+  source_arn    = aws_cloudwatch_event_rule.project_idp.arn
+}
+
+# Dynasty age curves refit once a season, after the playoffs are in the backfill
+resource "aws_cloudwatch_event_rule" "dynasty" {
+  name                = "dynasty-yearly"
+  schedule_expression = "cron(0 16 20 2 ? *)" # Feb 20 16:00 UTC
 }
-resource "aws_cloudwatch_event_target" "snaps_trends_target" {
-  rule      = aws_cloudwatch_event_rule.snaps_trends.name
-  target_id = "snapstrends"
+resource "aws_cloudwatch_event_target" "dynasty_target" {
+  rule      = aws_cloudwatch_event_rule.dynasty.name
+  target_id = "dynasty"
   arn       = aws_lambda_function.pfr_snaps_2024.arn
-  input     = jsonencode({ mode = "materialize_snap_trends", season = "2024" })
+  input     = jsonencode({ mode = "dynasty", season = "2024" })
+}
+resource "aws_lambda_permission" "dynasty_invoke" {
+  statement_id  = "AllowDynastyInvoke"
+  action        = "lambda:InvokeFunction"
+  function_name = aws_lambda_function.pfr_snaps_2024.function_name
+  principal     = "events.amazonaws.com"
+  source_arn    = aws_cloudwatch_event_rule.dynasty.arn
+}
+
+# Waiver report once snaps, points and the free-agent pool are fresh
+resource "aws_cloudwatch_event_rule" "waiver_report" {
+  name                = "waiver-report-weekly"
+  schedule_expression = "cron(0 16 ? * TUE *)"
 }
+resource "aws_cloudwatch_event_target" "waiver_report_target" {
+  rule      = aws_cloudwatch_event_rule.waiver_report.name
+  target_id = "waiver-report"
+  arn       = aws_lambda_function.waiver_report.arn
+}
+resource "aws_lambda_permission" "waiver_report_invoke" {
+  statement_id  = "AllowWaiverReportInvoke"
+  action        = "lambda:InvokeFunction"
+  function_name = aws_lambda_function.waiver_report.function_name
+  principal     = "events.amazonaws.com"
+  source_arn    = aws_cloudwatch_event_rule.waiver_report.arn
+}
+
+# Lineup before the weekend slate, once Friday injury designations are in
+resource "aws_cloudwatch_event_rule" "lineup" {
+  name                = "lineup-weekly"
+  schedule_expression = "cron(0 15 ? * SAT *)"
+}
+resource "aws_cloudwatch_event_target" "lineup_target" {
+  rule      = aws_cloudwatch_event_rule.lineup.name
+  target_id = "lineup"
+  arn       = aws_lambda_function.waiver_report.arn
+  input     = jsonencode({ mode = "lineup" })
+}
+resource "aws_lambda_permission" "lineup_invoke" {
+  statement_id  = "AllowLineupInvoke"
+  action        = "lambda:InvokeFunction"
+  function_name = aws_lambda_function.waiver_report.function_name
+  principal     = "events.amazonaws.com"
+  source_arn    = aws_cloudwatch_event_rule.lineup.arn
+}
+
+# Trends are no longer on a cron: the snaps-stream Lambda (lambda.tf) recomputes them
+# from the defensive_snaps_by_game stream as soon as the ingest chunks write rows.
+# A full recompute is still available by invoking pfr-snaps with
+# { mode = "materialize_snap_trends" }.
 
 # Weekly ingestion: Monday 03:00 UTC
 resource "aws_cloudwatch_event_rule" "curator_weekly" {
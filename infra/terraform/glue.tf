@@ -69,7 +69,8 @@ resource "aws_glue_crawler" "curated" {
   database_name = aws_glue_catalog_database.curated.name
 
   s3_target {
-    path = "s3://${aws_s3_bucket.curated.id}/${local.curated.prefix}"
+    path       = "s3://${aws_s3_bucket.curated.id}/${local.curated.prefix}"
+    exclusions = ["_manifests/**"] # symlink manifests, not data
   }
 
   # Only keys supported by the JSON "configuration" go here.
@@ -26,6 +26,8 @@ resource "aws_lambda_function" "pfr_weekly" {
       S3_BUCKET  = aws_s3_bucket.pfr.id
       S3_PREFIX  = "pfr"
       DEBUG      = "1" # Set to "1" to enable debug logging
+
+      DEAD_LETTER_TABLE = aws_dynamodb_table.write_dead_letters.name
     }
   }
 }
@@ -70,7 +72,8 @@ data "aws_iam_policy_document" "pfr_inline" {
       "dynamodb:PutItem"
     ]
     resources = [
-      aws_dynamodb_table.defensive_players_by_team.arn
+      aws_dynamodb_table.defensive_players_by_team.arn,
+      aws_dynamodb_table.write_dead_letters.arn
     ]
   }
 
@@ -117,6 +120,15 @@ resource "aws_lambda_function" "pfr_snaps_2024" {
       PASS_MAX               = "3"
       SHUFFLE_TEAMS          = "1"
       DEBUG                  = "1"
+      DEAD_LETTER_TABLE      = aws_dynamodb_table.write_dead_letters.name
+      CHECKPOINT_TABLE       = aws_dynamodb_table.pipeline_checkpoints.name
+      CHECKPOINT_MARGIN_SEC  = "30"
+      ALERTS_TABLE_NAME      = aws_dynamodb_table.defensive_role_alerts.name
+      PROJECTIONS_TABLE_NAME = aws_dynamodb_table.defensive_projections.name
+      REPORT_BUCKET          = aws_s3_bucket.curated.bucket
+      REPORT_PREFIX          = "reports/backtest"
+      ROSTER_TABLE_NAME      = aws_dynamodb_table.nfl_roster_rows.name
+      DYNASTY_PREFIX         = "reports/dynasty"
     }
   }
 }
@@ -137,12 +149,26 @@ data "aws_iam_policy_document" "pfr_snaps_ddb" {
     ]
     resources = [
       aws_dynamodb_table.defensive_snaps_by_game.arn,
+      "${aws_dynamodb_table.defensive_snaps_by_game.arn}/index/*",
       aws_dynamodb_table.defensive_players_by_team.arn,
       "${aws_dynamodb_table.defensive_players_by_team.arn}/index/*",
       aws_dynamodb_table.defensive_starters_allgames.arn,
       aws_dynamodb_table.nfl_roster_rows.arn,
+      aws_dynamodb_table.write_dead_letters.arn,
+      aws_dynamodb_table.defensive_role_alerts.arn,
+      "${aws_dynamodb_table.defensive_role_alerts.arn}/index/*",
+      aws_dynamodb_table.defensive_projections.arn,
     ]
   }
+  statement {
+    actions   = ["dynamodb:GetItem", "dynamodb:PutItem", "dynamodb:DeleteItem"]
+    resources = [aws_dynamodb_table.pipeline_checkpoints.arn]
+  }
+  # Backtest reports (mode backtest, invoked on demand) and dynasty reports
+  statement {
+    actions   = ["s3:PutObject"]
+    resources = ["${aws_s3_bucket.curated.arn}/reports/backtest/*", "${aws_s3_bucket.curated.arn}/reports/dynasty/*"]
+  }
   # CloudWatch logs
   statement {
     actions   = ["logs:CreateLogGroup", "logs:CreateLogStream", "logs:PutLogEvents"]
@@ -160,6 +186,201 @@ resource "aws_iam_role_policy_attachment" "pfr_snaps_ddb_attach" {
   policy_arn = aws_iam_policy.pfr_snaps_ddb.arn
 }
 
+# --- snaps-stream (DynamoDB Streams consumer -> trend recompute) ---
+resource "aws_lambda_function" "snaps_stream" {
+  function_name = "snaps-stream"
+  role          = aws_iam_role.snaps_stream_role.arn
+  handler       = "bootstrap"
+  runtime       = "provided.al2023"
+  filename      = "${local.artifacts_dir}/snaps-stream.zip"
+  memory_size   = 256
+  timeout       = 300
+  architectures = ["x86_64"]
+  environment {
+    variables = {
+      SNAP_TABLE_NAME   = aws_dynamodb_table.defensive_snaps_by_game.name
+      TABLE_NAME        = aws_dynamodb_table.defensive_players_by_team.name
+      ALERTS_TABLE_NAME = aws_dynamodb_table.defensive_role_alerts.name
+      DEBUG             = "1"
+    }
+  }
+}
+
+resource "aws_iam_role" "snaps_stream_role" {
+  name               = "snaps-stream-role"
+  assume_role_policy = data.aws_iam_policy_document.lambda_assume.json
+}
+
+data "aws_iam_policy_document" "snaps_stream" {
+  statement {
+    actions = [
+      "dynamodb:DescribeStream",
+      "dynamodb:GetRecords",
+      "dynamodb:GetShardIterator",
+      "dynamodb:ListStreams"
+    ]
+    resources = [aws_dynamodb_table.defensive_snaps_by_game.stream_arn]
+  }
+  statement {
+    actions = ["dynamodb:Query", "dynamodb:UpdateItem"]
+    resources = [
+      "${aws_dynamodb_table.defensive_snaps_by_game.arn}/index/*",
+      aws_dynamodb_table.defensive_players_by_team.arn,
+      "${aws_dynamodb_table.defensive_players_by_team.arn}/index/*",
+    ]
+  }
+  statement {
+    actions   = ["dynamodb:BatchWriteItem"]
+    resources = [aws_dynamodb_table.defensive_role_alerts.arn]
+  }
+  statement {
+    actions   = ["dynamodb:Query"]
+    resources = ["${aws_dynamodb_table.defensive_role_alerts.arn}/index/PlayerAlerts"]
+  }
+  statement {
+    actions   = ["logs:CreateLogGroup", "logs:CreateLogStream", "logs:PutLogEvents"]
+    resources = ["*"]
+  }
+}
+
+resource "aws_iam_role_policy" "snaps_stream" {
+  name   = "snaps-stream-inline"
+  role   = aws_iam_role.snaps_stream_role.id
+  policy = data.aws_iam_policy_document.snaps_stream.json
+}
+
+# A one-minute batching window coalesces an ingest chunk's writes so each player is
+# recomputed once per chunk rather than once per game row.
+resource "aws_lambda_event_source_mapping" "snaps_stream" {
+  event_source_arn                   = aws_dynamodb_table.defensive_snaps_by_game.stream_arn
+  function_name                      = aws_lambda_function.snaps_stream.arn
+  starting_position                  = "LATEST"
+  batch_size                         = 500
+  maximum_batching_window_in_seconds = 60
+  maximum_retry_attempts             = 5
+  bisect_batch_on_function_error     = true
+  function_response_types            = ["ReportBatchItemFailures"]
+}
+
+# --- mfl-free-agents: MFL free-agent pool joined with snap trends ---
+resource "aws_lambda_function" "mfl_free_agents" {
+  function_name = "mfl-free-agents"
+  role          = aws_iam_role.mfl_free_agents_role.arn
+  handler       = "bootstrap"
+  runtime       = "provided.al2023"
+  filename      = "${local.artifacts_dir}/mfl-free-agents.zip"
+  memory_size   = 512
+  timeout       = 300
+  architectures = ["x86_64"]
+  environment {
+    variables = {
+      MFL_SECRET_ID          = module.secrets-manager.secret_arn
+      FREE_AGENTS_TABLE_NAME = aws_dynamodb_table.mfl_free_agents.name
+      CROSSWALK_TABLE_NAME   = aws_dynamodb_table.mfl_pfr_crosswalk.name
+      TABLE_NAME             = aws_dynamodb_table.defensive_players_by_team.name
+      SEASON                 = var.season
+      FA_POSITIONS           = "DE,DT,LB,CB,S"
+      DEAD_LETTER_TABLE      = aws_dynamodb_table.write_dead_letters.name
+      DEBUG                  = "1"
+    }
+  }
+}
+
+resource "aws_iam_role" "mfl_free_agents_role" {
+  name               = "mfl-free-agents-role"
+  assume_role_policy = data.aws_iam_policy_document.lambda_assume.json
+}
+
+data "aws_iam_policy_document" "mfl_free_agents" {
+  statement {
+    actions   = ["secretsmanager:GetSecretValue"]
+    resources = [module.secrets-manager.secret_arn]
+  }
+  statement {
+    actions   = ["dynamodb:Scan", "dynamodb:BatchWriteItem"]
+    resources = [aws_dynamodb_table.mfl_free_agents.arn, aws_dynamodb_table.mfl_pfr_crosswalk.arn]
+  }
+  statement {
+    actions   = ["dynamodb:Query"]
+    resources = ["${aws_dynamodb_table.defensive_players_by_team.arn}/index/*"]
+  }
+  statement {
+    actions   = ["dynamodb:BatchWriteItem"]
+    resources = [aws_dynamodb_table.write_dead_letters.arn]
+  }
+  statement {
+    actions   = ["logs:CreateLogGroup", "logs:CreateLogStream", "logs:PutLogEvents"]
+    resources = ["*"]
+  }
+}
+
+resource "aws_iam_role_policy" "mfl_free_agents" {
+  name   = "mfl-free-agents-inline"
+  role   = aws_iam_role.mfl_free_agents_role.id
+  policy = data.aws_iam_policy_document.mfl_free_agents.json
+}
+
+# --- waiver-report: weekly ranked free agents and lineup (JSON + Markdown to S3) ---
+resource "aws_lambda_function" "waiver_report" {
+  function_name = "waiver-report"
+  role          = aws_iam_role.waiver_report_role.arn
+  handler       = "bootstrap"
+  runtime       = "provided.al2023"
+  filename      = "${local.artifacts_dir}/waiver-report.zip"
+  memory_size   = 512
+  timeout       = 120
+  architectures = ["x86_64"]
+  environment {
+    variables = {
+      MFL_SECRET_ID          = module.secrets-manager.secret_arn
+      FREE_AGENTS_TABLE_NAME = aws_dynamodb_table.mfl_free_agents.name
+      CROSSWALK_TABLE_NAME   = aws_dynamodb_table.mfl_pfr_crosswalk.name
+      TABLE_NAME             = aws_dynamodb_table.defensive_players_by_team.name
+      SEASON                 = var.season
+      REPORT_BUCKET          = aws_s3_bucket.curated.bucket
+      REPORT_PREFIX          = "reports/waiver"
+      LINEUP_PREFIX          = "reports/lineup"
+      TRADE_PREFIX           = "reports/trade"
+      PROJECTIONS_TABLE_NAME = aws_dynamodb_table.defensive_projections.name
+      WAIVER_TOP             = "25"
+    }
+  }
+}
+
+resource "aws_iam_role" "waiver_report_role" {
+  name               = "waiver-report-role"
+  assume_role_policy = data.aws_iam_policy_document.lambda_assume.json
+}
+
+data "aws_iam_policy_document" "waiver_report" {
+  statement {
+    actions   = ["secretsmanager:GetSecretValue"]
+    resources = [module.secrets-manager.secret_arn]
+  }
+  statement {
+    actions   = ["dynamodb:Scan"]
+    resources = [aws_dynamodb_table.mfl_free_agents.arn, aws_dynamodb_table.mfl_pfr_crosswalk.arn]
+  }
+  statement {
+    actions   = ["dynamodb:Query"]
+    resources = ["${aws_dynamodb_table.defensive_players_by_team.arn}/index/*", aws_dynamodb_table.defensive_projections.arn]
+  }
+  statement {
+    actions   = ["s3:PutObject"]
+    resources = ["${aws_s3_bucket.curated.arn}/reports/waiver/*", "${aws_s3_bucket.curated.arn}/reports/lineup/*", "${aws_s3_bucket.curated.arn}/reports/trade/*"]
+  }
+  statement {
+    actions   = ["logs:CreateLogGroup", "logs:CreateLogStream", "logs:PutLogEvents"]
+    resources = ["*"]
+  }
+}
+
+resource "aws_iam_role_policy" "waiver_report" {
+  name   = "waiver-report-inline"
+  role   = aws_iam_role.waiver_report_role.id
+  policy = data.aws_iam_policy_document.waiver_report.json
+}
+
 # --- nflverse-curator (Go / custom runtime) ---
 resource "aws_iam_role" "nflverse_curator_role" {
   name = "nflverse-curator-role"
@@ -204,6 +425,60 @@ data "aws_iam_policy_document" "s3_and_logs" {
   }
 }
 
+# The curator checks (and extends) the Glue schemas before writing and runs its
+# data-quality checks through Athena after each ingest.
+resource "aws_iam_role_policy" "nflverse_curator_dq" {
+  name   = "nflverse-curator-dq"
+  role   = aws_iam_role.nflverse_curator_role.id
+  policy = data.aws_iam_policy_document.nflverse_curator_dq.json
+}
+
+data "aws_iam_policy_document" "nflverse_curator_dq" {
+  version = "2012-10-17"
+
+  statement {
+    effect = "Allow"
+    actions = [
+      "athena:StartQueryExecution",
+      "athena:GetQueryExecution",
+      "athena:GetQueryResults",
+      "athena:StopQueryExecution",
+      "athena:GetWorkGroup",
+    ]
+    resources = [
+      "arn:aws:athena:${var.aws_region}:${data.aws_caller_identity.current.account_id}:workgroup/${aws_athena_workgroup.wg.name}"
+    ]
+  }
+
+  statement {
+    effect = "Allow"
+    actions = [
+      "glue:GetDatabase",
+      "glue:GetDatabases",
+      "glue:GetTable",
+      "glue:GetTables",
+      "glue:GetPartition",
+      "glue:GetPartitions",
+      "glue:UpdateTable", # ALTER TABLE ADD COLUMNS for new nullable columns
+    ]
+    resources = ["*"]
+  }
+
+  statement {
+    effect = "Allow"
+    actions = [
+      "s3:GetObject",
+      "s3:PutObject",
+      "s3:ListBucket",
+      "s3:GetBucketLocation",
+    ]
+    resources = [
+      aws_s3_bucket.athena_out.arn,
+      "${aws_s3_bucket.athena_out.arn}/*",
+    ]
+  }
+}
+
 resource "aws_lambda_function" "nflverse_curator" {
   function_name = "nflverse-curator"
   role          = aws_iam_role.nflverse_curator_role.arn
@@ -221,6 +496,7 @@ resource "aws_lambda_function" "nflverse_curator" {
       GLUE_DATABASE    = aws_glue_catalog_database.curated.name
       ATHENA_WORKGROUP = aws_athena_workgroup.wg.name
       ATHENA_OUTPUT    = "s3://${aws_s3_bucket.athena_out.bucket}/results/"
+      DQ_RESULTS       = "s3://${aws_s3_bucket.athena_out.bucket}/dq"
       SEASON           = var.season_default
       MAX_AGE          = var.max_age_default
     }
@@ -278,6 +554,7 @@ data "aws_iam_policy_document" "athena_s3_dynamodb_logs" {
       "dynamodb:PutItem",
       "dynamodb:UpdateItem",
       "dynamodb:DescribeTable",
+      "dynamodb:Query",
     ]
     resources = [
       aws_dynamodb_table.defensive_starters_allgames.arn
@@ -335,7 +612,12 @@ data "aws_iam_policy_document" "athena_materializer_glue" {
       "glue:UpdateTable",
       "glue:DeleteTable",
       "glue:BatchCreatePartition",
-      "glue:BatchDeletePartition"
+      "glue:BatchDeletePartition",
+      # Partition-scoped rebuilds: ALTER TABLE ADD / DROP / SET LOCATION
+      "glue:CreatePartition",
+      "glue:UpdatePartition",
+      "glue:DeletePartition",
+      "glue:BatchUpdatePartition"
     ]
     resources = ["*"]
     # resources = [
@@ -387,15 +669,21 @@ resource "aws_lambda_function" "athena_materializer" {
 
   environment {
     variables = {
-      ATHENA_DB        = aws_glue_catalog_database.curated.name
-      CURATED_BUCKET   = aws_s3_bucket.curated.bucket
-      CURATED_PREFIX   = local.curated.prefix
-      ATHENA_WORKGROUP = aws_athena_workgroup.wg.name
-      ATHENA_OUTPUT    = "s3://${aws_s3_bucket.athena_out.bucket}/results/"
-      SERVE_TABLE      = aws_dynamodb_table.defensive_starters_allgames.name
-      SEASON           = var.season_default
-      MAX_AGE          = var.max_age_default
-      STARTER_PCT      = var.starter_pct_default
+      ATHENA_DB            = aws_glue_catalog_database.curated.name
+      CURATED_BUCKET       = aws_s3_bucket.curated.bucket
+      CURATED_PREFIX       = local.curated.prefix
+      ATHENA_WORKGROUP     = aws_athena_workgroup.wg.name
+      ATHENA_OUTPUT        = "s3://${aws_s3_bucket.athena_out.bucket}/results/"
+      SERVE_TABLE          = aws_dynamodb_table.defensive_starters_allgames.name
+      DDB_SYNC_TABLE       = aws_dynamodb_table.defensive_starters_allgames.name
+      MATERIALIZE_TABLES   = "defensive_starters_allgames,defensive_starters_team_summary"
+      ATHENA_QUERY_CAP_MB  = "2048"
+      ATHENA_RUN_BUDGET_MB = "8192"
+      ATHENA_COST_LEDGER   = "s3://${aws_s3_bucket.athena_out.bucket}/cost-ledger"
+      DQ_RESULTS           = "s3://${aws_s3_bucket.athena_out.bucket}/dq"
+      SEASON               = var.season_default
+      MAX_AGE              = var.max_age_default
+      STARTER_PCT          = var.starter_pct_default
     }
   }
 }
@@ -6,14 +6,16 @@ locals {
     Project     = "ff-backends"
   }
 
-  # Common SerDe / formats for Parquet tables
+  # Common SerDe / formats for Parquet tables. The curated tables are read through
+  # symlink manifests (one per partition, listing its Parquet files) so compaction can
+  # swap a partition's files in one PUT; see tools/nflverse-curator/internal/compact.
   parquet_serde  = "org.apache.hadoop.hive.ql.io.parquet.serde.ParquetHiveSerDe"
-  parquet_input  = "org.apache.hadoop.hive.ql.io.parquet.MapredParquetInputFormat"
-  parquet_output = "org.apache.hadoop.hive.ql.io.parquet.MapredParquetOutputFormat"
+  symlink_input  = "org.apache.hadoop.hive.ql.io.SymlinkTextInputFormat"
+  symlink_output = "org.apache.hadoop.hive.ql.io.HiveIgnoreKeyTextOutputFormat"
 
-  curated_root_players        = "s3://${aws_s3_bucket.curated.bucket}/${local.curated.prefix}/players/"
-  curated_root_rosters_weekly = "s3://${aws_s3_bucket.curated.bucket}/${local.curated.prefix}/rosters_weekly/"
-  curated_root_snap_counts    = "s3://${aws_s3_bucket.curated.bucket}/${local.curated.prefix}/snap_counts/"
+  curated_manifests_players        = "s3://${aws_s3_bucket.curated.bucket}/${local.curated.prefix}/_manifests/players/"
+  curated_manifests_rosters_weekly = "s3://${aws_s3_bucket.curated.bucket}/${local.curated.prefix}/_manifests/rosters_weekly/"
+  curated_manifests_snap_counts    = "s3://${aws_s3_bucket.curated.bucket}/${local.curated.prefix}/_manifests/snap_counts/"
 
   # NFL team codes for partition projection (snap_counts)
   team_codes = "ARI,ATL,BAL,BUF,CAR,CHI,CIN,CLE,DAL,DEN,DET,GB,HOU,IND,JAX,KC,LV,LAC,LA,MIA,MIN,NE,NO,NYG,NYJ,PHI,PIT,SF,SEA,TB,TEN,WAS"
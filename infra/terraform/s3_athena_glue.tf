@@ -42,10 +42,15 @@ resource "aws_athena_workgroup" "wg" {
       output_location = "s3://${aws_s3_bucket.athena_out.bucket}/results/"
     }
     enforce_workgroup_configuration = false
+
+    # Hard per-query cap (10 GiB); the materializer also enforces its own, smaller caps.
+    bytes_scanned_cutoff_per_query = 10737418240
   }
 }
 
 # --- Glue tables with partition projection ---
+# Locations are manifest directories, not the Parquet data: run
+# `make compact-curated` once to create the manifests before applying this.
 
 # 1) players (no partitions)
 resource "aws_glue_catalog_table" "players" {
@@ -54,34 +59,22 @@ resource "aws_glue_catalog_table" "players" {
   table_type    = "EXTERNAL_TABLE"
 
   storage_descriptor {
-    location      = local.curated_root_players
-    input_format  = local.parquet_input
-    output_format = local.parquet_output
+    location      = local.curated_manifests_players
+    input_format  = local.symlink_input
+    output_format = local.symlink_output
 
     ser_de_info {
       name                  = "ParquetHiveSerDe"
       serialization_library = local.parquet_serde
     }
 
-    columns {
-      name = "pfr_id"
-      type = "string"
-    }
-    columns {
-      name = "gsis_id"
-      type = "string"
-    }
-    columns {
-      name = "full_name"
-      type = "string"
-    }
-    columns {
-      name = "birth_date"
-      type = "string" # keep as string; cast in SQL if needed
-    }
-    columns {
-      name = "position"
-      type = "string"
+    # Columns come from the curator's row structs (curated_schema.tf.json).
+    dynamic "columns" {
+      for_each = local.curated_schema.players.columns
+      content {
+        name = columns.value.name
+        type = columns.value.type
+      }
     }
   }
 
@@ -100,49 +93,31 @@ resource "aws_glue_catalog_table" "rosters_weekly" {
   table_type    = "EXTERNAL_TABLE"
 
   storage_descriptor {
-    location      = local.curated_root_rosters_weekly
-    input_format  = local.parquet_input
-    output_format = local.parquet_output
+    location      = local.curated_manifests_rosters_weekly
+    input_format  = local.symlink_input
+    output_format = local.symlink_output
 
     ser_de_info {
       name                  = "ParquetHiveSerDe"
       serialization_library = local.parquet_serde
     }
 
-    # Non-partition columns
-    columns {
-      name = "team"
-      type = "string"
-    }
-    columns {
-      name = "player_id"
-      type = "string"
-    }
-    columns {
-      name = "pfr_id"
-      type = "string"
-    }
-    columns {
-      name = "full_name"
-      type = "string"
-    }
-    columns {
-      name = "position"
-      type = "string"
-    }
-    columns {
-      name = "status"
-      type = "string"
+    # Columns come from the curator's row structs (curated_schema.tf.json).
+    dynamic "columns" {
+      for_each = local.curated_schema.rosters_weekly.columns
+      content {
+        name = columns.value.name
+        type = columns.value.type
+      }
     }
   }
 
-  partition_keys {
-    name = "season"
-    type = "string"
-  }
-  partition_keys {
-    name = "week"
-    type = "string"
+  dynamic "partition_keys" {
+    for_each = local.curated_schema.rosters_weekly.partition_keys
+    content {
+      name = partition_keys.value.name
+      type = partition_keys.value.type
+    }
   }
 
   parameters = {
@@ -161,7 +136,7 @@ resource "aws_glue_catalog_table" "rosters_weekly" {
     "projection.week.format" = "%02d"
 
     # template
-    "storage.location.template" = "${local.curated_root_rosters_weekly}season=$${season}/week=$${week}/"
+    "storage.location.template" = "${local.curated_manifests_rosters_weekly}season=$${season}/week=$${week}/"
   }
 }
 
@@ -172,49 +147,31 @@ resource "aws_glue_catalog_table" "snap_counts" {
   table_type    = "EXTERNAL_TABLE"
 
   storage_descriptor {
-    location      = local.curated_root_snap_counts
-    input_format  = local.parquet_input
-    output_format = local.parquet_output
+    location      = local.curated_manifests_snap_counts
+    input_format  = local.symlink_input
+    output_format = local.symlink_output
 
     ser_de_info {
       name                  = "ParquetHiveSerDe"
       serialization_library = local.parquet_serde
     }
 
-    # Non-partition columns
-    columns {
-      name = "week"
-      type = "string"
-    }
-    columns {
-      name = "player"
-      type = "string"
-    }
-    columns {
-      name = "player_id"
-      type = "string"
-    }
-    columns {
-      name = "offense_pct"
-      type = "double"
-    }
-    columns {
-      name = "defense_pct"
-      type = "double"
-    }
-    columns {
-      name = "st_pct"
-      type = "double"
+    # Columns come from the curator's row structs (curated_schema.tf.json).
+    dynamic "columns" {
+      for_each = local.curated_schema.snap_counts.columns
+      content {
+        name = columns.value.name
+        type = columns.value.type
+      }
     }
   }
 
-  partition_keys {
-    name = "season"
-    type = "string"
-  }
-  partition_keys {
-    name = "team"
-    type = "string"
+  dynamic "partition_keys" {
+    for_each = local.curated_schema.snap_counts.partition_keys
+    content {
+      name = partition_keys.value.name
+      type = partition_keys.value.type
+    }
   }
 
   parameters = {
@@ -232,6 +189,6 @@ resource "aws_glue_catalog_table" "snap_counts" {
     "projection.team.values" = local.team_codes
 
     # template
-    "storage.location.template" = "${local.curated_root_snap_counts}season=$${season}/team=$${team}/"
+    "storage.location.template" = "${local.curated_manifests_snap_counts}season=$${season}/team=$${team}/"
   }
 }
\ No newline at end of file
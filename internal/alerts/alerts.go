@@ -0,0 +1,229 @@
+// Package alerts flags defensive role changes from a player's per-game snap series.
+package alerts
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/tyler180/fantasy-football-backends/internal/trends"
+)
+
+// Kind names a role change.
+type Kind string
+
+const (
+	StarterJump      Kind = "starter_jump"      // sudden jump to starter share
+	SustainedDecline Kind = "sustained_decline" // several straight games of falling share
+	EveryDown        Kind = "every_down"        // rotational player now playing every down
+	InjuryReturn     Kind = "injury_return"     // back after missing games, role intact
+)
+
+// Kinds lists every kind in a stable order.
+var Kinds = []Kind{StarterJump, SustainedDecline, EveryDown, InjuryReturn}
+
+// Flag is one detected role change as of the player's latest game.
+type Flag struct {
+	Kind       Kind
+	Confidence float64 // 0..1
+	Weeks      []int   // the games that support the flag
+	Detail     string
+}
+
+// Alert is a Flag attached to a player, as stored in the alerts table.
+type Alert struct {
+	Season   string
+	Week     int // latest game week the flag was evaluated at
+	Team     string
+	PlayerID string
+	Flag
+}
+
+// Options are the thresholds used by Detect. Zero values select the defaults.
+type Options struct {
+	StarterPct    float64 // share that counts as a starter (default 65)
+	JumpPts       float64 // minimum rise over the prior games for StarterJump (default 25)
+	DeclinePts    float64 // minimum total fall for SustainedDecline (default 15)
+	DeclineGames  int     // consecutive falling games for SustainedDecline (default 3)
+	RotationalMax float64 // prior average below this is rotational (default 65)
+	RotationalMin float64 // ... and above this (default 25)
+	EveryDownPct  float64 // share of the recent games for EveryDown (default 85)
+	MissedWeeks   int     // missed weeks that count as an absence (default 2)
+	MinConfidence float64 // flags below this are dropped (default 0.3)
+}
+
+func (o Options) withDefaults() Options {
+	def := func(v *float64, d float64) {
+		if *v <= 0 {
+			*v = d
+		}
+	}
+	def(&o.StarterPct, 65)
+	def(&o.JumpPts, 25)
+	def(&o.DeclinePts, 15)
+	def(&o.RotationalMax, 65)
+	def(&o.RotationalMin, 25)
+	def(&o.EveryDownPct, 85)
+	def(&o.MinConfidence, 0.3)
+	if o.DeclineGames < 2 {
+		o.DeclineGames = 3
+	}
+	if o.MissedWeeks < 1 {
+		o.MissedWeeks = 2
+	}
+	return o
+}
+
+// Detect evaluates pts (week order) as of the latest game and returns the flags that
+// apply, most confident first.
+func Detect(pts []trends.Point, opt Options) []Flag {
+	opt = opt.withDefaults()
+	var out []Flag
+	for _, f := range []*Flag{
+		starterJump(pts, opt),
+		sustainedDecline(pts, opt),
+		everyDown(pts, opt),
+		injuryReturn(pts, opt),
+	} {
+		if f != nil && f.Confidence >= opt.MinConfidence {
+			f.Confidence = math.Round(f.Confidence*100) / 100
+			out = append(out, *f)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Confidence > out[j].Confidence })
+	return out
+}
+
+// starterJump: the latest game reaches starter share, none of up to three games before
+// it did, and it sits well above their average.
+func starterJump(pts []trends.Point, o Options) *Flag {
+	n := len(pts)
+	if n < 2 {
+		return nil
+	}
+	last := pts[n-1]
+	prior := pts[max(0, n-4) : n-1]
+	base := avg(prior)
+	jump := last.Pct - base
+	if last.Pct < o.StarterPct || jump < o.JumpPts {
+		return nil
+	}
+	for _, p := range prior {
+		if p.Pct >= o.StarterPct {
+			return nil
+		}
+	}
+	// A bigger jump and a longer, steadier baseline are both more convincing.
+	conf := clamp(0.4 + (jump-o.JumpPts)/50 + 0.1*float64(len(prior)-1))
+	return &Flag{
+		Kind:       StarterJump,
+		Confidence: conf,
+		Weeks:      weeks(pts[max(0, n-4):]),
+		Detail:     fmt.Sprintf("%.0f%% after averaging %.0f%%", last.Pct, base),
+	}
+}
+
+// sustainedDecline: each of the last DeclineGames games is below the one before,
+// falling DeclinePts or more in total, from a meaningful share.
+func sustainedDecline(pts []trends.Point, o Options) *Flag {
+	n := len(pts)
+	k := o.DeclineGames
+	if n < k+1 {
+		return nil
+	}
+	win := pts[n-k-1:]
+	for i := 1; i < len(win); i++ {
+		if win[i].Pct >= win[i-1].Pct {
+			return nil
+		}
+	}
+	drop := win[0].Pct - win[len(win)-1].Pct
+	if drop < o.DeclinePts || win[0].Pct < o.RotationalMin {
+		return nil
+	}
+	conf := clamp(0.4 + (drop-o.DeclinePts)/40)
+	return &Flag{
+		Kind:       SustainedDecline,
+		Confidence: conf,
+		Weeks:      weeks(win),
+		Detail:     fmt.Sprintf("%.0f%% -> %.0f%% over %d games", win[0].Pct, win[len(win)-1].Pct, k),
+	}
+}
+
+// everyDown: the last two games are at every-down share after at least three
+// rotational games.
+func everyDown(pts []trends.Point, o Options) *Flag {
+	n := len(pts)
+	if n < 5 {
+		return nil
+	}
+	recent, prior := pts[n-2:], pts[max(0, n-6):n-2]
+	for _, p := range recent {
+		if p.Pct < o.EveryDownPct {
+			return nil
+		}
+	}
+	base := avg(prior)
+	if base < o.RotationalMin || base >= o.RotationalMax {
+		return nil
+	}
+	conf := clamp(0.5 + (avg(recent)-base-20)/60 + 0.05*float64(len(prior)-3))
+	return &Flag{
+		Kind:       EveryDown,
+		Confidence: conf,
+		Weeks:      weeks(pts[max(0, n-6):]),
+		Detail:     fmt.Sprintf("%.0f%% in the last 2 games after averaging %.0f%%", avg(recent), base),
+	}
+}
+
+// injuryReturn: the latest game follows an absence of MissedWeeks or more, and the
+// player is back near the share held before it. One missing week is not enough on
+// its own because of byes.
+func injuryReturn(pts []trends.Point, o Options) *Flag {
+	n := len(pts)
+	if n < 2 {
+		return nil
+	}
+	last, prev := pts[n-1], pts[n-2]
+	missed := last.Week - prev.Week - 1
+	if missed < o.MissedWeeks {
+		return nil
+	}
+	before := pts[max(0, n-4) : n-1]
+	base := avg(before)
+	if base < o.RotationalMin {
+		return nil
+	}
+	ratio := last.Pct / base
+	if ratio < 0.6 {
+		return nil
+	}
+	conf := clamp(0.3 + 0.5*math.Min(ratio, 1) + 0.05*float64(missed-o.MissedWeeks))
+	return &Flag{
+		Kind:       InjuryReturn,
+		Confidence: conf,
+		Weeks:      weeks(append(append([]trends.Point(nil), before...), last)),
+		Detail:     fmt.Sprintf("back at %.0f%% after missing %d weeks (was %.0f%%)", last.Pct, missed, base),
+	}
+}
+
+func avg(pts []trends.Point) float64 {
+	if len(pts) == 0 {
+		return 0
+	}
+	var s float64
+	for _, p := range pts {
+		s += p.Pct
+	}
+	return s / float64(len(pts))
+}
+
+func weeks(pts []trends.Point) []int {
+	out := make([]int, len(pts))
+	for i, p := range pts {
+		out[i] = p.Week
+	}
+	return out
+}
+
+func clamp(v float64) float64 { return math.Max(0, math.Min(1, v)) }
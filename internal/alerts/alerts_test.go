@@ -0,0 +1,78 @@
+package alerts
+
+import (
+	"testing"
+
+	"github.com/tyler180/fantasy-football-backends/internal/trends"
+)
+
+func series(pcts ...float64) []trends.Point {
+	pts := make([]trends.Point, len(pcts))
+	for i, p := range pcts {
+		pts[i] = trends.Point{Week: i + 1, Pct: p}
+	}
+	return pts
+}
+
+func at(week int, pct float64) trends.Point { return trends.Point{Week: week, Pct: pct} }
+
+func kinds(flags []Flag) map[Kind]Flag {
+	m := map[Kind]Flag{}
+	for _, f := range flags {
+		m[f.Kind] = f
+	}
+	return m
+}
+
+func TestDetect(t *testing.T) {
+	cases := []struct {
+		name  string
+		pts   []trends.Point
+		want  []Kind
+		weeks []int // supporting weeks of the first wanted kind
+	}{
+		{"starter jump", series(20, 25, 30, 78), []Kind{StarterJump}, []int{1, 2, 3, 4}},
+		{"sustained decline", series(90, 85, 70, 60, 50), []Kind{SustainedDecline}, []int{2, 3, 4, 5}},
+		{"rotational to every down", series(40, 45, 50, 48, 92, 95), []Kind{EveryDown}, []int{1, 2, 3, 4, 5, 6}},
+		{"injury return", []trends.Point{at(1, 80), at(2, 85), at(3, 82), at(7, 79)}, []Kind{InjuryReturn}, []int{1, 2, 3, 7}},
+		{"bye week is not an absence", []trends.Point{at(1, 80), at(2, 85), at(4, 82)}, nil, nil},
+		{"steady starter", series(88, 90, 91, 89, 90), nil, nil},
+		{"too short", series(80), nil, nil},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := kinds(Detect(tc.pts, Options{}))
+			if len(got) != len(tc.want) {
+				t.Fatalf("flags = %v, want %v", got, tc.want)
+			}
+			for _, k := range tc.want {
+				f, ok := got[k]
+				if !ok {
+					t.Fatalf("missing %s in %v", k, got)
+				}
+				if f.Confidence < 0.3 || f.Confidence > 1 {
+					t.Fatalf("%s confidence %v out of range", k, f.Confidence)
+				}
+			}
+			if tc.weeks != nil {
+				w := got[tc.want[0]].Weeks
+				if len(w) != len(tc.weeks) {
+					t.Fatalf("weeks = %v, want %v", w, tc.weeks)
+				}
+				for i := range w {
+					if w[i] != tc.weeks[i] {
+						t.Fatalf("weeks = %v, want %v", w, tc.weeks)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestDetect_BiggerJumpIsMoreConfident(t *testing.T) {
+	small := kinds(Detect(series(30, 35, 40, 70), Options{}))[StarterJump]
+	big := kinds(Detect(series(10, 10, 15, 95), Options{}))[StarterJump]
+	if big.Confidence <= small.Confidence {
+		t.Fatalf("big jump %.2f should beat small jump %.2f", big.Confidence, small.Confidence)
+	}
+}
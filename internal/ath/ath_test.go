@@ -0,0 +1,267 @@
+package ath_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/athena/types"
+
+	"github.com/tyler180/fantasy-football-backends/internal/ath"
+	"github.com/tyler180/fantasy-football-backends/internal/ath/athtest"
+)
+
+func newClient(f *athtest.Fake) *ath.Client {
+	c := ath.New(f, "nflverse_curated", "wg", "s3://results/out/")
+	c.Poll, c.MaxPoll = time.Millisecond, 2*time.Millisecond
+	return c
+}
+
+type starter struct {
+	PlayerID string   `athena:"player_id"`
+	Team     string   // matched to "team" case-insensitively
+	Age      *int     `athena:"age_yrs"`
+	Pct      float64  `athena:"recent_def_pct"`
+	Starts   int      `athena:"games_started"`
+	Skip     string   `athena:"-"`
+	Missing  *float64 `athena:"not_selected"`
+}
+
+func TestSelectDecodesPagesAndNulls(t *testing.T) {
+	f := &athtest.Fake{PageSize: 2}
+	resp := f.On("FROM starters",
+		[]string{"player_id", "team", "age_yrs", "recent_def_pct", "games_started", "extra"},
+		[]any{"00-1", "SEA", 24, 88.5, 17, "x"},
+		[]any{"00-2", "SEA", nil, 71.25, "12.0", "y"},
+		[]any{"00-3", "KC", 30, nil, 9, nil},
+	)
+	resp.Pending = 2
+	c := newClient(f)
+
+	got, err := ath.Select[starter](context.Background(), c, "SELECT * FROM starters WHERE season = ? AND team = ?", 2024, "SEA")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 3 {
+		t.Fatalf("rows = %d, want 3 (header dropped, three pages)", len(got))
+	}
+	if got[0].PlayerID != "00-1" || got[0].Team != "SEA" || *got[0].Age != 24 || got[0].Pct != 88.5 || got[0].Starts != 17 {
+		t.Errorf("row 0 = %+v", got[0])
+	}
+	if got[1].Age != nil || got[1].Starts != 12 {
+		t.Errorf("row 1: NULL age should be nil and 12.0 an int, got %+v", got[1])
+	}
+	if got[2].Pct != 0 || got[2].Missing != nil {
+		t.Errorf("row 2 = %+v", got[2])
+	}
+	calls := f.Calls()
+	if len(calls) != 1 || !reflect.DeepEqual(calls[0].Params, []string{"2024", "'SEA'"}) {
+		t.Fatalf("calls = %+v", calls)
+	}
+	if calls[0].DB != "nflverse_curated" || calls[0].Output != "s3://results/out/" {
+		t.Errorf("call context = %+v", calls[0])
+	}
+}
+
+func TestOneScalar(t *testing.T) {
+	f := &athtest.Fake{}
+	f.On("COUNT(*)", []string{"_col0"}, []any{412})
+	f.On("", []string{"n"})
+	c := newClient(f)
+	n, err := ath.One[int64](context.Background(), c, "SELECT COUNT(*) FROM t")
+	if err != nil || n != 412 {
+		t.Fatalf("count = %d, %v", n, err)
+	}
+	if _, err := ath.One[int64](context.Background(), c, "SELECT 1 WHERE false"); !errors.Is(err, ath.ErrNoRows) {
+		t.Errorf("empty result: err = %v, want ErrNoRows", err)
+	}
+}
+
+func TestDDLHasNoHeader(t *testing.T) {
+	f := &athtest.Fake{}
+	f.On("SHOW PARTITIONS", nil, []any{"season=2024/team=SEA"}, []any{"season=2024/team=KC"}).Statement = types.StatementTypeUtility
+	rows, err := newClient(f).Query(context.Background(), "SHOW PARTITIONS db.t")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 2 || rows[0][0] != "season=2024/team=SEA" {
+		t.Errorf("rows = %v", rows)
+	}
+}
+
+func TestFailedQuery(t *testing.T) {
+	f := &athtest.Fake{}
+	f.On("bad", nil).Fail = "SYNTAX_ERROR: line 1:8"
+	_, err := newClient(f).Exec(context.Background(), "SELECT bad")
+	var qe *ath.QueryError
+	if !errors.As(err, &qe) || qe.State != types.QueryExecutionStateFailed || !strings.Contains(qe.Reason, "SYNTAX_ERROR") {
+		t.Fatalf("err = %v, want a FAILED QueryError", err)
+	}
+	if !ath.IsQueryError(err) {
+		t.Error("IsQueryError = false")
+	}
+
+	f.On("boom", nil).Err = errors.New("throttled")
+	if _, err := newClient(f).Exec(context.Background(), "boom"); err == nil || ath.IsQueryError(err) {
+		t.Errorf("start failure: err = %v, want a plain error", err)
+	}
+}
+
+func TestCancelStopsQuery(t *testing.T) {
+	f := &athtest.Fake{}
+	f.On("slow", nil).Pending = 1 << 30
+	c := newClient(f)
+	c.Timeout = 20 * time.Millisecond
+	_, err := c.Exec(context.Background(), "SELECT slow")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err = %v, want deadline exceeded", err)
+	}
+	if got := f.Stopped(); len(got) != 1 || got[0] != "q1" {
+		t.Errorf("stopped = %v, want [q1]", got)
+	}
+}
+
+func TestNamed(t *testing.T) {
+	sql := `SELECT ':nope', "a:b" FROM t -- :also_not
+WHERE season = :season AND team = :team /* :nor_this */ AND prior = :season - 1`
+	got, vals, err := ath.Named(sql, map[string]any{"season": 2024, "team": "SEA"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `SELECT ':nope', "a:b" FROM t -- :also_not
+WHERE season = ? AND team = ? /* :nor_this */ AND prior = ? - 1`
+	if got != want {
+		t.Errorf("sql =\n%s\nwant\n%s", got, want)
+	}
+	if !reflect.DeepEqual(vals, []any{2024, "SEA", 2024}) {
+		t.Errorf("vals = %v", vals)
+	}
+	if _, _, err := ath.Named("SELECT :a", nil); err == nil {
+		t.Error("missing value: want error")
+	}
+	if _, _, err := ath.Named("SELECT 1", map[string]any{"a": 1}); err == nil {
+		t.Error("unused value: want error")
+	}
+}
+
+func TestParams(t *testing.T) {
+	got, err := ath.Params("O'Neil", 3, 1.5, true, nil,
+		time.Date(2024, 9, 1, 0, 0, 0, 0, time.UTC),
+		time.Date(2024, 9, 1, 13, 0, 0, 0, time.UTC),
+		ath.Literal("CURRENT_DATE"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"'O''Neil'", "3", "1.5", "true", "NULL", "DATE '2024-09-01'", "TIMESTAMP '2024-09-01 13:00:00.000'", "CURRENT_DATE"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("params = %v\nwant %v", got, want)
+	}
+	if _, err := ath.Params(struct{}{}); err == nil {
+		t.Error("struct parameter: want error")
+	}
+}
+
+type ledger struct{ costs []ath.Cost }
+
+func (l *ledger) Record(_ context.Context, c ath.Cost) error {
+	l.costs = append(l.costs, c)
+	return nil
+}
+
+func TestBudgetStopsAndRecords(t *testing.T) {
+	f := &athtest.Fake{}
+	small := f.On("small", []string{"n"}, []any{1})
+	small.ScannedBytes = 30 << 20
+	big := f.On("big", []string{"n"})
+	big.ScannedBytes, big.Pending = 200<<20, 5
+	c := newClient(f)
+	c.Budget = ath.NewBudget(100, 50)
+	l := &ledger{}
+	c.Ledger = l
+	ctx := ath.WithLabel(context.Background(), "starters", 2024)
+
+	if _, err := c.Exec(ctx, "SELECT small"); err != nil {
+		t.Fatal(err)
+	}
+	_, err := c.Exec(ctx, "SELECT big")
+	var be *ath.BudgetError
+	if !errors.As(err, &be) || be.Limit != "query" || !errors.Is(err, ath.ErrBudget) {
+		t.Fatalf("err = %v, want a per-query BudgetError", err)
+	}
+	if got := f.Stopped(); len(got) != 1 || got[0] != "q2" {
+		t.Errorf("stopped = %v, want [q2]", got)
+	}
+	// 30MB + 200MB charged: the run's 50MB is gone, so nothing else starts.
+	if _, err := c.Exec(ctx, "SELECT small"); !errors.As(err, &be) || be.Limit != "run" || be.ID != "" {
+		t.Fatalf("err = %v, want a refused run BudgetError", err)
+	}
+	if len(f.Calls()) != 2 {
+		t.Errorf("calls = %d, want 2 (third refused before starting)", len(f.Calls()))
+	}
+	if c.Budget.Spent() != 230<<20 || c.Budget.Queries() != 2 {
+		t.Errorf("spent = %d over %d queries", c.Budget.Spent(), c.Budget.Queries())
+	}
+
+	if len(l.costs) != 2 {
+		t.Fatalf("ledger = %+v, want 2 records", l.costs)
+	}
+	ok, stopped := l.costs[0], l.costs[1]
+	if ok.Query != "starters" || ok.Season != 2024 || ok.QueryID != "q1" || ok.State != "SUCCEEDED" || ok.Bytes != 30<<20 || ok.Error != "" {
+		t.Errorf("first record = %+v", ok)
+	}
+	if stopped.State != "CANCELLED" || stopped.Error == "" || stopped.USD <= ok.USD {
+		t.Errorf("stopped record = %+v", stopped)
+	}
+}
+
+func TestRunBudgetStopsRunningQuery(t *testing.T) {
+	f := &athtest.Fake{}
+	r := f.On("", []string{"n"})
+	r.ScannedBytes, r.Pending = 40<<20, 3
+	c := newClient(f)
+	c.Budget = ath.NewBudget(0, 60)
+	if _, err := c.Exec(context.Background(), "SELECT 1"); err != nil {
+		t.Fatal(err)
+	}
+	var be *ath.BudgetError
+	if _, err := c.Exec(context.Background(), "SELECT 2"); !errors.As(err, &be) || be.Limit != "run" || be.ID != "q2" {
+		t.Fatalf("err = %v, want the second query stopped on the run cap", err)
+	}
+}
+
+func TestBudgetFailsFinishedQuery(t *testing.T) {
+	// Both queries finish before the first poll, so only their final bytes show the overrun.
+	f := &athtest.Fake{}
+	f.On("big", []string{"n"}, []any{1}).ScannedBytes = 150 << 20
+	f.On("small", []string{"n"}, []any{1}).ScannedBytes = 40 << 20
+	c := newClient(f)
+	c.Budget = ath.NewBudget(100, 180)
+	l := &ledger{}
+	c.Ledger = l
+
+	var be *ath.BudgetError
+	if _, err := c.Exec(context.Background(), "SELECT big"); !errors.As(err, &be) || be.Limit != "query" || !be.Finished {
+		t.Fatalf("err = %v, want a per-query BudgetError for the finished query", err)
+	}
+	if _, err := c.Exec(context.Background(), "SELECT small"); !errors.As(err, &be) || be.Limit != "run" || be.Scanned != 190<<20 {
+		t.Fatalf("err = %v, want the run cap passed", err)
+	}
+	if len(f.Stopped()) != 0 || c.Budget.Spent() != 190<<20 {
+		t.Errorf("stopped = %v, spent = %d", f.Stopped(), c.Budget.Spent())
+	}
+	if len(l.costs) != 2 || l.costs[0].State != "SUCCEEDED" || l.costs[0].Error == "" {
+		t.Errorf("ledger = %+v, want the overrun recorded as an error", l.costs)
+	}
+}
+
+func TestUSD(t *testing.T) {
+	if got := ath.USD(0); got != ath.USD(ath.MinBilledBytes) {
+		t.Errorf("minimum billing: %v", got)
+	}
+	if got := ath.USD(1 << 40); got != ath.PricePerTB {
+		t.Errorf("1TB = %v, want %v", got, ath.PricePerTB)
+	}
+}
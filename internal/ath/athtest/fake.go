@@ -0,0 +1,218 @@
+// Package athtest is an in-memory ath.API for tests. Queries are answered from canned
+// responses matched by substring, results are paged like the real service, and every
+// statement, parameter list and stop request is recorded.
+package athtest
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/athena"
+	"github.com/aws/aws-sdk-go-v2/service/athena/types"
+)
+
+// Response answers every query containing Match ("" matches anything). The first
+// matching response wins.
+type Response struct {
+	Match string
+
+	Statement types.StatementType // default DML, whose results start with a header row
+	Columns   []string
+	Rows      [][]any // nil is NULL; anything else is printed with fmt
+
+	Pending int    // polls reporting RUNNING before the final state
+	Fail    string // if set, the query ends FAILED with this reason
+	Err     error  // returned by StartQueryExecution instead of starting
+
+	ScannedBytes int64
+}
+
+// Call is one recorded StartQueryExecution.
+type Call struct {
+	ID     string
+	SQL    string
+	Params []string
+	DB     string
+	Output string
+}
+
+// Fake implements ath.API.
+type Fake struct {
+	Responses []*Response
+	PageSize  int // rows per GetQueryResults page; default 1000
+
+	mu      sync.Mutex
+	calls   []Call
+	stopped []string
+	queries map[string]*query
+}
+
+type query struct {
+	resp  Response
+	polls int
+}
+
+// On adds a response for queries containing match and returns it for filling in.
+func (f *Fake) On(match string, columns []string, rows ...[]any) *Response {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	r := &Response{Match: match, Columns: columns, Rows: rows}
+	f.Responses = append(f.Responses, r)
+	return r
+}
+
+// Calls returns the statements started so far.
+func (f *Fake) Calls() []Call {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]Call(nil), f.calls...)
+}
+
+// SQL returns the text of each statement started so far.
+func (f *Fake) SQL() []string {
+	var out []string
+	for _, c := range f.Calls() {
+		out = append(out, c.SQL)
+	}
+	return out
+}
+
+// Stopped returns the ids StopQueryExecution was called with.
+func (f *Fake) Stopped() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.stopped...)
+}
+
+func (f *Fake) StartQueryExecution(ctx context.Context, in *athena.StartQueryExecutionInput, _ ...func(*athena.Options)) (*athena.StartQueryExecutionOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	sql := aws.ToString(in.QueryString)
+	resp := Response{}
+	for _, r := range f.Responses {
+		if strings.Contains(sql, r.Match) {
+			resp = *r
+			break
+		}
+	}
+	if resp.Err != nil {
+		return nil, resp.Err
+	}
+	if f.queries == nil {
+		f.queries = map[string]*query{}
+	}
+	id := "q" + strconv.Itoa(len(f.calls)+1)
+	call := Call{ID: id, SQL: sql, Params: in.ExecutionParameters}
+	if in.QueryExecutionContext != nil {
+		call.DB = aws.ToString(in.QueryExecutionContext.Database)
+	}
+	if in.ResultConfiguration != nil {
+		call.Output = aws.ToString(in.ResultConfiguration.OutputLocation)
+	}
+	f.calls = append(f.calls, call)
+	f.queries[id] = &query{resp: resp}
+	return &athena.StartQueryExecutionOutput{QueryExecutionId: aws.String(id)}, nil
+}
+
+func (f *Fake) GetQueryExecution(ctx context.Context, in *athena.GetQueryExecutionInput, _ ...func(*athena.Options)) (*athena.GetQueryExecutionOutput, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	id := aws.ToString(in.QueryExecutionId)
+	q, ok := f.queries[id]
+	if !ok {
+		return nil, fmt.Errorf("athtest: unknown query %s", id)
+	}
+	q.polls++
+	st := &types.QueryExecutionStatus{State: types.QueryExecutionStateSucceeded}
+	switch {
+	case q.polls <= q.resp.Pending:
+		st.State = types.QueryExecutionStateRunning
+	case q.resp.Fail != "":
+		st.State = types.QueryExecutionStateFailed
+		st.StateChangeReason = aws.String(q.resp.Fail)
+	}
+	stmt := q.resp.Statement
+	if stmt == "" {
+		stmt = types.StatementTypeDml
+	}
+	return &athena.GetQueryExecutionOutput{QueryExecution: &types.QueryExecution{
+		QueryExecutionId: aws.String(id),
+		StatementType:    stmt,
+		Status:           st,
+		Statistics:       &types.QueryExecutionStatistics{DataScannedInBytes: aws.Int64(q.resp.ScannedBytes)},
+	}}, nil
+}
+
+func (f *Fake) GetQueryResults(ctx context.Context, in *athena.GetQueryResultsInput, _ ...func(*athena.Options)) (*athena.GetQueryResultsOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	id := aws.ToString(in.QueryExecutionId)
+	q, ok := f.queries[id]
+	if !ok {
+		return nil, fmt.Errorf("athtest: unknown query %s", id)
+	}
+	var rows []types.Row
+	if q.resp.Statement == "" || q.resp.Statement == types.StatementTypeDml {
+		rows = append(rows, row(stringsAny(q.resp.Columns)))
+	}
+	for _, r := range q.resp.Rows {
+		rows = append(rows, row(r))
+	}
+	size := f.PageSize
+	if size <= 0 {
+		size = 1000
+	}
+	start := 0
+	if tok := aws.ToString(in.NextToken); tok != "" {
+		n, err := strconv.Atoi(tok)
+		if err != nil {
+			return nil, fmt.Errorf("athtest: bad token %q", tok)
+		}
+		start = n
+	}
+	end := min(start+size, len(rows))
+	out := &athena.GetQueryResultsOutput{ResultSet: &types.ResultSet{Rows: rows[start:end]}}
+	if start == 0 {
+		meta := &types.ResultSetMetadata{}
+		for _, c := range q.resp.Columns {
+			meta.ColumnInfo = append(meta.ColumnInfo, types.ColumnInfo{Name: aws.String(c), Type: aws.String("varchar")})
+		}
+		out.ResultSet.ResultSetMetadata = meta
+	}
+	if end < len(rows) {
+		out.NextToken = aws.String(strconv.Itoa(end))
+	}
+	return out, nil
+}
+
+func (f *Fake) StopQueryExecution(ctx context.Context, in *athena.StopQueryExecutionInput, _ ...func(*athena.Options)) (*athena.StopQueryExecutionOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.stopped = append(f.stopped, aws.ToString(in.QueryExecutionId))
+	return &athena.StopQueryExecutionOutput{}, nil
+}
+
+func row(vals []any) types.Row {
+	r := types.Row{Data: make([]types.Datum, len(vals))}
+	for i, v := range vals {
+		if v != nil {
+			r.Data[i].VarCharValue = aws.String(fmt.Sprint(v))
+		}
+	}
+	return r
+}
+
+func stringsAny(ss []string) []any {
+	out := make([]any, len(ss))
+	for i, s := range ss {
+		out[i] = s
+	}
+	return out
+}
@@ -0,0 +1,144 @@
+package ath
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// ErrBudget is wrapped by every BudgetError.
+var ErrBudget = errors.New("athena scan budget exceeded")
+
+// Budget caps bytes scanned. Athena cannot price a query before running it, so the
+// caps are enforced while it runs: each status poll reads the bytes scanned so far
+// and stops the query once it passes PerQuery, or once the run's total would pass
+// PerRun. A query that finishes over a cap before a poll catches it fails the same
+// way. A query may overshoot by what it scans between two polls; pair PerQuery with
+// the workgroup's bytes_scanned_cutoff_per_query for a hard limit. One Budget is
+// shared by every query of a run.
+type Budget struct {
+	PerQuery int64 // bytes; 0 = no per-query cap
+	PerRun   int64 // bytes; 0 = no run cap
+
+	mu      sync.Mutex
+	spent   int64
+	queries int
+}
+
+// NewBudget returns a budget with caps given in megabytes (0 = uncapped).
+func NewBudget(perQueryMB, perRunMB int64) *Budget {
+	return &Budget{PerQuery: perQueryMB << 20, PerRun: perRunMB << 20}
+}
+
+// BudgetError is a query refused, stopped, or failed after finishing for scanning
+// too much.
+type BudgetError struct {
+	ID       string // empty when the query was refused before starting
+	Limit    string // "query" or "run"
+	Cap      int64
+	Scanned  int64 // bytes this query scanned (query) or the run's total (run)
+	Finished bool  // the query completed before a poll saw the overrun
+}
+
+func (e *BudgetError) Error() string {
+	what := "stopped " + e.ID
+	switch {
+	case e.ID == "":
+		what = "refused"
+	case e.Finished:
+		what = e.ID + " finished"
+	}
+	return fmt.Sprintf("athena %s: %s cap %.1fMB, scanned %.1fMB", what, e.Limit, mb(e.Cap), mb(e.Scanned))
+}
+
+func (e *BudgetError) Unwrap() error { return ErrBudget }
+
+// Spent is the bytes scanned by finished queries so far.
+func (b *Budget) Spent() int64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.spent
+}
+
+// Queries is how many queries have been charged.
+func (b *Budget) Queries() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.queries
+}
+
+// admit refuses a new query once the run's budget is gone.
+func (b *Budget) admit() error {
+	if b == nil {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.PerRun > 0 && b.spent >= b.PerRun {
+		return &BudgetError{Limit: "run", Cap: b.PerRun, Scanned: b.spent}
+	}
+	return nil
+}
+
+// check reports whether a running query that has scanned bytes is over a cap.
+func (b *Budget) check(id string, scanned int64) error {
+	if b == nil {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.PerQuery > 0 && scanned > b.PerQuery {
+		return &BudgetError{ID: id, Limit: "query", Cap: b.PerQuery, Scanned: scanned}
+	}
+	if b.PerRun > 0 && b.spent+scanned > b.PerRun {
+		return &BudgetError{ID: id, Limit: "run", Cap: b.PerRun, Scanned: b.spent + scanned}
+	}
+	return nil
+}
+
+// finished checks a completed query's final bytes against the caps.
+func (b *Budget) finished(id string, scanned int64) error {
+	err := b.check(id, scanned)
+	if be, ok := err.(*BudgetError); ok {
+		be.Finished = true
+	}
+	return err
+}
+
+// charge adds a finished (or stopped) query's bytes to the run.
+func (b *Budget) charge(scanned int64) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.spent += scanned
+	b.queries++
+}
+
+type labelKey struct{}
+
+// Label is what a cost record says a query was for.
+type Label struct {
+	Query  string // e.g. a template name; defaults to the statement's first keyword
+	Season int    // 0 when the query is not about one season
+}
+
+// WithLabel tags the queries run with ctx, so their cost records can be told apart.
+func WithLabel(ctx context.Context, query string, season int) context.Context {
+	return context.WithValue(ctx, labelKey{}, Label{Query: query, Season: season})
+}
+
+func labelOf(ctx context.Context, sql string) Label {
+	l, _ := ctx.Value(labelKey{}).(Label)
+	if l.Query == "" {
+		if f := strings.Fields(sql); len(f) > 0 {
+			l.Query = strings.ToUpper(f[0])
+		}
+	}
+	return l
+}
+
+func mb(b int64) float64 { return float64(b) / (1 << 20) }
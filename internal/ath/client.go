@@ -0,0 +1,354 @@
+// Package ath runs Athena queries: start, poll until done (honouring the context),
+// page through the results and decode them into Go values. Every Athena caller in the
+// repo goes through Client, so polling, output locations and errors behave the same
+// everywhere. athtest provides a fake API for tests.
+package ath
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/athena"
+	"github.com/aws/aws-sdk-go-v2/service/athena/types"
+)
+
+// API is the subset of the Athena client that Client uses; *athena.Client satisfies it.
+type API interface {
+	StartQueryExecution(ctx context.Context, in *athena.StartQueryExecutionInput, optFns ...func(*athena.Options)) (*athena.StartQueryExecutionOutput, error)
+	GetQueryExecution(ctx context.Context, in *athena.GetQueryExecutionInput, optFns ...func(*athena.Options)) (*athena.GetQueryExecutionOutput, error)
+	GetQueryResults(ctx context.Context, in *athena.GetQueryResultsInput, optFns ...func(*athena.Options)) (*athena.GetQueryResultsOutput, error)
+	StopQueryExecution(ctx context.Context, in *athena.StopQueryExecutionInput, optFns ...func(*athena.Options)) (*athena.StopQueryExecutionOutput, error)
+}
+
+// Defaults for Client's polling.
+const (
+	DefaultPoll    = 500 * time.Millisecond
+	DefaultMaxPoll = 5 * time.Second
+)
+
+// Client runs queries in one database and workgroup.
+type Client struct {
+	API       API
+	Database  string // default database for unqualified names; optional
+	Workgroup string // default "primary"
+	OutputS3  string // s3://bucket/prefix/; optional when the workgroup enforces one
+
+	Poll    time.Duration // first status poll interval (DefaultPoll); doubles up to MaxPoll
+	MaxPoll time.Duration // DefaultMaxPoll
+	Timeout time.Duration // per query, on top of ctx; 0 = ctx only
+
+	Budget *Budget // scan caps shared by the run; nil = uncapped
+	Ledger Ledger  // per-query cost records; nil = none
+
+	Logger *log.Logger // per-query summary lines; nil = quiet
+	Debug  bool        // also log each statement's SQL
+}
+
+// New returns a client for database in workgroup writing results to outputS3.
+func New(api API, database, workgroup, outputS3 string) *Client {
+	return &Client{API: api, Database: database, Workgroup: workgroup, OutputS3: outputS3}
+}
+
+// Execution is a finished query.
+type Execution struct {
+	ID            string
+	StatementType types.StatementType // DML results carry a header row; DDL and utility ones do not
+	State         types.QueryExecutionState
+	Output        string // result file location
+	ScannedBytes  int64
+	EngineTime    time.Duration
+	QueueTime     time.Duration
+}
+
+// QueryError is a query that ran but did not succeed.
+type QueryError struct {
+	ID        string
+	State     types.QueryExecutionState
+	Reason    string
+	ErrorType string // Athena's error category and type, e.g. "1/1002"; may be empty
+}
+
+func (e *QueryError) Error() string {
+	msg := fmt.Sprintf("athena query %s %s", e.ID, e.State)
+	if e.Reason != "" {
+		msg += ": " + e.Reason
+	}
+	return msg
+}
+
+// Exec runs sql with positional ? parameters bound to args (see Params) and waits for
+// it to finish. A context that ends first stops the query, and so does passing the
+// Budget's caps; label queries for the cost ledger with WithLabel.
+func (c *Client) Exec(ctx context.Context, sql string, args ...any) (Execution, error) {
+	params, err := Params(args...)
+	if err != nil {
+		return Execution{}, err
+	}
+	return c.exec(ctx, sql, params)
+}
+
+// ExecNamed is Exec with :name parameters bound from args.
+func (c *Client) ExecNamed(ctx context.Context, sql string, args map[string]any) (Execution, error) {
+	q, vals, err := Named(sql, args)
+	if err != nil {
+		return Execution{}, err
+	}
+	return c.Exec(ctx, q, vals...)
+}
+
+func (c *Client) exec(ctx context.Context, sql string, params []string) (Execution, error) {
+	if c.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.Timeout)
+		defer cancel()
+	}
+	if c.Debug {
+		c.logf("athena SQL:\n%s\nparams: %v", sql, params)
+	}
+	in := &athena.StartQueryExecutionInput{
+		QueryString: aws.String(sql),
+		WorkGroup:   aws.String(c.workgroup()),
+	}
+	if c.Database != "" {
+		in.QueryExecutionContext = &types.QueryExecutionContext{Database: aws.String(c.Database)}
+	}
+	if c.OutputS3 != "" {
+		in.ResultConfiguration = &types.ResultConfiguration{OutputLocation: aws.String(c.OutputS3)}
+	}
+	if len(params) > 0 {
+		in.ExecutionParameters = params
+	}
+	if err := c.Budget.admit(); err != nil {
+		return Execution{}, err
+	}
+	began := time.Now()
+	start, err := c.API.StartQueryExecution(ctx, in)
+	if err != nil {
+		return Execution{}, fmt.Errorf("athena start: %w", err)
+	}
+	id := aws.ToString(start.QueryExecutionId)
+	ex, err := c.wait(ctx, id)
+	if err == nil {
+		// A query can finish between two polls after passing a cap; it fails all the same.
+		err = c.Budget.finished(id, ex.ScannedBytes)
+	}
+	c.Budget.charge(ex.ScannedBytes)
+	c.record(ctx, sql, ex, time.Since(began), err)
+	if err != nil {
+		return ex, err
+	}
+	c.logf("athena: %s %s %s scanned=%.1fMB engine=%s queue=%s",
+		id, ex.StatementType, ex.State, mb(ex.ScannedBytes), ex.EngineTime, ex.QueueTime)
+	return ex, nil
+}
+
+// record writes ex's cost to the ledger.
+func (c *Client) record(ctx context.Context, sql string, ex Execution, runtime time.Duration, err error) {
+	if c.Ledger == nil {
+		return
+	}
+	l := labelOf(ctx, sql)
+	cost := Cost{
+		Query:     l.Query,
+		Season:    l.Season,
+		QueryID:   ex.ID,
+		State:     string(ex.State),
+		Bytes:     ex.ScannedBytes,
+		RuntimeMS: runtime.Milliseconds(),
+		EngineMS:  ex.EngineTime.Milliseconds(),
+		USD:       USD(ex.ScannedBytes),
+		At:        time.Now().UTC(),
+	}
+	if err != nil {
+		cost.Error = err.Error()
+	}
+	if lerr := c.Ledger.Record(context.WithoutCancel(ctx), cost); lerr != nil {
+		c.logf("athena: cost ledger: %v", lerr)
+	}
+}
+
+// wait polls id until it finishes, backing off from Poll to MaxPoll.
+func (c *Client) wait(ctx context.Context, id string) (Execution, error) {
+	delay, maxDelay := c.Poll, c.MaxPoll
+	if delay <= 0 {
+		delay = DefaultPoll
+	}
+	if maxDelay <= 0 {
+		maxDelay = DefaultMaxPoll
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			c.stop(ctx, id)
+			return Execution{ID: id}, fmt.Errorf("athena query %s: %w", id, ctx.Err())
+		case <-timer.C:
+		}
+		out, err := c.API.GetQueryExecution(ctx, &athena.GetQueryExecutionInput{QueryExecutionId: aws.String(id)})
+		if err != nil {
+			if ctx.Err() != nil {
+				c.stop(ctx, id)
+			}
+			return Execution{ID: id}, fmt.Errorf("athena status %s: %w", id, err)
+		}
+		ex := execution(id, out.QueryExecution)
+		switch ex.State {
+		case types.QueryExecutionStateSucceeded:
+			return ex, nil
+		case types.QueryExecutionStateFailed, types.QueryExecutionStateCancelled:
+			qe := &QueryError{ID: id, State: ex.State}
+			if st := out.QueryExecution.Status; st != nil {
+				qe.Reason = aws.ToString(st.StateChangeReason)
+				if ae := st.AthenaError; ae != nil {
+					if qe.Reason == "" {
+						qe.Reason = aws.ToString(ae.ErrorMessage)
+					}
+					if ae.ErrorCategory != nil || ae.ErrorType != nil {
+						qe.ErrorType = fmt.Sprintf("%d/%d", aws.ToInt32(ae.ErrorCategory), aws.ToInt32(ae.ErrorType))
+					}
+				}
+			}
+			return ex, qe
+		}
+		if err := c.Budget.check(id, ex.ScannedBytes); err != nil {
+			c.stop(ctx, id)
+			ex.State = types.QueryExecutionStateCancelled
+			return ex, err
+		}
+		if delay = delay * 2; delay > maxDelay {
+			delay = maxDelay
+		}
+		timer.Reset(delay)
+	}
+}
+
+// stop asks Athena to cancel id after ctx ended, so an abandoned query stops scanning.
+func (c *Client) stop(ctx context.Context, id string) {
+	sctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 10*time.Second)
+	defer cancel()
+	if _, err := c.API.StopQueryExecution(sctx, &athena.StopQueryExecutionInput{QueryExecutionId: aws.String(id)}); err != nil {
+		c.logf("athena: stop %s: %v", id, err)
+	}
+}
+
+func execution(id string, q *types.QueryExecution) Execution {
+	ex := Execution{ID: id}
+	if q == nil {
+		return ex
+	}
+	ex.StatementType = q.StatementType
+	if q.Status != nil {
+		ex.State = q.Status.State
+	}
+	if q.ResultConfiguration != nil {
+		ex.Output = aws.ToString(q.ResultConfiguration.OutputLocation)
+	}
+	if s := q.Statistics; s != nil {
+		ex.ScannedBytes = aws.ToInt64(s.DataScannedInBytes)
+		ex.EngineTime = time.Duration(aws.ToInt64(s.EngineExecutionTimeInMillis)) * time.Millisecond
+		ex.QueueTime = time.Duration(aws.ToInt64(s.QueryQueueTimeInMillis)) * time.Millisecond
+	}
+	return ex
+}
+
+// Result is a finished query's columns and rows. A nil cell is SQL NULL.
+type Result struct {
+	Columns []string
+	Rows    [][]*string
+}
+
+// Strings returns the rows with NULL as "".
+func (r Result) Strings() [][]string {
+	out := make([][]string, len(r.Rows))
+	for i, row := range r.Rows {
+		out[i] = make([]string, len(row))
+		for j, v := range row {
+			out[i][j] = aws.ToString(v)
+		}
+	}
+	return out
+}
+
+// Results pages through ex's results. DML results repeat the column names as their
+// first row, which is dropped; DDL results (SHOW PARTITIONS, SHOW TABLES) have none.
+func (c *Client) Results(ctx context.Context, ex Execution) (Result, error) {
+	var res Result
+	header := ex.StatementType == types.StatementTypeDml
+	p := athena.NewGetQueryResultsPaginator(c.API, &athena.GetQueryResultsInput{
+		QueryExecutionId: aws.String(ex.ID),
+		MaxResults:       aws.Int32(1000),
+	})
+	first := true
+	for p.HasMorePages() {
+		page, err := p.NextPage(ctx)
+		if err != nil {
+			return res, fmt.Errorf("athena results %s: %w", ex.ID, err)
+		}
+		if page.ResultSet == nil {
+			continue
+		}
+		if first && page.ResultSet.ResultSetMetadata != nil {
+			for _, col := range page.ResultSet.ResultSetMetadata.ColumnInfo {
+				res.Columns = append(res.Columns, aws.ToString(col.Name))
+			}
+		}
+		for _, row := range page.ResultSet.Rows {
+			if header {
+				header = false
+				continue
+			}
+			vals := make([]*string, len(row.Data))
+			for i, d := range row.Data {
+				vals[i] = d.VarCharValue
+			}
+			res.Rows = append(res.Rows, vals)
+		}
+		first = false
+	}
+	return res, nil
+}
+
+// Run executes sql with args and returns its results.
+func (c *Client) Run(ctx context.Context, sql string, args ...any) (Result, error) {
+	ex, err := c.Exec(ctx, sql, args...)
+	if err != nil {
+		return Result{}, err
+	}
+	return c.Results(ctx, ex)
+}
+
+// Query runs sql and returns its rows as strings, NULL as "". It is the shape the
+// materializer's Runner wants.
+func (c *Client) Query(ctx context.Context, sql string) ([][]string, error) {
+	res, err := c.Run(ctx, sql)
+	if err != nil {
+		return nil, err
+	}
+	return res.Strings(), nil
+}
+
+// IsQueryError reports whether err is (or wraps) a QueryError, i.e. Athena ran the
+// query and it failed, rather than the call or the context failing.
+func IsQueryError(err error) bool {
+	var qe *QueryError
+	return errors.As(err, &qe)
+}
+
+func (c *Client) workgroup() string {
+	if strings.TrimSpace(c.Workgroup) == "" {
+		return "primary"
+	}
+	return c.Workgroup
+}
+
+func (c *Client) logf(format string, args ...any) {
+	if c.Logger != nil {
+		c.Logger.Printf(format, args...)
+	}
+}
@@ -0,0 +1,166 @@
+package ath
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrNoRows is returned by One when the query returned nothing.
+var ErrNoRows = errors.New("athena: no rows")
+
+// Select runs sql with args and decodes each row into a T.
+//
+// A struct T is filled by column name: a field tagged `athena:"col"` takes column col,
+// an untagged field takes the column whose name matches case-insensitively, and
+// `athena:"-"` skips the field. Columns with no field are ignored. Any other T
+// (int64, string, ...) decodes the first column. NULL leaves a field at its zero
+// value, or nil for a pointer field.
+func Select[T any](ctx context.Context, c *Client, sql string, args ...any) ([]T, error) {
+	res, err := c.Run(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	return Decode[T](res)
+}
+
+// One is Select for a query that returns a single row, e.g. a COUNT(*).
+func One[T any](ctx context.Context, c *Client, sql string, args ...any) (T, error) {
+	var zero T
+	rows, err := Select[T](ctx, c, sql, args...)
+	if err != nil {
+		return zero, err
+	}
+	if len(rows) == 0 {
+		return zero, ErrNoRows
+	}
+	return rows[0], nil
+}
+
+// Decode decodes an already fetched Result the way Select does.
+func Decode[T any](res Result) ([]T, error) {
+	out := make([]T, len(res.Rows))
+	typ := reflect.TypeOf(out).Elem()
+	if typ.Kind() != reflect.Struct || typ == timeType {
+		for i, row := range res.Rows {
+			if len(row) == 0 {
+				return nil, fmt.Errorf("row %d: no columns", i+1)
+			}
+			if err := set(reflect.ValueOf(&out[i]).Elem(), row[0]); err != nil {
+				return nil, fmt.Errorf("row %d: %w", i+1, err)
+			}
+		}
+		return out, nil
+	}
+	fields := fieldMap(typ, res.Columns)
+	for i, row := range res.Rows {
+		v := reflect.ValueOf(&out[i]).Elem()
+		for col, idx := range fields {
+			if col >= len(row) {
+				continue
+			}
+			if err := set(v.FieldByIndex(idx), row[col]); err != nil {
+				return nil, fmt.Errorf("row %d column %s: %w", i+1, res.Columns[col], err)
+			}
+		}
+	}
+	return out, nil
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// fieldMap maps column positions to the struct fields they fill.
+func fieldMap(typ reflect.Type, columns []string) map[int][]int {
+	byName := map[string][]int{}
+	for _, f := range reflect.VisibleFields(typ) {
+		if !f.IsExported() || f.Anonymous {
+			continue
+		}
+		name := strings.ToLower(f.Name)
+		if tag, ok := f.Tag.Lookup("athena"); ok {
+			if tag == "-" {
+				continue
+			}
+			name = strings.ToLower(tag)
+		}
+		if _, dup := byName[name]; !dup {
+			byName[name] = f.Index
+		}
+	}
+	out := map[int][]int{}
+	for i, col := range columns {
+		if idx, ok := byName[strings.ToLower(col)]; ok {
+			out[i] = idx
+		}
+	}
+	return out
+}
+
+// set parses s into v. A nil s (NULL) leaves v's zero value.
+func set(v reflect.Value, s *string) error {
+	if s == nil {
+		v.Set(reflect.Zero(v.Type()))
+		return nil
+	}
+	if v.Kind() == reflect.Pointer {
+		p := reflect.New(v.Type().Elem())
+		if err := set(p.Elem(), s); err != nil {
+			return err
+		}
+		v.Set(p)
+		return nil
+	}
+	str := strings.TrimSpace(*s)
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(*s)
+		return nil
+	case reflect.Bool:
+		b, err := strconv.ParseBool(str)
+		if err != nil {
+			return fmt.Errorf("bool %q", str)
+		}
+		v.SetBool(b)
+		return nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(str, 10, v.Type().Bits())
+		if err != nil {
+			// Athena prints whole DOUBLEs as 12.0.
+			f, ferr := strconv.ParseFloat(str, 64)
+			if ferr != nil || f != float64(int64(f)) {
+				return fmt.Errorf("integer %q", str)
+			}
+			n = int64(f)
+		}
+		v.SetInt(n)
+		return nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(str, 10, v.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("unsigned integer %q", str)
+		}
+		v.SetUint(n)
+		return nil
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(str, v.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("number %q", str)
+		}
+		v.SetFloat(f)
+		return nil
+	}
+	if v.Type() == timeType {
+		for _, layout := range []string{time.DateOnly, "2006-01-02 15:04:05.000", time.DateTime, time.RFC3339Nano, "2006-01-02 15:04:05.000 MST"} {
+			if t, err := time.Parse(layout, str); err == nil {
+				v.Set(reflect.ValueOf(t))
+				return nil
+			}
+		}
+		return fmt.Errorf("time %q", str)
+	}
+	return fmt.Errorf("unsupported field type %s", v.Type())
+}
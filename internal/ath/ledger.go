@@ -0,0 +1,126 @@
+package ath
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// PricePerTB is Athena's on-demand price in USD per terabyte scanned; every query is
+// billed at least MinBilledBytes.
+const (
+	PricePerTB     = 5.0
+	MinBilledBytes = 10 << 20
+)
+
+// Cost is one query's entry in the cost ledger.
+type Cost struct {
+	RunID     string    `json:"run_id,omitempty"`
+	Query     string    `json:"query"`
+	Season    int       `json:"season,omitempty"`
+	QueryID   string    `json:"query_id"`
+	State     string    `json:"state"`
+	Bytes     int64     `json:"bytes_scanned"`
+	RuntimeMS int64     `json:"runtime_ms"` // wall clock, start to finish
+	EngineMS  int64     `json:"engine_ms"`
+	USD       float64   `json:"usd"`
+	Error     string    `json:"error,omitempty"`
+	At        time.Time `json:"at"`
+}
+
+// USD prices bytes scanned by one query.
+func USD(bytes int64) float64 {
+	return float64(max(bytes, MinBilledBytes)) / (1 << 40) * PricePerTB
+}
+
+// Ledger records query costs. Client records every query that started, including
+// failed and budget-stopped ones; a ledger error is logged, never returned.
+type Ledger interface {
+	Record(ctx context.Context, c Cost) error
+}
+
+// S3PutAPI is the S3 call S3Ledger needs.
+type S3PutAPI interface {
+	PutObject(ctx context.Context, in *s3.PutObjectInput, optFns ...func(*s3.Options)) (*s3.PutObjectOutput, error)
+}
+
+// S3Ledger collects a run's costs and writes them as one JSON-lines object,
+// <prefix>/dt=YYYY-MM-DD/<run>.jsonl, so the ledger itself can be queried from Athena.
+type S3Ledger struct {
+	API   S3PutAPI
+	URI   string // s3://bucket/prefix
+	RunID string
+	Clock func() time.Time // default time.Now
+
+	mu     sync.Mutex
+	costs  []Cost
+	closed bool
+}
+
+// Record buffers c until Flush.
+func (l *S3Ledger) Record(_ context.Context, c Cost) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.closed {
+		return fmt.Errorf("cost ledger: record after flush")
+	}
+	if c.RunID == "" {
+		c.RunID = l.RunID
+	}
+	l.costs = append(l.costs, c)
+	return nil
+}
+
+// Costs returns what has been recorded.
+func (l *S3Ledger) Costs() []Cost {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]Cost(nil), l.costs...)
+}
+
+// Flush writes the recorded costs and returns the object's URI. Nothing recorded
+// writes nothing.
+func (l *S3Ledger) Flush(ctx context.Context) (string, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.closed = true
+	if len(l.costs) == 0 {
+		return "", nil
+	}
+	rest, ok := strings.CutPrefix(l.URI, "s3://")
+	if !ok {
+		return "", fmt.Errorf("cost ledger: not an s3:// uri: %q", l.URI)
+	}
+	bucket, prefix, _ := strings.Cut(rest, "/")
+	now := time.Now
+	if l.Clock != nil {
+		now = l.Clock
+	}
+	key := fmt.Sprintf("dt=%s/%s.jsonl", now().UTC().Format(time.DateOnly), l.RunID)
+	if prefix = strings.Trim(prefix, "/"); prefix != "" {
+		key = prefix + "/" + key
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, c := range l.costs {
+		if err := enc.Encode(c); err != nil {
+			return "", err
+		}
+	}
+	if _, err := l.API.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(bucket),
+		Key:         aws.String(key),
+		Body:        bytes.NewReader(buf.Bytes()),
+		ContentType: aws.String("application/x-ndjson"),
+	}); err != nil {
+		return "", fmt.Errorf("cost ledger: put s3://%s/%s: %w", bucket, key, err)
+	}
+	return "s3://" + bucket + "/" + key, nil
+}
@@ -0,0 +1,147 @@
+package ath
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Params renders args as Athena execution parameters, which Athena splices into the
+// query's ? placeholders as SQL literals: strings are quoted, numbers and booleans are
+// bare, a time.Time is a DATE at midnight UTC and a TIMESTAMP otherwise. Literal passes
+// through as written.
+func Params(args ...any) ([]string, error) {
+	out := make([]string, len(args))
+	for i, a := range args {
+		s, err := literal(a)
+		if err != nil {
+			return nil, fmt.Errorf("parameter %d: %w", i+1, err)
+		}
+		out[i] = s
+	}
+	return out, nil
+}
+
+// Literal is SQL text passed as a parameter unchanged, e.g. Literal("DATE '2024-09-01'").
+type Literal string
+
+func literal(v any) (string, error) {
+	switch x := v.(type) {
+	case nil:
+		return "NULL", nil
+	case Literal:
+		return string(x), nil
+	case string:
+		for _, r := range x {
+			if r < 0x20 && r != '\t' {
+				return "", fmt.Errorf("control character in %q", x)
+			}
+		}
+		return "'" + strings.ReplaceAll(x, "'", "''") + "'", nil
+	case bool:
+		return strconv.FormatBool(x), nil
+	case int:
+		return strconv.Itoa(x), nil
+	case int32:
+		return strconv.FormatInt(int64(x), 10), nil
+	case int64:
+		return strconv.FormatInt(x, 10), nil
+	case float64:
+		return strconv.FormatFloat(x, 'g', -1, 64), nil
+	case float32:
+		return strconv.FormatFloat(float64(x), 'g', -1, 32), nil
+	case time.Time:
+		u := x.UTC()
+		if u.Equal(u.Truncate(24 * time.Hour)) {
+			return "DATE '" + u.Format(time.DateOnly) + "'", nil
+		}
+		return "TIMESTAMP '" + u.Format("2006-01-02 15:04:05.000") + "'", nil
+	}
+	return "", fmt.Errorf("unsupported type %T", v)
+}
+
+// Named rewrites :name placeholders in sql to ? and returns the matching positional
+// arguments, so a name used twice is bound twice. Placeholders inside string literals,
+// quoted identifiers and comments are left alone. Every placeholder must have an
+// argument and every argument must be used.
+func Named(sql string, args map[string]any) (string, []any, error) {
+	var b strings.Builder
+	var vals []any
+	used := map[string]bool{}
+	for i := 0; i < len(sql); {
+		ch := sql[i]
+		switch {
+		case ch == '\'' || ch == '"':
+			j := closing(sql, i+1, ch)
+			b.WriteString(sql[i:j])
+			i = j
+		case ch == '-' && strings.HasPrefix(sql[i:], "--"):
+			j := strings.IndexByte(sql[i:], '\n')
+			if j < 0 {
+				j = len(sql) - i
+			}
+			b.WriteString(sql[i : i+j])
+			i += j
+		case ch == '/' && strings.HasPrefix(sql[i:], "/*"):
+			j := strings.Index(sql[i+2:], "*/")
+			end := len(sql)
+			if j >= 0 {
+				end = i + 2 + j + 2
+			}
+			b.WriteString(sql[i:end])
+			i = end
+		case ch == ':' && i+1 < len(sql) && isNameStart(sql[i+1]) && (i == 0 || sql[i-1] != ':'):
+			j := i + 1
+			for j < len(sql) && isNamePart(sql[j]) {
+				j++
+			}
+			name := sql[i+1 : j]
+			v, ok := args[name]
+			if !ok {
+				return "", nil, fmt.Errorf("no value for :%s", name)
+			}
+			used[name] = true
+			vals = append(vals, v)
+			b.WriteByte('?')
+			i = j
+		default:
+			b.WriteByte(ch)
+			i++
+		}
+	}
+	var unused []string
+	for name := range args {
+		if !used[name] {
+			unused = append(unused, name)
+		}
+	}
+	if len(unused) > 0 {
+		sort.Strings(unused)
+		return "", nil, fmt.Errorf("parameters not in query: %s", strings.Join(unused, ", "))
+	}
+	return b.String(), vals, nil
+}
+
+// closing returns the index just past the quote that closes the literal opened before
+// start; a doubled quote is an escaped one.
+func closing(sql string, start int, q byte) int {
+	for i := start; i < len(sql); i++ {
+		if sql[i] != q {
+			continue
+		}
+		if i+1 < len(sql) && sql[i+1] == q {
+			i++
+			continue
+		}
+		return i + 1
+	}
+	return len(sql)
+}
+
+func isNameStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isNamePart(c byte) bool { return isNameStart(c) || (c >= '0' && c <= '9') }
@@ -0,0 +1,266 @@
+// Package backtest replays seasons week by week to measure how well snap-share
+// signals predict the next game: error (MAE, RMSE, bias), how well they order players
+// (Spearman rank correlation within each week) and how often their breakout flags
+// come true. Every prediction sees only games before the week it predicts.
+package backtest
+
+import (
+	"math"
+	"sort"
+
+	"github.com/tyler180/fantasy-football-backends/internal/projection"
+	"github.com/tyler180/fantasy-football-backends/internal/trends"
+)
+
+// Season is one season's replay input.
+type Season struct {
+	Season  string
+	Players []projection.Player
+	Env     *projection.Env
+}
+
+// Options control the replay. Zero values select the defaults.
+type Options struct {
+	FromWeek int // first predicted week (default 4)
+	MinGames int // games a player needs before a prediction counts (default 3)
+	// A breakout is a game at or above StarterPct that is at least BreakoutPts above
+	// the average of the previous three, by a player not already a starter.
+	StarterPct  float64 // default 65
+	BreakoutPts float64 // default 20
+}
+
+func (o Options) withDefaults() Options {
+	if o.FromWeek <= 0 {
+		o.FromWeek = 4
+	}
+	if o.MinGames <= 0 {
+		o.MinGames = 3
+	}
+	if o.StarterPct <= 0 {
+		o.StarterPct = 65
+	}
+	if o.BreakoutPts <= 0 {
+		o.BreakoutPts = 20
+	}
+	return o
+}
+
+// Prediction is one signal's call for one player-week and what happened.
+type Prediction struct {
+	Season   string  `json:"season"`
+	Week     int     `json:"week"`
+	PlayerID string  `json:"player_id"`
+	Signal   string  `json:"signal"`
+	Pred     float64 `json:"pred"`
+	Actual   float64 `json:"actual"`
+	Flag     bool    `json:"flag"`
+	Breakout bool    `json:"breakout"`
+}
+
+// Run replays every season and returns every prediction of every signal.
+func Run(seasons []Season, signals []Signal, opt Options) []Prediction {
+	opt = opt.withDefaults()
+	var out []Prediction
+	for _, s := range seasons {
+		last := 0
+		for _, p := range s.Players {
+			for _, g := range p.Games {
+				last = max(last, g.Week)
+			}
+		}
+		for week := opt.FromWeek; week <= last; week++ {
+			priors := projection.Priors(s.Players, s.Env, week)
+			for _, p := range s.Players {
+				c, actual, ok := caseFor(s, p, week, priors, opt)
+				if !ok {
+					continue
+				}
+				breakout := isBreakout(c.Series, actual, opt)
+				for _, sig := range signals {
+					pred, flag := sig.Predict(c)
+					out = append(out, Prediction{
+						Season: s.Season, Week: week, PlayerID: p.PlayerID, Signal: sig.Name,
+						Pred: pred, Actual: actual, Flag: flag, Breakout: breakout,
+					})
+				}
+			}
+		}
+	}
+	return out
+}
+
+// caseFor builds p's view as of week. ok is false when p did not play week or has too
+// little history.
+func caseFor(s Season, p projection.Player, week int, priors map[string]float64, opt Options) (Case, float64, bool) {
+	var hist []projection.Game
+	actual, played := 0.0, false
+	trimmed := p
+	for _, g := range p.Games {
+		switch {
+		case g.Week < week && g.SnapPct > 0:
+			hist = append(hist, g)
+		case g.Week == week && g.SnapPct > 0:
+			actual, played = g.SnapPct, true
+			if g.Team != "" {
+				trimmed.Team = g.Team // the team that week, not the one the season ended with
+			}
+		}
+	}
+	if !played || len(hist) < opt.MinGames {
+		return Case{}, 0, false
+	}
+	sort.Slice(hist, func(i, j int) bool { return hist[i].Week < hist[j].Week })
+	series := make([]trends.Point, len(hist))
+	for i, g := range hist {
+		series[i] = trends.Point{Week: g.Week, Pct: g.SnapPct}
+	}
+	trimmed.Games = hist
+	return Case{Season: s.Season, Week: week, Player: trimmed, Series: series, Env: s.Env, Priors: priors}, actual, true
+}
+
+func isBreakout(series []trends.Point, actual float64, opt Options) bool {
+	n := len(series)
+	k := min(n, 3)
+	var sum float64
+	for _, p := range series[n-k:] {
+		sum += p.Pct
+	}
+	base := sum / float64(k)
+	return base < opt.StarterPct && actual >= opt.StarterPct && actual-base >= opt.BreakoutPts
+}
+
+// Score is one signal's accuracy over a set of predictions.
+type Score struct {
+	Signal    string  `json:"signal"`
+	N         int     `json:"n"`
+	MAE       float64 `json:"mae"`
+	RMSE      float64 `json:"rmse"`
+	Bias      float64 `json:"bias"`
+	Spearman  float64 `json:"spearman"` // mean within-week rank correlation, weighted by players
+	Flags     int     `json:"flags"`
+	Hits      int     `json:"hits"`      // flags followed by a breakout
+	HitRate   float64 `json:"hit_rate"`  // Hits / Flags
+	Breakouts int     `json:"breakouts"` // breakouts among the predicted player-weeks
+	Recall    float64 `json:"recall"`    // Hits / Breakouts
+}
+
+// Evaluate scores predictions per signal, in the order signals first appear.
+func Evaluate(preds []Prediction) []Score {
+	var order []string
+	bySig := map[string][]Prediction{}
+	for _, p := range preds {
+		if _, ok := bySig[p.Signal]; !ok {
+			order = append(order, p.Signal)
+		}
+		bySig[p.Signal] = append(bySig[p.Signal], p)
+	}
+	out := make([]Score, 0, len(order))
+	for _, name := range order {
+		out = append(out, score(name, bySig[name]))
+	}
+	return out
+}
+
+func score(name string, ps []Prediction) Score {
+	s := Score{Signal: name, N: len(ps)}
+	if len(ps) == 0 {
+		return s
+	}
+	var abs, sq, bias float64
+	type wk struct {
+		season string
+		week   int
+	}
+	groups := map[wk][]Prediction{}
+	for _, p := range ps {
+		d := p.Pred - p.Actual
+		abs += math.Abs(d)
+		sq += d * d
+		bias += d
+		if p.Flag {
+			s.Flags++
+			if p.Breakout {
+				s.Hits++
+			}
+		}
+		if p.Breakout {
+			s.Breakouts++
+		}
+		k := wk{p.Season, p.Week}
+		groups[k] = append(groups[k], p)
+	}
+	n := float64(len(ps))
+	s.MAE, s.RMSE, s.Bias = round3(abs/n), round3(math.Sqrt(sq/n)), round3(bias/n)
+
+	var rhoSum, rhoW float64
+	for _, g := range groups {
+		if len(g) < 3 {
+			continue
+		}
+		pred, act := make([]float64, len(g)), make([]float64, len(g))
+		for i, p := range g {
+			pred[i], act[i] = p.Pred, p.Actual
+		}
+		if rho, ok := Spearman(pred, act); ok {
+			rhoSum += rho * float64(len(g))
+			rhoW += float64(len(g))
+		}
+	}
+	if rhoW > 0 {
+		s.Spearman = round3(rhoSum / rhoW)
+	}
+	if s.Flags > 0 {
+		s.HitRate = round3(float64(s.Hits) / float64(s.Flags))
+	}
+	if s.Breakouts > 0 {
+		s.Recall = round3(float64(s.Hits) / float64(s.Breakouts))
+	}
+	return s
+}
+
+// Spearman is the rank correlation of x and y (ties get their average rank). ok is
+// false when either side is constant.
+func Spearman(x, y []float64) (float64, bool) {
+	rx, ry := ranks(x), ranks(y)
+	n := float64(len(x))
+	var mx, my float64
+	for i := range rx {
+		mx += rx[i]
+		my += ry[i]
+	}
+	mx, my = mx/n, my/n
+	var sxy, sxx, syy float64
+	for i := range rx {
+		dx, dy := rx[i]-mx, ry[i]-my
+		sxy += dx * dy
+		sxx += dx * dx
+		syy += dy * dy
+	}
+	if sxx == 0 || syy == 0 {
+		return 0, false
+	}
+	return sxy / math.Sqrt(sxx*syy), true
+}
+
+func ranks(v []float64) []float64 {
+	idx := make([]int, len(v))
+	for i := range idx {
+		idx[i] = i
+	}
+	sort.Slice(idx, func(a, b int) bool { return v[idx[a]] < v[idx[b]] })
+	r := make([]float64, len(v))
+	for i := 0; i < len(idx); {
+		j := i
+		for j+1 < len(idx) && v[idx[j+1]] == v[idx[i]] {
+			j++
+		}
+		avg := float64(i+j)/2 + 1
+		for k := i; k <= j; k++ {
+			r[idx[k]] = avg
+		}
+		i = j + 1
+	}
+	return r
+}
+
+func round3(v float64) float64 { return math.Round(v*1000) / 1000 }
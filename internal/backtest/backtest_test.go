@@ -0,0 +1,110 @@
+package backtest
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/tyler180/fantasy-football-backends/internal/projection"
+)
+
+func TestSpearman(t *testing.T) {
+	if r, ok := Spearman([]float64{1, 2, 3, 4}, []float64{10, 20, 30, 40}); !ok || r != 1 {
+		t.Fatalf("monotone = %v", r)
+	}
+	if r, _ := Spearman([]float64{1, 2, 3}, []float64{3, 2, 1}); r != -1 {
+		t.Fatalf("reversed = %v", r)
+	}
+	// Ties share the average rank.
+	if r, _ := Spearman([]float64{1, 1, 2}, []float64{1, 2, 3}); math.Abs(r-0.866) > 1e-3 {
+		t.Fatalf("ties = %v", r)
+	}
+	if _, ok := Spearman([]float64{1, 1}, []float64{1, 2}); ok {
+		t.Fatal("constant side should not correlate")
+	}
+}
+
+func season() Season {
+	g := func(pcts ...float64) []projection.Game {
+		out := make([]projection.Game, len(pcts))
+		for i, p := range pcts {
+			out[i] = projection.Game{Week: i + 1, SnapPct: p}
+		}
+		return out
+	}
+	return Season{
+		Season: "2024",
+		Env:    projection.NewEnv(nil),
+		Players: []projection.Player{
+			{PlayerID: "riser", Team: "SEA", Pos: "LB", Games: g(20, 30, 40, 75, 60)},
+			{PlayerID: "steady", Team: "SEA", Pos: "LB", Games: g(90, 90, 90, 90, 90)},
+			{PlayerID: "fader", Team: "SEA", Pos: "S", Games: g(60, 50, 40, 30, 20)},
+		},
+	}
+}
+
+func TestRun_NoLookahead(t *testing.T) {
+	maxSeen := 0
+	peek := Signal{Name: "peek", Predict: func(c Case) (float64, bool) {
+		for _, g := range c.Player.Games {
+			if g.Week >= c.Week {
+				t.Fatalf("week %d case sees week %d", c.Week, g.Week)
+			}
+			maxSeen = max(maxSeen, g.Week)
+		}
+		return 0, false
+	}}
+	preds := Run([]Season{season()}, []Signal{peek}, Options{})
+	// Weeks 4 and 5 for three players.
+	if len(preds) != 6 || maxSeen != 4 {
+		t.Fatalf("preds = %d maxSeen = %d", len(preds), maxSeen)
+	}
+}
+
+func TestRun_CaseUsesThatWeeksTeam(t *testing.T) {
+	s := season()
+	// Traded after week 4: the season-end team must not leak into earlier cases.
+	s.Players = []projection.Player{{PlayerID: "traded", Team: "KAN", Pos: "LB", Games: []projection.Game{
+		{Week: 1, Team: "SEA", SnapPct: 80}, {Week: 2, Team: "SEA", SnapPct: 80},
+		{Week: 3, Team: "SEA", SnapPct: 80}, {Week: 4, Team: "SEA", SnapPct: 80},
+		{Week: 5, Team: "KAN", SnapPct: 40},
+	}}}
+	teams := map[int]string{}
+	spy := Signal{Name: "spy", Predict: func(c Case) (float64, bool) {
+		teams[c.Week] = c.Player.Team
+		return 0, false
+	}}
+	Run([]Season{s}, []Signal{spy}, Options{})
+	if teams[4] != "SEA" || teams[5] != "KAN" {
+		t.Fatalf("teams = %v", teams)
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	preds := Run([]Season{season()}, DefaultSignals(Thresholds{}), Options{})
+	scores := map[string]Score{}
+	for _, s := range Evaluate(preds) {
+		scores[s.Signal] = s
+	}
+	if len(scores) != 6 {
+		t.Fatalf("signals = %v", scores)
+	}
+	// The riser's week 4 jump (40 -> 75 over a 30 average) is the only breakout; slope3
+	// flags it at week 4 (slope 10) and again at week 5, when the share falls back.
+	s3 := scores["slope3"]
+	if s3.Breakouts != 1 || s3.Flags != 2 || s3.Hits != 1 || s3.HitRate != 0.5 || s3.Recall != 1 {
+		t.Fatalf("slope3 = %+v", s3)
+	}
+	if l := scores["last"]; l.Flags != 0 || l.N != 6 || l.Spearman <= 0 {
+		t.Fatalf("last = %+v", l)
+	}
+	// slope3 extrapolates the fader's decline exactly.
+	if scores["slope3"].MAE >= scores["last"].MAE {
+		t.Fatalf("slope3 %v vs last %v", scores["slope3"].MAE, scores["last"].MAE)
+	}
+
+	rep := NewReport(preds, Options{}, Thresholds{}, time.Time{})
+	if len(rep.Seasons) != 1 || len(rep.BySeason["2024"]) != 6 || rep.Markdown() == "" {
+		t.Fatalf("report = %+v", rep)
+	}
+}
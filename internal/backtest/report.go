@@ -0,0 +1,70 @@
+package backtest
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Report compares signals overall and per season.
+type Report struct {
+	Seasons     []string           `json:"seasons"`
+	Options     Options            `json:"options"`
+	Thresholds  Thresholds         `json:"thresholds"`
+	GeneratedAt time.Time          `json:"generated_at"`
+	Overall     []Score            `json:"overall"`
+	BySeason    map[string][]Score `json:"by_season"`
+}
+
+// NewReport scores predictions overall and per season.
+func NewReport(preds []Prediction, opt Options, t Thresholds, now time.Time) Report {
+	r := Report{
+		Options:     opt.withDefaults(),
+		Thresholds:  t.withDefaults(),
+		GeneratedAt: now.UTC(),
+		Overall:     Evaluate(preds),
+		BySeason:    map[string][]Score{},
+	}
+	bySeason := map[string][]Prediction{}
+	for _, p := range preds {
+		bySeason[p.Season] = append(bySeason[p.Season], p)
+	}
+	for s, ps := range bySeason {
+		r.Seasons = append(r.Seasons, s)
+		r.BySeason[s] = Evaluate(ps)
+	}
+	sort.Strings(r.Seasons)
+	return r
+}
+
+// Markdown renders one comparison table overall and one per season.
+func (r Report) Markdown() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Signal backtest: %s\n\n", strings.Join(r.Seasons, ", "))
+	fmt.Fprintf(&b, "_Generated %s. Predicting next-game DEF%% from week %d with at least %d prior games. "+
+		"Breakout: a game at %.0f%%+ that is %.0f+ points above the prior three-game average._\n\n",
+		r.GeneratedAt.Format(time.RFC3339), r.Options.FromWeek, r.Options.MinGames, r.Options.StarterPct, r.Options.BreakoutPts)
+	fmt.Fprintf(&b, "Flag thresholds: slope3 >= %.1f, slope5 >= %.1f, EWMA over average >= %.1f.\n\n",
+		r.Thresholds.Slope3, r.Thresholds.Slope5, r.Thresholds.EWMA)
+	b.WriteString("## Overall\n\n")
+	writeTable(&b, r.Overall)
+	for _, s := range r.Seasons {
+		fmt.Fprintf(&b, "\n## %s\n\n", s)
+		writeTable(&b, r.BySeason[s])
+	}
+	return b.String()
+}
+
+func writeTable(b *strings.Builder, scores []Score) {
+	b.WriteString("| Signal | N | MAE | RMSE | Bias | Spearman | Flags | Hit rate | Recall |\n|---|---:|---:|---:|---:|---:|---:|---:|---:|\n")
+	for _, s := range scores {
+		hit, rec := "-", "-"
+		if s.Flags > 0 {
+			hit = fmt.Sprintf("%.1f%% (%d/%d)", 100*s.HitRate, s.Hits, s.Flags)
+			rec = fmt.Sprintf("%.1f%% of %d", 100*s.Recall, s.Breakouts)
+		}
+		fmt.Fprintf(b, "| %s | %d | %.2f | %.2f | %+.2f | %.3f | %d | %s | %s |\n",
+			s.Signal, s.N, s.MAE, s.RMSE, s.Bias, s.Spearman, s.Flags, hit, rec)
+	}
+}
@@ -0,0 +1,93 @@
+package backtest
+
+import (
+	"math"
+
+	"github.com/tyler180/fantasy-football-backends/internal/alerts"
+	"github.com/tyler180/fantasy-football-backends/internal/projection"
+	"github.com/tyler180/fantasy-football-backends/internal/trends"
+)
+
+// Case is what a signal may look at when predicting Week: the player's games before
+// Week only, and the league context (whose lookups are themselves week-bounded).
+type Case struct {
+	Season string
+	Week   int
+	Player projection.Player // Games are trimmed to weeks before Week, in order
+	Series []trends.Point    // the same games as DEF% points
+	Env    *projection.Env
+	Priors map[string]float64 // projection priors as of Week
+}
+
+// Signal predicts a player's next-game DEF% and may flag a breakout.
+type Signal struct {
+	Name    string
+	Predict func(c Case) (pct float64, flag bool)
+}
+
+// Thresholds set when each trend signal raises a breakout flag.
+type Thresholds struct {
+	Slope3 float64 // DEF% per game over the last 3 (default 8)
+	Slope5 float64 // DEF% per game over the last 5 (default 5)
+	EWMA   float64 // EWMA above the season average, in DEF% points (default 8)
+}
+
+func (t Thresholds) withDefaults() Thresholds {
+	if t.Slope3 <= 0 {
+		t.Slope3 = 8
+	}
+	if t.Slope5 <= 0 {
+		t.Slope5 = 5
+	}
+	if t.EWMA <= 0 {
+		t.EWMA = 8
+	}
+	return t
+}
+
+// DefaultSignals are the heuristics stored on player items plus the projection model:
+//
+//	last        next = last game (baseline, never flags)
+//	slope3      next = last + slope3; flags when slope3 >= Thresholds.Slope3
+//	slope5      next = last + slope5; flags when slope5 >= Thresholds.Slope5
+//	ewma        next = EWMA; flags when EWMA - season average >= Thresholds.EWMA
+//	alerts      next = last; flags on a starter_jump or every_down alert
+//	projection  next = projection.Project snap share (never flags)
+func DefaultSignals(t Thresholds) []Signal {
+	t = t.withDefaults()
+	metrics := func(c Case) trends.Metrics { return trends.Compute(c.Series, trends.Options{}) }
+	return []Signal{
+		{Name: "last", Predict: func(c Case) (float64, bool) { return metrics(c).Last, false }},
+		{Name: "slope3", Predict: func(c Case) (float64, bool) {
+			m := metrics(c)
+			return clampPct(m.Last + m.Slope3), len(c.Series) >= 3 && m.Slope3 >= t.Slope3
+		}},
+		{Name: "slope5", Predict: func(c Case) (float64, bool) {
+			m := metrics(c)
+			return clampPct(m.Last + m.Slope5), len(c.Series) >= 5 && m.Slope5 >= t.Slope5
+		}},
+		{Name: "ewma", Predict: func(c Case) (float64, bool) {
+			m := metrics(c)
+			return m.EWMA, m.EWMA-m.SeasonAvg >= t.EWMA
+		}},
+		{Name: "alerts", Predict: func(c Case) (float64, bool) {
+			flag := false
+			for _, f := range alerts.Detect(c.Series, alerts.Options{}) {
+				if f.Kind == alerts.StarterJump || f.Kind == alerts.EveryDown {
+					flag = true
+				}
+			}
+			return metrics(c).Last, flag
+		}},
+		{Name: "projection", Predict: func(c Case) (float64, bool) {
+			opp := c.Env.Opponent(c.Player.Team, c.Week)
+			if opp == "" {
+				opp = "UNK" // the player did play; an unknown opponent falls back to league averages
+			}
+			pr, _ := projection.Project(c.Player, c.Week, opp, "", c.Env, c.Priors, projection.Options{})
+			return pr.SnapPct, false
+		}},
+	}
+}
+
+func clampPct(v float64) float64 { return math.Max(0, math.Min(100, v)) }
@@ -0,0 +1,242 @@
+// Package crosswalk matches MFL player ids to PFR player ids. Matches are seeded from
+// name, position, team and birth date; manual overrides always win, and players that
+// cannot be matched confidently are reported instead of guessed.
+package crosswalk
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/tyler180/fantasy-football-backends/internal/starters"
+)
+
+// Person is one player on either side of the match. Team is an nflverse team code and
+// BirthDate is YYYY-MM-DD; both may be empty.
+type Person struct {
+	ID        string
+	Name      string // "First Last"
+	Position  string
+	Team      string
+	BirthDate string
+}
+
+// Methods recorded on entries. Automatic matches record the signals that agreed,
+// e.g. "name+dob+pos".
+const (
+	MethodOverride  = "override"
+	MethodIDMap     = "id_map"
+	MethodUnmatched = "unmatched"
+)
+
+// Entry is one crosswalk row.
+type Entry struct {
+	MFLID     string
+	PFRID     string // "" when unmatched or overridden to "no PFR player"
+	Method    string
+	Score     float64
+	Name      string
+	Position  string
+	Team      string
+	BirthDate string
+	Reason    string // why an entry is unmatched
+}
+
+// Matched reports whether the entry resolves to a PFR id.
+func (e Entry) Matched() bool { return e.PFRID != "" }
+
+// Options tune automatic matching.
+type Options struct {
+	MinScore float64 // lowest accepted score
+	MinGap   float64 // best must beat the runner-up by this much
+}
+
+// DefaultOptions accepts an exact name plus one more agreeing signal.
+func DefaultOptions() Options { return Options{MinScore: 0.6, MinGap: 0.1} }
+
+// NoPFR is the override value for an MFL player with no PFR counterpart.
+const NoPFR = "NONE"
+
+// Build matches every MFL player. overrides (MFL id -> PFR id or NoPFR) win, then
+// idMap (an external MFL -> PFR id list, used only when the PFR id is known), then
+// scored name matching.
+func Build(mflPlayers, pfrPlayers []Person, overrides, idMap map[string]string, opt Options) []Entry {
+	byName := map[string][]Person{}
+	byLast := map[string][]Person{}
+	known := make(map[string]bool, len(pfrPlayers))
+	for _, p := range pfrPlayers {
+		known[p.ID] = true
+		byName[NormName(p.Name)] = append(byName[NormName(p.Name)], p)
+		if l := lastName(p.Name); l != "" {
+			byLast[l] = append(byLast[l], p)
+		}
+	}
+
+	out := make([]Entry, 0, len(mflPlayers))
+	for _, m := range mflPlayers {
+		e := Entry{MFLID: m.ID, Name: m.Name, Position: m.Position, Team: m.Team, BirthDate: m.BirthDate}
+		if ov, ok := overrides[m.ID]; ok {
+			e.Method, e.Score = MethodOverride, 1
+			if !strings.EqualFold(ov, NoPFR) {
+				e.PFRID = ov
+			}
+			out = append(out, e)
+			continue
+		}
+		if id := idMap[m.ID]; id != "" && known[id] {
+			e.PFRID, e.Method, e.Score = id, MethodIDMap, 1
+			out = append(out, e)
+			continue
+		}
+
+		cands := scoreAll(m, byName[NormName(m.Name)], true)
+		if len(cands) == 0 {
+			// Nicknames ("Cam" vs "Cameron"): same last name and birth date.
+			cands = scoreAll(m, byLast[lastName(m.Name)], false)
+		}
+		out = append(out, pick(e, cands, opt))
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].MFLID < out[j].MFLID })
+	return out
+}
+
+// Unmatched returns the entries without a PFR id, excluding NoPFR overrides.
+func Unmatched(entries []Entry) []Entry {
+	var out []Entry
+	for _, e := range entries {
+		if !e.Matched() && e.Method != MethodOverride {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+// Map returns MFL id -> PFR id for matched entries.
+func Map(entries []Entry) map[string]string {
+	out := make(map[string]string, len(entries))
+	for _, e := range entries {
+		if e.Matched() {
+			out[e.MFLID] = e.PFRID
+		}
+	}
+	return out
+}
+
+type scored struct {
+	p       Person
+	score   float64
+	signals []string
+}
+
+// scoreAll scores candidates: name 0.5 (exact) or 0.25 (last name only), birth date
+// +0.3, team +0.1, position group +0.1. A known birth date or position group that
+// disagrees rules the candidate out.
+func scoreAll(m Person, cands []Person, exactName bool) []scored {
+	var out []scored
+	for _, p := range cands {
+		s := scored{p: p}
+		if exactName {
+			s.score, s.signals = 0.5, []string{"name"}
+		} else {
+			s.score, s.signals = 0.25, []string{"last_name"}
+		}
+		if m.BirthDate != "" && p.BirthDate != "" {
+			if !sameDay(m.BirthDate, p.BirthDate) {
+				continue
+			}
+			s.score += 0.3
+			s.signals = append(s.signals, "dob")
+		} else if !exactName {
+			continue // a last name alone is never enough
+		}
+		if m.Team != "" && m.Team == p.Team {
+			s.score += 0.1
+			s.signals = append(s.signals, "team")
+		}
+		switch mg, pg := positionGroup(m.Position), positionGroup(p.Position); {
+		case mg == "" || pg == "":
+		case mg == pg:
+			s.score += 0.1
+			s.signals = append(s.signals, "pos")
+		default:
+			continue
+		}
+		out = append(out, s)
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].score > out[j].score })
+	return out
+}
+
+func pick(e Entry, cands []scored, opt Options) Entry {
+	e.Method = MethodUnmatched
+	switch {
+	case len(cands) == 0:
+		e.Reason = "no PFR player with this name"
+	case cands[0].score < opt.MinScore:
+		e.Reason = fmt.Sprintf("best candidate %s scored %.2f", cands[0].p.ID, cands[0].score)
+	case len(cands) > 1 && cands[0].score-cands[1].score < opt.MinGap:
+		ids := make([]string, 0, len(cands))
+		for _, c := range cands {
+			if cands[0].score-c.score < opt.MinGap {
+				ids = append(ids, c.p.ID)
+			}
+		}
+		e.Reason = "ambiguous: " + strings.Join(ids, ", ")
+	default:
+		e.PFRID = cands[0].p.ID
+		e.Method = strings.Join(cands[0].signals, "+")
+		e.Score = round2(cands[0].score)
+	}
+	return e
+}
+
+// positionGroup maps defensive codes to DL/LB/DB and keeps offensive codes as-is.
+func positionGroup(pos string) string {
+	if g := starters.GroupOf(pos); g != "" {
+		return g
+	}
+	return strings.ToUpper(strings.TrimSpace(pos))
+}
+
+var suffixes = map[string]bool{"JR": true, "SR": true, "II": true, "III": true, "IV": true, "V": true}
+
+// NormName uppercases, drops punctuation and generational suffixes, and collapses
+// spaces: "Chris Jones Jr." and "CHRIS JONES" normalize the same.
+func NormName(s string) string {
+	repl := strings.NewReplacer(
+		".", "", ",", "", "'", "", "`", "", "’", "",
+		"-", " ", "–", " ", "—", " ",
+		"(", "", ")", "",
+	)
+	fields := strings.Fields(repl.Replace(strings.ToUpper(strings.TrimSpace(s))))
+	out := fields[:0]
+	for _, f := range fields {
+		if !suffixes[f] {
+			out = append(out, f)
+		}
+	}
+	return strings.Join(out, " ")
+}
+
+func lastName(s string) string {
+	f := strings.Fields(NormName(s))
+	if len(f) < 2 {
+		return ""
+	}
+	return f[len(f)-1]
+}
+
+// sameDay compares YYYY-MM-DD dates with a day of slack: MFL stores birth dates as
+// timestamps, which can land on the neighbouring day after a time-zone shift.
+func sameDay(a, b string) bool {
+	ta, errA := time.Parse("2006-01-02", a)
+	tb, errB := time.Parse("2006-01-02", b)
+	if errA != nil || errB != nil {
+		return a == b
+	}
+	d := ta.Sub(tb)
+	return d >= -24*time.Hour && d <= 24*time.Hour
+}
+
+func round2(v float64) float64 { return float64(int(v*100+0.5)) / 100 }
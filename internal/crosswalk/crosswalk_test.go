@@ -0,0 +1,82 @@
+package crosswalk
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBuild_SignalsOverridesAndUnmatched(t *testing.T) {
+	pfr := []Person{
+		{ID: "JoneCh03", Name: "Chris Jones", Position: "DT", Team: "KC", BirthDate: "1994-07-03"},
+		{ID: "JoneCh01", Name: "Chris Jones", Position: "DE", Team: "ARI", BirthDate: "1995-11-12"},
+		{ID: "SmitJo00", Name: "John Smith", Position: "LB", Team: "SEA"},
+		{ID: "SmitJo01", Name: "John Smith", Position: "OLB", Team: "DAL"},
+		{ID: "CampCa00", Name: "Cameron Campbell", Position: "CB", Team: "GB", BirthDate: "2000-01-01"},
+		{ID: "DoeJa00", Name: "Jake Doe", Position: "WR", Team: "NYJ"},
+		{ID: "RoeRi00", Name: "Rick Roe", Position: "S", Team: "ATL"},
+	}
+	mfl := []Person{
+		{ID: "1", Name: "Chris Jones Jr.", Position: "DT", Team: "KC", BirthDate: "1994-07-03"}, // suffix + dob
+		{ID: "2", Name: "John Smith", Position: "LB"},                                           // two LBs, no tiebreak
+		{ID: "3", Name: "Cam Campbell", Position: "CB", BirthDate: "1999-12-31"},                // nickname, dob a day off
+		{ID: "4", Name: "Jake Doe", Position: "LB", Team: "NYJ"},                                // position conflict
+		{ID: "5", Name: "Nobody Here", Position: "S"},
+		{ID: "6", Name: "John Smith", Position: "LB"},
+		{ID: "7", Name: "Rick Roe", Position: "S", Team: "ATL"},
+		{ID: "8", Name: "Gone Player", Position: "LB"},
+	}
+	overrides := map[string]string{"6": "SmitJo01", "8": NoPFR}
+	got := Build(mfl, pfr, overrides, map[string]string{"7": "RoeRi00"}, DefaultOptions())
+	by := map[string]Entry{}
+	for _, e := range got {
+		by[e.MFLID] = e
+	}
+
+	if e := by["1"]; e.PFRID != "JoneCh03" || e.Method != "name+dob+team+pos" || e.Score != 1 {
+		t.Fatalf("suffix/dob match = %+v", e)
+	}
+	if e := by["2"]; e.Matched() || !strings.HasPrefix(e.Reason, "ambiguous: SmitJo00, SmitJo01") {
+		t.Fatalf("ambiguous = %+v", e)
+	}
+	if e := by["3"]; e.PFRID != "CampCa00" || e.Method != "last_name+dob+pos" {
+		t.Fatalf("nickname = %+v", e)
+	}
+	if e := by["4"]; e.Matched() || e.Reason != "no PFR player with this name" {
+		t.Fatalf("position conflict = %+v", e)
+	}
+	if e := by["6"]; e.PFRID != "SmitJo01" || e.Method != MethodOverride {
+		t.Fatalf("override = %+v", e)
+	}
+	if e := by["7"]; e.Method != MethodIDMap {
+		t.Fatalf("id map = %+v", e)
+	}
+
+	un := Unmatched(got)
+	if len(un) != 3 { // 2, 4, 5; 8 is overridden to NoPFR
+		t.Fatalf("unmatched = %+v", un)
+	}
+	if ov := Overrides(got); ov["8"] != NoPFR || ov["6"] != "SmitJo01" || len(ov) != 2 {
+		t.Fatalf("overrides = %v", ov)
+	}
+	if m := Map(got); len(m) != 4 {
+		t.Fatalf("map = %v", m)
+	}
+}
+
+func TestParseOverrides(t *testing.T) {
+	csv := "mfl_id,pfr_id,note\n# comment\n123, SmitJo01 ,the Dallas one\n456,NONE\n"
+	got, err := ParseOverrides([]byte(csv))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got["123"] != "SmitJo01" || got["456"] != NoPFR || len(got) != 2 {
+		t.Fatalf("csv overrides = %v", got)
+	}
+	got, err = ParseOverrides([]byte(`{"123": "SmitJo00"}`))
+	if err != nil || got["123"] != "SmitJo00" {
+		t.Fatalf("json overrides = %v, %v", got, err)
+	}
+	if _, err := ParseOverrides([]byte("123\n")); err == nil {
+		t.Fatal("expected an error for a one-column line")
+	}
+}
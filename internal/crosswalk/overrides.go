@@ -0,0 +1,74 @@
+package crosswalk
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Overrides collects the override entries already stored in the crosswalk as
+// MFL id -> PFR id (NoPFR for "no counterpart").
+func Overrides(entries []Entry) map[string]string {
+	out := map[string]string{}
+	for _, e := range entries {
+		if e.Method != MethodOverride {
+			continue
+		}
+		if e.PFRID == "" {
+			out[e.MFLID] = NoPFR
+		} else {
+			out[e.MFLID] = e.PFRID
+		}
+	}
+	return out
+}
+
+// LoadOverridesFile reads overrides from a JSON object ({"mfl_id": "pfr_id"}) or a CSV
+// with mfl_id,pfr_id columns (extra columns such as a note are ignored).
+func LoadOverridesFile(path string) (map[string]string, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read overrides: %w", err)
+	}
+	return ParseOverrides(b)
+}
+
+// ParseOverrides decodes the JSON or CSV override formats.
+func ParseOverrides(b []byte) (map[string]string, error) {
+	b = bytes.TrimSpace(b)
+	out := map[string]string{}
+	if len(b) > 0 && b[0] == '{' {
+		if err := json.Unmarshal(b, &out); err != nil {
+			return nil, fmt.Errorf("parse overrides json: %w", err)
+		}
+		return out, nil
+	}
+
+	r := csv.NewReader(bytes.NewReader(b))
+	r.FieldsPerRecord = -1
+	r.Comment = '#'
+	for line := 1; ; line++ {
+		rec, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("parse overrides csv: %w", err)
+		}
+		if len(rec) < 2 {
+			return nil, fmt.Errorf("overrides line %d: want mfl_id,pfr_id", line)
+		}
+		mfl, pfr := strings.TrimSpace(rec[0]), strings.TrimSpace(rec[1])
+		if line == 1 && strings.EqualFold(mfl, "mfl_id") {
+			continue
+		}
+		if mfl != "" {
+			out[mfl] = pfr
+		}
+	}
+	return out, nil
+}
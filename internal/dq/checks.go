@@ -0,0 +1,172 @@
+package dq
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Teams is the number of NFL teams.
+const Teams = 32
+
+// Defenders per team per week: players with a defensive snap in a game.
+const (
+	MinDefenders = 10
+	MaxDefenders = 20
+)
+
+// Curated is the suite for the curator's tables. Only checks whose table is in tables
+// are returned; no tables means all of them.
+func Curated(tables ...string) []Check {
+	all := []Check{
+		TeamsPresent("snap_counts", Teams),
+		TeamWeekCount("snap_counts", "defenders_per_team_week", "defense_pct > 0", MinDefenders, MaxDefenders),
+		Range("snap_counts", "def_pct_range", 0, 100, "defense_pct"),
+		Unique("snap_counts", "week", "team", "COALESCE(NULLIF(player_id, ''), NULLIF(pfr_id, ''), player)"),
+		WeekCoverage("snap_counts"),
+		TeamsPresent("rosters_weekly", Teams),
+		Unique("rosters_weekly", "week", "team", "COALESCE(NULLIF(player_id, ''), NULLIF(pfr_id, ''), full_name)"),
+		WeekCoverage("rosters_weekly"),
+	}
+	if len(tables) == 0 {
+		return all
+	}
+	var out []Check
+	for _, c := range all {
+		for _, t := range tables {
+			if strings.TrimSpace(t) == c.Table {
+				out = append(out, c)
+				break
+			}
+		}
+	}
+	return out
+}
+
+// Starters is the suite for the materialized starters table. There is no team-count
+// check: a team can legitimately have no starter young enough to qualify.
+func Starters(table string) []Check {
+	return []Check{
+		Range(table, "def_pct_range", 0, 100, "avg_def_pct", "recent_def_pct", "min_def_pct", "max_def_pct"),
+		Unique(table, "team", "COALESCE(NULLIF(player_id, ''), pfr_id)"),
+	}
+}
+
+// TeamsPresent asserts the season's completed weeks have exactly n distinct teams.
+// Rows for weeks not yet played (such as preseason rosters) are not counted.
+func TeamsPresent(table string, n int) Check {
+	return Check{
+		Name:  table + ".teams_present",
+		Table: table,
+		Rule:  fmt.Sprintf("%d distinct teams through the latest completed week", n),
+		Query: func(s Scope) string {
+			if s.Through < 1 {
+				return ""
+			}
+			return fmt.Sprintf("SELECT COUNT(DISTINCT team) AS teams\nFROM %s.%s\nWHERE %s AND TRY_CAST(week AS INTEGER) <= %d\nHAVING COUNT(DISTINCT team) <> %d",
+				s.DB, table, seasonIs(s), s.Through, n)
+		},
+	}
+}
+
+// TeamWeekCount asserts every team has between lo and hi rows matching where in each
+// week it played.
+func TeamWeekCount(table, rule, where string, lo, hi int) Check {
+	return Check{
+		Name:  table + "." + rule,
+		Table: table,
+		Rule:  fmt.Sprintf("%d-%d rows per team and week where %s", lo, hi, where),
+		Query: func(s Scope) string {
+			return fmt.Sprintf(`SELECT team, TRY_CAST(week AS INTEGER) AS week, COUNT(*) AS n
+FROM %s.%s
+WHERE %s AND (%s)
+GROUP BY team, TRY_CAST(week AS INTEGER)
+HAVING COUNT(*) < %d OR COUNT(*) > %d
+ORDER BY 2, 1`, s.DB, table, seasonIs(s), where, lo, hi)
+		},
+	}
+}
+
+// Range asserts cols are within [lo, hi]; NULL passes.
+func Range(table, rule string, lo, hi float64, cols ...string) Check {
+	var out []string
+	for _, c := range cols {
+		out = append(out, fmt.Sprintf("%s < %g OR %s > %g", c, lo, c, hi))
+	}
+	return Check{
+		Name:  table + "." + rule,
+		Table: table,
+		Rule:  fmt.Sprintf("%s within %g-%g", strings.Join(cols, ", "), lo, hi),
+		Query: func(s Scope) string {
+			return fmt.Sprintf("SELECT team, %s\nFROM %s.%s\nWHERE %s\n  AND (%s)",
+				strings.Join(cols, ", "), s.DB, table, seasonIs(s), strings.Join(out, " OR "))
+		},
+	}
+}
+
+// Unique asserts no two rows of the season share key (SQL expressions).
+func Unique(table string, key ...string) Check {
+	k := strings.Join(key, ", ")
+	return Check{
+		Name:  table + ".unique",
+		Table: table,
+		Rule:  "one row per season, " + k,
+		Query: func(s Scope) string {
+			return fmt.Sprintf("SELECT %s, COUNT(*) AS n\nFROM %s.%s\nWHERE %s\nGROUP BY %s\nHAVING COUNT(*) > 1",
+				k, s.DB, table, seasonIs(s), k)
+		},
+	}
+}
+
+// WeekCoverage asserts every week from 1 through the latest completed one has rows.
+func WeekCoverage(table string) Check {
+	return Check{
+		Name:  table + ".week_coverage",
+		Table: table,
+		Rule:  "rows for every week through the latest completed one",
+		Query: func(s Scope) string {
+			if s.Through < 1 {
+				return ""
+			}
+			weeks := make([]string, s.Through)
+			for i := range weeks {
+				weeks[i] = fmt.Sprintf("(%d)", i+1)
+			}
+			return fmt.Sprintf(`SELECT w.week AS missing_week
+FROM (VALUES %s) AS w(week)
+LEFT JOIN (
+  SELECT DISTINCT TRY_CAST(week AS INTEGER) AS week FROM %s.%s WHERE %s
+) d ON d.week = w.week
+WHERE d.week IS NULL
+ORDER BY 1`, strings.Join(weeks, ", "), s.DB, table, seasonIs(s))
+		},
+	}
+}
+
+func seasonIs(s Scope) string {
+	return fmt.Sprintf("TRY_CAST(season AS INTEGER) = %d", s.Season)
+}
+
+// RegularSeasonWeeks is the length of season's regular season.
+func RegularSeasonWeeks(season int) int {
+	if season >= 2021 {
+		return 18
+	}
+	return 17
+}
+
+// CompletedWeek is the latest regular-season week of season whose games have all been
+// played at now. Week 1 opens the Thursday after Labor Day; a week is done once its
+// Monday night game is, counted from Tuesday 12:00 UTC.
+func CompletedWeek(season int, now time.Time) int {
+	labor := time.Date(season, time.September, 1, 0, 0, 0, 0, time.UTC)
+	for labor.Weekday() != time.Monday {
+		labor = labor.AddDate(0, 0, 1)
+	}
+	firstDone := labor.AddDate(0, 0, 8).Add(12 * time.Hour) // Tuesday after week 1
+	if now.Before(firstDone) {
+		return 0
+	}
+	week := 1 + int(now.Sub(firstDone)/(7*24*time.Hour))
+	return min(week, RegularSeasonWeeks(season))
+}
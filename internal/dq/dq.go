@@ -0,0 +1,203 @@
+// Package dq runs declarative data-quality checks against the curated and
+// materialized Athena tables. A Check is a query returning one row per violation, so
+// an empty result passes; Run executes a suite for one season, and Save writes the
+// results to S3 as JSON lines next to the cost ledger.
+package dq
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+
+	"github.com/tyler180/fantasy-football-backends/internal/ath"
+)
+
+// Result statuses.
+const (
+	Pass  = "pass"
+	Fail  = "fail"
+	Error = "error" // the check could not run; counts as a failure
+	Skip  = "skip"  // nothing to check yet, e.g. week coverage before week 1 ends
+)
+
+// MaxViolations caps the rows read per check; SampleSize of them are kept.
+const (
+	MaxViolations = 100
+	SampleSize    = 5
+)
+
+// ErrFailed is wrapped by the error Report.Err returns.
+var ErrFailed = errors.New("data quality checks failed")
+
+// Scope is what a check runs against.
+type Scope struct {
+	DB      string
+	Season  int
+	Through int // latest completed week (see CompletedWeek); 0 before week 1 ends
+}
+
+// Check is one declarative assertion about a table.
+type Check struct {
+	Name  string // <table>.<rule>, stable across runs
+	Table string // table read, without the database
+	Rule  string // what must hold, one line
+	// Query returns SQL yielding one row per violation, or "" when there is nothing to
+	// check in s.
+	Query func(s Scope) string
+}
+
+// Querier runs one statement and returns its rows; *ath.Client and the local DuckDB
+// engine both satisfy it.
+type Querier interface {
+	Query(ctx context.Context, sql string) ([][]string, error)
+}
+
+// Result is one check's outcome.
+type Result struct {
+	Check      string     `json:"check"`
+	Table      string     `json:"table"`
+	Rule       string     `json:"rule"`
+	Status     string     `json:"status"`
+	Violations int        `json:"violations"`
+	Truncated  bool       `json:"truncated,omitempty"` // more than MaxViolations
+	Sample     [][]string `json:"sample,omitempty"`
+	Error      string     `json:"error,omitempty"`
+}
+
+// Failed reports whether r fails the run.
+func (r Result) Failed() bool { return r.Status == Fail || r.Status == Error }
+
+// Report is one run of a suite.
+type Report struct {
+	Job     string    `json:"job"`
+	RunID   string    `json:"run_id"`
+	Season  int       `json:"season"`
+	Through int       `json:"through_week"`
+	At      time.Time `json:"at"`
+	Results []Result  `json:"results"`
+}
+
+// Run executes checks in order against s. A check that cannot run is recorded with
+// Status Error and the rest still run; Run itself never fails.
+func Run(ctx context.Context, q Querier, job, runID string, checks []Check, s Scope) Report {
+	rep := Report{Job: job, RunID: runID, Season: s.Season, Through: s.Through, At: time.Now().UTC()}
+	for _, c := range checks {
+		res := Result{Check: c.Name, Table: c.Table, Rule: c.Rule, Status: Skip}
+		sql := c.Query(s)
+		if sql == "" {
+			rep.Results = append(rep.Results, res)
+			continue
+		}
+		rows, err := q.Query(ath.WithLabel(ctx, "dq "+c.Name, s.Season),
+			fmt.Sprintf("SELECT * FROM (\n%s\n) v\nLIMIT %d", sql, MaxViolations+1))
+		switch {
+		case err != nil:
+			res.Status, res.Error = Error, err.Error()
+		case len(rows) == 0:
+			res.Status = Pass
+		default:
+			res.Status = Fail
+			if len(rows) > MaxViolations {
+				rows, res.Truncated = rows[:MaxViolations], true
+			}
+			res.Violations = len(rows)
+			res.Sample = rows[:min(len(rows), SampleSize)]
+		}
+		rep.Results = append(rep.Results, res)
+	}
+	return rep
+}
+
+// Failed returns the results that fail the run.
+func (r Report) Failed() []Result {
+	var out []Result
+	for _, res := range r.Results {
+		if res.Failed() {
+			out = append(out, res)
+		}
+	}
+	return out
+}
+
+// Err is nil when every check passed or was skipped, otherwise an error naming the
+// failed checks that wraps ErrFailed.
+func (r Report) Err() error {
+	failed := r.Failed()
+	if len(failed) == 0 {
+		return nil
+	}
+	names := make([]string, len(failed))
+	for i, f := range failed {
+		if f.Status == Error {
+			names[i] = fmt.Sprintf("%s (error: %s)", f.Check, f.Error)
+			continue
+		}
+		n := fmt.Sprint(f.Violations)
+		if f.Truncated {
+			n += "+"
+		}
+		names[i] = fmt.Sprintf("%s (%s violations)", f.Check, n)
+	}
+	return fmt.Errorf("%w: %s season=%d: %d of %d: %s", ErrFailed, r.Job, r.Season, len(failed), len(r.Results), strings.Join(names, ", "))
+}
+
+// String summarizes the report for logs.
+func (r Report) String() string {
+	count := map[string]int{}
+	for _, res := range r.Results {
+		count[res.Status]++
+	}
+	return fmt.Sprintf("%s season=%d through week %d: %d pass, %d fail, %d error, %d skip",
+		r.Job, r.Season, r.Through, count[Pass], count[Fail], count[Error], count[Skip])
+}
+
+// S3PutAPI is the S3 call Save needs.
+type S3PutAPI interface {
+	PutObject(ctx context.Context, in *s3.PutObjectInput, optFns ...func(*s3.Options)) (*s3.PutObjectOutput, error)
+}
+
+// Save writes rep as one JSON line per check to
+// <uri>/job=<job>/dt=YYYY-MM-DD/<run>.jsonl, so results can be queried from Athena,
+// and returns the object's URI.
+func Save(ctx context.Context, api S3PutAPI, uri string, rep Report) (string, error) {
+	rest, ok := strings.CutPrefix(uri, "s3://")
+	if !ok {
+		return "", fmt.Errorf("dq results: not an s3:// uri: %q", uri)
+	}
+	bucket, prefix, _ := strings.Cut(rest, "/")
+	key := fmt.Sprintf("job=%s/dt=%s/%s.jsonl", rep.Job, rep.At.Format(time.DateOnly), rep.RunID)
+	if prefix = strings.Trim(prefix, "/"); prefix != "" {
+		key = prefix + "/" + key
+	}
+	type line struct {
+		Job     string    `json:"job"`
+		RunID   string    `json:"run_id"`
+		Season  int       `json:"season"`
+		Through int       `json:"through_week"`
+		At      time.Time `json:"at"`
+		Result
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, res := range rep.Results {
+		if err := enc.Encode(line{rep.Job, rep.RunID, rep.Season, rep.Through, rep.At, res}); err != nil {
+			return "", err
+		}
+	}
+	if _, err := api.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(bucket),
+		Key:         aws.String(key),
+		Body:        bytes.NewReader(buf.Bytes()),
+		ContentType: aws.String("application/x-ndjson"),
+	}); err != nil {
+		return "", fmt.Errorf("dq results: put s3://%s/%s: %w", bucket, key, err)
+	}
+	return "s3://" + bucket + "/" + key, nil
+}
@@ -0,0 +1,130 @@
+package dq_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+
+	"github.com/tyler180/fantasy-football-backends/internal/ath"
+	"github.com/tyler180/fantasy-football-backends/internal/ath/athtest"
+	"github.com/tyler180/fantasy-football-backends/internal/dq"
+)
+
+func TestRunCuratedSuite(t *testing.T) {
+	f := &athtest.Fake{}
+	f.On("defense_pct < 0", []string{"team", "defense_pct"}, []any{"SEA", 140.0}, []any{"KC", -1.0})
+	f.On("missing_week", nil).Fail = "TABLE_NOT_FOUND: rosters_weekly"
+	f.On("", []string{"n"})
+	c := ath.New(f, "nflverse_curated", "wg", "s3://results/")
+	c.Poll, c.MaxPoll = time.Millisecond, time.Millisecond
+
+	checks := dq.Curated("snap_counts")
+	rep := dq.Run(context.Background(), c, "curator", "r1", checks, dq.Scope{DB: "nflverse_curated", Season: 2024, Through: 3})
+	if len(rep.Results) != len(checks) || len(checks) != 5 {
+		t.Fatalf("results = %+v", rep.Results)
+	}
+	got := map[string]dq.Result{}
+	for _, r := range rep.Results {
+		got[r.Check] = r
+	}
+	if r := got["snap_counts.def_pct_range"]; r.Status != dq.Fail || r.Violations != 2 || r.Sample[0][1] != "140" {
+		t.Errorf("range = %+v", r)
+	}
+	if r := got["snap_counts.week_coverage"]; r.Status != dq.Error || !strings.Contains(r.Error, "TABLE_NOT_FOUND") {
+		t.Errorf("coverage = %+v", r)
+	}
+	if r := got["snap_counts.teams_present"]; r.Status != dq.Pass {
+		t.Errorf("teams = %+v", r)
+	}
+	err := rep.Err()
+	if !errors.Is(err, dq.ErrFailed) || !strings.Contains(err.Error(), "2 of 5") || !strings.Contains(err.Error(), "def_pct_range (2 violations)") {
+		t.Errorf("err = %v", err)
+	}
+
+	var coverage, teams string
+	for _, sql := range f.SQL() {
+		if !strings.HasSuffix(sql, "LIMIT 101") || !strings.Contains(sql, "TRY_CAST(season AS INTEGER) = 2024") {
+			t.Errorf("check SQL not limited or not season-scoped:\n%s", sql)
+		}
+		if strings.Contains(sql, "missing_week") {
+			coverage = sql
+		}
+		if strings.Contains(sql, "COUNT(DISTINCT team)") {
+			teams = sql
+		}
+	}
+	if !strings.Contains(coverage, "(VALUES (1), (2), (3)) AS w(week)") {
+		t.Errorf("coverage SQL = %s", coverage)
+	}
+	if !strings.Contains(teams, "AND TRY_CAST(week AS INTEGER) <= 3\n") {
+		t.Errorf("teams SQL counts weeks not yet played:\n%s", teams)
+	}
+
+	// Before week 1 is over there is no coverage or team count to check.
+	rep = dq.Run(context.Background(), c, "curator", "r2", []dq.Check{dq.WeekCoverage("snap_counts"), dq.TeamsPresent("rosters_weekly", dq.Teams)}, dq.Scope{DB: "db", Season: 2024})
+	if rep.Results[0].Status != dq.Skip || rep.Results[1].Status != dq.Skip || rep.Err() != nil {
+		t.Errorf("pre-season checks = %+v", rep.Results)
+	}
+}
+
+func TestCompletedWeek(t *testing.T) {
+	at := func(s string) time.Time {
+		v, err := time.Parse(time.DateTime, s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return v
+	}
+	for _, c := range []struct {
+		now  string
+		want int
+	}{
+		{"2024-08-20 00:00:00", 0},
+		{"2024-09-09 03:00:00", 0}, // week 1's Monday night game not played yet
+		{"2024-09-10 12:00:00", 1},
+		{"2024-09-16 03:00:00", 1}, // the Monday cron
+		{"2024-09-17 12:00:00", 2},
+		{"2025-02-01 00:00:00", 18},
+	} {
+		if got := dq.CompletedWeek(2024, at(c.now)); got != c.want {
+			t.Errorf("CompletedWeek(2024, %s) = %d, want %d", c.now, got, c.want)
+		}
+	}
+	if got := dq.CompletedWeek(2020, at("2021-01-10 00:00:00")); got != 17 {
+		t.Errorf("2020 = %d, want 17", got)
+	}
+}
+
+type putS3 struct {
+	key, body string
+}
+
+func (p *putS3) PutObject(_ context.Context, in *s3.PutObjectInput, _ ...func(*s3.Options)) (*s3.PutObjectOutput, error) {
+	b, _ := io.ReadAll(in.Body)
+	p.key, p.body = aws.ToString(in.Bucket)+"/"+aws.ToString(in.Key), string(b)
+	return &s3.PutObjectOutput{}, nil
+}
+
+func TestSave(t *testing.T) {
+	rep := dq.Report{Job: "materializer", RunID: "20240916030500", Season: 2024, Through: 1,
+		At:      time.Date(2024, 9, 16, 3, 5, 0, 0, time.UTC),
+		Results: []dq.Result{{Check: "a.unique", Status: dq.Pass}, {Check: "a.def_pct_range", Status: dq.Fail, Violations: 1}}}
+	p := &putS3{}
+	uri, err := dq.Save(context.Background(), p, "s3://athena-out/dq/", rep)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "s3://athena-out/dq/job=materializer/dt=2024-09-16/20240916030500.jsonl"; uri != want || "s3://"+p.key != want {
+		t.Errorf("uri = %s (put %s), want %s", uri, p.key, want)
+	}
+	lines := strings.Split(strings.TrimSpace(p.body), "\n")
+	if len(lines) != 2 || !strings.Contains(lines[1], `"check":"a.def_pct_range"`) || !strings.Contains(lines[1], `"run_id":"20240916030500"`) {
+		t.Errorf("body = %s", p.body)
+	}
+}
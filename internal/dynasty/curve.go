@@ -0,0 +1,238 @@
+// Package dynasty fits age curves for defensive players from multi-season snap and
+// production history and turns them into a dynasty value: the discounted IDP points a
+// player is expected to score over the next few seasons.
+//
+// Curves use the delta method: every pair of back-to-back qualifying seasons by the
+// same player contributes the change in DEF% and points per game at that age,
+// weighted by the harmonic mean of games played. Deltas are shrunk toward zero where
+// an age has few pairs, then accumulated into a level per age. Survival, the share of
+// qualifying seasons followed by another one, captures players who lose their job or
+// leave the league.
+package dynasty
+
+import (
+	"math"
+	"sort"
+
+	"github.com/tyler180/fantasy-football-backends/internal/starters"
+)
+
+// Season is one player-season of history.
+type Season struct {
+	Season  int     `json:"season"`
+	Team    string  `json:"team,omitempty"`
+	Age     int     `json:"age"`      // age in the season, 0 = unknown
+	G       int     `json:"g"`        // games played
+	GS      int     `json:"gs"`       // games started
+	SnapPct float64 `json:"snap_pct"` // season DEF%, 0..100
+	PPG     float64 `json:"ppg"`      // IDP points per game
+	Scored  bool    `json:"scored"`   // PPG is known for the season
+}
+
+// Player is one defender's history, oldest season first or in any order.
+type Player struct {
+	PlayerID string
+	Player   string
+	Pos      string
+	Seasons  []Season
+}
+
+// Options control the fit and the valuation. Zero values select the defaults.
+type Options struct {
+	MinGames int     // games a season needs to count (default 4)
+	MinAge   int     // youngest age on the curve (default 21)
+	MaxAge   int     // oldest age on the curve (default 36)
+	Prior    float64 // games of zero-change pseudo-data added to every age delta (default 40)
+	Horizon  int     // seasons valued (default 5)
+	Discount float64 // per-season discount on future points (default 0.85)
+	Games    int     // games per season (default 17)
+}
+
+func (o Options) withDefaults() Options {
+	if o.MinGames <= 0 {
+		o.MinGames = 4
+	}
+	if o.MinAge <= 0 {
+		o.MinAge = 21
+	}
+	if o.MaxAge <= o.MinAge {
+		o.MaxAge = 36
+	}
+	if o.Prior <= 0 {
+		o.Prior = 40
+	}
+	if o.Horizon <= 0 {
+		o.Horizon = 5
+	}
+	if o.Discount <= 0 || o.Discount > 1 {
+		o.Discount = 0.85
+	}
+	if o.Games <= 0 {
+		o.Games = 17
+	}
+	return o
+}
+
+// Point is one age on a group's curve. Snap and PPG are relative to the group's peak
+// (0 at the peak, negative elsewhere).
+type Point struct {
+	Age      int     `json:"age"`
+	Snap     float64 `json:"snap"`      // DEF% points relative to peak
+	PPG      float64 `json:"ppg"`       // points per game relative to peak
+	Survival float64 `json:"survival"`  // chance a qualifying season at this age is followed by another
+	Pairs    int     `json:"pairs"`     // back-to-back seasons starting at this age
+	Seasons  int     `json:"seasons"`   // qualifying seasons at this age
+	SnapStep float64 `json:"snap_step"` // expected DEF% change to the next age
+	PPGStep  float64 `json:"ppg_step"`  // expected points-per-game change to the next age
+}
+
+// Curve is one position group's expected-value curve.
+type Curve struct {
+	Group   string  `json:"group"`
+	PeakAge int     `json:"peak_age"` // age of the highest points-per-game level
+	PPGPer  float64 `json:"ppg_per_snap_pct"`
+	Points  []Point `json:"points"`
+}
+
+// point returns the curve at age, clamped to the fitted range.
+func (c Curve) point(age int) Point {
+	if len(c.Points) == 0 {
+		return Point{Age: age, Survival: 1}
+	}
+	i := age - c.Points[0].Age
+	i = max(0, min(i, len(c.Points)-1))
+	return c.Points[i]
+}
+
+// Model is the fitted curves keyed by group, plus the seasons they came from.
+type Model struct {
+	Seasons []int            `json:"seasons"`
+	Options Options          `json:"options"`
+	Curves  map[string]Curve `json:"curves"`
+}
+
+type stepAcc struct {
+	snapW, snapSum float64
+	ppgW, ppgSum   float64
+	pairs          int
+	seasons, kept  int
+}
+
+// Fit builds one curve per position group from players' season histories.
+func Fit(players []Player, opt Options) Model {
+	opt = opt.withDefaults()
+	m := Model{Options: opt, Curves: map[string]Curve{}}
+
+	lastSeason := 0
+	seen := map[int]bool{}
+	for _, p := range players {
+		for _, s := range p.Seasons {
+			lastSeason = max(lastSeason, s.Season)
+			if !seen[s.Season] {
+				seen[s.Season] = true
+				m.Seasons = append(m.Seasons, s.Season)
+			}
+		}
+	}
+	sort.Ints(m.Seasons)
+
+	acc := map[string]map[int]*stepAcc{}
+	ppgSum, snapSum := map[string]float64{}, map[string]float64{}
+	get := func(g string, age int) *stepAcc {
+		if acc[g] == nil {
+			acc[g] = map[int]*stepAcc{}
+		}
+		if acc[g][age] == nil {
+			acc[g][age] = &stepAcc{}
+		}
+		return acc[g][age]
+	}
+	for _, p := range players {
+		g := starters.GroupOf(p.Pos)
+		if g == "" {
+			continue
+		}
+		by := map[int]Season{}
+		for _, s := range p.Seasons {
+			if s.G >= opt.MinGames && s.Age > 0 {
+				by[s.Season] = s
+			}
+		}
+		for yr, s := range by {
+			if s.Scored {
+				ppgSum[g] += s.PPG * float64(s.G)
+				snapSum[g] += s.SnapPct * float64(s.G)
+			}
+			if yr >= lastSeason {
+				continue // no later season to survive into yet
+			}
+			a := get(g, s.Age)
+			a.seasons++
+			next, ok := by[yr+1]
+			if !ok {
+				continue
+			}
+			a.kept++
+			a.pairs++
+			w := 2 / (1/float64(s.G) + 1/float64(next.G))
+			a.snapW += w
+			a.snapSum += w * (next.SnapPct - s.SnapPct)
+			if s.Scored && next.Scored {
+				a.ppgW += w
+				a.ppgSum += w * (next.PPG - s.PPG)
+			}
+		}
+	}
+
+	for g, ages := range acc {
+		var seasons, kept int
+		for _, a := range ages {
+			seasons += a.seasons
+			kept += a.kept
+		}
+		base := 0.0
+		if seasons > 0 {
+			base = float64(kept) / float64(seasons)
+		}
+		c := Curve{Group: g}
+		if snapSum[g] > 0 {
+			c.PPGPer = ppgSum[g] / snapSum[g]
+		}
+		snap, ppg := 0.0, 0.0
+		for age := opt.MinAge; age <= opt.MaxAge; age++ {
+			pt := Point{Age: age, Snap: snap, PPG: ppg, Survival: base}
+			if a := ages[age]; a != nil {
+				pt.Pairs, pt.Seasons = a.pairs, a.seasons
+				pt.SnapStep = a.snapSum / (a.snapW + opt.Prior)
+				pt.PPGStep = a.ppgSum / (a.ppgW + opt.Prior)
+				// Survival shrinks toward the group rate by 10 pseudo-seasons.
+				pt.Survival = (float64(a.kept) + 10*base) / (float64(a.seasons) + 10)
+			}
+			c.Points = append(c.Points, pt)
+			snap += pt.SnapStep
+			ppg += pt.PPGStep
+		}
+		peakSnap, peakPPG := math.Inf(-1), math.Inf(-1)
+		for _, pt := range c.Points {
+			peakSnap = math.Max(peakSnap, pt.Snap)
+			if pt.PPG > peakPPG {
+				peakPPG, c.PeakAge = pt.PPG, pt.Age
+			}
+		}
+		for i := range c.Points {
+			c.Points[i].Snap = round(c.Points[i].Snap-peakSnap, 2)
+			c.Points[i].PPG = round(c.Points[i].PPG-peakPPG, 3)
+			c.Points[i].SnapStep = round(c.Points[i].SnapStep, 2)
+			c.Points[i].PPGStep = round(c.Points[i].PPGStep, 3)
+			c.Points[i].Survival = round(c.Points[i].Survival, 3)
+		}
+		c.PPGPer = round(c.PPGPer, 4)
+		m.Curves[g] = c
+	}
+	return m
+}
+
+func round(v float64, places int) float64 {
+	p := math.Pow(10, float64(places))
+	return math.Round(v*p) / p
+}
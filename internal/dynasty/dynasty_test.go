@@ -0,0 +1,156 @@
+package dynasty
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+// history builds LBs following snap = 80 - 4*|age-26|, PPG = snap/10, each playing
+// from startAge for n seasons ending no later than 2024.
+func history(id string, startAge, first, n int) Player {
+	p := Player{PlayerID: id, Player: id, Pos: "LB"}
+	for k := 0; k < n; k++ {
+		age := startAge + k
+		snap := 80 - 4*math.Abs(float64(age-26))
+		p.Seasons = append(p.Seasons, Season{Season: first + k, Age: age, G: 16, SnapPct: snap, PPG: snap / 10, Scored: true})
+	}
+	return p
+}
+
+func fixture() []Player {
+	var ps []Player
+	for i, start := range []int{21, 22, 23, 24, 25, 26, 27, 28, 29, 30} {
+		for j := 0; j < 6; j++ {
+			id := string(rune('A'+i)) + string(rune('a'+j))
+			ps = append(ps, history(id, start, 2018, 7))
+		}
+	}
+	return ps
+}
+
+func TestFitFindsPeak(t *testing.T) {
+	m := Fit(fixture(), Options{Prior: 1})
+	c, ok := m.Curves["LB"]
+	if !ok {
+		t.Fatalf("no LB curve: %+v", m.Curves)
+	}
+	if c.PeakAge != 26 {
+		t.Fatalf("peak age = %d, want 26", c.PeakAge)
+	}
+	if len(m.Seasons) != 7 || m.Seasons[0] != 2018 {
+		t.Fatalf("seasons = %v", m.Seasons)
+	}
+	if p := c.point(24); p.SnapStep < 3 || p.SnapStep > 4 {
+		t.Fatalf("age 24 snap step = %.2f, want ~+4 shrunk", p.SnapStep)
+	}
+	if p := c.point(30); p.Snap >= c.point(27).Snap {
+		t.Fatalf("age 30 (%.1f) should sit below age 27 (%.1f)", p.Snap, c.point(27).Snap)
+	}
+	if math.Abs(c.PPGPer-0.1) > 1e-3 {
+		t.Fatalf("ppg per snap pct = %.4f, want 0.1", c.PPGPer)
+	}
+}
+
+func TestSurvival(t *testing.T) {
+	ps := fixture()
+	// Every 33-year-old retires.
+	for i := 0; i < 10; i++ {
+		ps = append(ps, Player{PlayerID: "old" + string(rune('a'+i)), Pos: "LB", Seasons: []Season{
+			{Season: 2020, Age: 33, G: 16, SnapPct: 60},
+		}})
+	}
+	c := Fit(ps, Options{}).Curves["LB"]
+	if s := c.point(33).Survival; s >= c.point(25).Survival {
+		t.Fatalf("survival at 33 (%.3f) should be below 25 (%.3f)", s, c.point(25).Survival)
+	}
+	// The latest season has nothing to survive into and is not counted.
+	if pt := c.point(27); pt.Seasons != 6*6 {
+		t.Fatalf("age 27 seasons = %d, want 36 (the 2024 seasons are censored)", pt.Seasons)
+	}
+}
+
+func TestValueFavoursYouth(t *testing.T) {
+	m := Fit(fixture(), Options{Prior: 1})
+	young := Player{PlayerID: "young", Player: "Young", Pos: "OLB", Seasons: []Season{
+		{Season: 2024, Team: "SEA", Age: 23, G: 17, SnapPct: 70, PPG: 7, Scored: true},
+	}}
+	old := Player{PlayerID: "old", Player: "Old", Pos: "ILB", Seasons: []Season{
+		{Season: 2024, Team: "TAM", Age: 30, G: 17, SnapPct: 70, PPG: 7, Scored: true},
+	}}
+	vy, ok := m.Value(young, 2024)
+	if !ok {
+		t.Fatal("young player not valued")
+	}
+	vo, _ := m.Value(old, 2024)
+	if vy.Dynasty <= vo.Dynasty {
+		t.Fatalf("young %.1f should beat old %.1f", vy.Dynasty, vo.Dynasty)
+	}
+	if len(vy.Years) != 5 || vy.Years[0].Season != 2025 || vy.Years[0].Age != 24 {
+		t.Fatalf("years = %+v", vy.Years)
+	}
+	if vy.Years[0].SnapPct <= 70 || vo.Years[0].SnapPct >= 70 {
+		t.Fatalf("next-season DEF%%: young %.1f, old %.1f", vy.Years[0].SnapPct, vo.Years[0].SnapPct)
+	}
+	if vy.Team != "SEA" || vy.Group != "LB" {
+		t.Fatalf("value = %+v", vy)
+	}
+}
+
+func TestValueWeightsAndEstimates(t *testing.T) {
+	m := Fit(fixture(), Options{Prior: 1})
+	// Unscored seasons: PPG comes from DEF% at the group's points per DEF%.
+	p := Player{PlayerID: "x", Pos: "LB", Seasons: []Season{
+		{Season: 2023, Age: 26, G: 16, SnapPct: 60},
+		{Season: 2024, Age: 27, G: 16, SnapPct: 80},
+	}}
+	v, ok := m.Value(p, 2024)
+	if !ok || !v.Estimated {
+		t.Fatalf("value = %+v ok=%v", v, ok)
+	}
+	// 2024 weighs 3, 2023 weighs 2; both are age-adjusted to 27 (about -4 from 26).
+	if v.SnapPct < 69 || v.SnapPct > 72 {
+		t.Fatalf("snap level = %.1f, want ~70", v.SnapPct)
+	}
+	if math.Abs(v.PPG-v.SnapPct*0.1) > 0.05 {
+		t.Fatalf("estimated ppg = %.2f for %.1f%%", v.PPG, v.SnapPct)
+	}
+
+	// Not active in the last three seasons, or too few games: no value.
+	gone := Player{PlayerID: "g", Pos: "LB", Seasons: []Season{{Season: 2020, Age: 25, G: 16, SnapPct: 80}}}
+	if _, ok := m.Value(gone, 2024); ok {
+		t.Fatal("inactive player valued")
+	}
+	thin := Player{PlayerID: "t", Pos: "LB", Seasons: []Season{{Season: 2024, Age: 25, G: 2, SnapPct: 80}}}
+	if _, ok := m.Value(thin, 2024); ok {
+		t.Fatal("two-game player valued")
+	}
+	if _, ok := m.Value(Player{PlayerID: "k", Pos: "K", Seasons: []Season{{Season: 2024, Age: 25, G: 17}}}, 2024); ok {
+		t.Fatal("non-defender valued")
+	}
+}
+
+func TestValuesRankAndReport(t *testing.T) {
+	ps := fixture()
+	m := Fit(ps, Options{})
+	vs := m.Values(ps, 2024)
+	if len(vs) != len(ps) {
+		t.Fatalf("values = %d, want %d", len(vs), len(ps))
+	}
+	for i := 1; i < len(vs); i++ {
+		if vs[i].Dynasty > vs[i-1].Dynasty || vs[i].Rank != i+1 || vs[i].GroupRank != i+1 {
+			t.Fatalf("values out of order at %d: %+v", i, vs[i])
+		}
+	}
+	r := Report{Season: 2024, GeneratedAt: time.Date(2025, 2, 15, 0, 0, 0, 0, time.UTC), Model: m, Values: vs, Top: 3}
+	md := r.Markdown()
+	for _, want := range []string{"# Dynasty values after 2024", "## LB (peak", "2018, 2019", "| 1 | "} {
+		if !strings.Contains(md, want) {
+			t.Fatalf("markdown missing %q:\n%s", want, md)
+		}
+	}
+	if strings.Contains(md, "| 4 | ") {
+		t.Fatalf("markdown should stop at 3 players:\n%s", md)
+	}
+}
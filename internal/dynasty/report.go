@@ -0,0 +1,73 @@
+package dynasty
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Report is the fitted curves and every valued player after a season.
+type Report struct {
+	Season      int       `json:"season"`
+	GeneratedAt time.Time `json:"generated_at"`
+	Model       Model     `json:"model"`
+	Values      []Value   `json:"values"`
+	Top         int       `json:"-"` // players per group in Markdown (default 25)
+}
+
+// Markdown renders each group's curve and its top players by dynasty value.
+func (r Report) Markdown() string {
+	top := r.Top
+	if top <= 0 {
+		top = 25
+	}
+	opt := r.Model.Options.withDefaults()
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Dynasty values after %d\n\n", r.Season)
+	seasons := make([]string, len(r.Model.Seasons))
+	for i, s := range r.Model.Seasons {
+		seasons[i] = fmt.Sprint(s)
+	}
+	fmt.Fprintf(&b, "_Generated %s. Curves fitted on %s (seasons with %d+ games). "+
+		"Value is expected IDP points over the next %d seasons at %d games, discounted %.0f%% a season._\n",
+		r.GeneratedAt.Format(time.RFC3339), strings.Join(seasons, ", "), opt.MinGames,
+		opt.Horizon, opt.Games, 100*(1-opt.Discount))
+
+	groups := make([]string, 0, len(r.Model.Curves))
+	for g := range r.Model.Curves {
+		groups = append(groups, g)
+	}
+	sort.Strings(groups)
+	for _, g := range groups {
+		c := r.Model.Curves[g]
+		fmt.Fprintf(&b, "\n## %s (peak %d)\n\n", g, c.PeakAge)
+		b.WriteString("| Age | DEF% vs peak | PPG vs peak | Survival | Pairs |\n|---:|---:|---:|---:|---:|\n")
+		for _, pt := range c.Points {
+			if pt.Seasons == 0 {
+				continue
+			}
+			fmt.Fprintf(&b, "| %d | %+.1f | %+.2f | %.0f%% | %d |\n", pt.Age, pt.Snap, pt.PPG, 100*pt.Survival, pt.Pairs)
+		}
+		b.WriteString("\n| # | Player | Pos | Team | Age | DEF% | PPG | Next season | Dynasty |\n|---:|---|---|---|---:|---:|---:|---:|---:|\n")
+		n := 0
+		for _, v := range r.Values {
+			if v.Group != g || n >= top {
+				continue
+			}
+			n++
+			ppg := fmt.Sprintf("%.2f", v.PPG)
+			if v.Estimated {
+				ppg += "*"
+			}
+			next := 0.0
+			if len(v.Years) > 0 {
+				next = v.Years[0].Points
+			}
+			fmt.Fprintf(&b, "| %d | %s | %s | %s | %d | %.1f | %s | %.1f | %.1f |\n",
+				v.GroupRank, v.Player, v.Pos, v.Team, v.Age, v.SnapPct, ppg, next, v.Dynasty)
+		}
+	}
+	b.WriteString("\n_* points per game estimated from DEF% (no scored season)._\n")
+	return b.String()
+}
@@ -0,0 +1,140 @@
+package dynasty
+
+import (
+	"math"
+	"sort"
+
+	"github.com/tyler180/fantasy-football-backends/internal/starters"
+)
+
+// Year is one projected future season.
+type Year struct {
+	Season   int     `json:"season"`
+	Age      int     `json:"age"`
+	SnapPct  float64 `json:"snap_pct"`
+	PPG      float64 `json:"ppg"`
+	Survival float64 `json:"survival"` // chance the player is still a regular by this season
+	Points   float64 `json:"points"`   // Survival * PPG * games
+}
+
+// Value is a player's dynasty valuation after a season.
+type Value struct {
+	PlayerID  string  `json:"player_id"`
+	Player    string  `json:"player"`
+	Team      string  `json:"team,omitempty"`
+	Pos       string  `json:"pos"`
+	Group     string  `json:"group"`
+	Season    int     `json:"season"`
+	Age       int     `json:"age"`
+	SnapPct   float64 `json:"snap_pct"`            // age-adjusted current level
+	PPG       float64 `json:"ppg"`                 // age-adjusted current level
+	Estimated bool    `json:"estimated,omitempty"` // PPG from DEF% because no season was scored
+	Years     []Year  `json:"years"`
+	Dynasty   float64 `json:"dynasty"` // discounted expected points over the horizon
+	Rank      int     `json:"rank"`
+	GroupRank int     `json:"group_rank"`
+}
+
+// recent are the weights of the latest three qualifying seasons, newest first.
+var recent = []float64{3, 2, 1}
+
+// Value projects p's next Horizon seasons from its level through season. Players
+// without a qualifying season in the last three, an age or a fitted group curve have
+// no value.
+func (m Model) Value(p Player, season int) (Value, bool) {
+	opt := m.Options.withDefaults()
+	c, ok := m.Curves[starters.GroupOf(p.Pos)]
+	if !ok {
+		return Value{}, false
+	}
+	var hist []Season
+	age, team := 0, ""
+	latest := 0
+	for _, s := range p.Seasons {
+		if s.Season > season {
+			continue
+		}
+		if s.Season > latest && s.Age > 0 {
+			latest, age, team = s.Season, s.Age+(season-s.Season), s.Team
+		}
+		if s.G >= opt.MinGames && s.Age > 0 && s.Season > season-len(recent) {
+			hist = append(hist, s)
+		}
+	}
+	if len(hist) == 0 || age <= 0 {
+		return Value{}, false
+	}
+	sort.Slice(hist, func(i, j int) bool { return hist[i].Season > hist[j].Season })
+
+	// Weighted recent level, each season moved along the curve to the current age.
+	now := c.point(age)
+	var snapW, snap, ppgW, ppg float64
+	for _, s := range hist {
+		w := recent[season-s.Season] * float64(s.G)
+		then := c.point(s.Age + (season - s.Season))
+		snapW += w
+		snap += w * (s.SnapPct + now.Snap - then.Snap)
+		if s.Scored {
+			ppgW += w
+			ppg += w * (s.PPG + now.PPG - then.PPG)
+		}
+	}
+	v := Value{
+		PlayerID: p.PlayerID, Player: p.Player, Team: team, Pos: p.Pos, Group: c.Group,
+		Season: season, Age: age,
+		SnapPct: clamp(snap/snapW, 0, 100),
+	}
+	if ppgW > 0 {
+		v.PPG = math.Max(ppg/ppgW, 0)
+	} else {
+		v.PPG, v.Estimated = v.SnapPct*c.PPGPer, true
+	}
+
+	surv, disc := 1.0, 1.0
+	for k := 1; k <= opt.Horizon; k++ {
+		a := age + k
+		pt := c.point(a)
+		surv *= c.point(a - 1).Survival
+		y := Year{
+			Season:   season + k,
+			Age:      a,
+			SnapPct:  round(clamp(v.SnapPct+pt.Snap-now.Snap, 0, 100), 1),
+			PPG:      round(math.Max(v.PPG+pt.PPG-now.PPG, 0), 2),
+			Survival: round(surv, 3),
+		}
+		y.Points = round(surv*y.PPG*float64(opt.Games), 1)
+		v.Dynasty += disc * y.Points
+		disc *= opt.Discount
+		v.Years = append(v.Years, y)
+	}
+	v.SnapPct, v.PPG, v.Dynasty = round(v.SnapPct, 1), round(v.PPG, 2), round(v.Dynasty, 1)
+	return v, true
+}
+
+// Values values every player active through season, highest dynasty value first,
+// ranked overall and within group.
+func (m Model) Values(players []Player, season int) []Value {
+	var out []Value
+	for _, p := range players {
+		if v, ok := m.Value(p, season); ok {
+			out = append(out, v)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].Dynasty != out[j].Dynasty {
+			return out[i].Dynasty > out[j].Dynasty
+		}
+		return out[i].PlayerID < out[j].PlayerID
+	})
+	byGroup := map[string]int{}
+	for i := range out {
+		out[i].Rank = i + 1
+		byGroup[out[i].Group]++
+		out[i].GroupRank = byGroup[out[i].Group]
+	}
+	return out
+}
+
+func clamp(v, lo, hi float64) float64 {
+	return math.Max(lo, math.Min(hi, v))
+}
@@ -0,0 +1,68 @@
+package lineup
+
+// flowGraph is a small min-cost flow network solved with successive shortest paths
+// (Bellman-Ford, so negative edge costs are fine). Lineups have a few dozen nodes.
+// Costs are integers so zero-cost residual cycles never look negative.
+type flowGraph struct {
+	edges []flowEdge
+	adj   [][]int
+}
+
+const unreached = int64(1) << 62
+
+type flowEdge struct {
+	to, cap, flow int
+	cost          int64
+}
+
+func newFlowGraph(n int) *flowGraph { return &flowGraph{adj: make([][]int, n)} }
+
+// addEdge adds u->v and its residual; it returns the forward edge index.
+func (g *flowGraph) addEdge(u, v, cap int, cost int64) int {
+	g.adj[u] = append(g.adj[u], len(g.edges))
+	g.edges = append(g.edges, flowEdge{to: v, cap: cap, cost: cost})
+	g.adj[v] = append(g.adj[v], len(g.edges))
+	g.edges = append(g.edges, flowEdge{to: u, cap: 0, cost: -cost})
+	return len(g.edges) - 2
+}
+
+// minCost pushes flow from s to t one unit at a time while an augmenting path has
+// negative cost, which maximizes total value when values are negative costs.
+func (g *flowGraph) minCost(s, t int) {
+	n := len(g.adj)
+	for {
+		dist := make([]int64, n)
+		prev := make([]int, n)
+		for i := range dist {
+			dist[i], prev[i] = unreached, -1
+		}
+		dist[s] = 0
+		for iter := 0; iter < n; iter++ {
+			changed := false
+			for u := 0; u < n; u++ {
+				if dist[u] == unreached {
+					continue
+				}
+				for _, ei := range g.adj[u] {
+					e := g.edges[ei]
+					if e.cap-e.flow > 0 && dist[u]+e.cost < dist[e.to] {
+						dist[e.to], prev[e.to] = dist[u]+e.cost, ei
+						changed = true
+					}
+				}
+			}
+			if !changed {
+				break
+			}
+		}
+		if dist[t] == unreached || dist[t] >= 0 {
+			return
+		}
+		for v := t; v != s; {
+			ei := prev[v]
+			g.edges[ei].flow++
+			g.edges[ei^1].flow--
+			v = g.edges[ei^1].to
+		}
+	}
+}
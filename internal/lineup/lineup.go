@@ -0,0 +1,270 @@
+// Package lineup picks the legal starting lineup with the highest expected points
+// under MFL-style rules: per-position minimums and maximums, an overall starter
+// count, and flex slots that accept several positions. Byes and injury statuses
+// reduce or zero a player's expected points.
+package lineup
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/tyler180/fantasy-football-backends/internal/mfl"
+	"github.com/tyler180/fantasy-football-backends/internal/starters"
+)
+
+// Player is a rostered player with a projection for the week.
+type Player struct {
+	ID         string  `json:"id"`
+	Name       string  `json:"name"`
+	Position   string  `json:"position"`
+	Projection float64 `json:"projection"`
+	ByeWeek    int     `json:"bye_week,omitempty"`
+	Injury     string  `json:"injury,omitempty"` // MFL injury status, e.g. "Questionable"
+}
+
+// Slot is a lineup requirement. Eligible lists position codes or groups (DL/LB/DB);
+// a slot with several entries is a flex slot.
+type Slot struct {
+	Name     string   `json:"name"`
+	Eligible []string `json:"eligible"`
+	Min      int      `json:"min"`
+	Max      int      `json:"max"`
+}
+
+// Rules are the league's lineup requirements. Total caps the number of starters
+// across all slots; 0 means every slot may be filled to its Max.
+type Rules struct {
+	Slots []Slot `json:"slots"`
+	Total int    `json:"total"`
+}
+
+// RulesFromMFL converts the league's starter settings. With idpOnly, offensive slots
+// are dropped and Total becomes the IDP starter count: idp_starters when numeric,
+// else the league total less the offensive minimums.
+func RulesFromMFL(l mfl.League, idpOnly bool) Rules {
+	r := Rules{Total: l.StarterCount}
+	offMin := 0
+	for _, s := range l.Starters {
+		if idpOnly && starters.GroupOf(s.Position) == "" {
+			offMin += s.Min
+			continue
+		}
+		r.Slots = append(r.Slots, Slot{Name: s.Position, Eligible: []string{s.Position}, Min: s.Min, Max: max(s.Max, s.Min)})
+	}
+	if idpOnly && r.Total > 0 {
+		if n, err := strconv.Atoi(strings.TrimSpace(l.IDPStarters)); err == nil && n > 0 {
+			r.Total = n
+		} else {
+			r.Total -= offMin
+		}
+	}
+	return r
+}
+
+// Options control availability.
+type Options struct {
+	Week int // bye check; 0 skips it
+	// InjuryFactors scales a player's projection by MFL injury status; 0 benches
+	// the player. Statuses not listed count as healthy.
+	InjuryFactors map[string]float64
+}
+
+// DefaultOptions rules out Out/IR/suspended players and discounts doubtful and
+// questionable ones.
+func DefaultOptions() Options {
+	return Options{InjuryFactors: map[string]float64{
+		"OUT": 0, "IR": 0, "IR-R": 0, "IR-PUP": 0, "IR-NFI": 0, "SUSPENDED": 0, "HOLDOUT": 0,
+		"DOUBTFUL": 0.25, "QUESTIONABLE": 0.85,
+	}}
+}
+
+// Expected is the projection after bye and injury adjustments, with the reason
+// when it was adjusted.
+func (o Options) Expected(p Player) (float64, string) {
+	if o.Week > 0 && p.ByeWeek == o.Week {
+		return 0, fmt.Sprintf("bye in week %d", o.Week)
+	}
+	if st := strings.ToUpper(strings.TrimSpace(p.Injury)); st != "" {
+		if f, ok := o.InjuryFactors[st]; ok {
+			if f == 0 {
+				return 0, strings.ToLower(st)
+			}
+			return p.Projection * f, fmt.Sprintf("%s (x%.2f)", strings.ToLower(st), f)
+		}
+	}
+	return p.Projection, ""
+}
+
+// Assigned is a player placed in a slot (or on the bench).
+type Assigned struct {
+	Slot     string  `json:"slot,omitempty"`
+	Player   Player  `json:"player"`
+	Expected float64 `json:"expected"`
+	Note     string  `json:"note,omitempty"`
+}
+
+// Alternate is who would replace a starter if they sat, and what it would cost.
+type Alternate struct {
+	Starter     string   `json:"starter"`
+	Replacement []string `json:"replacement"` // may reshuffle more than one player
+	Delta       float64  `json:"delta"`       // lineup points lost
+}
+
+// Result is the recommended lineup.
+type Result struct {
+	Starters   []Assigned  `json:"starters"`
+	Bench      []Assigned  `json:"bench"`
+	Total      float64     `json:"total"`
+	Unfilled   []string    `json:"unfilled,omitempty"` // slots whose minimum could not be met
+	Alternates []Alternate `json:"alternates"`
+}
+
+// Optimize returns the lineup with the highest expected points.
+func Optimize(players []Player, rules Rules, opt Options) Result {
+	res := solve(players, rules, opt, "")
+	for _, s := range res.Starters {
+		alt := solve(players, rules, opt, s.Player.ID)
+		a := Alternate{Starter: s.Player.Name, Delta: round2(res.Total - alt.Total)}
+		in := map[string]bool{}
+		for _, x := range alt.Starters {
+			in[x.Player.ID] = true
+		}
+		for _, x := range res.Starters {
+			delete(in, x.Player.ID)
+		}
+		for _, x := range alt.Starters {
+			if in[x.Player.ID] {
+				a.Replacement = append(a.Replacement, x.Player.Name)
+			}
+		}
+		res.Alternates = append(res.Alternates, a)
+	}
+	sort.SliceStable(res.Alternates, func(i, j int) bool { return res.Alternates[i].Delta > res.Alternates[j].Delta })
+	return res
+}
+
+// Best is the optimal lineup without alternates, for callers that only need totals.
+func Best(players []Player, rules Rules, opt Options) Result {
+	return solve(players, rules, opt, "")
+}
+
+// Edge costs are in thousandths of a hundredth of a point so a filled slot (+1) only
+// breaks ties, and a slot minimum (minBonus) outranks any projection.
+const minBonus = int64(1) << 40
+
+func cost(exp float64) int64 { return -(int64(exp*100+0.5)*1000 + 1) }
+
+// solve builds the flow network
+//
+//	source -> player (1) -> slot (if eligible) -> sink  (Min, bonus)
+//	                                          -> pool  (Max-Min) -> sink (Total-sum(Min))
+//
+// and reads the lineup off the saturated player->slot edges. exclude sits one player.
+func solve(players []Player, rules Rules, opt Options, exclude string) Result {
+	type cand struct {
+		p    Player
+		exp  float64
+		note string
+	}
+	var avail []cand
+	var res Result
+	for _, p := range players {
+		exp, note := opt.Expected(p)
+		if p.ID == exclude || (exp <= 0 && note != "") {
+			res.Bench = append(res.Bench, Assigned{Player: p, Expected: round2(exp), Note: note})
+			continue
+		}
+		avail = append(avail, cand{p, exp, note})
+	}
+
+	nP, nS := len(avail), len(rules.Slots)
+	src, pool, sink := 0, 1+nP+nS, 2+nP+nS
+	g := newFlowGraph(sink + 1)
+	sumMin, sumRange := 0, 0
+	for j, s := range rules.Slots {
+		node := 1 + nP + j
+		g.addEdge(node, sink, s.Min, -minBonus)
+		if s.Max > s.Min {
+			g.addEdge(node, pool, s.Max-s.Min, 0)
+		}
+		sumMin += s.Min
+		sumRange += max(s.Max-s.Min, 0)
+	}
+	extra := sumRange
+	if rules.Total > 0 {
+		extra = max(rules.Total-sumMin, 0)
+	}
+	g.addEdge(pool, sink, extra, 0)
+
+	slotEdge := map[int]struct{ player, slot int }{}
+	for i, c := range avail {
+		g.addEdge(src, 1+i, 1, 0)
+		for j, s := range rules.Slots {
+			if eligible(c.p.Position, s) {
+				ei := g.addEdge(1+i, 1+nP+j, 1, cost(c.exp))
+				slotEdge[ei] = struct{ player, slot int }{i, j}
+			}
+		}
+	}
+	g.minCost(src, sink)
+
+	started := make([]bool, nP)
+	filled := make([]int, nS)
+	for ei, ps := range slotEdge {
+		if g.edges[ei].flow > 0 {
+			c := avail[ps.player]
+			started[ps.player] = true
+			filled[ps.slot]++
+			res.Starters = append(res.Starters, Assigned{
+				Slot: rules.Slots[ps.slot].Name, Player: c.p, Expected: round2(c.exp), Note: c.note,
+			})
+			res.Total += c.exp
+		}
+	}
+	for i, c := range avail {
+		if !started[i] {
+			res.Bench = append(res.Bench, Assigned{Player: c.p, Expected: round2(c.exp), Note: c.note})
+		}
+	}
+	for j, s := range rules.Slots {
+		if filled[j] < s.Min {
+			res.Unfilled = append(res.Unfilled, fmt.Sprintf("%s (%d of %d)", s.Name, filled[j], s.Min))
+		}
+	}
+	res.Total = round2(res.Total)
+
+	slotOrder := make(map[string]int, nS)
+	for j, s := range rules.Slots {
+		slotOrder[s.Name] = j
+	}
+	sort.SliceStable(res.Starters, func(i, j int) bool {
+		a, b := res.Starters[i], res.Starters[j]
+		if slotOrder[a.Slot] != slotOrder[b.Slot] {
+			return slotOrder[a.Slot] < slotOrder[b.Slot]
+		}
+		return a.Expected > b.Expected
+	})
+	sort.SliceStable(res.Bench, func(i, j int) bool { return res.Bench[i].Expected > res.Bench[j].Expected })
+	return res
+}
+
+func eligible(pos string, s Slot) bool {
+	pos = strings.ToUpper(strings.TrimSpace(pos))
+	g := starters.GroupOf(pos)
+	for _, e := range s.Eligible {
+		e = strings.ToUpper(e)
+		if e == pos || (g != "" && e == g) {
+			return true
+		}
+	}
+	return false
+}
+
+func round2(v float64) float64 {
+	if v < 0 {
+		return -round2(-v)
+	}
+	return float64(int64(v*100+0.5)) / 100
+}
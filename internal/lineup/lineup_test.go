@@ -0,0 +1,107 @@
+package lineup
+
+import (
+	"testing"
+
+	"github.com/tyler180/fantasy-football-backends/internal/mfl"
+)
+
+func names(as []Assigned) map[string]string {
+	out := map[string]string{}
+	for _, a := range as {
+		out[a.Player.Name] = a.Slot
+	}
+	return out
+}
+
+func TestOptimize_RangesAndTotal(t *testing.T) {
+	// DE 1-2, LB 1-2, one more starter overall: the best extra wins regardless of position.
+	rules := Rules{Total: 3, Slots: []Slot{
+		{Name: "DE", Eligible: []string{"DE"}, Min: 1, Max: 2},
+		{Name: "LB", Eligible: []string{"LB"}, Min: 1, Max: 2},
+	}}
+	players := []Player{
+		{ID: "1", Name: "de1", Position: "DE", Projection: 10},
+		{ID: "2", Name: "de2", Position: "DE", Projection: 9},
+		{ID: "3", Name: "lb1", Position: "LB", Projection: 4},
+		{ID: "4", Name: "lb2", Position: "LB", Projection: 3},
+	}
+	res := Optimize(players, rules, DefaultOptions())
+	got := names(res.Starters)
+	if len(got) != 3 || got["de1"] != "DE" || got["de2"] != "DE" || got["lb1"] != "LB" {
+		t.Fatalf("starters = %+v", res.Starters)
+	}
+	if res.Total != 23 || len(res.Unfilled) != 0 {
+		t.Fatalf("total = %v unfilled = %v", res.Total, res.Unfilled)
+	}
+	// Sitting lb1 forces lb2 in (the LB minimum) at a cost of 1.
+	for _, a := range res.Alternates {
+		if a.Starter == "lb1" && (a.Delta != 1 || len(a.Replacement) != 1 || a.Replacement[0] != "lb2") {
+			t.Fatalf("alternate = %+v", a)
+		}
+	}
+}
+
+func TestOptimize_FlexByeAndInjury(t *testing.T) {
+	rules := Rules{Slots: []Slot{
+		{Name: "LB", Eligible: []string{"LB"}, Min: 1, Max: 1},
+		{Name: "DB", Eligible: []string{"DB"}, Min: 1, Max: 1},
+		{Name: "IDP_FLEX", Eligible: []string{"DL", "LB", "DB"}, Min: 1, Max: 1},
+	}}
+	players := []Player{
+		{ID: "1", Name: "lbA", Position: "LB", Projection: 12, ByeWeek: 7},
+		{ID: "2", Name: "lbB", Position: "LB", Projection: 8},
+		{ID: "3", Name: "lbC", Position: "LB", Projection: 7, Injury: "Out"},
+		{ID: "4", Name: "cb", Position: "CB", Projection: 6},
+		{ID: "5", Name: "s", Position: "S", Projection: 9, Injury: "Questionable"},
+		{ID: "6", Name: "dt", Position: "DT", Projection: 5},
+	}
+	opt := DefaultOptions()
+	opt.Week = 7
+	res := Optimize(players, rules, opt)
+	got := names(res.Starters)
+	// s (9 * 0.85 = 7.65) takes DB, lbB takes LB, cb (6) beats dt (5) for the flex.
+	if got["lbB"] != "LB" || got["s"] != "DB" || got["cb"] != "IDP_FLEX" || len(got) != 3 {
+		t.Fatalf("starters = %+v", res.Starters)
+	}
+	if _, ok := got["lbA"]; ok {
+		t.Fatal("player on bye started")
+	}
+	if res.Total != 21.65 {
+		t.Fatalf("total = %v", res.Total)
+	}
+}
+
+func TestOptimize_Unfilled(t *testing.T) {
+	rules := Rules{Slots: []Slot{{Name: "S", Eligible: []string{"S"}, Min: 2, Max: 2}}}
+	res := Optimize([]Player{{ID: "1", Name: "s", Position: "S", Projection: 0}}, rules, DefaultOptions())
+	if len(res.Starters) != 1 || len(res.Unfilled) != 1 {
+		t.Fatalf("res = %+v", res)
+	}
+}
+
+func TestRulesFromMFL(t *testing.T) {
+	l := mfl.League{StarterCount: 20, IDPStarters: "DL|LB|DB", Starters: []mfl.StarterSlot{
+		{Position: "QB", Min: 1, Max: 1}, {Position: "RB", Min: 2, Max: 3},
+		{Position: "DE", Min: 2, Max: 3}, {Position: "LB", Min: 3, Max: 4},
+	}}
+	r := RulesFromMFL(l, true)
+	if len(r.Slots) != 2 || r.Total != 17 {
+		t.Fatalf("rules = %+v", r)
+	}
+	if r := RulesFromMFL(l, false); len(r.Slots) != 4 || r.Total != 20 {
+		t.Fatalf("rules = %+v", r)
+	}
+}
+
+func TestProject(t *testing.T) {
+	if got := Project(10, 90, 60); got != 12.5 {
+		t.Fatalf("capped boost = %v", got)
+	}
+	if got := Project(10, 30, 60); got != 6 {
+		t.Fatalf("floored drop = %v", got)
+	}
+	if got := Project(10, 0, 0); got != 10 {
+		t.Fatalf("no trend = %v", got)
+	}
+}
@@ -0,0 +1,67 @@
+package lineup
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Project turns stored season data into a week's projection: fantasy points per game
+// scaled by recent role, the snap-share EWMA against the season average, clamped to
+// [0.6, 1.25] so one odd week cannot swing it far.
+func Project(ppg, ewma, avg float64) float64 {
+	if ppg <= 0 {
+		return 0
+	}
+	f := 1.0
+	if avg > 0 && ewma > 0 {
+		f = min(max(ewma/avg, 0.6), 1.25)
+	}
+	return ppg * f
+}
+
+// Report is the weekly lineup recommendation.
+type Report struct {
+	League      string    `json:"league"`
+	Franchise   string    `json:"franchise"`
+	Season      string    `json:"season"`
+	Week        int       `json:"week,omitempty"`
+	GeneratedAt time.Time `json:"generated_at"`
+	Rules       Rules     `json:"rules"`
+	Result
+}
+
+// Markdown renders starters, alternates and the bench.
+func (r Report) Markdown() string {
+	var b strings.Builder
+	title := fmt.Sprintf("# Lineup: %s (%s), %s", r.League, r.Franchise, r.Season)
+	if r.Week > 0 {
+		title += fmt.Sprintf(" week %d", r.Week)
+	}
+	fmt.Fprintf(&b, "%s\n\n_Generated %s. Expected total %.2f._\n\n", title, r.GeneratedAt.Format(time.RFC3339), r.Total)
+	if len(r.Unfilled) > 0 {
+		fmt.Fprintf(&b, "**Unfilled:** %s\n\n", strings.Join(r.Unfilled, ", "))
+	}
+
+	b.WriteString("## Starters\n\n| Slot | Player | Pos | Expected | Note |\n|---|---|---|---:|---|\n")
+	for _, a := range r.Starters {
+		fmt.Fprintf(&b, "| %s | %s | %s | %.2f | %s |\n", a.Slot, mdEscape(a.Player.Name), a.Player.Position, a.Expected, a.Note)
+	}
+
+	b.WriteString("\n## Alternates\n\n| If out | Replacement | Points lost |\n|---|---|---:|\n")
+	for _, a := range r.Alternates {
+		repl := strings.Join(a.Replacement, " + ")
+		if repl == "" {
+			repl = "(empty slot)"
+		}
+		fmt.Fprintf(&b, "| %s | %s | %.2f |\n", mdEscape(a.Starter), mdEscape(repl), a.Delta)
+	}
+
+	b.WriteString("\n## Bench\n\n| Player | Pos | Expected | Note |\n|---|---|---:|---|\n")
+	for _, a := range r.Bench {
+		fmt.Fprintf(&b, "| %s | %s | %.2f | %s |\n", mdEscape(a.Player.Name), a.Player.Position, a.Expected, a.Note)
+	}
+	return b.String()
+}
+
+func mdEscape(s string) string { return strings.ReplaceAll(s, "|", `\|`) }
@@ -0,0 +1,304 @@
+// Package mfl is a small client for the MyFantasyLeague export API: league settings,
+// franchise rosters, the free-agent pool and player records.
+package mfl
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultBaseURL is MFL's API host; it redirects to the league's own host.
+const DefaultBaseURL = "https://api.myfantasyleague.com"
+
+// APIError is an error MFL reported in the response body (it usually sends HTTP 200).
+type APIError struct {
+	Type    string // export TYPE or "login"
+	Message string
+}
+
+func (e *APIError) Error() string { return fmt.Sprintf("mfl %s: %s", e.Type, e.Message) }
+
+// ErrThrottled is returned when MFL kept answering 429/503 after every retry.
+var ErrThrottled = errors.New("mfl: throttled")
+
+// Client calls the export API for one league. Authentication uses the API key when set,
+// otherwise it logs in with username/password on first use and sends the MFL_USER_ID
+// cookie.
+type Client struct {
+	Config      Config
+	HTTP        *http.Client
+	UserAgent   string
+	MaxAttempts int
+	BaseBackoff time.Duration
+
+	mu     sync.Mutex
+	host   string // league host learned from League(); overrides Config.BaseURL
+	userID string // MFL_USER_ID cookie from Login
+}
+
+// New returns a client with a 30s timeout and 4 attempts per request.
+func New(cfg Config) *Client {
+	if cfg.BaseURL == "" {
+		cfg.BaseURL = DefaultBaseURL
+	}
+	return &Client{
+		Config:      cfg,
+		HTTP:        &http.Client{Timeout: 30 * time.Second},
+		UserAgent:   "fantasy-football-backends/1.0",
+		MaxAttempts: 4,
+		BaseBackoff: 500 * time.Millisecond,
+	}
+}
+
+// League fetches league settings. MFL serves each league from its own host, so later
+// requests go there directly when the response names one.
+func (c *Client) League(ctx context.Context) (League, error) {
+	var w wireLeague
+	if err := c.export(ctx, "league", nil, &w, &w.League); err != nil {
+		return League{}, err
+	}
+	l := w.league()
+	if l.BaseURL != "" && strings.HasPrefix(l.BaseURL, "http") {
+		c.mu.Lock()
+		c.host = l.BaseURL
+		c.mu.Unlock()
+	}
+	return l, nil
+}
+
+// Rosters fetches rosters for every franchise, or just franchiseID when it is set.
+func (c *Client) Rosters(ctx context.Context, franchiseID string) ([]Roster, error) {
+	q := url.Values{}
+	if franchiseID != "" {
+		q.Set("FRANCHISE", franchiseID)
+	}
+	var w wireRosters
+	if err := c.export(ctx, "rosters", q, &w, &w.Rosters); err != nil {
+		return nil, err
+	}
+	return w.rosters(), nil
+}
+
+// FreeAgents fetches the free-agent pool, optionally limited to one MFL position.
+func (c *Client) FreeAgents(ctx context.Context, position string) ([]RosterPlayer, error) {
+	q := url.Values{}
+	if position != "" {
+		q.Set("POSITION", position)
+	}
+	var w wireFreeAgents
+	if err := c.export(ctx, "freeAgents", q, &w, &w.FreeAgents); err != nil {
+		return nil, err
+	}
+	return w.players(), nil
+}
+
+// Players fetches player records with details. With no ids it returns the whole
+// universe, which is large; callers usually pass roster or free-agent ids.
+func (c *Client) Players(ctx context.Context, ids ...string) ([]Player, error) {
+	q := url.Values{"DETAILS": {"1"}}
+	if len(ids) > 0 {
+		q.Set("PLAYERS", strings.Join(ids, ","))
+	}
+	var w wirePlayers
+	if err := c.export(ctx, "players", q, &w, &w.Players); err != nil {
+		return nil, err
+	}
+	return w.players(), nil
+}
+
+// Injuries fetches the injury report for week (0 = current), keyed by player id.
+func (c *Client) Injuries(ctx context.Context, week int) (map[string]Injury, error) {
+	q := url.Values{}
+	if week > 0 {
+		q.Set("W", strconv.Itoa(week))
+	}
+	var w wireInjuries
+	if err := c.export(ctx, "injuries", q, &w, &w.Injuries); err != nil {
+		return nil, err
+	}
+	return w.injuries(), nil
+}
+
+// Rules returns the raw league rules export (JSON), which scoring.ParseRuleset reads.
+func (c *Client) Rules(ctx context.Context) ([]byte, error) {
+	b, _, err := c.get(ctx, "export", c.exportQuery("rules", nil))
+	if err != nil {
+		return nil, err
+	}
+	if err := checkError("rules", b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// Login exchanges username/password for the MFL_USER_ID cookie.
+func (c *Client) Login(ctx context.Context) error {
+	if c.Config.Username == "" || c.Config.Password == "" {
+		return fmt.Errorf("mfl login: username and password are required")
+	}
+	q := url.Values{"USERNAME": {c.Config.Username}, "PASSWORD": {c.Config.Password}, "XML": {"1"}}
+	b, _, err := c.get(ctx, "login", q)
+	if err != nil {
+		return err
+	}
+	if err := checkError("login", b); err != nil {
+		return err
+	}
+	// <status MFL_USER_ID="...">OK</status>
+	var st struct {
+		UserID string `xml:"MFL_USER_ID,attr"`
+	}
+	if err := xml.Unmarshal(b, &st); err != nil || st.UserID == "" {
+		return &APIError{Type: "login", Message: "no MFL_USER_ID in response"}
+	}
+	c.mu.Lock()
+	c.userID = st.UserID
+	c.mu.Unlock()
+	return nil
+}
+
+func (c *Client) exportQuery(typ string, q url.Values) url.Values {
+	out := url.Values{}
+	for k, v := range q {
+		out[k] = v
+	}
+	out.Set("TYPE", typ)
+	out.Set("L", c.Config.LeagueID)
+	out.Set("JSON", "1")
+	if c.Config.APIKey != "" {
+		out.Set("APIKEY", c.Config.APIKey)
+	}
+	return out
+}
+
+// export fetches one export TYPE and decodes it into jsonDst, or into xmlDst when MFL
+// answers with XML (whose root element is the inner object).
+func (c *Client) export(ctx context.Context, typ string, q url.Values, jsonDst, xmlDst any) error {
+	if c.Config.APIKey == "" && c.Config.Username != "" {
+		c.mu.Lock()
+		loggedIn := c.userID != ""
+		c.mu.Unlock()
+		if !loggedIn {
+			if err := c.Login(ctx); err != nil {
+				return err
+			}
+		}
+	}
+	b, isXML, err := c.get(ctx, "export", c.exportQuery(typ, q))
+	if err != nil {
+		return err
+	}
+	if err := checkError(typ, b); err != nil {
+		return err
+	}
+	if isXML {
+		err = xml.Unmarshal(b, xmlDst)
+	} else {
+		err = json.Unmarshal(b, jsonDst)
+	}
+	if err != nil {
+		return fmt.Errorf("mfl %s: decode: %w", typ, err)
+	}
+	return nil
+}
+
+// checkError turns an error body (JSON or XML) into an *APIError.
+func checkError(typ string, b []byte) error {
+	b = bytes.TrimSpace(b)
+	if isXMLBody(b) {
+		var e struct {
+			XMLName xml.Name
+			Msg     string `xml:",chardata"`
+		}
+		if xml.Unmarshal(b, &e) == nil && e.XMLName.Local == "error" {
+			return &APIError{Type: typ, Message: strings.TrimSpace(e.Msg)}
+		}
+		return nil
+	}
+	var e wireError
+	if json.Unmarshal(b, &e) == nil && e.Error != "" {
+		return &APIError{Type: typ, Message: string(e.Error)}
+	}
+	return nil
+}
+
+func isXMLBody(b []byte) bool { return len(b) > 0 && b[0] == '<' }
+
+// get issues GET {base}/{year}/{path}?q with retries on 429/5xx and transport errors.
+func (c *Client) get(ctx context.Context, path string, q url.Values) ([]byte, bool, error) {
+	c.mu.Lock()
+	base, userID := c.host, c.userID
+	c.mu.Unlock()
+	if base == "" {
+		base = strings.TrimRight(c.Config.BaseURL, "/")
+	}
+	u := fmt.Sprintf("%s/%s/%s?%s", base, c.Config.Year, path, q.Encode())
+
+	attempts := c.MaxAttempts
+	if attempts <= 0 {
+		attempts = 1
+	}
+	var lastErr error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+		if err != nil {
+			return nil, false, err
+		}
+		req.Header.Set("User-Agent", c.UserAgent)
+		if userID != "" {
+			req.AddCookie(&http.Cookie{Name: "MFL_USER_ID", Value: userID})
+		}
+
+		wait := c.BaseBackoff << (attempt - 1)
+		resp, err := c.HTTP.Do(req)
+		if err != nil {
+			lastErr = err
+		} else {
+			b, rerr := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			switch {
+			case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusServiceUnavailable:
+				lastErr = fmt.Errorf("%w (%s)", ErrThrottled, resp.Status)
+				if s, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && s > 0 {
+					wait = time.Duration(s) * time.Second
+				}
+			case resp.StatusCode >= 500:
+				lastErr = fmt.Errorf("mfl %s: %s", path, resp.Status)
+			case resp.StatusCode != http.StatusOK:
+				return nil, false, fmt.Errorf("mfl %s: %s (%s)", path, resp.Status, truncate(b, 256))
+			case rerr != nil:
+				lastErr = rerr
+			default:
+				ct := resp.Header.Get("Content-Type")
+				return b, strings.Contains(ct, "xml") || isXMLBody(bytes.TrimSpace(b)), nil
+			}
+		}
+		if attempt == attempts {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return nil, false, ctx.Err()
+		case <-time.After(wait):
+		}
+	}
+	return nil, false, fmt.Errorf("mfl %s after %d attempts: %w", path, attempts, lastErr)
+}
+
+func truncate(b []byte, n int) string {
+	if len(b) > n {
+		b = b[:n]
+	}
+	return string(b)
+}
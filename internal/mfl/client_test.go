@@ -0,0 +1,165 @@
+package mfl_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/tyler180/fantasy-football-backends/internal/mfl"
+	"github.com/tyler180/fantasy-football-backends/internal/mfl/mfltest"
+)
+
+func newClient(s *mfltest.Server, cfg mfl.Config) *mfl.Client {
+	cfg.BaseURL = s.URL
+	if cfg.LeagueID == "" {
+		cfg.LeagueID = "12345"
+	}
+	if cfg.Year == "" {
+		cfg.Year = "2024"
+	}
+	c := mfl.New(cfg)
+	c.BaseBackoff = time.Millisecond
+	return c
+}
+
+func TestClient_JSONQuirks(t *testing.T) {
+	s := mfltest.NewServer()
+	defer s.Close()
+	s.APIKey = "k"
+	c := newClient(s, mfl.Config{APIKey: "k"})
+	ctx := context.Background()
+
+	l, err := c.League(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(l.Franchises) != 2 || l.RosterSize != 30 || len(l.Starters) != 5 || l.StarterCount != 20 {
+		t.Fatalf("league = %+v", l)
+	}
+	if de := l.Starters[1]; de.Position != "DE" || de.Min != 2 || de.Max != 3 {
+		t.Fatalf("DE slot = %+v", de)
+	}
+	if qb := l.Starters[0]; qb.Min != 1 || qb.Max != 1 {
+		t.Fatalf("QB slot = %+v", qb)
+	}
+
+	rosters, err := c.Rosters(ctx, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Franchise 0002 has a single player sent as an object.
+	if len(rosters) != 2 || len(rosters[1].Players) != 1 || rosters[0].Players[0].Salary != 1.5 {
+		t.Fatalf("rosters = %+v", rosters)
+	}
+
+	fa, err := c.FreeAgents(ctx, "LB")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fa) != 2 {
+		t.Fatalf("free agents = %+v", fa)
+	}
+
+	players, err := c.Players(ctx, "15001", "15003")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p := players[0]; p.DisplayName() != "John Doe" || p.Age(time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)) != 24 {
+		t.Fatalf("player = %+v", p)
+	}
+	if players[2].Birthdate.Year() != 1999 || !players[4].Birthdate.IsZero() {
+		t.Fatalf("birthdates = %v / %v", players[2].Birthdate, players[4].Birthdate)
+	}
+
+	inj, err := c.Injuries(ctx, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if i := inj["15002"]; i.Status != "Questionable" || len(inj) != 1 {
+		t.Fatalf("injuries = %+v", inj)
+	}
+
+	for _, q := range s.Requests() {
+		if q.Get("APIKEY") != "k" || q.Get("L") != "12345" || q.Get("JSON") != "1" {
+			t.Fatalf("request query = %v", q)
+		}
+	}
+	if got := s.Requests()[2].Get("POSITION"); got != "LB" {
+		t.Fatalf("POSITION = %q", got)
+	}
+}
+
+func TestClient_XMLAndErrors(t *testing.T) {
+	s := mfltest.NewServer()
+	defer s.Close()
+	c := newClient(s, mfl.Config{})
+	ctx := context.Background()
+
+	s.SetBody("rosters", `<rosters><franchise id="0001"><player id="1" status="ROSTER"/><player id="2" status="TAXI_SQUAD"/></franchise></rosters>`)
+	rosters, err := c.Rosters(ctx, "0001")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rosters) != 1 || len(rosters[0].Players) != 2 || rosters[0].Players[1].Status != "TAXI_SQUAD" {
+		t.Fatalf("xml rosters = %+v", rosters)
+	}
+
+	s.SetBody("freeAgents", `<error>Invalid league ID</error>`)
+	var apiErr *mfl.APIError
+	if _, err := c.FreeAgents(ctx, ""); !errors.As(err, &apiErr) || apiErr.Message != "Invalid league ID" {
+		t.Fatalf("xml error = %v", err)
+	}
+	s.SetBody("players", `{"error":{"$t":"API requires logged in user"}}`)
+	if _, err := c.Players(ctx); !errors.As(err, &apiErr) {
+		t.Fatalf("json error = %v", err)
+	}
+}
+
+func TestClient_RetriesThrottling(t *testing.T) {
+	s := mfltest.NewServer()
+	defer s.Close()
+	c := newClient(s, mfl.Config{})
+	ctx := context.Background()
+
+	s.Set("freeAgents", mfltest.Response{Status: 429}, mfltest.Response{Status: 503},
+		mfltest.Response{Body: mfltest.Default["freeAgents"]})
+	if _, err := c.FreeAgents(ctx, ""); err != nil {
+		t.Fatal(err)
+	}
+	if n := s.Count("freeAgents"); n != 3 {
+		t.Fatalf("requests = %d, want 3", n)
+	}
+
+	s.Set("players", mfltest.Response{Status: 429})
+	if _, err := c.Players(ctx); !errors.Is(err, mfl.ErrThrottled) {
+		t.Fatalf("err = %v, want ErrThrottled", err)
+	}
+}
+
+func TestClient_LoginCookie(t *testing.T) {
+	s := mfltest.NewServer()
+	defer s.Close()
+	s.APIKey = "secret"
+	c := newClient(s, mfl.Config{Username: "u", Password: "p"})
+
+	if _, err := c.Rosters(context.Background(), ""); err != nil {
+		t.Fatal(err)
+	}
+	if s.Count("login") != 1 {
+		t.Fatalf("logins = %d", s.Count("login"))
+	}
+}
+
+func TestParseSecret(t *testing.T) {
+	cfg, err := mfl.ParseSecret([]byte(`{"league_id":"12345","franchise_id":"0003","api_key":"k","league_year":2025,"json":1}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.LeagueID != "12345" || cfg.FranchiseID != "0003" || cfg.Year != "2025" || cfg.Validate() != nil {
+		t.Fatalf("cfg = %+v", cfg)
+	}
+	if (mfl.Config{LeagueID: "1"}).Validate() == nil {
+		t.Fatal("expected a missing-year error")
+	}
+}
@@ -0,0 +1,102 @@
+package mfl
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+)
+
+// Config is what the client needs to talk to one league. It mirrors the mfl-secrets
+// Secrets Manager entry.
+type Config struct {
+	LeagueID    string
+	FranchiseID string
+	APIKey      string
+	Username    string
+	Password    string
+	Year        string
+	BaseURL     string // default https://api.myfantasyleague.com
+}
+
+// SecretsAPI is the slice of Secrets Manager the config loader uses.
+type SecretsAPI interface {
+	GetSecretValue(ctx context.Context, in *secretsmanager.GetSecretValueInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.GetSecretValueOutput, error)
+}
+
+// ParseSecret decodes the mfl-secrets JSON document. Values may be strings or numbers.
+func ParseSecret(b []byte) (Config, error) {
+	var raw map[string]any
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return Config{}, fmt.Errorf("parse mfl secret: %w", err)
+	}
+	get := func(k string) string {
+		switch v := raw[k].(type) {
+		case string:
+			return strings.TrimSpace(v)
+		case float64:
+			return strconv.FormatFloat(v, 'f', -1, 64)
+		default:
+			return ""
+		}
+	}
+	return Config{
+		LeagueID:    get("league_id"),
+		FranchiseID: get("franchise_id"),
+		APIKey:      get("api_key"),
+		Username:    get("username"),
+		Password:    get("password"),
+		Year:        get("league_year"),
+	}, nil
+}
+
+// ConfigFromSecret reads and parses a secret by name or ARN.
+func ConfigFromSecret(ctx context.Context, sm SecretsAPI, secretID string) (Config, error) {
+	out, err := sm.GetSecretValue(ctx, &secretsmanager.GetSecretValueInput{SecretId: aws.String(secretID)})
+	if err != nil {
+		return Config{}, fmt.Errorf("get secret %s: %w", secretID, err)
+	}
+	return ParseSecret([]byte(aws.ToString(out.SecretString)))
+}
+
+// LoadConfig reads the secret named by MFL_SECRET_ID (when set and sm is non-nil) and
+// then applies the MFL_LEAGUE_ID, MFL_FRANCHISE_ID, MFL_API_KEY, MFL_YEAR and
+// MFL_BASE_URL env overrides.
+func LoadConfig(ctx context.Context, sm SecretsAPI) (Config, error) {
+	var cfg Config
+	if id := os.Getenv("MFL_SECRET_ID"); id != "" && sm != nil {
+		c, err := ConfigFromSecret(ctx, sm, id)
+		if err != nil {
+			return Config{}, err
+		}
+		cfg = c
+	}
+	for env, dst := range map[string]*string{
+		"MFL_LEAGUE_ID":    &cfg.LeagueID,
+		"MFL_FRANCHISE_ID": &cfg.FranchiseID,
+		"MFL_API_KEY":      &cfg.APIKey,
+		"MFL_YEAR":         &cfg.Year,
+		"MFL_BASE_URL":     &cfg.BaseURL,
+	} {
+		if v := strings.TrimSpace(os.Getenv(env)); v != "" {
+			*dst = v
+		}
+	}
+	return cfg, cfg.Validate()
+}
+
+// Validate checks the fields every export needs.
+func (c Config) Validate() error {
+	if c.LeagueID == "" {
+		return fmt.Errorf("mfl config: league_id is required")
+	}
+	if c.Year == "" {
+		return fmt.Errorf("mfl config: league_year is required")
+	}
+	return nil
+}
@@ -0,0 +1,137 @@
+// Package mfltest runs a local MFL export API for tests. It serves canned responses
+// per export TYPE, checks the API key, and records every request.
+package mfltest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// Response is what the stub returns for one export TYPE (or "login").
+type Response struct {
+	Status      int    // default 200
+	ContentType string // default application/json, or text/xml for bodies starting with '<'
+	Body        string
+}
+
+// Server is a running stub. Point mfl.Config.BaseURL at URL.
+type Server struct {
+	*httptest.Server
+
+	// APIKey, when set, is required on exports unless the request carries the
+	// MFL_USER_ID cookie from a login.
+	APIKey string
+
+	mu        sync.Mutex
+	responses map[string][]Response // queued; the last one repeats
+	requests  []url.Values
+}
+
+// NewServer starts a stub loaded with the Default fixtures.
+func NewServer() *Server {
+	s := &Server{responses: map[string][]Response{}}
+	for typ, body := range Default {
+		s.responses[typ] = []Response{{Body: body}}
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.handle))
+	return s
+}
+
+// Set replaces the responses for typ. Responses are served in order and the last one
+// repeats, so Set(typ, throttled, ok) answers one 429 and then succeeds.
+func (s *Server) Set(typ string, rs ...Response) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.responses[typ] = rs
+}
+
+// SetBody serves body for typ with HTTP 200.
+func (s *Server) SetBody(typ, body string) { s.Set(typ, Response{Body: body}) }
+
+// Requests returns the query of every request served so far.
+func (s *Server) Requests() []url.Values {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]url.Values(nil), s.requests...)
+}
+
+// Count returns how many requests were made for typ.
+func (s *Server) Count(typ string) int {
+	n := 0
+	for _, q := range s.Requests() {
+		if q.Get("TYPE") == typ {
+			n++
+		}
+	}
+	return n
+}
+
+func (s *Server) handle(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	typ := q.Get("TYPE")
+	if strings.HasSuffix(r.URL.Path, "/login") {
+		typ = "login"
+		q.Set("TYPE", typ)
+	}
+
+	s.mu.Lock()
+	s.requests = append(s.requests, q)
+	queue := s.responses[typ]
+	var resp Response
+	switch {
+	case len(queue) == 0:
+		resp = Response{Body: `{"error":{"$t":"Unknown export type"}}`}
+	case len(queue) == 1:
+		resp = queue[0]
+	default:
+		resp, s.responses[typ] = queue[0], queue[1:]
+	}
+	s.mu.Unlock()
+
+	if typ != "login" && s.APIKey != "" && q.Get("APIKEY") != s.APIKey {
+		if c, err := r.Cookie("MFL_USER_ID"); err != nil || c.Value == "" {
+			resp = Response{Body: `{"error":{"$t":"API requires logged in user"}}`}
+		}
+	}
+
+	ct := resp.ContentType
+	if ct == "" {
+		ct = "application/json; charset=utf-8"
+		if strings.HasPrefix(strings.TrimSpace(resp.Body), "<") {
+			ct = "text/xml; charset=utf-8"
+		}
+	}
+	w.Header().Set("Content-Type", ct)
+	if resp.Status != 0 {
+		w.WriteHeader(resp.Status)
+	}
+	_, _ = w.Write([]byte(resp.Body))
+}
+
+// Default fixtures: a two-franchise IDP league. Single-entry lists are sent as objects
+// and a few values as {"$t": ...} nodes, the way MFL does.
+var Default = map[string]string{
+	"league": `{"version":"1.0","league":{"id":"12345","name":"Stub IDP League","rosterSize":"30",
+	  "franchises":{"count":"2","franchise":[{"id":"0001","name":"Alpha"},{"id":"0002","name":"Bravo"}]},
+	  "starters":{"count":"20","idp_starters":"DL|LB|DB","position":[
+	    {"name":"QB","limit":"1"},{"name":"DE","limit":"2-3"},{"name":"LB","limit":"3-4"},{"name":"CB","limit":"2-3"},{"name":"S","limit":"2"}]}}}`,
+	"rosters": `{"version":"1.0","rosters":{"franchise":[
+	  {"id":"0001","player":[{"id":"15001","status":"ROSTER","salary":"1.50","contractYear":"2"},{"id":"15002","status":"ROSTER"}]},
+	  {"id":"0002","player":{"id":"15003","status":"INJURED_RESERVE"}}]}}`,
+	"freeAgents": `{"version":"1.0","freeAgents":{"leagueUnit":{"unit":"LEAGUE","player":[{"id":"15004"},{"id":"15005"}]}}}`,
+	"players": `{"version":"1.0","players":{"timestamp":"1726000000","player":[
+	  {"id":"15001","name":"Doe, John","position":"LB","team":"SEA","birthdate":"946684800","draft_year":"2022"},
+	  {"id":"15002","name":"Roe, Rick","position":"DE","team":"ATL","birthdate":"978307200","draft_year":"2023"},
+	  {"id":"15003","name":"Poe, Pat","position":"CB","team":"KCC","birthdate":{"$t":"915148800"}},
+	  {"id":"15004","name":"Moe, Max","position":"S","team":"GBP","birthdate":"1009843200","draft_year":"2024"},
+	  {"id":"15005","name":"Loe, Lee","position":"LB","team":"FA","birthdate":""}]}}`,
+	"rules": `{"version":"1.0","rules":{"positionRules":{"positions":"DE|DT|LB|CB|S","rule":[
+	  {"event":{"$t":"TK"},"points":{"$t":"*1"},"range":{"$t":"0-99"}},
+	  {"event":{"$t":"AS"},"points":{"$t":"*0.5"},"range":{"$t":"0-99"}},
+	  {"event":{"$t":"SK"},"points":{"$t":"*3"},"range":{"$t":"0-99"}}]}}}`,
+	"injuries": `{"version":"1.0","injuries":{"week":"5","injury":{"id":"15002","status":"Questionable","details":"Ankle"}}}`,
+	"login":    `<status MFL_USER_ID="stub-user">OK</status>`,
+}
@@ -0,0 +1,21 @@
+package mfl
+
+// teamCodes maps MFL team codes to PFR and nflverse codes. MFL uses "FA" for players
+// without a team.
+var teamCodes = map[string]struct{ PFR, Nflverse string }{
+	"ARI": {"ARI", "ARI"}, "ATL": {"ATL", "ATL"}, "BAL": {"BAL", "BAL"}, "BUF": {"BUF", "BUF"},
+	"CAR": {"CAR", "CAR"}, "CHI": {"CHI", "CHI"}, "CIN": {"CIN", "CIN"}, "CLE": {"CLE", "CLE"},
+	"DAL": {"DAL", "DAL"}, "DEN": {"DEN", "DEN"}, "DET": {"DET", "DET"}, "GBP": {"GNB", "GB"},
+	"HOU": {"HOU", "HOU"}, "IND": {"CLT", "IND"}, "JAC": {"JAX", "JAX"}, "KCC": {"KAN", "KC"},
+	"LVR": {"LVR", "LV"}, "LAC": {"LAC", "LAC"}, "LAR": {"LAR", "LAR"}, "MIA": {"MIA", "MIA"},
+	"MIN": {"MIN", "MIN"}, "NEP": {"NWE", "NE"}, "NOS": {"NOR", "NO"}, "NYG": {"NYG", "NYG"},
+	"NYJ": {"NYJ", "NYJ"}, "PHI": {"PHI", "PHI"}, "PIT": {"PIT", "PIT"}, "SFO": {"SFO", "SF"},
+	"SEA": {"SEA", "SEA"}, "TBB": {"TAM", "TB"}, "TEN": {"TEN", "TEN"}, "WAS": {"WAS", "WAS"},
+}
+
+// PFRTeam returns the PFR code for an MFL team code, or "" for free agents and
+// unknown codes.
+func PFRTeam(code string) string { return teamCodes[code].PFR }
+
+// NflverseTeam returns the nflverse code for an MFL team code, or "".
+func NflverseTeam(code string) string { return teamCodes[code].Nflverse }
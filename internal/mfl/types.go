@@ -0,0 +1,168 @@
+package mfl
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// League is the subset of league settings the pipelines use.
+type League struct {
+	ID           string
+	Name         string
+	BaseURL      string // the league's own host, e.g. https://www48.myfantasyleague.com
+	RosterSize   int
+	Franchises   []Franchise
+	Starters     []StarterSlot
+	StarterCount int    // total starters across all positions
+	IDPStarters  string // MFL's raw idp_starters setting
+}
+
+// Franchise is one team in the league.
+type Franchise struct {
+	ID   string
+	Name string
+}
+
+// StarterSlot is a lineup requirement: between Min and Max starters at Position.
+type StarterSlot struct {
+	Position string
+	Min      int
+	Max      int
+}
+
+// Roster is one franchise's players.
+type Roster struct {
+	FranchiseID string
+	Players     []RosterPlayer
+}
+
+// RosterPlayer is a player on a roster (or in the free-agent pool).
+type RosterPlayer struct {
+	ID           string
+	Status       string // ROSTER, TAXI_SQUAD, INJURED_RESERVE
+	Salary       float64
+	ContractYear string
+}
+
+// Player is an MFL player record.
+type Player struct {
+	ID        string
+	Name      string // "Last, First" as MFL sends it
+	Position  string
+	Team      string // MFL team code
+	Status    string
+	Birthdate time.Time // zero when unknown
+	DraftYear int
+}
+
+// Injury is a player's entry on the weekly injury report.
+type Injury struct {
+	ID      string
+	Status  string // Questionable, Doubtful, Out, IR, ...
+	Details string
+}
+
+// DisplayName turns MFL's "Last, First" into "First Last".
+func (p Player) DisplayName() string {
+	last, first, ok := strings.Cut(p.Name, ",")
+	if !ok {
+		return strings.TrimSpace(p.Name)
+	}
+	return strings.TrimSpace(first) + " " + strings.TrimSpace(last)
+}
+
+// Age is the player's age in whole years at t, or 0 when the birthdate is unknown.
+func (p Player) Age(t time.Time) int {
+	if p.Birthdate.IsZero() {
+		return 0
+	}
+	age := t.Year() - p.Birthdate.Year()
+	if t.YearDay() < p.Birthdate.YearDay() {
+		age--
+	}
+	return age
+}
+
+func (w wireLeague) league() League {
+	l := w.League
+	out := League{
+		ID:           string(l.ID),
+		Name:         string(l.Name),
+		BaseURL:      strings.TrimRight(string(l.BaseURL), "/"),
+		RosterSize:   atoi(l.RosterSize),
+		StarterCount: atoi(l.Starters.Count),
+		IDPStarters:  string(l.Starters.IDPStarters),
+	}
+	for _, f := range l.Franchises.Franchise {
+		out.Franchises = append(out.Franchises, Franchise{ID: string(f.ID), Name: string(f.Name)})
+	}
+	for _, p := range l.Starters.Position {
+		lo, hi, ok := strings.Cut(string(p.Limit), "-")
+		if !ok {
+			hi = lo
+		}
+		out.Starters = append(out.Starters, StarterSlot{Position: string(p.Name), Min: atoi(text(lo)), Max: atoi(text(hi))})
+	}
+	return out
+}
+
+func (w wirePlayerRef) player() RosterPlayer {
+	sal, _ := strconv.ParseFloat(string(w.Salary), 64)
+	return RosterPlayer{ID: string(w.ID), Status: string(w.Status), Salary: sal, ContractYear: string(w.ContractYear)}
+}
+
+func (w wireRosters) rosters() []Roster {
+	var out []Roster
+	for _, f := range w.Rosters.Franchise {
+		r := Roster{FranchiseID: string(f.ID)}
+		for _, p := range f.Player {
+			r.Players = append(r.Players, p.player())
+		}
+		out = append(out, r)
+	}
+	return out
+}
+
+func (w wireFreeAgents) players() []RosterPlayer {
+	var out []RosterPlayer
+	for _, u := range w.FreeAgents.LeagueUnit {
+		for _, p := range u.Player {
+			out = append(out, p.player())
+		}
+	}
+	return out
+}
+
+func (w wirePlayers) players() []Player {
+	out := make([]Player, 0, len(w.Players.Player))
+	for _, p := range w.Players.Player {
+		pl := Player{
+			ID:        string(p.ID),
+			Name:      string(p.Name),
+			Position:  string(p.Position),
+			Team:      string(p.Team),
+			Status:    string(p.Status),
+			DraftYear: atoi(p.DraftYear),
+		}
+		// birthdate is unix seconds; 0 or blank means unknown.
+		if sec, err := strconv.ParseInt(string(p.Birthdate), 10, 64); err == nil && sec != 0 {
+			pl.Birthdate = time.Unix(sec, 0).UTC()
+		}
+		out = append(out, pl)
+	}
+	return out
+}
+
+func (w wireInjuries) injuries() map[string]Injury {
+	out := make(map[string]Injury, len(w.Injuries.Injury))
+	for _, i := range w.Injuries.Injury {
+		out[string(i.ID)] = Injury{ID: string(i.ID), Status: string(i.Status), Details: string(i.Details)}
+	}
+	return out
+}
+
+func atoi(t text) int {
+	n, _ := strconv.Atoi(strings.TrimSpace(string(t)))
+	return n
+}
@@ -0,0 +1,160 @@
+package mfl
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"strings"
+)
+
+// MFL's exports are loose about shapes: JSON values are usually strings but sometimes
+// {"$t": "..."} text nodes or bare numbers, a list with one entry is sent as an object,
+// and errors come back with HTTP 200 in either JSON or XML. The wire types below
+// absorb that so the public types stay plain.
+
+// text is a string that also accepts numbers and {"$t": ...} nodes, and XML attributes
+// or character data.
+type text string
+
+func (t *text) UnmarshalJSON(b []byte) error {
+	b = bytes.TrimSpace(b)
+	switch {
+	case len(b) == 0 || bytes.Equal(b, []byte("null")):
+		*t = ""
+	case b[0] == '"':
+		var s string
+		if err := json.Unmarshal(b, &s); err != nil {
+			return err
+		}
+		*t = text(strings.TrimSpace(s))
+	case b[0] == '{':
+		var node struct {
+			T text `json:"$t"`
+		}
+		if err := json.Unmarshal(b, &node); err != nil {
+			return err
+		}
+		*t = node.T
+	default:
+		*t = text(b)
+	}
+	return nil
+}
+
+func (t *text) UnmarshalXMLAttr(a xml.Attr) error {
+	*t = text(strings.TrimSpace(a.Value))
+	return nil
+}
+
+func (t *text) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	var s string
+	if err := d.DecodeElement(&s, &start); err != nil {
+		return err
+	}
+	*t = text(strings.TrimSpace(s))
+	return nil
+}
+
+// list decodes a JSON array or a single object. XML repeats elements, which
+// encoding/xml already appends.
+type list[T any] []T
+
+func (l *list[T]) UnmarshalJSON(b []byte) error {
+	b = bytes.TrimSpace(b)
+	if len(b) == 0 || bytes.Equal(b, []byte("null")) {
+		return nil
+	}
+	if b[0] == '[' {
+		var many []T
+		if err := json.Unmarshal(b, &many); err != nil {
+			return err
+		}
+		*l = many
+		return nil
+	}
+	var one T
+	if err := json.Unmarshal(b, &one); err != nil {
+		return err
+	}
+	*l = list[T]{one}
+	return nil
+}
+
+type wireLeague struct {
+	League struct {
+		ID         text `json:"id" xml:"id,attr"`
+		Name       text `json:"name" xml:"name,attr"`
+		BaseURL    text `json:"baseURL" xml:"baseURL,attr"`
+		RosterSize text `json:"rosterSize" xml:"rosterSize,attr"`
+		Franchises struct {
+			Franchise list[struct {
+				ID   text `json:"id" xml:"id,attr"`
+				Name text `json:"name" xml:"name,attr"`
+			}] `json:"franchise" xml:"franchise"`
+		} `json:"franchises" xml:"franchises"`
+		Starters struct {
+			Count       text `json:"count" xml:"count,attr"`
+			IDPStarters text `json:"idp_starters" xml:"idp_starters,attr"`
+			Position    list[struct {
+				Name  text `json:"name" xml:"name,attr"`
+				Limit text `json:"limit" xml:"limit,attr"`
+			}] `json:"position" xml:"position"`
+		} `json:"starters" xml:"starters"`
+	} `json:"league" xml:"league"`
+}
+
+type wirePlayerRef struct {
+	ID           text `json:"id" xml:"id,attr"`
+	Status       text `json:"status" xml:"status,attr"`
+	Salary       text `json:"salary" xml:"salary,attr"`
+	ContractYear text `json:"contractYear" xml:"contractYear,attr"`
+}
+
+type wireRosters struct {
+	Rosters struct {
+		Franchise list[struct {
+			ID     text                `json:"id" xml:"id,attr"`
+			Player list[wirePlayerRef] `json:"player" xml:"player"`
+		}] `json:"franchise" xml:"franchise"`
+	} `json:"rosters" xml:"rosters"`
+}
+
+type wireFreeAgents struct {
+	FreeAgents struct {
+		LeagueUnit list[struct {
+			Unit   text                `json:"unit" xml:"unit,attr"`
+			Player list[wirePlayerRef] `json:"player" xml:"player"`
+		}] `json:"leagueUnit" xml:"leagueUnit"`
+	} `json:"freeAgents" xml:"freeAgents"`
+}
+
+type wirePlayers struct {
+	Players struct {
+		Timestamp text `json:"timestamp" xml:"timestamp,attr"`
+		Player    list[struct {
+			ID        text `json:"id" xml:"id,attr"`
+			Name      text `json:"name" xml:"name,attr"`
+			Position  text `json:"position" xml:"position,attr"`
+			Team      text `json:"team" xml:"team,attr"`
+			Status    text `json:"status" xml:"status,attr"`
+			Birthdate text `json:"birthdate" xml:"birthdate,attr"`
+			DraftYear text `json:"draft_year" xml:"draft_year,attr"`
+		}] `json:"player" xml:"player"`
+	} `json:"players" xml:"players"`
+}
+
+// wireError is {"error": {"$t": "..."}} or <error>...</error>.
+type wireError struct {
+	Error text `json:"error"`
+}
+
+type wireInjuries struct {
+	Injuries struct {
+		Week   text `json:"week" xml:"week,attr"`
+		Injury list[struct {
+			ID      text `json:"id" xml:"id,attr"`
+			Status  text `json:"status" xml:"status,attr"`
+			Details text `json:"details" xml:"details,attr"`
+		}] `json:"injury" xml:"injury"`
+	} `json:"injuries" xml:"injuries"`
+}
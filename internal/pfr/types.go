@@ -19,6 +19,8 @@ type PlayerRow struct {
 	Pos        string
 	DefSnapNum int
 	DefSnapPct float64
+	// StarterRank is the 1-based rank within Team under the starter spec (0 = unranked).
+	StarterRank int
 }
 
 type RosterRow struct {
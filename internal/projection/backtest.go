@@ -0,0 +1,91 @@
+package projection
+
+import "math"
+
+// Outcome pairs a past projection with what happened.
+type Outcome struct {
+	Projection
+	ActualSnaps  float64 `json:"actual_snaps"`
+	ActualPoints float64 `json:"actual_points"`
+}
+
+// Backtest projects every week in [from, to] from the games before it and returns one
+// Outcome per player who played that week. Injuries are unknown in hindsight, so every
+// player is projected healthy.
+func Backtest(players []Player, env *Env, from, to int, opt Options) []Outcome {
+	var out []Outcome
+	for week := from; week <= to; week++ {
+		priors := Priors(players, env, week)
+		for _, p := range players {
+			var actual *Game
+			for i := range p.Games {
+				if p.Games[i].Week == week && p.Games[i].SnapPct > 0 {
+					actual = &p.Games[i]
+				}
+			}
+			if actual == nil {
+				continue
+			}
+			team := teamOf(p, *actual)
+			pr, ok := Project(p, week, env.Opponent(team, week), "", env, priors, opt)
+			if !ok {
+				continue
+			}
+			out = append(out, Outcome{
+				Projection:   pr,
+				ActualSnaps:  round(actual.SnapPct/100*env.DefPlays(team, week), 1),
+				ActualPoints: actual.Points,
+			})
+		}
+	}
+	return out
+}
+
+// Accuracy summarizes errors (projected - actual) and interval coverage.
+type Accuracy struct {
+	N        int     `json:"n"`
+	MAE      float64 `json:"mae"`
+	RMSE     float64 `json:"rmse"`
+	Bias     float64 `json:"bias"`
+	Coverage float64 `json:"coverage"` // share of actuals inside [Lo, Hi]
+}
+
+// Score computes points and snaps accuracy over outcomes.
+func Score(outs []Outcome) (points, snaps Accuracy) {
+	var pa, sa accum
+	for _, o := range outs {
+		pa.add(o.Points, o.ActualPoints, o.PointsLo, o.PointsHi)
+		sa.add(o.Snaps, o.ActualSnaps, o.SnapsLo, o.SnapsHi)
+	}
+	return pa.result(), sa.result()
+}
+
+type accum struct {
+	n                     int
+	abs, sq, bias, inside float64
+}
+
+func (a *accum) add(pred, actual, lo, hi float64) {
+	d := pred - actual
+	a.n++
+	a.abs += math.Abs(d)
+	a.sq += d * d
+	a.bias += d
+	if actual >= lo && actual <= hi {
+		a.inside++
+	}
+}
+
+func (a accum) result() Accuracy {
+	if a.n == 0 {
+		return Accuracy{}
+	}
+	n := float64(a.n)
+	return Accuracy{
+		N:        a.n,
+		MAE:      round(a.abs/n, 3),
+		RMSE:     round(math.Sqrt(a.sq/n), 3),
+		Bias:     round(a.bias/n, 3),
+		Coverage: round(a.inside/n, 3),
+	}
+}
@@ -0,0 +1,98 @@
+package projection
+
+// TeamGame is one team's offensive volume in one game.
+type TeamGame struct {
+	Team      string
+	Opponent  string
+	Week      int
+	Plays     float64 // pass attempts + sacks taken + carries
+	PassPlays float64 // pass attempts + sacks taken
+}
+
+// Env is the league context a projection reads: every team's offensive games and the
+// schedule. All lookups take a week and only use games before it, so a backtest
+// cannot see the future.
+type Env struct {
+	games    map[string]map[int]TeamGame // team -> week
+	schedule map[string]map[int]string   // team -> week -> opponent
+}
+
+// DefaultPlays is the offensive plays per game assumed when nothing is known.
+const DefaultPlays = 63.0
+
+// NewEnv indexes games. Opponents found in games also fill the schedule.
+func NewEnv(games []TeamGame) *Env {
+	e := &Env{games: map[string]map[int]TeamGame{}, schedule: map[string]map[int]string{}}
+	for _, g := range games {
+		if e.games[g.Team] == nil {
+			e.games[g.Team] = map[int]TeamGame{}
+		}
+		e.games[g.Team][g.Week] = g
+		if g.Opponent != "" {
+			e.AddGame(g.Week, g.Team, g.Opponent)
+		}
+	}
+	return e
+}
+
+// AddGame records a scheduled matchup (either order).
+func (e *Env) AddGame(week int, a, b string) {
+	for _, p := range [][2]string{{a, b}, {b, a}} {
+		if e.schedule[p[0]] == nil {
+			e.schedule[p[0]] = map[int]string{}
+		}
+		e.schedule[p[0]][week] = p[1]
+	}
+}
+
+// Opponent is team's opponent in week, "" on a bye or when unknown.
+func (e *Env) Opponent(team string, week int) string { return e.schedule[team][week] }
+
+// Offense averages team's plays per game and pass rate over games before week. n is
+// the number of games; with none, the league averages are returned.
+func (e *Env) Offense(team string, week int) (plays, passRate float64, n int) {
+	var sp, spass float64
+	for w, g := range e.games[team] {
+		if w < week && g.Plays > 0 {
+			sp += g.Plays
+			spass += g.PassPlays
+			n++
+		}
+	}
+	if n == 0 || sp == 0 {
+		lp, lr := e.League(week)
+		return lp, lr, 0
+	}
+	return sp / float64(n), spass / sp, n
+}
+
+// League averages plays per game and pass rate over every game before week.
+func (e *Env) League(week int) (plays, passRate float64) {
+	var sp, spass float64
+	n := 0
+	for _, byWeek := range e.games {
+		for w, g := range byWeek {
+			if w < week && g.Plays > 0 {
+				sp += g.Plays
+				spass += g.PassPlays
+				n++
+			}
+		}
+	}
+	if n == 0 || sp == 0 {
+		return DefaultPlays, 0.6
+	}
+	return sp / float64(n), spass / sp
+}
+
+// DefPlays is how many offensive plays team's defense faced in week: the opponent's
+// plays in that game, else the league average before it.
+func (e *Env) DefPlays(team string, week int) float64 {
+	if opp := e.Opponent(team, week); opp != "" {
+		if g, ok := e.games[opp][week]; ok && g.Plays > 0 {
+			return g.Plays
+		}
+	}
+	p, _ := e.League(week)
+	return p
+}
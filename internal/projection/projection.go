@@ -0,0 +1,236 @@
+// Package projection estimates a defender's snaps and fantasy points for an upcoming
+// week, with prediction intervals.
+//
+// The model is deliberately simple and explainable:
+//
+//	snap share  = EWMA of the player's recent DEF%
+//	snaps       = snap share x the opponent's offensive plays per game
+//	points/snap = the player's season rate, shrunk toward the position group's rate
+//	points      = snaps x points/snap x a pass-rate tilt x an injury factor
+//
+// Intervals come from the player's own one-step-ahead errors (snap share) and
+// per-game residuals (points), padded by priors when the history is short.
+package projection
+
+import (
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/tyler180/fantasy-football-backends/internal/starters"
+)
+
+// Game is one game of a player's history.
+type Game struct {
+	Week    int
+	Team    string // team played for; "" means Player.Team
+	SnapPct float64
+	Points  float64
+}
+
+// Player is a defender and the games so far, in any order.
+type Player struct {
+	PlayerID string
+	Player   string
+	Team     string
+	Pos      string
+	Games    []Game
+}
+
+// Options tune the model. Zero values select the defaults.
+type Options struct {
+	Alpha      float64            // EWMA smoothing for snap share (default 0.4)
+	Z          float64            // interval half-width in standard deviations (default 1.2816, an 80% interval)
+	PriorSnaps float64            // snaps of group-average production blended into each rate (default 150)
+	PassTilt   map[string]float64 // per group: points scale by 1 + tilt x (opp pass rate - league) (default DL/DB +0.5, LB -0.5)
+	Injury     map[string]float64 // status (upper case) -> points factor (default Out/IR 0, Doubtful 0.25, Questionable 0.85)
+}
+
+func (o Options) withDefaults() Options {
+	if o.Alpha <= 0 || o.Alpha > 1 {
+		o.Alpha = 0.4
+	}
+	if o.Z <= 0 {
+		o.Z = 1.2816
+	}
+	if o.PriorSnaps <= 0 {
+		o.PriorSnaps = 150
+	}
+	if o.PassTilt == nil {
+		o.PassTilt = map[string]float64{"DL": 0.5, "DB": 0.5, "LB": -0.5}
+	}
+	if o.Injury == nil {
+		o.Injury = map[string]float64{"OUT": 0, "IR": 0, "DOUBTFUL": 0.25, "QUESTIONABLE": 0.85}
+	}
+	return o
+}
+
+const (
+	priorPctSD    = 15.0 // snap-share error (pct points) assumed with no history
+	minPctSD      = 4.0  // floor so a perfectly steady starter still gets an interval
+	priorPointsSD = 4.0  // per-game points residual assumed with no history
+	priorWeight   = 2.0  // games' worth of weight the priors carry
+)
+
+// Projection is one player's estimate for one week. Lo/Hi bound the interval.
+type Projection struct {
+	PlayerID      string  `json:"player_id"`
+	Player        string  `json:"player"`
+	Team          string  `json:"team"`
+	Pos           string  `json:"pos"`
+	Week          int     `json:"week"`
+	Opponent      string  `json:"opponent,omitempty"`
+	Games         int     `json:"games"`
+	SnapPct       float64 `json:"snap_pct"`
+	SnapPctLo     float64 `json:"snap_pct_lo"`
+	SnapPctHi     float64 `json:"snap_pct_hi"`
+	Snaps         float64 `json:"snaps"`
+	SnapsLo       float64 `json:"snaps_lo"`
+	SnapsHi       float64 `json:"snaps_hi"`
+	PointsPerSnap float64 `json:"points_per_snap"`
+	Points        float64 `json:"points"`
+	PointsLo      float64 `json:"points_lo"`
+	PointsHi      float64 `json:"points_hi"`
+	Injury        string  `json:"injury,omitempty"`
+}
+
+// Priors is the points-per-snap of each position group over games before week, the
+// rate a player with little history is shrunk toward.
+func Priors(players []Player, env *Env, week int) map[string]float64 {
+	pts, snaps := map[string]float64{}, map[string]float64{}
+	for _, p := range players {
+		g := starters.GroupOf(p.Pos)
+		for _, gm := range p.Games {
+			if gm.Week >= week || gm.SnapPct <= 0 {
+				continue
+			}
+			pts[g] += gm.Points
+			snaps[g] += gm.SnapPct / 100 * env.DefPlays(teamOf(p, gm), gm.Week)
+		}
+	}
+	out := make(map[string]float64, len(pts))
+	for g, s := range snaps {
+		if s > 0 {
+			out[g] = pts[g] / s
+		}
+	}
+	return out
+}
+
+// Project estimates p for week against opp (use Env.Opponent; "" projects zero, a
+// bye). Only games before week are used. ok is false when p has no game to go on.
+func Project(p Player, week int, opp, injury string, env *Env, priors map[string]float64, opt Options) (Projection, bool) {
+	opt = opt.withDefaults()
+	group := starters.GroupOf(p.Pos)
+	pr := Projection{PlayerID: p.PlayerID, Player: p.Player, Team: p.Team, Pos: p.Pos, Week: week, Opponent: opp, Injury: injury}
+
+	// Games played before week, in week order.
+	var hist []Game
+	for _, g := range p.Games {
+		if g.Week < week && g.SnapPct > 0 {
+			hist = append(hist, g)
+		}
+	}
+	sort.Slice(hist, func(i, j int) bool { return hist[i].Week < hist[j].Week })
+	pr.Games = len(hist)
+	if len(hist) == 0 {
+		return pr, false
+	}
+
+	// Snap share: EWMA and its one-step-ahead errors.
+	ewma := hist[0].SnapPct
+	var sq float64
+	for _, g := range hist[1:] {
+		d := g.SnapPct - ewma
+		sq += d * d
+		ewma = opt.Alpha*g.SnapPct + (1-opt.Alpha)*ewma
+	}
+	n := float64(len(hist) - 1)
+	pctSD := math.Max(math.Sqrt((sq+priorWeight*priorPctSD*priorPctSD)/(n+priorWeight)), minPctSD)
+
+	// Production rate, shrunk toward the group.
+	var pts, snaps float64
+	gameSnaps := make([]float64, len(hist))
+	for i, g := range hist {
+		gameSnaps[i] = g.SnapPct / 100 * env.DefPlays(teamOf(p, g), g.Week)
+		pts += g.Points
+		snaps += gameSnaps[i]
+	}
+	prior := priors[group]
+	pps := (pts + opt.PriorSnaps*prior) / (snaps + opt.PriorSnaps)
+	var rsq float64
+	for i, g := range hist {
+		r := g.Points - pps*gameSnaps[i]
+		rsq += r * r
+	}
+	ptsSD := math.Sqrt((rsq + priorWeight*priorPointsSD*priorPointsSD) / (float64(len(hist)) + priorWeight))
+
+	pr.PointsPerSnap = round(pps, 4)
+	pr.SnapPct = round(ewma, 1)
+	pr.SnapPctLo = round(math.Max(ewma-opt.Z*pctSD, 0), 1)
+	pr.SnapPctHi = round(math.Min(ewma+opt.Z*pctSD, 100), 1)
+	if opp == "" {
+		return zero(pr), true
+	}
+
+	plays, passRate, _ := env.Offense(opp, week)
+	_, leaguePass := env.League(week)
+	tilt := math.Max(1+opt.PassTilt[group]*(passRate-leaguePass), 0)
+	inj := 1.0
+	if st := strings.ToUpper(strings.TrimSpace(injury)); st != "" {
+		if f, ok := opt.Injury[st]; ok {
+			inj = f
+		}
+	}
+
+	s := ewma / 100 * plays
+	sSD := opt.Z * pctSD / 100 * plays
+	mean := s * pps * tilt
+	sd := math.Sqrt(ptsSD*ptsSD + (pps*tilt*pctSD/100*plays)*(pps*tilt*pctSD/100*plays))
+
+	pr.Snaps = round(s, 1)
+	pr.SnapsLo = round(math.Max(s-sSD, 0), 1)
+	pr.SnapsHi = round(math.Min(s+sSD, plays), 1)
+	pr.Points = round(mean*inj, 2)
+	pr.PointsLo = round(math.Max(mean-opt.Z*sd, 0)*inj, 2)
+	// A questionable player who plays can still hit the full upside.
+	pr.PointsHi = round(mean+opt.Z*sd, 2)
+	if inj == 0 {
+		return zero(pr), true
+	}
+	return pr, true
+}
+
+// Matchup scales a defender's per-game output for team's game in week: the opponent's
+// plays per game against the league, tilted by pass rate for the group, using games
+// before asOf. 1 is a league-average opponent; 0 is a bye.
+func Matchup(env *Env, team string, week, asOf int, group string, opt Options) float64 {
+	opt = opt.withDefaults()
+	opp := env.Opponent(team, week)
+	if opp == "" {
+		return 0
+	}
+	plays, passRate, _ := env.Offense(opp, asOf)
+	leaguePlays, leaguePass := env.League(asOf)
+	return plays / leaguePlays * math.Max(1+opt.PassTilt[group]*(passRate-leaguePass), 0)
+}
+
+// zero clears the week's volume for a bye or a player ruled out.
+func zero(pr Projection) Projection {
+	pr.SnapPct, pr.SnapPctLo, pr.SnapPctHi = 0, 0, 0
+	pr.Snaps, pr.SnapsLo, pr.SnapsHi = 0, 0, 0
+	pr.Points, pr.PointsLo, pr.PointsHi = 0, 0, 0
+	return pr
+}
+
+func teamOf(p Player, g Game) string {
+	if g.Team != "" {
+		return g.Team
+	}
+	return p.Team
+}
+
+func round(v float64, places int) float64 {
+	p := math.Pow(10, float64(places))
+	return math.Round(v*p) / p
+}
@@ -0,0 +1,96 @@
+package projection
+
+import (
+	"math"
+	"testing"
+)
+
+// env: SEA faces a 60-play offense every week; week 4's opponent DAL runs 70 plays
+// and passes more than the league.
+func testEnv() *Env {
+	var games []TeamGame
+	for w := 1; w <= 3; w++ {
+		games = append(games,
+			TeamGame{Team: "OPP", Opponent: "SEA", Week: w, Plays: 60, PassPlays: 36},
+			TeamGame{Team: "SEA", Opponent: "OPP", Week: w, Plays: 60, PassPlays: 30},
+			TeamGame{Team: "DAL", Opponent: "NYG", Week: w, Plays: 70, PassPlays: 49},
+			TeamGame{Team: "NYG", Opponent: "DAL", Week: w, Plays: 60, PassPlays: 33},
+		)
+	}
+	e := NewEnv(games)
+	e.AddGame(4, "SEA", "DAL")
+	return e
+}
+
+func TestEnv_NoLookahead(t *testing.T) {
+	e := testEnv()
+	if p, r, n := e.Offense("DAL", 4); p != 70 || r != 0.7 || n != 3 {
+		t.Fatalf("DAL offense = %v %v %d", p, r, n)
+	}
+	if p, _, n := e.Offense("DAL", 1); n != 0 || p != DefaultPlays {
+		t.Fatalf("week 1 offense should fall back: %v %d", p, n)
+	}
+	if e.DefPlays("SEA", 2) != 60 || e.Opponent("DAL", 4) != "SEA" {
+		t.Fatal("schedule lookups")
+	}
+	// DAL runs 70 plays against a 62.5 league average; the LB tilt takes 5.4% off.
+	if m := Matchup(e, "SEA", 4, 4, "LB", Options{}); math.Abs(m-70/62.5*0.946) > 1e-9 {
+		t.Fatalf("matchup = %v", m)
+	}
+	if m := Matchup(e, "SEA", 5, 4, "LB", Options{}); m != 0 {
+		t.Fatalf("bye matchup = %v", m)
+	}
+}
+
+func TestProject(t *testing.T) {
+	e := testEnv()
+	lb := Player{PlayerID: "lb", Team: "SEA", Pos: "LB", Games: []Game{
+		{Week: 1, SnapPct: 100, Points: 12}, {Week: 2, SnapPct: 100, Points: 6}, {Week: 3, SnapPct: 100, Points: 9},
+		{Week: 4, SnapPct: 100, Points: 99}, // the future: must be ignored
+	}}
+	priors := Priors([]Player{lb}, e, 4)
+	if math.Abs(priors["LB"]-0.15) > 1e-9 {
+		t.Fatalf("LB prior = %v", priors["LB"])
+	}
+	pr, ok := Project(lb, 4, e.Opponent("SEA", 4), "", e, priors, Options{})
+	if !ok || pr.Games != 3 || pr.SnapPct != 100 || pr.Snaps != 70 {
+		t.Fatalf("projection = %+v", pr)
+	}
+	// 70 snaps x 0.15 pts/snap = 10.5, tilted down 5.4% for a LB facing a 70% pass
+	// rate against a 59.2% league.
+	if pr.Points != 9.93 || pr.PointsLo >= pr.Points || pr.PointsHi <= pr.Points {
+		t.Fatalf("points = %v [%v, %v]", pr.Points, pr.PointsLo, pr.PointsHi)
+	}
+	if pr.SnapPctHi != 100 || pr.SnapPctLo >= 100 {
+		t.Fatalf("snap interval = [%v, %v]", pr.SnapPctLo, pr.SnapPctHi)
+	}
+
+	q, _ := Project(lb, 4, "DAL", "Questionable", e, priors, Options{})
+	if q.Points >= pr.Points || q.PointsHi != pr.PointsHi {
+		t.Fatalf("questionable = %+v", q)
+	}
+	if out, _ := Project(lb, 4, "DAL", "Out", e, priors, Options{}); out.Points != 0 || out.Snaps != 0 {
+		t.Fatalf("out = %+v", out)
+	}
+	if bye, _ := Project(lb, 4, "", "", e, priors, Options{}); bye.Points != 0 {
+		t.Fatalf("bye = %+v", bye)
+	}
+	if _, ok := Project(Player{PlayerID: "new", Pos: "LB"}, 4, "DAL", "", e, priors, Options{}); ok {
+		t.Fatal("player with no history projected")
+	}
+}
+
+func TestBacktest(t *testing.T) {
+	e := testEnv()
+	p := Player{PlayerID: "s", Team: "SEA", Pos: "S", Games: []Game{
+		{Week: 1, SnapPct: 50, Points: 3}, {Week: 2, SnapPct: 50, Points: 3}, {Week: 3, SnapPct: 50, Points: 3},
+	}}
+	outs := Backtest([]Player{p}, e, 2, 3, Options{})
+	if len(outs) != 2 {
+		t.Fatalf("outcomes = %d", len(outs))
+	}
+	pts, snaps := Score(outs)
+	if pts.N != 2 || pts.MAE > 0.01 || snaps.MAE != 0 || pts.Coverage != 1 {
+		t.Fatalf("points = %+v snaps = %+v", pts, snaps)
+	}
+}
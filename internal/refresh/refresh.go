@@ -0,0 +1,82 @@
+package refresh
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"github.com/tyler180/fantasy-football-backends/internal/alerts"
+	"github.com/tyler180/fantasy-football-backends/internal/store"
+	"github.com/tyler180/fantasy-football-backends/internal/trends"
+)
+
+// API is the DynamoDB surface needed to read a player's games and write trends.
+type API interface {
+	store.DynamoDBAPI
+	store.DynamoDBReadAPI
+}
+
+// Refresher recomputes snap trends for individual players in the players table.
+type Refresher struct {
+	DDB          API
+	SnapTable    string // defensive_snaps_by_game
+	PlayersTable string // defensive_players_by_team
+	Options      trends.Options
+
+	// AlertsTable, when set, receives role-change flags for the player's latest game.
+	AlertsTable string
+	Alerts      alerts.Options
+}
+
+// ErrPlayerNotFound means the player has no item in the players table to update.
+var ErrPlayerNotFound = errors.New("player not in players table")
+
+// RefreshPlayer recomputes trends for playerID on team (the players-table SeasonTeam
+// partition) from every game the player has in season.
+func (r *Refresher) RefreshPlayer(ctx context.Context, season, team, playerID string) error {
+	pts, err := store.QueryPlayerSnapSeries(ctx, r.DDB, r.SnapTable, playerID, season)
+	if err != nil {
+		return fmt.Errorf("query snaps %s: %w", playerID, err)
+	}
+	m := trends.Compute(pts, r.Options)
+	err = store.UpdatePlayerTrends(ctx, r.DDB, r.PlayersTable, season, team, playerID, m)
+	var ccf *types.ConditionalCheckFailedException
+	if errors.As(err, &ccf) {
+		return ErrPlayerNotFound
+	}
+	if err != nil || r.AlertsTable == "" || len(pts) == 0 {
+		return err
+	}
+	flags := alerts.Detect(pts, r.Alerts)
+	if _, err := store.ReplacePlayerAlerts(ctx, r.DDB, r.AlertsTable, season, team, playerID, pts[len(pts)-1].Week, flags); err != nil {
+		return fmt.Errorf("write alerts %s: %w", playerID, err)
+	}
+	return nil
+}
+
+// RefreshPlayerAnyTeam is RefreshPlayer for callers that only know the player's ID.
+// It resolves every team the player is stored under for the season and falls back to
+// hintTeam (e.g. the team on the snap row) when the index has no entry yet.
+// It returns the number of items updated.
+func (r *Refresher) RefreshPlayerAnyTeam(ctx context.Context, season, hintTeam, playerID string) (int, error) {
+	teams, err := store.FindPlayerSeasonTeams(ctx, r.DDB, r.PlayersTable, playerID, season)
+	if err != nil {
+		return 0, fmt.Errorf("find teams %s: %w", playerID, err)
+	}
+	if len(teams) == 0 && hintTeam != "" {
+		teams = []string{hintTeam}
+	}
+	updated := 0
+	for _, tm := range teams {
+		switch err := r.RefreshPlayer(ctx, season, tm, playerID); {
+		case errors.Is(err, ErrPlayerNotFound):
+			continue
+		case err != nil:
+			return updated, err
+		}
+		updated++
+	}
+	return updated, nil
+}
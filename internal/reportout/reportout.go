@@ -0,0 +1,96 @@
+// Package reportout writes a report's JSON and Markdown renderings wherever the job
+// is configured to put them: an S3 bucket (REPORT_BUCKET), a local directory
+// (REPORT_DIR), both, or, when neither is set, the log.
+package reportout
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// S3PutAPI is the S3 call Write needs.
+type S3PutAPI interface {
+	PutObject(ctx context.Context, in *s3.PutObjectInput, optFns ...func(*s3.Options)) (*s3.PutObjectOutput, error)
+}
+
+// Writer is where reports go.
+type Writer struct {
+	Bucket string   // S3 bucket; "" skips S3
+	Dir    string   // local directory; "" skips files
+	S3     S3PutAPI // required when Bucket is set
+}
+
+// FromEnv reads REPORT_BUCKET and REPORT_DIR, loading the AWS config only when a
+// bucket is set.
+func FromEnv(ctx context.Context) (Writer, error) {
+	w := Writer{Bucket: getenv("REPORT_BUCKET"), Dir: getenv("REPORT_DIR")}
+	if w.Bucket != "" {
+		awsCfg, err := config.LoadDefaultConfig(ctx)
+		if err != nil {
+			return Writer{}, fmt.Errorf("aws config: %w", err)
+		}
+		w.S3 = s3.NewFromConfig(awsCfg)
+	}
+	return w, nil
+}
+
+func getenv(k string) string { return strings.TrimSpace(os.Getenv(k)) }
+
+// Write writes <name>.json and <name>.md to s3://<Bucket>/<prefix>/<sub>/ and
+// <Dir>/<sub>/ and returns where they went. With neither configured the Markdown is
+// logged, so an invoke still shows the report.
+func (w Writer) Write(ctx context.Context, prefix, sub, name string, js, md []byte) ([]string, error) {
+	files := []struct {
+		name, contentType string
+		body              []byte
+	}{
+		{name + ".json", "application/json", js},
+		{name + ".md", "text/markdown; charset=utf-8", md},
+	}
+	var where []string
+	if w.Bucket != "" {
+		if w.S3 == nil {
+			return nil, fmt.Errorf("report bucket %s: no S3 client", w.Bucket)
+		}
+		prefix := strings.Trim(prefix, "/") + "/" + sub
+		for _, f := range files {
+			key := prefix + "/" + f.name
+			if _, err := w.S3.PutObject(ctx, &s3.PutObjectInput{
+				Bucket:      aws.String(w.Bucket),
+				Key:         aws.String(key),
+				Body:        bytes.NewReader(f.body),
+				ContentType: aws.String(f.contentType),
+			}); err != nil {
+				return nil, fmt.Errorf("put s3://%s/%s: %w", w.Bucket, key, err)
+			}
+			where = append(where, "s3://"+w.Bucket+"/"+key)
+		}
+	}
+	if w.Dir != "" {
+		dir := filepath.Join(w.Dir, filepath.FromSlash(sub))
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, err
+		}
+		for _, f := range files {
+			p := filepath.Join(dir, f.name)
+			if err := os.WriteFile(p, f.body, 0o644); err != nil {
+				return nil, err
+			}
+			where = append(where, p)
+		}
+	}
+	if len(where) == 0 {
+		log.Print(string(md))
+		where = append(where, "log")
+	}
+	return where, nil
+}
@@ -0,0 +1,53 @@
+package reportout
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+type putS3 struct{ puts map[string]string }
+
+func (p *putS3) PutObject(_ context.Context, in *s3.PutObjectInput, _ ...func(*s3.Options)) (*s3.PutObjectOutput, error) {
+	b, _ := io.ReadAll(in.Body)
+	p.puts[aws.ToString(in.Key)+" "+aws.ToString(in.ContentType)] = string(b)
+	return &s3.PutObjectOutput{}, nil
+}
+
+func TestWrite(t *testing.T) {
+	dir := t.TempDir()
+	p := &putS3{puts: map[string]string{}}
+	w := Writer{Bucket: "reports", Dir: dir, S3: p}
+	where, err := w.Write(context.Background(), "/reports/waiver/", "2024/week03", "report", []byte(`{}`), []byte("# Waivers"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"s3://reports/reports/waiver/2024/week03/report.json",
+		"s3://reports/reports/waiver/2024/week03/report.md",
+		filepath.Join(dir, "2024", "week03", "report.json"),
+		filepath.Join(dir, "2024", "week03", "report.md"),
+	}
+	if !reflect.DeepEqual(where, want) {
+		t.Errorf("where = %v, want %v", where, want)
+	}
+	if p.puts["reports/waiver/2024/week03/report.md text/markdown; charset=utf-8"] != "# Waivers" {
+		t.Errorf("puts = %v", p.puts)
+	}
+	if b, err := os.ReadFile(want[2]); err != nil || string(b) != "{}" {
+		t.Errorf("json file = %q, %v", b, err)
+	}
+
+	if where, err := (Writer{}).Write(context.Background(), "x", "2024", "report", nil, []byte("# Log")); err != nil || !reflect.DeepEqual(where, []string{"log"}) {
+		t.Errorf("unconfigured writer = %v, %v; want the log", where, err)
+	}
+	if _, err := (Writer{Bucket: "reports"}).Write(context.Background(), "x", "2024", "report", nil, nil); err == nil {
+		t.Error("bucket without a client accepted")
+	}
+}
@@ -0,0 +1,219 @@
+package scoring
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// LoadRuleset reads a ruleset file. ".yaml"/".yml" files are YAML; anything else is
+// JSON, either a Ruleset document or an MFL league rules export.
+func LoadRuleset(path string) (Ruleset, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return Ruleset{}, fmt.Errorf("read ruleset: %w", err)
+	}
+	format := "json"
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		format = "yaml"
+	}
+	r, err := ParseRuleset(b, format)
+	if err != nil {
+		return Ruleset{}, fmt.Errorf("%s: %w", path, err)
+	}
+	if r.Name == "" {
+		r.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	return r, nil
+}
+
+// ParseRuleset decodes a ruleset in "json" or "yaml". JSON that looks like an MFL
+// rules export ({"rules": {"positionRules": ...}}) is converted with FromMFLRules.
+func ParseRuleset(b []byte, format string) (Ruleset, error) {
+	var r Ruleset
+	switch format {
+	case "yaml":
+		if err := yaml.Unmarshal(b, &r); err != nil {
+			return Ruleset{}, fmt.Errorf("parse yaml ruleset: %w", err)
+		}
+	case "json":
+		if isMFLRules(b) {
+			r, _, err := FromMFLRules(b)
+			return r, err
+		}
+		dec := json.NewDecoder(bytes.NewReader(b))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&r); err != nil {
+			return Ruleset{}, fmt.Errorf("parse json ruleset: %w", err)
+		}
+	default:
+		return Ruleset{}, fmt.Errorf("unknown ruleset format %q", format)
+	}
+	return r, r.Validate()
+}
+
+// Validate rejects unknown stats so typos do not silently score zero.
+func (r Ruleset) Validate() error {
+	known := make(map[Stat]bool, len(Stats))
+	for _, s := range Stats {
+		known[s] = true
+	}
+	check := func(where string, m map[Stat]float64) error {
+		for s := range m {
+			if !known[s] {
+				return fmt.Errorf("ruleset %s: unknown stat %q", where, s)
+			}
+		}
+		return nil
+	}
+	if err := check("points", r.Points); err != nil {
+		return err
+	}
+	for pos, m := range r.PositionPoints {
+		if err := check("position_points."+pos, m); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// MFLEvents maps MFL scoring event codes to stats. Leagues that use other codes can
+// add to it before calling FromMFLRules.
+var MFLEvents = map[string]Stat{
+	"TK":  TackleSolo,
+	"AS":  TackleAssist,
+	"SK":  Sack,
+	"TF":  TFL,
+	"TFL": TFL,
+	"QH":  QBHit,
+	"PD":  PassDefended,
+	"IC":  Interception,
+	"FF":  ForcedFumble,
+	"FC":  FumbleRec,
+	"DTD": DefTD,
+	"SF":  Safety,
+}
+
+type mflText struct {
+	T string `json:"$t"`
+}
+
+type mflRule struct {
+	Points mflText `json:"points"`
+	Event  mflText `json:"event"`
+	Range  mflText `json:"range"`
+}
+
+type mflPositionRules struct {
+	Positions string          `json:"positions"`
+	Rule      json.RawMessage `json:"rule"` // object or array
+}
+
+// isMFLRules reports whether b is an MFL "rules" export.
+func isMFLRules(b []byte) bool {
+	var probe struct {
+		Rules *struct {
+			PositionRules json.RawMessage `json:"positionRules"`
+		} `json:"rules"`
+	}
+	return json.Unmarshal(b, &probe) == nil && probe.Rules != nil && len(probe.Rules.PositionRules) > 0
+}
+
+// FromMFLRules converts an MFL league rules export (TYPE=rules&JSON=1) into a ruleset.
+// Points go to PositionPoints for each listed position. Only the tier whose range
+// covers a single unit is used, so "*1" and "1" both mean one point per unit.
+// It also returns the event codes it did not recognize.
+func FromMFLRules(b []byte) (Ruleset, []string, error) {
+	var doc struct {
+		Rules struct {
+			PositionRules json.RawMessage `json:"positionRules"`
+		} `json:"rules"`
+	}
+	if err := json.Unmarshal(b, &doc); err != nil {
+		return Ruleset{}, nil, fmt.Errorf("parse mfl rules: %w", err)
+	}
+	var groups []mflPositionRules
+	if err := oneOrMany(doc.Rules.PositionRules, &groups); err != nil {
+		return Ruleset{}, nil, fmt.Errorf("parse mfl positionRules: %w", err)
+	}
+
+	r := Ruleset{Name: "mfl", Points: map[Stat]float64{}, PositionPoints: map[string]map[Stat]float64{}}
+	unknown := map[string]bool{}
+	for _, g := range groups {
+		var rules []mflRule
+		if err := oneOrMany(g.Rule, &rules); err != nil {
+			return Ruleset{}, nil, fmt.Errorf("parse mfl rule for %s: %w", g.Positions, err)
+		}
+		for _, rule := range rules {
+			ev := strings.TrimSpace(rule.Event.T)
+			stat, ok := MFLEvents[ev]
+			if !ok {
+				unknown[ev] = true
+				continue
+			}
+			if !rangeCoversOne(rule.Range.T) {
+				continue
+			}
+			pts, err := strconv.ParseFloat(strings.TrimPrefix(strings.TrimSpace(rule.Points.T), "*"), 64)
+			if err != nil {
+				return Ruleset{}, nil, fmt.Errorf("mfl rule %s points %q: %w", ev, rule.Points.T, err)
+			}
+			for _, pos := range strings.Split(g.Positions, "|") {
+				pos = strings.ToUpper(strings.TrimSpace(pos))
+				if pos == "" {
+					continue
+				}
+				if r.PositionPoints[pos] == nil {
+					r.PositionPoints[pos] = map[Stat]float64{}
+				}
+				r.PositionPoints[pos][stat] = pts
+			}
+		}
+	}
+	out := make([]string, 0, len(unknown))
+	for ev := range unknown {
+		out = append(out, ev)
+	}
+	sort.Strings(out)
+	return r, out, nil
+}
+
+// rangeCoversOne reports whether an MFL range such as "0-99" or "1-5" includes 1.
+func rangeCoversOne(rng string) bool {
+	rng = strings.TrimSpace(rng)
+	if rng == "" {
+		return true
+	}
+	lo, hi, ok := strings.Cut(rng, "-")
+	if !ok {
+		return true
+	}
+	l, err1 := strconv.ParseFloat(strings.TrimSpace(lo), 64)
+	h, err2 := strconv.ParseFloat(strings.TrimSpace(hi), 64)
+	return err1 != nil || err2 != nil || (l <= 1 && h >= 1)
+}
+
+// oneOrMany decodes MFL's "single object or array" JSON shape into out.
+func oneOrMany[T any](raw json.RawMessage, out *[]T) error {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) == 0 || bytes.Equal(raw, []byte("null")) {
+		return nil
+	}
+	if raw[0] == '[' {
+		return json.Unmarshal(raw, out)
+	}
+	var one T
+	if err := json.Unmarshal(raw, &one); err != nil {
+		return err
+	}
+	*out = append(*out, one)
+	return nil
+}
@@ -0,0 +1,176 @@
+// Package scoring turns per-game defensive stats into IDP fantasy points under a
+// league-configurable ruleset.
+package scoring
+
+import (
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/tyler180/fantasy-football-backends/internal/starters"
+)
+
+// Stat is a scoring category.
+type Stat string
+
+const (
+	TackleSolo   Stat = "tackle_solo"
+	TackleAssist Stat = "tackle_assist"
+	Sack         Stat = "sack"
+	TFL          Stat = "tfl" // tackle for loss
+	QBHit        Stat = "qb_hit"
+	PassDefended Stat = "pd"
+	Interception Stat = "int"
+	ForcedFumble Stat = "ff"
+	FumbleRec    Stat = "fr"
+	DefTD        Stat = "td"
+	Safety       Stat = "safety"
+)
+
+// Stats lists every category in display order.
+var Stats = []Stat{TackleSolo, TackleAssist, Sack, TFL, QBHit, PassDefended, Interception, ForcedFumble, FumbleRec, DefTD, Safety}
+
+// GameStats is one player's defensive line for one game.
+type GameStats struct {
+	Season   string
+	Week     int
+	Team     string
+	PlayerID string
+	Player   string
+	Pos      string
+	Stats    map[Stat]float64
+}
+
+// Ruleset is a league's IDP scoring.
+//
+//	points:          base points per unit of each stat
+//	position_points: per position code or group (DL/LB/DB); replaces base points for the listed stats
+//	multipliers:     per position code or group; scales the game total
+type Ruleset struct {
+	Name           string                      `json:"name" yaml:"name"`
+	Points         map[Stat]float64            `json:"points" yaml:"points"`
+	PositionPoints map[string]map[Stat]float64 `json:"position_points,omitempty" yaml:"position_points,omitempty"`
+	Multipliers    map[string]float64          `json:"multipliers,omitempty" yaml:"multipliers,omitempty"`
+}
+
+// Default is a common balanced IDP ruleset.
+func Default() Ruleset {
+	return Ruleset{
+		Name: "default",
+		Points: map[Stat]float64{
+			TackleSolo: 1, TackleAssist: 0.5, Sack: 3, TFL: 1, QBHit: 0.5, PassDefended: 1,
+			Interception: 3, ForcedFumble: 3, FumbleRec: 2, DefTD: 6, Safety: 2,
+		},
+	}
+}
+
+// pointsFor returns the per-unit points of stat for pos: exact position code first,
+// then its group, then the base points.
+func (r Ruleset) pointsFor(pos string, stat Stat) float64 {
+	for _, k := range positionKeys(pos) {
+		if pp, ok := r.PositionPoints[k]; ok {
+			if v, ok := pp[stat]; ok {
+				return v
+			}
+		}
+	}
+	return r.Points[stat]
+}
+
+func (r Ruleset) multiplierFor(pos string) float64 {
+	for _, k := range positionKeys(pos) {
+		if m, ok := r.Multipliers[k]; ok {
+			return m
+		}
+	}
+	return 1
+}
+
+// positionKeys are the lookup keys for a (possibly comma-listed) position: the first
+// code, then its group.
+func positionKeys(pos string) []string {
+	code := strings.ToUpper(strings.TrimSpace(strings.Split(pos, ",")[0]))
+	keys := []string{code}
+	if g := starters.GroupOf(pos); g != "" && g != code {
+		keys = append(keys, g)
+	}
+	return keys
+}
+
+// Score is the fantasy points for one game.
+func (r Ruleset) Score(g GameStats) float64 {
+	var total float64
+	for stat, n := range g.Stats {
+		total += n * r.pointsFor(g.Pos, stat)
+	}
+	return round2(total * r.multiplierFor(g.Pos))
+}
+
+// Breakdown is the points contributed by each stat before the position multiplier.
+func (r Ruleset) Breakdown(g GameStats) map[Stat]float64 {
+	out := make(map[Stat]float64, len(g.Stats))
+	for stat, n := range g.Stats {
+		if p := n * r.pointsFor(g.Pos, stat); p != 0 {
+			out[stat] = round2(p)
+		}
+	}
+	return out
+}
+
+// WeekPoints is one scored game.
+type WeekPoints struct {
+	Week   int
+	Points float64
+}
+
+// PlayerPoints is a player's scored season.
+type PlayerPoints struct {
+	Season   string
+	PlayerID string
+	Player   string
+	Team     string // team of the latest game
+	Pos      string
+	Weeks    []WeekPoints // week order
+	Total    float64
+	PPG      float64
+}
+
+// Season scores every game and rolls them up per player (sorted by total, highest first).
+func (r Ruleset) Season(games []GameStats) []PlayerPoints {
+	by := map[string]*PlayerPoints{}
+	latest := map[string]int{}
+	for _, g := range games {
+		if g.PlayerID == "" {
+			continue
+		}
+		p := by[g.PlayerID]
+		if p == nil {
+			p = &PlayerPoints{Season: g.Season, PlayerID: g.PlayerID}
+			by[g.PlayerID] = p
+		}
+		p.Weeks = append(p.Weeks, WeekPoints{Week: g.Week, Points: r.Score(g)})
+		if wk, seen := latest[g.PlayerID]; !seen || g.Week >= wk {
+			latest[g.PlayerID] = g.Week
+			p.Team, p.Player, p.Pos = g.Team, g.Player, g.Pos
+		}
+	}
+	out := make([]PlayerPoints, 0, len(by))
+	for _, p := range by {
+		sort.Slice(p.Weeks, func(i, j int) bool { return p.Weeks[i].Week < p.Weeks[j].Week })
+		for _, w := range p.Weeks {
+			p.Total += w.Points
+		}
+		p.Total = round2(p.Total)
+		p.PPG = round2(p.Total / float64(len(p.Weeks)))
+		out = append(out, *p)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Total != out[j].Total {
+			return out[i].Total > out[j].Total
+		}
+		return out[i].PlayerID < out[j].PlayerID
+	})
+	return out
+}
+
+func round2(v float64) float64 { return math.Round(v*100) / 100 }
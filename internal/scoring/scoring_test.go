@@ -0,0 +1,98 @@
+package scoring
+
+import (
+	"testing"
+)
+
+func TestScore_PositionPointsAndMultipliers(t *testing.T) {
+	r := Default()
+	r.PositionPoints = map[string]map[Stat]float64{
+		"DB": {TackleSolo: 1.5},
+		"CB": {PassDefended: 2},
+	}
+	r.Multipliers = map[string]float64{"DL": 1.5}
+
+	cb := GameStats{Pos: "CB", Stats: map[Stat]float64{TackleSolo: 4, PassDefended: 2, Interception: 1}}
+	// CB falls back to the DB group for tackles: 4*1.5 + 2*2 + 3
+	if got := r.Score(cb); got != 13 {
+		t.Fatalf("cb score = %v, want 13", got)
+	}
+	de := GameStats{Pos: "DE,OLB", Stats: map[Stat]float64{Sack: 1.5, TackleAssist: 1}}
+	// (1.5*3 + 0.5) * 1.5
+	if got := r.Score(de); got != 7.5 {
+		t.Fatalf("de score = %v, want 7.5", got)
+	}
+	if b := r.Breakdown(de); b[Sack] != 4.5 || b[TackleAssist] != 0.5 {
+		t.Fatalf("breakdown = %v", b)
+	}
+}
+
+func TestSeason_RollsUpPerPlayer(t *testing.T) {
+	r := Default()
+	games := []GameStats{
+		{Season: "2024", Week: 3, Team: "SEA", PlayerID: "A", Pos: "LB", Stats: map[Stat]float64{TackleSolo: 6}},
+		{Season: "2024", Week: 1, Team: "ATL", PlayerID: "A", Pos: "LB", Stats: map[Stat]float64{TackleSolo: 4}},
+		{Season: "2024", Week: 1, Team: "ATL", PlayerID: "B", Pos: "S", Stats: map[Stat]float64{Interception: 1}},
+		{Season: "2024", Week: 1, Team: "ATL", Stats: map[Stat]float64{Sack: 1}}, // no id
+	}
+	got := r.Season(games)
+	if len(got) != 2 || got[0].PlayerID != "A" {
+		t.Fatalf("season = %+v", got)
+	}
+	a := got[0]
+	if a.Total != 10 || a.PPG != 5 || a.Team != "SEA" || a.Weeks[0].Week != 1 {
+		t.Fatalf("player A = %+v", a)
+	}
+}
+
+func TestParseRuleset_Formats(t *testing.T) {
+	js := `{"name": "big-play", "points": {"sack": 4, "int": 5}, "multipliers": {"DB": 0.5}}`
+	r, err := ParseRuleset([]byte(js), "json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Points[Sack] != 4 || r.Multipliers["DB"] != 0.5 {
+		t.Fatalf("json ruleset = %+v", r)
+	}
+
+	y := "name: tackle-heavy\npoints:\n  tackle_solo: 2\nposition_points:\n  LB:\n    tackle_solo: 2.5\n"
+	r, err = ParseRuleset([]byte(y), "yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.pointsFor("ILB", TackleSolo) != 2.5 || r.pointsFor("CB", TackleSolo) != 2 {
+		t.Fatalf("yaml ruleset = %+v", r)
+	}
+
+	if _, err := ParseRuleset([]byte(`{"points": {"sacks": 3}}`), "json"); err == nil {
+		t.Fatal("expected an error for an unknown stat")
+	}
+	if _, err := ParseRuleset([]byte(`{"pointz": {}}`), "json"); err == nil {
+		t.Fatal("expected an error for an unknown field")
+	}
+}
+
+func TestFromMFLRules(t *testing.T) {
+	doc := `{"rules": {"positionRules": [
+	  {"positions": "DE|DT|LB", "rule": [
+	    {"event": {"$t": "TK"}, "points": {"$t": "*1.5"}, "range": {"$t": "0-99"}},
+	    {"event": {"$t": "SK"}, "points": {"$t": "4"}, "range": {"$t": "1-5"}},
+	    {"event": {"$t": "SK"}, "points": {"$t": "10"}, "range": {"$t": "6-99"}},
+	    {"event": {"$t": "ZZ"}, "points": {"$t": "1"}, "range": {"$t": "0-99"}}
+	  ]},
+	  {"positions": "CB|S", "rule": {"event": {"$t": "PD"}, "points": {"$t": "*2"}, "range": {"$t": "0-99"}}}
+	]}}`
+	r, unknown, err := FromMFLRules([]byte(doc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(unknown) != 1 || unknown[0] != "ZZ" {
+		t.Fatalf("unknown = %v", unknown)
+	}
+	if r.PositionPoints["LB"][TackleSolo] != 1.5 || r.PositionPoints["DE"][Sack] != 4 || r.PositionPoints["S"][PassDefended] != 2 {
+		t.Fatalf("mfl ruleset = %+v", r.PositionPoints)
+	}
+	if _, err := ParseRuleset([]byte(doc), "json"); err != nil {
+		t.Fatalf("ParseRuleset did not detect the MFL export: %v", err)
+	}
+}
@@ -0,0 +1,134 @@
+package snaps
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/tyler180/fantasy-football-backends/internal/scoring"
+)
+
+// defStatColumns maps nflverse weekly player-stat columns to scoring stats.
+var defStatColumns = map[string]scoring.Stat{
+	"def_tackles_solo":     scoring.TackleSolo,
+	"def_tackle_assists":   scoring.TackleAssist,
+	"def_sacks":            scoring.Sack,
+	"def_tackles_for_loss": scoring.TFL,
+	"def_qb_hits":          scoring.QBHit,
+	"def_pass_defended":    scoring.PassDefended,
+	"def_interceptions":    scoring.Interception,
+	"def_fumbles_forced":   scoring.ForcedFumble,
+	"fumble_recovery_opp":  scoring.FumbleRec,
+	"def_tds":              scoring.DefTD,
+	"def_safeties":         scoring.Safety,
+}
+
+// FetchNflverseDefStats downloads nflverse weekly player stats for a regular season and
+// returns one GameStats per player-game with any defensive production.
+// PlayerID is the GSIS id and Team is the nflverse code; callers translate both.
+func FetchNflverseDefStats(ctx context.Context, season int, url string) ([]scoring.GameStats, error) {
+	if url == "" {
+		url = fmt.Sprintf("https://github.com/nflverse/nflverse-data/releases/download/stats_player/stats_player_week_%d.csv", season)
+	}
+
+	req, _ := http.NewRequestWithContext(ctx, "GET", url, nil)
+	req.Header.Set("User-Agent", "pfr-snaps/1.1 (+https://example.com)")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("get player stats csv: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		b, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("player stats download %s: %s (%s)", url, resp.Status, string(b))
+	}
+	return parseDefStats(resp.Body, season)
+}
+
+func parseDefStats(rd io.Reader, season int) ([]scoring.GameStats, error) {
+	r := csv.NewReader(rd)
+	r.FieldsPerRecord = -1
+
+	hdr, err := r.Read()
+	if err != nil {
+		return nil, fmt.Errorf("read header: %w", err)
+	}
+	idx := func(names ...string) int {
+		for _, name := range names {
+			for i, h := range hdr {
+				if strings.EqualFold(strings.TrimSpace(h), name) {
+					return i
+				}
+			}
+		}
+		return -1
+	}
+
+	iSeason := idx("season")
+	iWeek := idx("week")
+	iType := idx("season_type")
+	iTeam := idx("team", "recent_team")
+	iID := idx("player_id")
+	iName := idx("player_display_name", "player_name")
+	iPos := idx("position")
+	if iSeason < 0 || iWeek < 0 || iTeam < 0 || iID < 0 {
+		return nil, fmt.Errorf("required columns missing (need season, week, team, player_id)")
+	}
+	statIdx := map[scoring.Stat]int{}
+	for col, st := range defStatColumns {
+		if i := idx(col); i >= 0 {
+			statIdx[st] = i
+		}
+	}
+	if len(statIdx) == 0 {
+		return nil, fmt.Errorf("no defensive stat columns found")
+	}
+
+	get := func(rec []string, i int) string {
+		if i < 0 || i >= len(rec) {
+			return ""
+		}
+		return strings.TrimSpace(rec[i])
+	}
+
+	var out []scoring.GameStats
+	for {
+		rec, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read row: %w", err)
+		}
+		if s, _ := strconv.Atoi(get(rec, iSeason)); s != season {
+			continue
+		}
+		if t := get(rec, iType); t != "" && !strings.EqualFold(t, "REG") {
+			continue
+		}
+		stats := map[scoring.Stat]float64{}
+		for st, i := range statIdx {
+			if v, err := strconv.ParseFloat(get(rec, i), 64); err == nil && v != 0 {
+				stats[st] = v
+			}
+		}
+		if len(stats) == 0 {
+			continue
+		}
+		wk, _ := strconv.Atoi(get(rec, iWeek))
+		out = append(out, scoring.GameStats{
+			Season:   strconv.Itoa(season),
+			Week:     wk,
+			Team:     strings.ToUpper(get(rec, iTeam)),
+			PlayerID: get(rec, iID),
+			Player:   get(rec, iName),
+			Pos:      strings.ToUpper(get(rec, iPos)),
+			Stats:    stats,
+		})
+	}
+	return out, nil
+}
@@ -114,3 +114,153 @@ func normName(s string) string {
 	fields := strings.Fields(s)
 	return strings.Join(fields, " ")
 }
+
+// FetchMFLPlayerIDs downloads the DynastyProcess id crosswalk and returns
+// mflID -> pfrID.
+func FetchMFLPlayerIDs(ctx context.Context, url string) (map[string]string, error) {
+	if url == "" {
+		url = "https://github.com/dynastyprocess/data/raw/master/files/db_playerids.csv"
+	}
+
+	req, _ := http.NewRequestWithContext(ctx, "GET", url, nil)
+	req.Header.Set("User-Agent", "pfr-snaps/1.0 (+https://github.com)")
+	client := &http.Client{Timeout: 20 * time.Second}
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != 200 {
+		body, _ := io.ReadAll(io.LimitReader(res.Body, 5120))
+		return nil, fmt.Errorf("mfl ids fetch %s: status %d body=%q", url, res.StatusCode, string(body))
+	}
+	return parseMFLPlayerIDs(res.Body)
+}
+
+func parseMFLPlayerIDs(rd io.Reader) (map[string]string, error) {
+	cr := csv.NewReader(rd)
+	cr.FieldsPerRecord = -1
+	header, err := cr.Read()
+	if err != nil {
+		return nil, err
+	}
+	ciMFL, ciPfr := -1, -1
+	for i, h := range header {
+		switch strings.ToLower(strings.TrimSpace(h)) {
+		case "mfl_id":
+			ciMFL = i
+		case "pfr_id":
+			ciPfr = i
+		}
+	}
+	if ciMFL < 0 || ciPfr < 0 {
+		return nil, fmt.Errorf("required columns missing (need mfl_id, pfr_id)")
+	}
+
+	out := make(map[string]string, 5000)
+	for {
+		rec, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return out, nil // partial ok
+		}
+		mfl := strings.TrimSpace(safeGet(rec, ciMFL))
+		pfr := strings.TrimSpace(safeGet(rec, ciPfr))
+		if mfl == "" || pfr == "" || strings.EqualFold(pfr, "NA") {
+			continue
+		}
+		out[mfl] = normalizePfrID(pfr)
+	}
+	return out, nil
+}
+
+// NflversePlayer is one row of the nflverse players dataset with the fields the
+// MFL crosswalk matches on.
+type NflversePlayer struct {
+	PFRID     string
+	GSISID    string
+	Name      string
+	Position  string
+	Team      string // nflverse code of the latest team
+	BirthDate string // YYYY-MM-DD, "" when unknown
+}
+
+// FetchNflversePlayers downloads the nflverse players dataset and returns every
+// player with a PFR id.
+func FetchNflversePlayers(ctx context.Context, url string) ([]NflversePlayer, error) {
+	if url == "" {
+		url = "https://github.com/nflverse/nflverse-data/releases/download/players/players.csv"
+	}
+
+	req, _ := http.NewRequestWithContext(ctx, "GET", url, nil)
+	req.Header.Set("User-Agent", "pfr-snaps/1.0 (+https://github.com)")
+	client := &http.Client{Timeout: 30 * time.Second}
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != 200 {
+		body, _ := io.ReadAll(io.LimitReader(res.Body, 5120))
+		return nil, fmt.Errorf("players fetch %s: status %d body=%q", url, res.StatusCode, string(body))
+	}
+	return parseNflversePlayers(res.Body)
+}
+
+func parseNflversePlayers(rd io.Reader) ([]NflversePlayer, error) {
+	cr := csv.NewReader(rd)
+	cr.FieldsPerRecord = -1
+	header, err := cr.Read()
+	if err != nil {
+		return nil, err
+	}
+	col := func(names ...string) int {
+		for _, name := range names {
+			for i, h := range header {
+				if strings.EqualFold(strings.TrimSpace(h), name) {
+					return i
+				}
+			}
+		}
+		return -1
+	}
+	ciPfr := col("pfr_id", "pfr_player_id")
+	ciGSIS := col("gsis_id")
+	ciName := col("display_name", "full_name")
+	ciPos := col("position")
+	ciTeam := col("latest_team", "team_abbr", "team")
+	ciBirth := col("birth_date")
+	if ciPfr < 0 || ciName < 0 {
+		return nil, fmt.Errorf("required columns missing (need pfr_id, display_name)")
+	}
+
+	out := make([]NflversePlayer, 0, 8000)
+	for {
+		rec, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return out, nil // partial ok
+		}
+		pfr := strings.TrimSpace(safeGet(rec, ciPfr))
+		if pfr == "" || strings.EqualFold(pfr, "NA") {
+			continue
+		}
+		birth := strings.TrimSpace(safeGet(rec, ciBirth))
+		if len(birth) > 10 {
+			birth = birth[:10]
+		}
+		out = append(out, NflversePlayer{
+			PFRID:     normalizePfrID(pfr),
+			GSISID:    strings.TrimSpace(safeGet(rec, ciGSIS)),
+			Name:      strings.TrimSpace(safeGet(rec, ciName)),
+			Position:  strings.ToUpper(strings.TrimSpace(safeGet(rec, ciPos))),
+			Team:      strings.ToUpper(strings.TrimSpace(safeGet(rec, ciTeam))),
+			BirthDate: birth,
+		})
+	}
+	return out, nil
+}
@@ -0,0 +1,89 @@
+package snaps
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// InjuryReport is one player's game status for one week.
+type InjuryReport struct {
+	Week   int
+	Team   string // nflverse code
+	GSISID string
+	Player string
+	Status string // Out, Doubtful, Questionable
+}
+
+// FetchNflverseInjuries downloads the nflverse injury reports for a regular season.
+func FetchNflverseInjuries(ctx context.Context, season int, url string) ([]InjuryReport, error) {
+	if url == "" {
+		url = fmt.Sprintf("https://github.com/nflverse/nflverse-data/releases/download/injuries/injuries_%d.csv", season)
+	}
+
+	req, _ := http.NewRequestWithContext(ctx, "GET", url, nil)
+	req.Header.Set("User-Agent", "pfr-snaps/1.1 (+https://example.com)")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("get injuries csv: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		b, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("injuries download %s: %s (%s)", url, resp.Status, string(b))
+	}
+
+	r := csv.NewReader(resp.Body)
+	r.FieldsPerRecord = -1
+	hdr, err := r.Read()
+	if err != nil {
+		return nil, fmt.Errorf("read header: %w", err)
+	}
+	idx := func(name string) int {
+		for i, h := range hdr {
+			if strings.EqualFold(strings.TrimSpace(h), name) {
+				return i
+			}
+		}
+		return -1
+	}
+	iSeason, iType, iWeek, iTeam := idx("season"), idx("game_type"), idx("week"), idx("team")
+	iID, iName, iStatus := idx("gsis_id"), idx("full_name"), idx("report_status")
+	if iSeason < 0 || iWeek < 0 || iID < 0 || iStatus < 0 {
+		return nil, fmt.Errorf("required columns missing (need season, week, gsis_id, report_status)")
+	}
+
+	var out []InjuryReport
+	for {
+		rec, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read row: %w", err)
+		}
+		if s, _ := strconv.Atoi(safeGet(rec, iSeason)); s != season {
+			continue
+		}
+		if t := strings.TrimSpace(safeGet(rec, iType)); t != "" && !strings.EqualFold(t, "REG") {
+			continue
+		}
+		status := strings.TrimSpace(safeGet(rec, iStatus))
+		if status == "" || strings.EqualFold(status, "NA") {
+			continue
+		}
+		wk, _ := strconv.Atoi(safeGet(rec, iWeek))
+		out = append(out, InjuryReport{
+			Week:   wk,
+			Team:   strings.ToUpper(strings.TrimSpace(safeGet(rec, iTeam))),
+			GSISID: strings.TrimSpace(safeGet(rec, iID)),
+			Player: strings.TrimSpace(safeGet(rec, iName)),
+			Status: status,
+		})
+	}
+	return out, nil
+}
@@ -0,0 +1,123 @@
+package snaps
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// ScheduledGame is one regular-season game from the nflverse schedule (nflverse team codes).
+type ScheduledGame struct {
+	Week int
+	Home string
+	Away string
+}
+
+// FetchNflverseSchedule downloads the nflverse schedule and returns the season's
+// regular-season games.
+func FetchNflverseSchedule(ctx context.Context, season int, url string) ([]ScheduledGame, error) {
+	if url == "" {
+		url = "https://github.com/nflverse/nfldata/raw/master/data/games.csv"
+	}
+
+	req, _ := http.NewRequestWithContext(ctx, "GET", url, nil)
+	req.Header.Set("User-Agent", "pfr-snaps/1.1 (+https://example.com)")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("get games csv: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		b, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("games download %s: %s (%s)", url, resp.Status, string(b))
+	}
+	return parseSchedule(resp.Body, season)
+}
+
+// FetchNflverseByeWeeks downloads the nflverse schedule and returns each team's
+// regular-season bye week, keyed by nflverse team code.
+func FetchNflverseByeWeeks(ctx context.Context, season int, url string) (map[string]int, error) {
+	games, err := FetchNflverseSchedule(ctx, season, url)
+	if err != nil {
+		return nil, err
+	}
+	return ByeWeeks(games), nil
+}
+
+func parseSchedule(rd io.Reader, season int) ([]ScheduledGame, error) {
+	r := csv.NewReader(rd)
+	r.FieldsPerRecord = -1
+	hdr, err := r.Read()
+	if err != nil {
+		return nil, fmt.Errorf("read header: %w", err)
+	}
+	idx := func(name string) int {
+		for i, h := range hdr {
+			if strings.EqualFold(strings.TrimSpace(h), name) {
+				return i
+			}
+		}
+		return -1
+	}
+	iSeason, iType, iWeek, iAway, iHome := idx("season"), idx("game_type"), idx("week"), idx("away_team"), idx("home_team")
+	if iSeason < 0 || iWeek < 0 || iAway < 0 || iHome < 0 {
+		return nil, fmt.Errorf("required columns missing (need season, week, away_team, home_team)")
+	}
+
+	var out []ScheduledGame
+	for {
+		rec, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read row: %w", err)
+		}
+		if s, _ := strconv.Atoi(safeGet(rec, iSeason)); s != season {
+			continue
+		}
+		if t := strings.TrimSpace(safeGet(rec, iType)); t != "" && !strings.EqualFold(t, "REG") {
+			continue
+		}
+		wk, _ := strconv.Atoi(safeGet(rec, iWeek))
+		out = append(out, ScheduledGame{
+			Week: wk,
+			Home: strings.ToUpper(strings.TrimSpace(safeGet(rec, iHome))),
+			Away: strings.ToUpper(strings.TrimSpace(safeGet(rec, iAway))),
+		})
+	}
+	return out, nil
+}
+
+// ByeWeeks finds, for every team with a game, the first week up to the last scheduled
+// week in which it does not play.
+func ByeWeeks(games []ScheduledGame) map[string]int {
+	played := map[string]map[int]bool{}
+	maxWeek := 0
+	for _, g := range games {
+		if g.Week > maxWeek {
+			maxWeek = g.Week
+		}
+		for _, team := range []string{g.Away, g.Home} {
+			if played[team] == nil {
+				played[team] = map[int]bool{}
+			}
+			played[team][g.Week] = true
+		}
+	}
+
+	byes := make(map[string]int, len(played))
+	for team, weeks := range played {
+		for wk := 1; wk <= maxWeek; wk++ {
+			if !weeks[wk] {
+				byes[team] = wk
+				break
+			}
+		}
+	}
+	return byes
+}
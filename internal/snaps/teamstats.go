@@ -0,0 +1,103 @@
+package snaps
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/tyler180/fantasy-football-backends/internal/projection"
+)
+
+// FetchNflverseTeamOffense sums nflverse weekly player stats into each team's
+// offensive plays per game: pass attempts + sacks taken + carries, with the pass share.
+// Team and Opponent are nflverse codes; callers translate both.
+func FetchNflverseTeamOffense(ctx context.Context, season int, url string) ([]projection.TeamGame, error) {
+	if url == "" {
+		url = fmt.Sprintf("https://github.com/nflverse/nflverse-data/releases/download/stats_player/stats_player_week_%d.csv", season)
+	}
+
+	req, _ := http.NewRequestWithContext(ctx, "GET", url, nil)
+	req.Header.Set("User-Agent", "pfr-snaps/1.1 (+https://example.com)")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("get player stats csv: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		b, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("player stats download %s: %s (%s)", url, resp.Status, string(b))
+	}
+	return parseTeamOffense(resp.Body, season)
+}
+
+func parseTeamOffense(rd io.Reader, season int) ([]projection.TeamGame, error) {
+	r := csv.NewReader(rd)
+	r.FieldsPerRecord = -1
+	hdr, err := r.Read()
+	if err != nil {
+		return nil, fmt.Errorf("read header: %w", err)
+	}
+	idx := func(names ...string) int {
+		for _, name := range names {
+			for i, h := range hdr {
+				if strings.EqualFold(strings.TrimSpace(h), name) {
+					return i
+				}
+			}
+		}
+		return -1
+	}
+	iSeason, iWeek, iType := idx("season"), idx("week"), idx("season_type")
+	iTeam, iOpp := idx("team", "recent_team"), idx("opponent_team")
+	iAtt, iSacks, iCarries := idx("attempts"), idx("sacks_suffered", "sacks"), idx("carries")
+	if iSeason < 0 || iWeek < 0 || iTeam < 0 || iAtt < 0 || iCarries < 0 {
+		return nil, fmt.Errorf("required columns missing (need season, week, team, attempts, carries)")
+	}
+	num := func(rec []string, i int) float64 {
+		v, _ := strconv.ParseFloat(strings.TrimSpace(safeGet(rec, i)), 64)
+		return v
+	}
+
+	type key struct {
+		team string
+		week int
+	}
+	games := map[key]*projection.TeamGame{}
+	var order []key
+	for {
+		rec, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read row: %w", err)
+		}
+		if s, _ := strconv.Atoi(safeGet(rec, iSeason)); s != season {
+			continue
+		}
+		if t := strings.TrimSpace(safeGet(rec, iType)); t != "" && !strings.EqualFold(t, "REG") {
+			continue
+		}
+		wk, _ := strconv.Atoi(safeGet(rec, iWeek))
+		k := key{strings.ToUpper(strings.TrimSpace(safeGet(rec, iTeam))), wk}
+		g := games[k]
+		if g == nil {
+			g = &projection.TeamGame{Team: k.team, Week: wk, Opponent: strings.ToUpper(strings.TrimSpace(safeGet(rec, iOpp)))}
+			games[k] = g
+			order = append(order, k)
+		}
+		pass := num(rec, iAtt) + num(rec, iSacks)
+		g.PassPlays += pass
+		g.Plays += pass + num(rec, iCarries)
+	}
+
+	out := make([]projection.TeamGame, 0, len(order))
+	for _, k := range order {
+		out = append(out, *games[k])
+	}
+	return out, nil
+}
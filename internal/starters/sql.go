@@ -0,0 +1,81 @@
+package starters
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SQLColumns names the per-game and aggregate columns the SQL fragments refer to.
+type SQLColumns struct {
+	Week     string // per-game week column, e.g. "week"
+	Pct      string // per-game DEF% column, e.g. "defense_pct"
+	MaxWeek  string // latest week in the season (scalar column or subquery)
+	Team     string // partition for ranking
+	PlayerID string // ranking tie-break
+
+	// Aggregate columns, used by Where and Rank.
+	Age       string // e.g. "age_yrs"
+	Games     string // games played
+	Starts    string // from StartsExpr
+	RecentPct string // from RecentPctExpr
+}
+
+// PositionList renders Positions as a SQL IN list: 'CB','DB',...
+func (s Spec) PositionList() string {
+	ps := s.Positions()
+	q := make([]string, len(ps))
+	for i, p := range ps {
+		q[i] = "'" + strings.ReplaceAll(p, "'", "''") + "'"
+	}
+	return strings.Join(q, ",")
+}
+
+// RecentPctExpr averages the per-game DEF% over the spec's window of weeks.
+func (s Spec) RecentPctExpr(c SQLColumns) string {
+	if s.LastNWeeks <= 0 {
+		return fmt.Sprintf("AVG(%s)", c.Pct)
+	}
+	return fmt.Sprintf("AVG(CASE WHEN %s > %s - %d THEN %s END)", c.Week, c.MaxWeek, s.LastNWeeks, c.Pct)
+}
+
+// StartsExpr counts games at or above StartPct, the SQL stand-in for GS.
+func (s Spec) StartsExpr(c SQLColumns) string {
+	return fmt.Sprintf("COUNT_IF(%s >= %s)", c.Pct, fmtNum(s.startPct()))
+}
+
+// Where is the filter over aggregate columns; "TRUE" when the spec filters nothing.
+func (s Spec) Where(c SQLColumns) string {
+	var conds []string
+	if s.MaxAge > 0 {
+		conds = append(conds, fmt.Sprintf("%s <= %d", c.Age, s.MaxAge))
+	}
+	if s.MinGSRatio > 0 {
+		conds = append(conds, fmt.Sprintf("CAST(%s AS DOUBLE) >= %s * %s", c.Starts, fmtNum(s.MinGSRatio), c.Games))
+	}
+	if s.MinSnapPct > 0 {
+		conds = append(conds, fmt.Sprintf("%s >= %s", c.RecentPct, fmtNum(s.MinSnapPct)))
+	}
+	if len(conds) == 0 {
+		return "TRUE"
+	}
+	return strings.Join(conds, "\n  AND ")
+}
+
+// Rank is a ROW_NUMBER() expression ordering starters within a team like Select does.
+func (s Spec) Rank(c SQLColumns) string {
+	order := c.RecentPct + " DESC"
+	switch s.RankBy {
+	case RankGSRatio:
+		order = fmt.Sprintf("CAST(%s AS DOUBLE) / NULLIF(%s, 0) DESC", c.Starts, c.Games)
+	case RankAge:
+		order = c.Age + " ASC"
+	}
+	if c.PlayerID != "" {
+		order += ", " + c.PlayerID
+	}
+	return fmt.Sprintf("ROW_NUMBER() OVER (PARTITION BY %s ORDER BY %s)", c.Team, order)
+}
+
+func fmtNum(v float64) string {
+	return strings.TrimRight(strings.TrimRight(fmt.Sprintf("%.4f", v), "0"), ".")
+}
@@ -0,0 +1,316 @@
+// Package starters defines what counts as a "young defensive starter". The same Spec
+// ranks starters in Go (store.MaterializeDefenseFromRoster) and generates the WHERE
+// and ranking clauses of the Athena CTAS, so both paths agree.
+package starters
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/tyler180/fantasy-football-backends/internal/trends"
+)
+
+// Groups maps a position group to the position codes PFR and nflverse use for it.
+var Groups = map[string][]string{
+	"DL": {"DE", "DT", "NT", "DL", "EDGE"},
+	"LB": {"LB", "ILB", "OLB", "MLB"},
+	"DB": {"CB", "DB", "S", "FS", "SS", "SAF", "NB"},
+}
+
+// GroupOf returns the group of pos ("DL", "LB", "DB"), or "" if pos is not defensive.
+// Comma lists such as "DE,LB" resolve to the group of the first defensive code.
+func GroupOf(pos string) string {
+	for _, p := range strings.Split(strings.ToUpper(pos), ",") {
+		p = strings.TrimSpace(p)
+		for g, codes := range Groups {
+			for _, c := range codes {
+				if c == p {
+					return g
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// Rank orders.
+const (
+	RankSnapPct = "snap_pct" // recent DEF%, highest first (default)
+	RankGSRatio = "gs_ratio" // GS/G, highest first
+	RankAge     = "age"      // youngest first
+)
+
+// Spec is the declarative starter definition. Zero-valued fields do not filter.
+type Spec struct {
+	MaxAge     int      `json:"max_age,omitempty"`         // age ceiling (inclusive); unknown ages fail when set
+	MinGSRatio float64  `json:"min_gs_ratio,omitempty"`    // GS / G, 0..1
+	MinSnapPct float64  `json:"min_snap_pct,omitempty"`    // average DEF% over the window
+	LastNWeeks int      `json:"last_n_weeks,omitempty"`    // window for MinSnapPct; 0 = whole season
+	Groups     []string `json:"position_groups,omitempty"` // DL, LB, DB or raw codes; empty = all defensive
+	RankBy     string   `json:"rank_by,omitempty"`         // snap_pct | gs_ratio | age
+	// StartPct is the DEF% at which a game counts as a start when GS is not
+	// available (the nflverse tables have no starts column). Default 50.
+	StartPct float64 `json:"start_pct,omitempty"`
+}
+
+// Default is the young-starter definition: 24 or younger, started every game
+// played, and averaging 50%+ of defensive snaps.
+func Default() Spec {
+	return Spec{MaxAge: 24, MinGSRatio: 1, MinSnapPct: 50, Groups: []string{"DL", "LB", "DB"}, RankBy: RankSnapPct}
+}
+
+// Parse reads a JSON spec. Fields absent from the document keep their Default values.
+func Parse(b []byte) (Spec, error) {
+	s := Default()
+	if err := json.Unmarshal(b, &s); err != nil {
+		return Spec{}, fmt.Errorf("parse starter spec: %w", err)
+	}
+	return s, s.Validate()
+}
+
+// FromEnv builds a spec from STARTER_SPEC (inline JSON) or STARTER_SPEC_FILE, else
+// Default, then applies the single-field overrides MAX_AGE, MIN_GS_RATIO,
+// STARTER_PCT, STARTER_LAST_N_WEEKS and POSITION_GROUPS.
+func FromEnv() (Spec, error) {
+	s := Default()
+	var err error
+	if js := strings.TrimSpace(os.Getenv("STARTER_SPEC")); js != "" {
+		if s, err = Parse([]byte(js)); err != nil {
+			return Spec{}, err
+		}
+	} else if f := strings.TrimSpace(os.Getenv("STARTER_SPEC_FILE")); f != "" {
+		b, err := os.ReadFile(f)
+		if err != nil {
+			return Spec{}, fmt.Errorf("read starter spec: %w", err)
+		}
+		if s, err = Parse(b); err != nil {
+			return Spec{}, err
+		}
+	}
+	if v, ok := envNum("MAX_AGE"); ok {
+		s.MaxAge = int(v)
+	}
+	if v, ok := envNum("MIN_GS_RATIO"); ok {
+		s.MinGSRatio = v
+	}
+	if v, ok := envNum("STARTER_PCT"); ok {
+		s.MinSnapPct = v
+	}
+	if v, ok := envNum("STARTER_LAST_N_WEEKS"); ok {
+		s.LastNWeeks = int(v)
+	}
+	if g := strings.TrimSpace(os.Getenv("POSITION_GROUPS")); g != "" {
+		s.Groups = strings.Split(g, ",")
+	}
+	return s, s.Validate()
+}
+
+func envNum(k string) (float64, bool) {
+	v := strings.TrimSpace(os.Getenv(k))
+	if v == "" {
+		return 0, false
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	return f, err == nil
+}
+
+// Validate rejects out-of-range values and unknown rank orders.
+func (s Spec) Validate() error {
+	switch {
+	case s.MaxAge < 0:
+		return fmt.Errorf("starter spec: max_age %d < 0", s.MaxAge)
+	case s.MinGSRatio < 0 || s.MinGSRatio > 1:
+		return fmt.Errorf("starter spec: min_gs_ratio %v outside 0..1", s.MinGSRatio)
+	case s.MinSnapPct < 0 || s.MinSnapPct > 100:
+		return fmt.Errorf("starter spec: min_snap_pct %v outside 0..100", s.MinSnapPct)
+	case s.LastNWeeks < 0:
+		return fmt.Errorf("starter spec: last_n_weeks %d < 0", s.LastNWeeks)
+	}
+	switch s.RankBy {
+	case "", RankSnapPct, RankGSRatio, RankAge:
+	default:
+		return fmt.Errorf("starter spec: unknown rank_by %q", s.RankBy)
+	}
+	return nil
+}
+
+// Positions expands Groups into sorted position codes. Names that are not groups are
+// kept as raw codes; an empty Groups means every defensive code.
+func (s Spec) Positions() []string {
+	groups := s.Groups
+	if len(groups) == 0 {
+		groups = []string{"DL", "LB", "DB"}
+	}
+	set := map[string]struct{}{}
+	for _, g := range groups {
+		g = strings.ToUpper(strings.TrimSpace(g))
+		if codes, ok := Groups[g]; ok {
+			for _, c := range codes {
+				set[c] = struct{}{}
+			}
+		} else if g != "" {
+			set[g] = struct{}{}
+		}
+	}
+	out := make([]string, 0, len(set))
+	for p := range set {
+		out = append(out, p)
+	}
+	sort.Strings(out)
+	return out
+}
+
+func (s Spec) startPct() float64 {
+	if s.StartPct <= 0 {
+		return 50
+	}
+	return s.StartPct
+}
+
+// Candidate is one player-team season as seen by the Go path.
+type Candidate struct {
+	PlayerID  string
+	Team      string
+	Pos       string // may be a comma list
+	Age       int    // 0 = unknown
+	G, GS     int
+	SeasonPct float64        // season DEF%; used when Weekly is empty
+	Weekly    []trends.Point // per-game DEF% in week order, when available
+	// MaxWeek is the season's latest week with snaps, where the LastNWeeks window
+	// ends (the SQL's max_week); 0 ends it at the player's own last game.
+	MaxWeek int
+	Rank    int // 1-based within Team, set by Select
+}
+
+// GSRatio is GS/G (0 when no games).
+func (c Candidate) GSRatio() float64 {
+	if c.G <= 0 {
+		return 0
+	}
+	return float64(c.GS) / float64(c.G)
+}
+
+// RecentPct is the average DEF% over the spec's window, falling back to SeasonPct
+// when no weekly series is available. A player with no game in the window (injured
+// or benched since) averages 0, where the SQL's average is NULL; both fail a
+// MinSnapPct.
+func (s Spec) RecentPct(c Candidate) float64 {
+	if len(c.Weekly) == 0 {
+		return c.SeasonPct
+	}
+	pts := c.Weekly
+	if s.LastNWeeks > 0 {
+		// Window by week number ending at the season's latest week, not by game
+		// count or the player's own last game, to match the SQL (byes count).
+		end := c.MaxWeek
+		if end == 0 {
+			end = pts[len(pts)-1].Week
+		}
+		from := end - s.LastNWeeks
+		i := sort.Search(len(pts), func(i int) bool { return pts[i].Week > from })
+		pts = pts[i:]
+	}
+	if len(pts) == 0 {
+		return 0
+	}
+	var sum float64
+	for _, p := range pts {
+		sum += p.Pct
+	}
+	return sum / float64(len(pts))
+}
+
+// Eligible checks everything except the snap-share window, which may need a weekly
+// series the caller only loads for eligible players.
+func (s Spec) Eligible(c Candidate) bool {
+	_, reason := s.check(c, false)
+	return reason == ""
+}
+
+// Match reports whether c is a starter under s and, if not, why.
+func (s Spec) Match(c Candidate) (bool, string) {
+	return s.check(c, true)
+}
+
+func (s Spec) check(c Candidate, withSnaps bool) (bool, string) {
+	if !s.positionAllowed(c.Pos) {
+		return false, "position"
+	}
+	if s.MaxAge > 0 && (c.Age <= 0 || c.Age > s.MaxAge) {
+		return false, "age"
+	}
+	if s.MinGSRatio > 0 && c.GSRatio() < s.MinGSRatio {
+		return false, "gs_ratio"
+	}
+	if withSnaps && s.MinSnapPct > 0 && s.RecentPct(c) < s.MinSnapPct {
+		return false, "snap_pct"
+	}
+	return true, ""
+}
+
+func (s Spec) positionAllowed(pos string) bool {
+	allowed := s.Positions()
+	for _, p := range strings.Split(strings.ToUpper(pos), ",") {
+		p = strings.TrimSpace(p)
+		i := sort.SearchStrings(allowed, p)
+		if i < len(allowed) && allowed[i] == p {
+			return true
+		}
+	}
+	return false
+}
+
+// Select keeps the candidates that match and ranks them within each team.
+func (s Spec) Select(cands []Candidate) []Candidate {
+	out := make([]Candidate, 0, len(cands))
+	for _, c := range cands {
+		if ok, _ := s.Match(c); ok {
+			out = append(out, c)
+		}
+	}
+	less := s.less()
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].Team != out[j].Team {
+			return out[i].Team < out[j].Team
+		}
+		return less(out[i], out[j])
+	})
+	for i := range out {
+		if i > 0 && out[i].Team == out[i-1].Team {
+			out[i].Rank = out[i-1].Rank + 1
+		} else {
+			out[i].Rank = 1
+		}
+	}
+	return out
+}
+
+func (s Spec) less() func(a, b Candidate) bool {
+	tie := func(a, b Candidate) bool { return a.PlayerID < b.PlayerID }
+	switch s.RankBy {
+	case RankGSRatio:
+		return func(a, b Candidate) bool {
+			if a.GSRatio() != b.GSRatio() {
+				return a.GSRatio() > b.GSRatio()
+			}
+			return tie(a, b)
+		}
+	case RankAge:
+		return func(a, b Candidate) bool {
+			if a.Age != b.Age {
+				return a.Age < b.Age
+			}
+			return tie(a, b)
+		}
+	}
+	return func(a, b Candidate) bool {
+		if pa, pb := s.RecentPct(a), s.RecentPct(b); pa != pb {
+			return pa > pb
+		}
+		return tie(a, b)
+	}
+}
@@ -0,0 +1,118 @@
+package starters
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tyler180/fantasy-football-backends/internal/trends"
+)
+
+func TestSelect_FiltersAndRanks(t *testing.T) {
+	spec := Default()
+	cands := []Candidate{
+		{PlayerID: "A", Team: "SEA", Pos: "CB", Age: 23, G: 10, GS: 10, SeasonPct: 80},
+		{PlayerID: "B", Team: "SEA", Pos: "DE", Age: 22, G: 10, GS: 10, SeasonPct: 90},
+		{PlayerID: "C", Team: "SEA", Pos: "LB", Age: 27, G: 10, GS: 10, SeasonPct: 95}, // too old
+		{PlayerID: "D", Team: "SEA", Pos: "LB", Age: 23, G: 10, GS: 7, SeasonPct: 70},  // GS ratio
+		{PlayerID: "E", Team: "SEA", Pos: "WR", Age: 22, G: 10, GS: 10, SeasonPct: 99}, // position
+		{PlayerID: "F", Team: "ATL", Pos: "S", Age: 0, G: 10, GS: 10, SeasonPct: 99},   // unknown age
+		{PlayerID: "G", Team: "ATL", Pos: "OLB,DE", Age: 24, G: 9, GS: 9, SeasonPct: 55},
+		{PlayerID: "H", Team: "ATL", Pos: "NT", Age: 21, G: 9, GS: 9, SeasonPct: 45}, // snaps
+	}
+	got := spec.Select(cands)
+	want := []struct {
+		id   string
+		rank int
+	}{{"G", 1}, {"B", 1}, {"A", 2}}
+	if len(got) != len(want) {
+		t.Fatalf("selected %+v", got)
+	}
+	for i, w := range want {
+		if got[i].PlayerID != w.id || got[i].Rank != w.rank {
+			t.Fatalf("row %d = %s rank %d, want %s rank %d", i, got[i].PlayerID, got[i].Rank, w.id, w.rank)
+		}
+	}
+	for id, reason := range map[int]string{2: "age", 3: "gs_ratio", 4: "position", 5: "age", 7: "snap_pct"} {
+		if ok, r := spec.Match(cands[id]); ok || r != reason {
+			t.Fatalf("%s: ok=%v reason=%q, want %q", cands[id].PlayerID, ok, r, reason)
+		}
+	}
+}
+
+func TestRecentPct_WindowByWeek(t *testing.T) {
+	spec := Spec{LastNWeeks: 3, MinSnapPct: 60}
+	c := Candidate{Pos: "LB", SeasonPct: 40, Weekly: []trends.Point{
+		{Week: 1, Pct: 10}, {Week: 2, Pct: 20}, {Week: 3, Pct: 30}, {Week: 5, Pct: 70}, {Week: 6, Pct: 80},
+	}}
+	// Weeks 4..6 (week 4 is a bye): (70+80)/2
+	if got := spec.RecentPct(c); got != 75 {
+		t.Fatalf("recent pct = %v, want 75", got)
+	}
+	if ok, _ := spec.Match(c); !ok {
+		t.Fatal("expected a match on the recent window")
+	}
+	if got := (Spec{}).RecentPct(c); got != 42 {
+		t.Fatalf("whole-season pct = %v, want 42", got)
+	}
+}
+
+func TestRecentPct_WindowEndsAtSeasonMaxWeek(t *testing.T) {
+	// A starter hurt after week 6 while the season has reached week 9: the window is
+	// weeks 7..9, which the player missed, as in the SQL.
+	spec := Spec{LastNWeeks: 3, MinSnapPct: 60}
+	c := Candidate{Pos: "LB", MaxWeek: 9, Weekly: []trends.Point{
+		{Week: 4, Pct: 90}, {Week: 5, Pct: 95}, {Week: 6, Pct: 92},
+	}}
+	if got := spec.RecentPct(c); got != 0 {
+		t.Errorf("recent pct = %v, want 0 with no game in the window", got)
+	}
+	if ok, why := spec.Match(c); ok || why != "snap_pct" {
+		t.Errorf("match = %v %q, want dropped on snap_pct", ok, why)
+	}
+	c.MaxWeek = 7 // weeks 5..7
+	if got := spec.RecentPct(c); got != 93.5 {
+		t.Errorf("recent pct = %v, want 93.5", got)
+	}
+}
+
+func TestSQLFragments(t *testing.T) {
+	spec := Spec{MaxAge: 24, MinGSRatio: 0.75, MinSnapPct: 50, LastNWeeks: 4, Groups: []string{"LB", "nb"}}
+	cols := SQLColumns{
+		Week: "week", Pct: "defense_pct", MaxWeek: "max_week", Team: "team", PlayerID: "player_id",
+		Age: "age_yrs", Games: "games", Starts: "starts", RecentPct: "recent",
+	}
+	if got := spec.PositionList(); got != "'ILB','LB','MLB','NB','OLB'" {
+		t.Fatalf("positions = %s", got)
+	}
+	if got := spec.RecentPctExpr(cols); got != "AVG(CASE WHEN week > max_week - 4 THEN defense_pct END)" {
+		t.Fatalf("recent expr = %s", got)
+	}
+	if got := spec.StartsExpr(cols); got != "COUNT_IF(defense_pct >= 50)" {
+		t.Fatalf("starts expr = %s", got)
+	}
+	where := spec.Where(cols)
+	for _, frag := range []string{"age_yrs <= 24", "CAST(starts AS DOUBLE) >= 0.75 * games", "recent >= 50"} {
+		if !strings.Contains(where, frag) {
+			t.Fatalf("where %q missing %q", where, frag)
+		}
+	}
+	if got := (Spec{}).Where(cols); got != "TRUE" {
+		t.Fatalf("empty spec where = %s", got)
+	}
+	if got := spec.Rank(cols); got != "ROW_NUMBER() OVER (PARTITION BY team ORDER BY recent DESC, player_id)" {
+		t.Fatalf("rank = %s", got)
+	}
+}
+
+func TestParse_KeepsDefaults(t *testing.T) {
+	s, err := Parse([]byte(`{"max_age": 26, "position_groups": ["DB"]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.MaxAge != 26 || s.MinSnapPct != 50 || s.MinGSRatio != 1 || len(s.Groups) != 1 {
+		t.Fatalf("spec = %+v", s)
+	}
+	if _, err := Parse([]byte(`{"rank_by": "height"}`)); err == nil {
+		t.Fatal("expected an error for an unknown rank_by")
+	}
+}
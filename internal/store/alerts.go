@@ -0,0 +1,146 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"github.com/tyler180/fantasy-football-backends/internal/alerts"
+)
+
+// Role alerts table:
+//
+//	PK Season (S)   = "2024"
+//	SK AlertKey (S) = "07#AdauJa00#SEA#starter_jump"  (week, player, team, kind)
+//
+// GSI "PlayerAlerts": PK PlayerID (S), SK AlertKey (S)
+func alertKey(week int, playerID, team string, kind alerts.Kind) string {
+	return fmt.Sprintf("%02d#%s#%s#%s", week, playerID, team, kind)
+}
+
+// ReplacePlayerAlerts makes the player's alerts for team in season exactly flags as
+// of week: they are written, and every other alert stored for that player, team and
+// season (earlier weeks, kinds that no longer apply, a latest week a recompute no
+// longer reaches) is deleted, so re-running a refresh leaves no stale alerts. The
+// player's alerts for other teams are kept.
+func ReplacePlayerAlerts(ctx context.Context, ddb DynamoDBQueryAPI, table, season, team, playerID string, week int, flags []alerts.Flag) (WriteReport, error) {
+	now := time.Now().Unix()
+	keep := make(map[string]bool, len(flags))
+	reqs := make([]types.WriteRequest, 0, len(flags))
+	for _, f := range flags {
+		key := alertKey(week, playerID, team, f.Kind)
+		keep[key] = true
+		wks := make([]types.AttributeValue, len(f.Weeks))
+		for i, w := range f.Weeks {
+			wks[i] = &types.AttributeValueMemberN{Value: strconv.Itoa(w)}
+		}
+		reqs = append(reqs, types.WriteRequest{PutRequest: &types.PutRequest{Item: map[string]types.AttributeValue{
+			"Season":     &types.AttributeValueMemberS{Value: season},
+			"AlertKey":   &types.AttributeValueMemberS{Value: key},
+			"Week":       &types.AttributeValueMemberN{Value: strconv.Itoa(week)},
+			"Team":       &types.AttributeValueMemberS{Value: team},
+			"PlayerID":   &types.AttributeValueMemberS{Value: playerID},
+			"Kind":       &types.AttributeValueMemberS{Value: string(f.Kind)},
+			"Confidence": &types.AttributeValueMemberN{Value: strconv.FormatFloat(f.Confidence, 'f', 2, 64)},
+			"Weeks":      &types.AttributeValueMemberL{Value: wks},
+			"Detail":     &types.AttributeValueMemberS{Value: f.Detail},
+			"UpdatedAt":  &types.AttributeValueMemberN{Value: strconv.FormatInt(now, 10)},
+		}}})
+	}
+	var lastKey map[string]types.AttributeValue
+	for {
+		resp, err := ddb.Query(ctx, &dynamodb.QueryInput{
+			TableName:              aws.String(table),
+			IndexName:              aws.String("PlayerAlerts"),
+			KeyConditionExpression: aws.String("PlayerID = :p"),
+			FilterExpression:       aws.String("Season = :s AND Team = :t"),
+			ProjectionExpression:   aws.String("AlertKey"),
+			ExpressionAttributeValues: map[string]types.AttributeValue{
+				":p": &types.AttributeValueMemberS{Value: playerID},
+				":s": &types.AttributeValueMemberS{Value: season},
+				":t": &types.AttributeValueMemberS{Value: team},
+			},
+			ExclusiveStartKey: lastKey,
+		})
+		if err != nil {
+			return WriteReport{Table: table}, fmt.Errorf("query alerts %s: %w", playerID, err)
+		}
+		for _, it := range resp.Items {
+			if k := getStr(it, "AlertKey"); k != "" && !keep[k] {
+				reqs = append(reqs, types.WriteRequest{DeleteRequest: &types.DeleteRequest{Key: map[string]types.AttributeValue{
+					"Season":   &types.AttributeValueMemberS{Value: season},
+					"AlertKey": &types.AttributeValueMemberS{Value: k},
+				}}})
+			}
+		}
+		if len(resp.LastEvaluatedKey) == 0 {
+			break
+		}
+		lastKey = resp.LastEvaluatedKey
+	}
+	return NewBatchWriter(ddb, "Season", "AlertKey").Write(ctx, table, reqs)
+}
+
+// QueryRoleAlerts returns a season's alerts from fromWeek onward (0 = all weeks),
+// dropping those below minConfidence.
+func QueryRoleAlerts(ctx context.Context, ddb DynamoDBReadAPI, table, season string, fromWeek int, minConfidence float64) ([]alerts.Alert, error) {
+	var out []alerts.Alert
+	var lastKey map[string]types.AttributeValue
+	for {
+		resp, err := ddb.Query(ctx, &dynamodb.QueryInput{
+			TableName:              aws.String(table),
+			KeyConditionExpression: aws.String("#s = :s AND #k >= :from"),
+			ExpressionAttributeNames: map[string]string{
+				"#s": "Season",
+				"#k": "AlertKey",
+			},
+			ExpressionAttributeValues: map[string]types.AttributeValue{
+				":s":    &types.AttributeValueMemberS{Value: season},
+				":from": &types.AttributeValueMemberS{Value: fmt.Sprintf("%02d#", fromWeek)},
+			},
+			ExclusiveStartKey: lastKey,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, it := range resp.Items {
+			a := alertFromItem(it)
+			if a.Confidence >= minConfidence {
+				out = append(out, a)
+			}
+		}
+		if len(resp.LastEvaluatedKey) == 0 {
+			break
+		}
+		lastKey = resp.LastEvaluatedKey
+	}
+	return out, nil
+}
+
+func alertFromItem(it map[string]types.AttributeValue) alerts.Alert {
+	a := alerts.Alert{
+		Season:   getStr(it, "Season"),
+		Week:     getNum(it, "Week"),
+		Team:     getStr(it, "Team"),
+		PlayerID: getStr(it, "PlayerID"),
+		Flag: alerts.Flag{
+			Kind:       alerts.Kind(getStr(it, "Kind")),
+			Confidence: getFloat(it, "Confidence"),
+			Detail:     getStr(it, "Detail"),
+		},
+	}
+	if l, ok := it["Weeks"].(*types.AttributeValueMemberL); ok {
+		for _, v := range l.Value {
+			if n, ok := v.(*types.AttributeValueMemberN); ok {
+				w, _ := strconv.Atoi(n.Value)
+				a.Weeks = append(a.Weeks, w)
+			}
+		}
+	}
+	return a
+}
@@ -0,0 +1,64 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"testing"
+
+	ddb "github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"github.com/tyler180/fantasy-football-backends/internal/alerts"
+)
+
+// alertsDDB serves the PlayerAlerts index from items and records every write request.
+type alertsDDB struct {
+	scanDDB
+}
+
+func (f *alertsDDB) Query(ctx context.Context, in *ddb.QueryInput, _ ...func(*ddb.Options)) (*ddb.QueryOutput, error) {
+	v := func(k string) string { return in.ExpressionAttributeValues[k].(*types.AttributeValueMemberS).Value }
+	var out []map[string]types.AttributeValue
+	for _, it := range f.items {
+		if getStr(it, "PlayerID") == v(":p") && getStr(it, "Season") == v(":s") && getStr(it, "Team") == v(":t") {
+			out = append(out, it)
+		}
+	}
+	return &ddb.QueryOutput{Items: out}, nil
+}
+
+func TestReplacePlayerAlerts_DeletesEveryStaleAlert(t *testing.T) {
+	item := func(season, team, player string, week int, kind alerts.Kind) map[string]types.AttributeValue {
+		return map[string]types.AttributeValue{
+			"Season":   &types.AttributeValueMemberS{Value: season},
+			"AlertKey": &types.AttributeValueMemberS{Value: alertKey(week, player, team, kind)},
+			"Team":     &types.AttributeValueMemberS{Value: team},
+			"PlayerID": &types.AttributeValueMemberS{Value: player},
+		}
+	}
+	f := &alertsDDB{scanDDB{items: []map[string]types.AttributeValue{
+		item("2024", "SEA", "P1", 7, alerts.StarterJump), // rewritten
+		item("2024", "SEA", "P1", 7, alerts.EveryDown),   // kind no longer applies
+		item("2024", "SEA", "P1", 8, alerts.StarterJump), // week a recompute no longer reaches
+		item("2024", "KC", "P1", 5, alerts.StarterJump),  // traded: the other team's alert stays
+		item("2023", "SEA", "P1", 7, alerts.EveryDown),   // other season
+		item("2024", "SEA", "P2", 7, alerts.EveryDown),   // other player
+	}}}
+	flags := []alerts.Flag{{Kind: alerts.StarterJump, Confidence: 0.8, Weeks: []int{6, 7}}}
+	if _, err := ReplacePlayerAlerts(context.Background(), f, "alerts", "2024", "SEA", "P1", 7, flags); err != nil {
+		t.Fatal(err)
+	}
+	var puts, dels []string
+	for _, r := range f.reqs {
+		if r.PutRequest != nil {
+			puts = append(puts, getStr(r.PutRequest.Item, "AlertKey"))
+		} else {
+			dels = append(dels, getStr(r.DeleteRequest.Key, "AlertKey"))
+		}
+	}
+	sort.Strings(dels)
+	if fmt.Sprint(puts) != "[07#P1#SEA#starter_jump]" || fmt.Sprint(dels) != "[07#P1#SEA#every_down 08#P1#SEA#starter_jump]" {
+		t.Errorf("puts = %v, deletes = %v", puts, dels)
+	}
+}
@@ -0,0 +1,295 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/aws/smithy-go"
+)
+
+// ErrUnprocessed is returned when items could not be written and no dead-letter
+// sink is configured to keep them.
+var ErrUnprocessed = errors.New("unprocessed items remained after retries")
+
+// WriteReport describes the outcome of a BatchWriter.Write call.
+type WriteReport struct {
+	Table      string              `json:"table"`
+	Requested  int                 `json:"requested"`  // requests passed in
+	Duplicates int                 `json:"duplicates"` // dropped because a later request had the same key
+	Written    int                 `json:"written"`
+	Failed     int                 `json:"failed"`  // attempted but never accepted
+	Skipped    int                 `json:"skipped"` // never attempted (context cancelled first)
+	Calls      int                 `json:"calls"`   // BatchWriteItem calls, including retries
+	Retries    int                 `json:"retries"`
+	FailedKeys []map[string]string `json:"failed_keys,omitempty"`
+	// DeadLettered is true when every failed item was recorded by the dead-letter sink.
+	DeadLettered bool `json:"dead_lettered,omitempty"`
+}
+
+// Add folds o into r (for callers that write in several steps).
+func (r *WriteReport) Add(o WriteReport) {
+	prevKept := r.Failed == 0 || r.DeadLettered
+	if r.Table == "" {
+		r.Table = o.Table
+	}
+	r.Requested += o.Requested
+	r.Duplicates += o.Duplicates
+	r.Written += o.Written
+	r.Failed += o.Failed
+	r.Skipped += o.Skipped
+	r.Calls += o.Calls
+	r.Retries += o.Retries
+	r.FailedKeys = append(r.FailedKeys, o.FailedKeys...)
+	r.DeadLettered = r.Failed > 0 && prevKept && (o.Failed == 0 || o.DeadLettered)
+}
+
+func (r WriteReport) String() string {
+	return fmt.Sprintf("table=%s requested=%d written=%d failed=%d skipped=%d dup=%d calls=%d retries=%d",
+		r.Table, r.Requested, r.Written, r.Failed, r.Skipped, r.Duplicates, r.Calls, r.Retries)
+}
+
+// DeadLetterSink records write requests that could not be applied.
+type DeadLetterSink interface {
+	DeadLetter(ctx context.Context, table string, reqs []types.WriteRequest, cause error) error
+}
+
+// BatchWriter writes requests in chunks of 25 with jittered exponential backoff,
+// retrying only the items DynamoDB reports as unprocessed. Sleeps honor ctx.
+// Puts and deletes are idempotent, so re-running a Write (e.g. after a timeout) is safe.
+type BatchWriter struct {
+	DDB         DynamoDBAPI
+	MaxAttempts int           // per chunk, including the first call (default 8)
+	BaseBackoff time.Duration // default 100ms
+	MaxBackoff  time.Duration // default 2s
+	// KeyAttrs names the table's key attributes. When set, requests with the same key
+	// are collapsed (last wins, as BatchWriteItem rejects duplicates) and failed keys
+	// are listed in the report.
+	KeyAttrs   []string
+	DeadLetter DeadLetterSink
+}
+
+// NewBatchWriter returns a writer with default retry settings and a dead-letter sink
+// taken from env:
+//
+//	DEAD_LETTER_TABLE (DynamoDB table, PK SourceTable / SK FailedKey)
+//	DEAD_LETTER_FILE  (JSON lines, e.g. /tmp/dead-letter.jsonl for local runs)
+func NewBatchWriter(ddb DynamoDBAPI, keyAttrs ...string) *BatchWriter {
+	w := &BatchWriter{DDB: ddb, KeyAttrs: keyAttrs}
+	if t := envStr("DEAD_LETTER_TABLE", ""); t != "" {
+		w.DeadLetter = &TableDeadLetter{DDB: ddb, Table: t, KeyAttrs: keyAttrs}
+	} else if f := envStr("DEAD_LETTER_FILE", ""); f != "" {
+		w.DeadLetter = &FileDeadLetter{Path: f}
+	}
+	return w
+}
+
+func (w *BatchWriter) settings() (attempts int, base, max time.Duration) {
+	attempts, base, max = w.MaxAttempts, w.BaseBackoff, w.MaxBackoff
+	if attempts <= 0 {
+		attempts = 8
+	}
+	if base <= 0 {
+		base = 100 * time.Millisecond
+	}
+	if max <= 0 {
+		max = 2 * time.Second
+	}
+	return attempts, base, max
+}
+
+// Write applies reqs to table. Items that stay unprocessed after all attempts, or
+// that DynamoDB rejects outright, are handed to the dead-letter sink. The error is
+// non-nil when ctx ends (remaining items are counted as Skipped), when failures have
+// nowhere to go (ErrUnprocessed), or when the sink itself fails.
+func (w *BatchWriter) Write(ctx context.Context, table string, reqs []types.WriteRequest) (WriteReport, error) {
+	rep := WriteReport{Table: table, Requested: len(reqs)}
+	reqs = w.dedupe(reqs, &rep)
+
+	var failed []types.WriteRequest
+	var lastErr error
+	const chunk = 25
+	for start := 0; start < len(reqs); start += chunk {
+		if err := ctx.Err(); err != nil {
+			rep.Skipped += len(reqs) - start
+			return w.finish(ctx, &rep, failed, lastErr, err)
+		}
+		end := min(start+chunk, len(reqs))
+		left, err := w.writeChunk(ctx, table, reqs[start:end], &rep)
+		rep.Written += (end - start) - len(left)
+		if err != nil {
+			lastErr = err
+		}
+		failed = append(failed, left...)
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			rep.Skipped += len(reqs) - end
+			return w.finish(ctx, &rep, failed, lastErr, ctxErr)
+		}
+	}
+	return w.finish(ctx, &rep, failed, lastErr, nil)
+}
+
+// writeChunk returns the requests that were never accepted and the last error seen.
+func (w *BatchWriter) writeChunk(ctx context.Context, table string, batch []types.WriteRequest, rep *WriteReport) ([]types.WriteRequest, error) {
+	attempts, base, max := w.settings()
+	pending := batch
+	var lastErr error
+	for attempt := 1; ; attempt++ {
+		rep.Calls++
+		out, err := w.DDB.BatchWriteItem(ctx, &dynamodb.BatchWriteItemInput{
+			RequestItems: map[string][]types.WriteRequest{table: pending},
+		})
+		switch {
+		case err != nil && !retryable(err):
+			return pending, err
+		case err != nil:
+			lastErr = err
+		default:
+			pending = out.UnprocessedItems[table]
+			if len(pending) == 0 {
+				return nil, nil
+			}
+			lastErr = nil
+		}
+		if attempt >= attempts {
+			if lastErr == nil {
+				lastErr = fmt.Errorf("%w (%d items, %d attempts)", ErrUnprocessed, len(pending), attempt)
+			}
+			return pending, lastErr
+		}
+		if err := sleepCtx(ctx, jitter(backoffFor(attempt, base, max))); err != nil {
+			return pending, err
+		}
+		rep.Retries++
+	}
+}
+
+func (w *BatchWriter) finish(ctx context.Context, rep *WriteReport, failed []types.WriteRequest, lastErr, ctxErr error) (WriteReport, error) {
+	rep.Failed = len(failed)
+	for _, r := range failed {
+		rep.FailedKeys = append(rep.FailedKeys, w.keyOf(r))
+	}
+	if len(failed) > 0 {
+		cause := lastErr
+		if cause == nil {
+			cause = ctxErr
+		}
+		if w.DeadLetter == nil {
+			err := fmt.Errorf("%w: %d items for table %s: %v", ErrUnprocessed, len(failed), rep.Table, cause)
+			return *rep, errors.Join(err, ctxErr)
+		}
+		// The caller's ctx may already be done; dead-lettering must still happen.
+		dctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 10*time.Second)
+		defer cancel()
+		if err := w.DeadLetter.DeadLetter(dctx, rep.Table, failed, cause); err != nil {
+			return *rep, errors.Join(fmt.Errorf("dead-letter %d items: %w", len(failed), err), ctxErr)
+		}
+		rep.DeadLettered = true
+	}
+	return *rep, ctxErr
+}
+
+func (w *BatchWriter) dedupe(reqs []types.WriteRequest, rep *WriteReport) []types.WriteRequest {
+	if len(w.KeyAttrs) == 0 {
+		return reqs
+	}
+	idx := make(map[string]int, len(reqs))
+	out := make([]types.WriteRequest, 0, len(reqs))
+	for _, r := range reqs {
+		k := keyString(w.keyOf(r), w.KeyAttrs)
+		if i, ok := idx[k]; ok {
+			out[i] = r
+			rep.Duplicates++
+			continue
+		}
+		idx[k] = len(out)
+		out = append(out, r)
+	}
+	return out
+}
+
+func (w *BatchWriter) keyOf(r types.WriteRequest) map[string]string {
+	var item map[string]types.AttributeValue
+	switch {
+	case r.PutRequest != nil:
+		item = r.PutRequest.Item
+	case r.DeleteRequest != nil:
+		item = r.DeleteRequest.Key
+	}
+	attrs := w.KeyAttrs
+	if len(attrs) == 0 {
+		attrs = []string{"SeasonTeam", "SeasonTeamWeek", "Season", "SK", "PlayerID"}
+	}
+	key := map[string]string{}
+	for _, a := range attrs {
+		if v, ok := item[a]; ok {
+			key[a] = avString(v)
+		}
+	}
+	return key
+}
+
+func keyString(key map[string]string, attrs []string) string {
+	parts := make([]string, len(attrs))
+	for i, a := range attrs {
+		parts[i] = key[a]
+	}
+	return strings.Join(parts, "\x00")
+}
+
+func avString(v types.AttributeValue) string {
+	switch t := v.(type) {
+	case *types.AttributeValueMemberS:
+		return t.Value
+	case *types.AttributeValueMemberN:
+		return t.Value
+	}
+	return ""
+}
+
+// retryable reports whether a BatchWriteItem error is transient.
+func retryable(err error) bool {
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) {
+		switch apiErr.ErrorCode() {
+		case "ProvisionedThroughputExceededException", "ThrottlingException",
+			"RequestLimitExceeded", "InternalServerError", "ServiceUnavailable":
+			return true
+		}
+		return false
+	}
+	return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+}
+
+func backoffFor(attempt int, base, max time.Duration) time.Duration {
+	d := base << (attempt - 1)
+	if d <= 0 || d > max {
+		return max
+	}
+	return d
+}
+
+// jitter spreads retries over [d/2, d).
+func jitter(d time.Duration) time.Duration {
+	if d <= 1 {
+		return d
+	}
+	half := d / 2
+	return half + time.Duration(rand.Int63n(int64(half)))
+}
+
+func sleepCtx(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
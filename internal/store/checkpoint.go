@@ -0,0 +1,236 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// Checkpoint records which units of a job (usually team abbrs) have completed, so a
+// Lambda that times out partway through 32 teams can resume where it stopped.
+type Checkpoint struct {
+	Job       string          `json:"job"`
+	Done      map[string]bool `json:"done"`
+	UpdatedAt int64           `json:"updated_at"`
+}
+
+// IsDone reports whether unit already completed in a previous run.
+func (c *Checkpoint) IsDone(unit string) bool { return c != nil && c.Done[unit] }
+
+// MarkDone records unit as completed.
+func (c *Checkpoint) MarkDone(unit string) {
+	if c.Done == nil {
+		c.Done = map[string]bool{}
+	}
+	c.Done[unit] = true
+}
+
+// CheckpointStore persists checkpoints between invocations.
+type CheckpointStore interface {
+	Load(ctx context.Context, job string) (*Checkpoint, error) // empty checkpoint when none exists
+	Save(ctx context.Context, cp *Checkpoint) error
+	Clear(ctx context.Context, job string) error
+}
+
+// DynamoDBItemAPI is the single-item surface used by TableCheckpoints.
+type DynamoDBItemAPI interface {
+	GetItem(ctx context.Context, params *dynamodb.GetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error)
+	PutItem(ctx context.Context, params *dynamodb.PutItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error)
+	DeleteItem(ctx context.Context, params *dynamodb.DeleteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DeleteItemOutput, error)
+}
+
+// TableCheckpoints keeps checkpoints in a DynamoDB table (PK Job (S); Done (SS)).
+type TableCheckpoints struct {
+	DDB   DynamoDBItemAPI
+	Table string
+}
+
+func (t *TableCheckpoints) Load(ctx context.Context, job string) (*Checkpoint, error) {
+	out, err := t.DDB.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName:      aws.String(t.Table),
+		Key:            map[string]types.AttributeValue{"Job": &types.AttributeValueMemberS{Value: job}},
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return nil, err
+	}
+	cp := &Checkpoint{Job: job, Done: map[string]bool{}}
+	if ss, ok := out.Item["Done"].(*types.AttributeValueMemberSS); ok {
+		for _, u := range ss.Value {
+			cp.Done[u] = true
+		}
+	}
+	cp.UpdatedAt = int64(getNum(out.Item, "UpdatedAt"))
+	return cp, nil
+}
+
+func (t *TableCheckpoints) Save(ctx context.Context, cp *Checkpoint) error {
+	cp.UpdatedAt = time.Now().Unix()
+	item := map[string]types.AttributeValue{
+		"Job":       &types.AttributeValueMemberS{Value: cp.Job},
+		"UpdatedAt": &types.AttributeValueMemberN{Value: strconv.FormatInt(cp.UpdatedAt, 10)},
+		"ExpiresAt": &types.AttributeValueMemberN{Value: strconv.FormatInt(cp.UpdatedAt+7*24*3600, 10)},
+	}
+	// String sets cannot be empty
+	if units := cp.units(); len(units) > 0 {
+		item["Done"] = &types.AttributeValueMemberSS{Value: units}
+	}
+	_, err := t.DDB.PutItem(ctx, &dynamodb.PutItemInput{TableName: aws.String(t.Table), Item: item})
+	return err
+}
+
+func (t *TableCheckpoints) Clear(ctx context.Context, job string) error {
+	_, err := t.DDB.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName: aws.String(t.Table),
+		Key:       map[string]types.AttributeValue{"Job": &types.AttributeValueMemberS{Value: job}},
+	})
+	return err
+}
+
+// FileCheckpoints keeps checkpoints as JSON files in Dir (for local runs).
+type FileCheckpoints struct {
+	Dir string
+}
+
+func (f *FileCheckpoints) path(job string) string {
+	safe := make([]rune, 0, len(job))
+	for _, r := range job {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_':
+			safe = append(safe, r)
+		default:
+			safe = append(safe, '_')
+		}
+	}
+	return f.Dir + "/" + string(safe) + ".checkpoint.json"
+}
+
+func (f *FileCheckpoints) Load(_ context.Context, job string) (*Checkpoint, error) {
+	b, err := os.ReadFile(f.path(job))
+	if errors.Is(err, os.ErrNotExist) {
+		return &Checkpoint{Job: job, Done: map[string]bool{}}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var cp Checkpoint
+	if err := json.Unmarshal(b, &cp); err != nil {
+		return nil, err
+	}
+	if cp.Done == nil {
+		cp.Done = map[string]bool{}
+	}
+	return &cp, nil
+}
+
+func (f *FileCheckpoints) Save(_ context.Context, cp *Checkpoint) error {
+	cp.UpdatedAt = time.Now().Unix()
+	b, err := json.Marshal(cp)
+	if err != nil {
+		return err
+	}
+	tmp := f.path(cp.Job) + ".tmp"
+	if err := os.WriteFile(tmp, b, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, f.path(cp.Job))
+}
+
+func (f *FileCheckpoints) Clear(_ context.Context, job string) error {
+	err := os.Remove(f.path(job))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// CheckpointsFromEnv returns a table store when CHECKPOINT_TABLE is set, a file store
+// when CHECKPOINT_DIR is set, and nil (no resume) otherwise.
+func CheckpointsFromEnv(ddb DynamoDBItemAPI) CheckpointStore {
+	if t := envStr("CHECKPOINT_TABLE", ""); t != "" && ddb != nil {
+		return &TableCheckpoints{DDB: ddb, Table: t}
+	}
+	if d := envStr("CHECKPOINT_DIR", ""); d != "" {
+		return &FileCheckpoints{Dir: d}
+	}
+	return nil
+}
+
+// ErrCheckpointed is returned by jobs that stopped early to stay inside the Lambda
+// deadline after saving a checkpoint. Returning it as the invocation error lets the
+// async retry resume from the checkpoint.
+var ErrCheckpointed = errors.New("stopped before deadline; progress checkpointed")
+
+// NearDeadline reports whether ctx expires within margin.
+func NearDeadline(ctx context.Context, margin time.Duration) bool {
+	dl, ok := ctx.Deadline()
+	return ok && time.Until(dl) < margin
+}
+
+// RunUnits calls fn for each unit not already marked done in job's checkpoint, saving
+// progress after every unit. When ctx is within margin of its deadline it stops and
+// returns ErrCheckpointed; when fn fails the failing unit stays pending so the next
+// run retries it. The checkpoint is cleared once every unit has completed. A nil
+// store runs every unit with no resume.
+func RunUnits(ctx context.Context, cs CheckpointStore, job string, units []string, margin time.Duration,
+	fn func(ctx context.Context, unit string) error) (ran, resumed int, err error) {
+	cp := &Checkpoint{Job: job, Done: map[string]bool{}}
+	if cs != nil {
+		if cp, err = cs.Load(ctx, job); err != nil {
+			return 0, 0, fmt.Errorf("load checkpoint %s: %w", job, err)
+		}
+	}
+	for _, u := range units {
+		if cp.IsDone(u) {
+			resumed++
+			continue
+		}
+		if cs != nil && NearDeadline(ctx, margin) {
+			if err := cs.Save(ctx, cp); err != nil {
+				return ran, resumed, fmt.Errorf("save checkpoint %s: %w", job, err)
+			}
+			return ran, resumed, ErrCheckpointed
+		}
+		if err := fn(ctx, u); err != nil {
+			if cs != nil {
+				if serr := cs.Save(context.WithoutCancel(ctx), cp); serr != nil {
+					err = errors.Join(err, fmt.Errorf("save checkpoint %s: %w", job, serr))
+				}
+			}
+			return ran, resumed, err
+		}
+		ran++
+		cp.MarkDone(u)
+		if cs != nil {
+			if err := cs.Save(ctx, cp); err != nil {
+				return ran, resumed, fmt.Errorf("save checkpoint %s: %w", job, err)
+			}
+		}
+	}
+	if cs != nil {
+		if err := cs.Clear(ctx, job); err != nil {
+			return ran, resumed, fmt.Errorf("clear checkpoint %s: %w", job, err)
+		}
+	}
+	return ran, resumed, nil
+}
+
+func (c *Checkpoint) units() []string {
+	out := make([]string, 0, len(c.Done))
+	for u, ok := range c.Done {
+		if ok {
+			out = append(out, u)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
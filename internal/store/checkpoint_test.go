@@ -0,0 +1,61 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestRunUnits_ResumesAfterFailure(t *testing.T) {
+	cs := &FileCheckpoints{Dir: t.TempDir()}
+	teams := []string{"ATL", "BUF", "CHI", "DAL"}
+	boom := errors.New("boom")
+
+	var seen []string
+	ran, resumed, err := RunUnits(context.Background(), cs, "job#2025", teams, time.Second,
+		func(_ context.Context, team string) error {
+			if team == "CHI" {
+				return boom
+			}
+			seen = append(seen, team)
+			return nil
+		})
+	if !errors.Is(err, boom) || ran != 2 || resumed != 0 {
+		t.Fatalf("first run: ran=%d resumed=%d err=%v", ran, resumed, err)
+	}
+
+	seen = nil
+	ran, resumed, err = RunUnits(context.Background(), cs, "job#2025", teams, time.Second,
+		func(_ context.Context, team string) error {
+			seen = append(seen, team)
+			return nil
+		})
+	if err != nil || ran != 2 || resumed != 2 {
+		t.Fatalf("second run: ran=%d resumed=%d err=%v", ran, resumed, err)
+	}
+	if len(seen) != 2 || seen[0] != "CHI" || seen[1] != "DAL" {
+		t.Fatalf("second run visited %v, want [CHI DAL]", seen)
+	}
+	if _, err := os.Stat(cs.path("job#2025")); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("checkpoint not cleared after completion: %v", err)
+	}
+}
+
+func TestRunUnits_StopsNearDeadline(t *testing.T) {
+	cs := &FileCheckpoints{Dir: t.TempDir()}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Hour)
+	defer cancel()
+
+	calls := 0
+	_, _, err := RunUnits(ctx, cs, "job", []string{"ATL", "BUF"}, 2*time.Hour,
+		func(context.Context, string) error { calls++; return nil })
+	if !errors.Is(err, ErrCheckpointed) || calls != 0 {
+		t.Fatalf("calls=%d err=%v, want ErrCheckpointed before any unit", calls, err)
+	}
+	cp, err := cs.Load(context.Background(), "job")
+	if err != nil || cp.UpdatedAt == 0 {
+		t.Fatalf("checkpoint not saved: %+v %v", cp, err)
+	}
+}
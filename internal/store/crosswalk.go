@@ -0,0 +1,81 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"github.com/tyler180/fantasy-football-backends/internal/crosswalk"
+)
+
+// Crosswalk table: PK MFLID (S). PFRID is omitted for unmatched rows so the sparse
+// PFRIDIndex GSI only holds matches. Manual overrides are rows with Method
+// "override"; PutCrosswalk never writes those, so hand edits survive every run.
+//
+//	aws dynamodb put-item --table-name mfl_pfr_crosswalk --item \
+//	  '{"MFLID":{"S":"15001"},"PFRID":{"S":"SmitJo01"},"Method":{"S":"override"}}'
+
+// LoadCrosswalk reads every crosswalk row.
+func LoadCrosswalk(ctx context.Context, ddb DynamoDBScanReadAPI, table string) ([]crosswalk.Entry, error) {
+	var out []crosswalk.Entry
+	var lastKey map[string]types.AttributeValue
+	for {
+		page, err := ddb.Scan(ctx, &dynamodb.ScanInput{
+			TableName:         aws.String(table),
+			ExclusiveStartKey: lastKey,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("scan crosswalk: %w", err)
+		}
+		for _, it := range page.Items {
+			out = append(out, crosswalk.Entry{
+				MFLID:     getStr(it, "MFLID"),
+				PFRID:     getStr(it, "PFRID"),
+				Method:    getStr(it, "Method"),
+				Score:     getFloat(it, "Score"),
+				Name:      getStr(it, "Name"),
+				Position:  getStr(it, "Position"),
+				Team:      getStr(it, "Team"),
+				BirthDate: getStr(it, "BirthDate"),
+				Reason:    getStr(it, "Reason"),
+			})
+		}
+		if len(page.LastEvaluatedKey) == 0 {
+			break
+		}
+		lastKey = page.LastEvaluatedKey
+	}
+	return out, nil
+}
+
+// PutCrosswalk writes automatic matches and unmatched rows; override rows are skipped.
+func PutCrosswalk(ctx context.Context, ddb DynamoDBAPI, table string, entries []crosswalk.Entry) (WriteReport, error) {
+	now := strconv.FormatInt(time.Now().Unix(), 10)
+	reqs := make([]types.WriteRequest, 0, len(entries))
+	for _, e := range entries {
+		if e.MFLID == "" || e.Method == crosswalk.MethodOverride {
+			continue
+		}
+		it := map[string]types.AttributeValue{
+			"MFLID":     &types.AttributeValueMemberS{Value: e.MFLID},
+			"Method":    &types.AttributeValueMemberS{Value: e.Method},
+			"Score":     &types.AttributeValueMemberN{Value: strconv.FormatFloat(e.Score, 'f', 2, 64)},
+			"UpdatedAt": &types.AttributeValueMemberN{Value: now},
+		}
+		for k, v := range map[string]string{
+			"PFRID": e.PFRID, "Name": e.Name, "Position": e.Position,
+			"Team": e.Team, "BirthDate": e.BirthDate, "Reason": e.Reason,
+		} {
+			if v != "" {
+				it[k] = &types.AttributeValueMemberS{Value: v}
+			}
+		}
+		reqs = append(reqs, types.WriteRequest{PutRequest: &types.PutRequest{Item: it}})
+	}
+	return NewBatchWriter(ddb, "MFLID").Write(ctx, table, reqs)
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 
 	"github.com/tyler180/fantasy-football-backends/internal/pfr"
+	"github.com/tyler180/fantasy-football-backends/internal/trends"
 )
 
 type DynamoDBAPI interface {
@@ -18,124 +19,84 @@ type DynamoDBAPI interface {
 	UpdateItem(ctx context.Context, params *dynamodb.UpdateItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.UpdateItemOutput, error)
 }
 
-func PutRows(ctx context.Context, ddb DynamoDBAPI, tableName, season string, rows []pfr.PlayerRow) error {
+// PutRows upserts defensive player rows: PK=SeasonTeam (S), SK=PlayerID (S)
+func PutRows(ctx context.Context, ddb DynamoDBAPI, tableName, season string, rows []pfr.PlayerRow) (WriteReport, error) {
 	if len(rows) == 0 {
-		return nil
+		return WriteReport{Table: tableName}, nil
 	}
-	const maxBatch = 25
 	now := strconv.FormatInt(time.Now().Unix(), 10)
 
-	for i := 0; i < len(rows); i += maxBatch {
-		end := i + maxBatch
-		if end > len(rows) {
-			end = len(rows)
-		}
-
-		reqs := make([]types.WriteRequest, 0, end-i)
-		for _, r := range rows[i:end] {
-			if r.PlayerID == "" || r.Team == "" {
-				continue
-			}
-			item := map[string]types.AttributeValue{
-				"SeasonTeam":   &types.AttributeValueMemberS{Value: season + "#" + r.Team}, // PK
-				"PlayerID":     &types.AttributeValueMemberS{Value: r.PlayerID},            // SK
-				"Season":       &types.AttributeValueMemberS{Value: season},
-				"Team":         &types.AttributeValueMemberS{Value: r.Team},
-				"Player":       &types.AttributeValueMemberS{Value: r.Player}, // display name
-				"Teams":        &types.AttributeValueMemberS{Value: r.Teams},
-				"Age":          &types.AttributeValueMemberN{Value: strconv.Itoa(r.Age)},
-				"G":            &types.AttributeValueMemberN{Value: strconv.Itoa(r.G)},
-				"GS":           &types.AttributeValueMemberN{Value: strconv.Itoa(r.GS)},
-				"Pos":          &types.AttributeValueMemberS{Value: r.Pos},
-				"DefSnapNum":   &types.AttributeValueMemberN{Value: strconv.Itoa(r.DefSnapNum)},
-				"DefSnapPct":   &types.AttributeValueMemberN{Value: strconv.FormatFloat(r.DefSnapPct, 'f', 1, 64)},
-				"TeamPlayerID": &types.AttributeValueMemberS{Value: r.Team + "#" + r.PlayerID},
-				"UpdatedAt":    &types.AttributeValueMemberN{Value: now},
-			}
-			reqs = append(reqs, types.WriteRequest{
-				PutRequest: &types.PutRequest{Item: item},
-			})
-		}
-		if len(reqs) == 0 {
+	reqs := make([]types.WriteRequest, 0, len(rows))
+	for _, r := range rows {
+		if r.PlayerID == "" || r.Team == "" {
 			continue
 		}
-		if err := batchWriteWithRetry(ctx, ddb, tableName, reqs); err != nil {
-			return fmt.Errorf("batch write defensive rows: %w", err)
+		item := map[string]types.AttributeValue{
+			"SeasonTeam":   &types.AttributeValueMemberS{Value: season + "#" + r.Team}, // PK
+			"PlayerID":     &types.AttributeValueMemberS{Value: r.PlayerID},            // SK
+			"Season":       &types.AttributeValueMemberS{Value: season},
+			"Team":         &types.AttributeValueMemberS{Value: r.Team},
+			"Player":       &types.AttributeValueMemberS{Value: r.Player}, // display name
+			"Teams":        &types.AttributeValueMemberS{Value: r.Teams},
+			"Age":          &types.AttributeValueMemberN{Value: strconv.Itoa(r.Age)},
+			"G":            &types.AttributeValueMemberN{Value: strconv.Itoa(r.G)},
+			"GS":           &types.AttributeValueMemberN{Value: strconv.Itoa(r.GS)},
+			"Pos":          &types.AttributeValueMemberS{Value: r.Pos},
+			"DefSnapNum":   &types.AttributeValueMemberN{Value: strconv.Itoa(r.DefSnapNum)},
+			"DefSnapPct":   &types.AttributeValueMemberN{Value: strconv.FormatFloat(r.DefSnapPct, 'f', 1, 64)},
+			"TeamPlayerID": &types.AttributeValueMemberS{Value: r.Team + "#" + r.PlayerID},
+			"UpdatedAt":    &types.AttributeValueMemberN{Value: now},
 		}
+		if r.StarterRank > 0 {
+			item["StarterRank"] = &types.AttributeValueMemberN{Value: strconv.Itoa(r.StarterRank)}
+		}
+		reqs = append(reqs, types.WriteRequest{
+			PutRequest: &types.PutRequest{Item: item},
+		})
+	}
+	rep, err := NewBatchWriter(ddb, "SeasonTeam", "PlayerID").Write(ctx, tableName, reqs)
+	if err != nil {
+		return rep, fmt.Errorf("batch write defensive rows: %w", err)
 	}
-	return nil
+	return rep, nil
 }
 
 // Raw roster rows: PK=Season (S), SK=PlayerID#Team (S)
-func PutRosterRows(ctx context.Context, ddb DynamoDBAPI, table string, rows []pfr.RosterRow) error {
+func PutRosterRows(ctx context.Context, ddb DynamoDBAPI, table string, rows []pfr.RosterRow) (WriteReport, error) {
 	if len(rows) == 0 {
-		return nil
+		return WriteReport{Table: table}, nil
 	}
-	const maxBatch = 25
 	now := strconv.FormatInt(time.Now().Unix(), 10)
 
-	for i := 0; i < len(rows); i += maxBatch {
-		end := i + maxBatch
-		if end > len(rows) {
-			end = len(rows)
-		}
-
-		reqs := make([]types.WriteRequest, 0, end-i)
-		for _, r := range rows[i:end] {
-			if r.PlayerID == "" || r.Team == "" || r.Season == "" {
-				continue
-			}
-			sk := r.PlayerID + "#" + r.Team
-			item := map[string]types.AttributeValue{
-				"Season":     &types.AttributeValueMemberS{Value: r.Season},
-				"SK":         &types.AttributeValueMemberS{Value: sk},
-				"Player":     &types.AttributeValueMemberS{Value: r.Player},
-				"PlayerID":   &types.AttributeValueMemberS{Value: r.PlayerID},
-				"Team":       &types.AttributeValueMemberS{Value: r.Team},
-				"Age":        &types.AttributeValueMemberN{Value: strconv.Itoa(r.Age)},
-				"Pos":        &types.AttributeValueMemberS{Value: r.Pos},
-				"G":          &types.AttributeValueMemberN{Value: strconv.Itoa(r.G)},
-				"GS":         &types.AttributeValueMemberN{Value: strconv.Itoa(r.GS)},
-				"DefSnapNum": &types.AttributeValueMemberN{Value: strconv.Itoa(r.DefSnapNum)},
-				"DefSnapPct": &types.AttributeValueMemberN{Value: strconv.FormatFloat(r.DefSnapPct, 'f', 1, 64)},
-				"UpdatedAt":  &types.AttributeValueMemberN{Value: now},
-			}
-			reqs = append(reqs, types.WriteRequest{
-				PutRequest: &types.PutRequest{Item: item},
-			})
-		}
-		if len(reqs) == 0 {
+	reqs := make([]types.WriteRequest, 0, len(rows))
+	for _, r := range rows {
+		if r.PlayerID == "" || r.Team == "" || r.Season == "" {
 			continue
 		}
-		if err := batchWriteWithRetry(ctx, ddb, table, reqs); err != nil {
-			return fmt.Errorf("batch write roster rows: %w", err)
+		sk := r.PlayerID + "#" + r.Team
+		item := map[string]types.AttributeValue{
+			"Season":     &types.AttributeValueMemberS{Value: r.Season},
+			"SK":         &types.AttributeValueMemberS{Value: sk},
+			"Player":     &types.AttributeValueMemberS{Value: r.Player},
+			"PlayerID":   &types.AttributeValueMemberS{Value: r.PlayerID},
+			"Team":       &types.AttributeValueMemberS{Value: r.Team},
+			"Age":        &types.AttributeValueMemberN{Value: strconv.Itoa(r.Age)},
+			"Pos":        &types.AttributeValueMemberS{Value: r.Pos},
+			"G":          &types.AttributeValueMemberN{Value: strconv.Itoa(r.G)},
+			"GS":         &types.AttributeValueMemberN{Value: strconv.Itoa(r.GS)},
+			"DefSnapNum": &types.AttributeValueMemberN{Value: strconv.Itoa(r.DefSnapNum)},
+			"DefSnapPct": &types.AttributeValueMemberN{Value: strconv.FormatFloat(r.DefSnapPct, 'f', 1, 64)},
+			"UpdatedAt":  &types.AttributeValueMemberN{Value: now},
 		}
+		reqs = append(reqs, types.WriteRequest{
+			PutRequest: &types.PutRequest{Item: item},
+		})
 	}
-	return nil
-}
-
-func batchWriteWithRetry(ctx context.Context, ddb DynamoDBAPI, table string, reqs []types.WriteRequest) error {
-	input := &dynamodb.BatchWriteItemInput{
-		RequestItems: map[string][]types.WriteRequest{table: reqs},
-	}
-	const maxAttempts = 6
-	backoff := 120 * time.Millisecond
-
-	for attempt := 0; attempt < maxAttempts; attempt++ {
-		out, err := ddb.BatchWriteItem(ctx, input)
-		if err != nil {
-			return err
-		}
-		if len(out.UnprocessedItems) == 0 {
-			return nil
-		}
-		input.RequestItems = out.UnprocessedItems
-		time.Sleep(backoff)
-		if backoff < 2*time.Second {
-			backoff += 120 * time.Millisecond
-		}
+	rep, err := NewBatchWriter(ddb, "Season", "SK").Write(ctx, table, reqs)
+	if err != nil {
+		return rep, fmt.Errorf("batch write roster rows: %w", err)
 	}
-	return fmt.Errorf("unprocessed items remained after retries for table %s", table)
+	return rep, nil
 }
 
 // func PutSnapGameRows(ctx context.Context, ddb DynamoDBAPI, table string, rows []pfr.SnapGameRow) error {
@@ -175,37 +136,50 @@ func batchWriteWithRetry(ctx context.Context, ddb DynamoDBAPI, table string, req
 // 	return nil
 // }
 
-func UpdatePlayerTrends(
-	ctx context.Context,
-	ddb DynamoDBAPI,
-	table string,
-	season string,
-	team string,
-	playerID string,
-	last float64,
-	slope3 float64,
-	slope5 float64,
-	change3 float64,
-) error {
+// UpdatePlayerTrends writes a player's snap-share trend onto the players-table item:
+// the scalar metrics plus the per-week series DefSnapByWeek (M, "01" -> DEF%) so a
+// single item read is enough to chart the player's role over the season.
+// DefSnapWeeksSince50 is removed while the player has no 50%+ game.
+func UpdatePlayerTrends(ctx context.Context, ddb DynamoDBAPI, table, season, team, playerID string, m trends.Metrics) error {
 	key := map[string]types.AttributeValue{
 		"SeasonTeam": &types.AttributeValueMemberS{Value: season + "#" + team}, // PK
 		"PlayerID":   &types.AttributeValueMemberS{Value: playerID},            // SK
 	}
 
+	series := make(map[string]types.AttributeValue, len(m.Series))
+	for _, p := range m.Series {
+		series[fmt.Sprintf("%02d", p.Week)] = &types.AttributeValueMemberN{Value: strconv.FormatFloat(p.Pct, 'f', 1, 64)}
+	}
+
 	// format numbers (match how you write elsewhere)
 	now := strconv.FormatInt(time.Now().Unix(), 10)
 	vals := map[string]types.AttributeValue{
-		":l":   &types.AttributeValueMemberN{Value: strconv.FormatFloat(last, 'f', 1, 64)},    // last game %
-		":s3":  &types.AttributeValueMemberN{Value: strconv.FormatFloat(slope3, 'f', 3, 64)},  // slope over last 3
-		":s5":  &types.AttributeValueMemberN{Value: strconv.FormatFloat(slope5, 'f', 3, 64)},  // slope over last 5
-		":c3":  &types.AttributeValueMemberN{Value: strconv.FormatFloat(change3, 'f', 1, 64)}, // last - avg of prior 2 (in a 3-window)
-		":now": &types.AttributeValueMemberN{Value: now},
+		":l":      &types.AttributeValueMemberN{Value: strconv.FormatFloat(m.Last, 'f', 1, 64)},    // last game %
+		":s3":     &types.AttributeValueMemberN{Value: strconv.FormatFloat(m.Slope3, 'f', 3, 64)},  // slope over last 3
+		":s5":     &types.AttributeValueMemberN{Value: strconv.FormatFloat(m.Slope5, 'f', 3, 64)},  // slope over last 5
+		":c3":     &types.AttributeValueMemberN{Value: strconv.FormatFloat(m.Change3, 'f', 1, 64)}, // last - avg of prior 2 (in a 3-window)
+		":ewma":   &types.AttributeValueMemberN{Value: strconv.FormatFloat(m.EWMA, 'f', 1, 64)},
+		":avg":    &types.AttributeValueMemberN{Value: strconv.FormatFloat(m.SeasonAvg, 'f', 1, 64)},
+		":std":    &types.AttributeValueMemberN{Value: strconv.FormatFloat(m.RollingStd, 'f', 2, 64)},
+		":streak": &types.AttributeValueMemberN{Value: strconv.Itoa(m.MaxStreak)},
+		":games":  &types.AttributeValueMemberN{Value: strconv.Itoa(len(m.Series))},
+		":series": &types.AttributeValueMemberM{Value: series},
+		":now":    &types.AttributeValueMemberN{Value: now},
+	}
+	expr := "SET DefSnapPctLast=:l, DefSnapPctSlope3=:s3, DefSnapPctSlope5=:s5, DefSnapPctChange3=:c3, " +
+		"DefSnapPctEWMA=:ewma, DefSnapPctAvg=:avg, DefSnapPctStd=:std, DefSnapMaxStreak70=:streak, " +
+		"DefSnapGames=:games, DefSnapByWeek=:series, UpdatedAt=:now"
+	if m.WeeksSinceRole >= 0 {
+		vals[":w50"] = &types.AttributeValueMemberN{Value: strconv.Itoa(m.WeeksSinceRole)}
+		expr += ", DefSnapWeeksSince50=:w50"
+	} else {
+		expr += " REMOVE DefSnapWeeksSince50"
 	}
 
 	_, err := ddb.UpdateItem(ctx, &dynamodb.UpdateItemInput{
 		TableName:        aws.String(table),
 		Key:              key,
-		UpdateExpression: aws.String("SET DefSnapPctLast=:l, DefSnapPctSlope3=:s3, DefSnapPctSlope5=:s5, DefSnapPctChange3=:c3, UpdatedAt=:now"),
+		UpdateExpression: aws.String(expr),
 		// avoid creating new items accidentally
 		ConditionExpression:       aws.String("attribute_exists(SeasonTeam) AND attribute_exists(PlayerID)"),
 		ExpressionAttributeValues: vals,
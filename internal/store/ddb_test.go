@@ -2,11 +2,15 @@ package store
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
 	ddb "github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 
 	"github.com/tyler180/fantasy-football-backends/internal/pfr"
 )
@@ -14,19 +18,27 @@ import (
 // fake client implementing DynamoDBAPI
 type fakeDDB struct {
 	calls int
-	// simulate first attempt returning unprocessed, second succeeds
+	// simulate first attempt of each batch returning unprocessed, second succeeds
 	failFirst bool
+	// every attempt returns everything unprocessed
+	neverProcess bool
+	retrying     bool
+	written      int
 }
 
 func (f *fakeDDB) BatchWriteItem(ctx context.Context, in *ddb.BatchWriteItemInput, _ ...func(*ddb.Options)) (*ddb.BatchWriteItemOutput, error) {
 	f.calls++
-	if f.failFirst {
-		f.failFirst = false
+	if f.neverProcess || (f.failFirst && !f.retrying) {
+		f.retrying = true
 		// Echo back all as unprocessed to force a retry
 		return &ddb.BatchWriteItemOutput{
 			UnprocessedItems: in.RequestItems,
 		}, nil
 	}
+	f.retrying = false
+	for _, reqs := range in.RequestItems {
+		f.written += len(reqs)
+	}
 	// Success (no unprocessed)
 	return &ddb.BatchWriteItemOutput{}, nil
 }
@@ -40,13 +52,14 @@ func TestPutRows_BatchingAndRetry(t *testing.T) {
 	var rows []pfr.PlayerRow
 	for i := 0; i < 30; i++ {
 		rows = append(rows, pfr.PlayerRow{
-			Player: fmt.Sprintf("P%02d", i),
-			Team:   "ATL",
-			Teams:  "ATL",
-			Age:    23,
-			G:      1,
-			GS:     1,
-			Pos:    "CB",
+			PlayerID: fmt.Sprintf("PlayXx%02d", i),
+			Player:   fmt.Sprintf("P%02d", i),
+			Team:     "ATL",
+			Teams:    "ATL",
+			Age:      23,
+			G:        1,
+			GS:       1,
+			Pos:      "CB",
 		})
 	}
 
@@ -55,13 +68,216 @@ func TestPutRows_BatchingAndRetry(t *testing.T) {
 	defer cancel()
 
 	fc := &fakeDDB{failFirst: true}
-	err := PutRows(ctx, fc, "tbl", "2025", rows)
+	rep, err := PutRows(ctx, fc, "tbl", "2025", rows)
 	if err != nil {
 		t.Fatalf("PutRows error: %v", err)
 	}
+	if rep.Written != 30 || rep.Retries != 2 {
+		t.Fatalf("unexpected report: %s", rep)
+	}
 
 	// Each batch is attempted twice (one retry), and there are 2 batches.
 	if fc.calls != 4 {
 		t.Fatalf("expected 4 BatchWriteItem calls (2 batches x 2 attempts), got %d", fc.calls)
 	}
 }
+
+func putReqs(n int, pid func(i int) string) []types.WriteRequest {
+	reqs := make([]types.WriteRequest, 0, n)
+	for i := 0; i < n; i++ {
+		reqs = append(reqs, types.WriteRequest{PutRequest: &types.PutRequest{Item: map[string]types.AttributeValue{
+			"SeasonTeam": &types.AttributeValueMemberS{Value: "2025#ATL"},
+			"PlayerID":   &types.AttributeValueMemberS{Value: pid(i)},
+			"DefSnapPct": &types.AttributeValueMemberN{Value: "71.5"},
+		}}})
+	}
+	return reqs
+}
+
+func TestBatchWriter_DeadLettersPersistentFailures(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dl.jsonl")
+	fc := &fakeDDB{neverProcess: true}
+	w := &BatchWriter{
+		DDB: fc, MaxAttempts: 2, BaseBackoff: time.Millisecond,
+		KeyAttrs:   []string{"SeasonTeam", "PlayerID"},
+		DeadLetter: &FileDeadLetter{Path: path},
+	}
+
+	rep, err := w.Write(context.Background(), "tbl", putReqs(30, func(i int) string { return fmt.Sprintf("P%02d", i) }))
+	if err != nil {
+		t.Fatalf("Write error: %v", err)
+	}
+	if rep.Failed != 30 || rep.Written != 0 || !rep.DeadLettered || len(rep.FailedKeys) != 30 {
+		t.Fatalf("unexpected report: %s dead_lettered=%v keys=%d", rep, rep.DeadLettered, len(rep.FailedKeys))
+	}
+	if rep.FailedKeys[0]["PlayerID"] != "P00" {
+		t.Fatalf("failed key = %v", rep.FailedKeys[0])
+	}
+
+	back, err := LoadDeadLetterFile(path)
+	if err != nil {
+		t.Fatalf("LoadDeadLetterFile: %v", err)
+	}
+	if len(back["tbl"]) != 30 {
+		t.Fatalf("dead-lettered %d requests, want 30", len(back["tbl"]))
+	}
+
+	// Re-driving the dead letters against a healthy table writes them all.
+	ok := &fakeDDB{}
+	rep, err = (&BatchWriter{DDB: ok}).Write(context.Background(), "tbl", back["tbl"])
+	if err != nil || rep.Written != 30 || ok.written != 30 {
+		t.Fatalf("redrive: err=%v %s", err, rep)
+	}
+}
+
+func TestBatchWriter_NoSinkReportsUnprocessed(t *testing.T) {
+	w := &BatchWriter{DDB: &fakeDDB{neverProcess: true}, MaxAttempts: 2, BaseBackoff: time.Millisecond}
+	rep, err := w.Write(context.Background(), "tbl", putReqs(3, func(i int) string { return fmt.Sprint(i) }))
+	if !errors.Is(err, ErrUnprocessed) {
+		t.Fatalf("err = %v, want ErrUnprocessed", err)
+	}
+	if rep.Failed != 3 || rep.DeadLettered {
+		t.Fatalf("unexpected report: %s", rep)
+	}
+}
+
+func TestBatchWriter_CancelledContextSkipsRemaining(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	fc := &fakeDDB{}
+	rep, err := (&BatchWriter{DDB: fc}).Write(ctx, "tbl", putReqs(30, func(i int) string { return fmt.Sprint(i) }))
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v, want context.Canceled", err)
+	}
+	if fc.calls != 0 || rep.Skipped != 30 || rep.Written != 0 {
+		t.Fatalf("calls=%d %s", fc.calls, rep)
+	}
+}
+
+func TestBatchWriter_CollapsesDuplicateKeys(t *testing.T) {
+	fc := &fakeDDB{}
+	w := &BatchWriter{DDB: fc, KeyAttrs: []string{"SeasonTeam", "PlayerID"}}
+	rep, err := w.Write(context.Background(), "tbl", putReqs(3, func(int) string { return "SameXx00" }))
+	if err != nil {
+		t.Fatalf("Write error: %v", err)
+	}
+	if rep.Duplicates != 2 || rep.Written != 1 || fc.written != 1 {
+		t.Fatalf("unexpected report: %s (fake wrote %d)", rep, fc.written)
+	}
+}
+
+func TestTableDeadLetter_DistinctFailedKeys(t *testing.T) {
+	// Wide items: the real key attributes sort after several others.
+	reqs := putReqs(5, func(i int) string { return fmt.Sprintf("P%02d", i) })
+	for _, r := range reqs {
+		for _, a := range []string{"Age", "Birth", "Cap", "DefSnaps", "Games"} {
+			r.PutRequest.Item[a] = &types.AttributeValueMemberN{Value: "1"}
+		}
+	}
+	// The same key failing as both a put and a delete is two dead letters.
+	reqs = append(reqs, types.WriteRequest{DeleteRequest: &types.DeleteRequest{Key: map[string]types.AttributeValue{
+		"SeasonTeam": &types.AttributeValueMemberS{Value: "2025#ATL"},
+		"PlayerID":   &types.AttributeValueMemberS{Value: "P00"},
+	}}})
+
+	fc := &scanDDB{}
+	dl := &TableDeadLetter{DDB: fc, Table: "dead", KeyAttrs: []string{"SeasonTeam", "PlayerID"}}
+	if err := dl.DeadLetter(context.Background(), "tbl", reqs, errors.New("throttled")); err != nil {
+		t.Fatal(err)
+	}
+	keys := map[string]bool{}
+	for _, r := range fc.reqs {
+		k := getStr(r.PutRequest.Item, "FailedKey")
+		keys[k] = true
+		if !strings.Contains(k, "#PlayerID=P0") || !strings.Contains(k, "SeasonTeam=2025#ATL") {
+			t.Errorf("FailedKey %q lacks the source key", k)
+		}
+	}
+	if len(fc.reqs) != 6 || len(keys) != 6 {
+		t.Fatalf("wrote %d dead letters with %d distinct keys, want 6", len(fc.reqs), len(keys))
+	}
+}
+
+// scanDDB serves fixed items by partition and records every write request.
+type scanDDB struct {
+	fakeDDB
+	items []map[string]types.AttributeValue
+	reqs  []types.WriteRequest
+}
+
+func (f *scanDDB) BatchWriteItem(ctx context.Context, in *ddb.BatchWriteItemInput, opt ...func(*ddb.Options)) (*ddb.BatchWriteItemOutput, error) {
+	for _, reqs := range in.RequestItems {
+		f.reqs = append(f.reqs, reqs...)
+	}
+	return f.fakeDDB.BatchWriteItem(ctx, in, opt...)
+}
+
+// Query serves one SeasonTeam partition, a page of one item at a time.
+func (f *scanDDB) Query(ctx context.Context, in *ddb.QueryInput, _ ...func(*ddb.Options)) (*ddb.QueryOutput, error) {
+	want := in.ExpressionAttributeValues[":pk"].(*types.AttributeValueMemberS).Value
+	skip := ""
+	if in.ExclusiveStartKey != nil {
+		skip = getStr(in.ExclusiveStartKey, "PlayerID")
+	}
+	for _, it := range f.items {
+		if getStr(it, "SeasonTeam") != want {
+			continue
+		}
+		if skip != "" {
+			if getStr(it, "PlayerID") == skip {
+				skip = ""
+			}
+			continue
+		}
+		return &ddb.QueryOutput{Items: []map[string]types.AttributeValue{it}, LastEvaluatedKey: it}, nil
+	}
+	return &ddb.QueryOutput{}, nil
+}
+
+func TestReplaceSeasonStarters_UpsertsAndDeletesStale(t *testing.T) {
+	key := func(season, team, id string) map[string]types.AttributeValue {
+		return map[string]types.AttributeValue{
+			"SeasonTeam": &types.AttributeValueMemberS{Value: season + "#" + team},
+			"PlayerID":   &types.AttributeValueMemberS{Value: id},
+			"Season":     &types.AttributeValueMemberS{Value: season},
+		}
+	}
+	f := &scanDDB{items: []map[string]types.AttributeValue{
+		key("2024", "SEA", "00-1"), // still a starter
+		key("2024", "SEA", "00-9"), // lost the job
+		key("2024", "KC", "00-8"),  // whole team gone from the output
+		key("2023", "SEA", "00-9"), // other season: untouched
+	}}
+	age, pct := 24, 81.25
+	rows := []StarterRow{
+		{PlayerID: "00-1", Team: "SEA", Player: "A", Age: &age, RecentDefPct: &pct, StarterRank: 1},
+		{PFRID: "DoeJo00", Team: "SEA", Player: "B", StarterRank: 2},
+		{PlayerID: "00-3", Player: "no team"},
+	}
+	res, err := ReplaceSeasonStarters(context.Background(), f, "starters", "2024", rows)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Upserts != 2 || res.Deletes != 2 || res.Invalid != 1 || res.Write.Written != 4 {
+		t.Fatalf("sync = %+v", res)
+	}
+	var puts, dels []string
+	for _, r := range f.reqs {
+		if r.PutRequest != nil {
+			it := r.PutRequest.Item
+			puts = append(puts, getStr(it, "SeasonTeam")+"/"+getStr(it, "PlayerID"))
+			if getStr(it, "PlayerID") == "00-1" && (getNum(it, "Age") != 24 || it["DefSnapPct"].(*types.AttributeValueMemberN).Value != "81.2") {
+				t.Errorf("item = %v", it)
+			}
+		} else {
+			dels = append(dels, getStr(r.DeleteRequest.Key, "SeasonTeam")+"/"+getStr(r.DeleteRequest.Key, "PlayerID"))
+		}
+	}
+	if fmt.Sprint(puts) != "[2024#SEA/00-1 2024#SEA/pfr:DoeJo00]" || fmt.Sprint(dels) != "[2024#KC/00-8 2024#SEA/00-9]" {
+		t.Errorf("puts = %v, deletes = %v", puts, dels)
+	}
+
+	if _, err := ReplaceSeasonStarters(context.Background(), f, "starters", "2024", nil); !errors.Is(err, ErrNoStarters) {
+		t.Errorf("empty rows: err = %v, want ErrNoStarters", err)
+	}
+}
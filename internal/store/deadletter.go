@@ -0,0 +1,274 @@
+package store
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// DeadLetterRecord is one failed write request as stored by a dead-letter sink.
+type DeadLetterRecord struct {
+	Table    string            `json:"table"`
+	Op       string            `json:"op"` // "put" | "delete"
+	Item     map[string]any    `json:"item"`
+	Key      map[string]string `json:"key,omitempty"`
+	Cause    string            `json:"cause"`
+	FailedAt int64             `json:"failed_at"`
+}
+
+// FileDeadLetter appends failed requests as JSON lines, with items in DynamoDB JSON
+// ({"S": "..."}, {"N": "..."}) so they can be re-driven with LoadDeadLetterFile.
+type FileDeadLetter struct {
+	Path string
+	mu   sync.Mutex
+}
+
+func (f *FileDeadLetter) DeadLetter(_ context.Context, table string, reqs []types.WriteRequest, cause error) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	fh, err := os.OpenFile(f.Path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(fh)
+	now := time.Now().Unix()
+	for _, r := range reqs {
+		rec := DeadLetterRecord{Table: table, Cause: errString(cause), FailedAt: now}
+		switch {
+		case r.PutRequest != nil:
+			rec.Op, rec.Item = "put", itemToJSON(r.PutRequest.Item)
+		case r.DeleteRequest != nil:
+			rec.Op, rec.Item = "delete", itemToJSON(r.DeleteRequest.Key)
+		default:
+			continue
+		}
+		if err := enc.Encode(rec); err != nil {
+			_ = fh.Close()
+			return err
+		}
+	}
+	return fh.Close()
+}
+
+// LoadDeadLetterFile reads a FileDeadLetter file back into write requests per table.
+func LoadDeadLetterFile(path string) (map[string][]types.WriteRequest, error) {
+	fh, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer fh.Close()
+	out := map[string][]types.WriteRequest{}
+	sc := bufio.NewScanner(fh)
+	sc.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+	for line := 1; sc.Scan(); line++ {
+		if strings.TrimSpace(sc.Text()) == "" {
+			continue
+		}
+		var rec DeadLetterRecord
+		if err := json.Unmarshal(sc.Bytes(), &rec); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+		item, err := itemFromJSON(rec.Item)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+		var wr types.WriteRequest
+		if rec.Op == "delete" {
+			wr.DeleteRequest = &types.DeleteRequest{Key: item}
+		} else {
+			wr.PutRequest = &types.PutRequest{Item: item}
+		}
+		out[rec.Table] = append(out[rec.Table], wr)
+	}
+	return out, sc.Err()
+}
+
+// TableDeadLetter stores failed requests in a DynamoDB table:
+//
+//	PK SourceTable (S), SK FailedKey (S) = "<unix>#<key attrs>#<hash>"
+//	Op, Item (M, the original item or key), Cause, FailedAt, ExpiresAt (TTL, 30 days)
+//
+// The hash covers the op and the whole item, so distinct failures never share a
+// FailedKey even when their key attributes do.
+type TableDeadLetter struct {
+	DDB   DynamoDBAPI
+	Table string
+	// KeyAttrs are the source table's key attributes (the writer's KeyAttrs), rendered
+	// into FailedKey; empty falls back to BatchWriter's default key attributes.
+	KeyAttrs []string
+}
+
+func (t *TableDeadLetter) DeadLetter(ctx context.Context, table string, reqs []types.WriteRequest, cause error) error {
+	now := time.Now()
+	ts := strconv.FormatInt(now.Unix(), 10)
+	ttl := strconv.FormatInt(now.Add(30*24*time.Hour).Unix(), 10)
+	dl := make([]types.WriteRequest, 0, len(reqs))
+	for _, r := range reqs {
+		op, item := "put", map[string]types.AttributeValue(nil)
+		switch {
+		case r.PutRequest != nil:
+			item = r.PutRequest.Item
+		case r.DeleteRequest != nil:
+			op, item = "delete", r.DeleteRequest.Key
+		default:
+			continue
+		}
+		dl = append(dl, types.WriteRequest{PutRequest: &types.PutRequest{Item: map[string]types.AttributeValue{
+			"SourceTable": &types.AttributeValueMemberS{Value: table},
+			"FailedKey":   &types.AttributeValueMemberS{Value: ts + "#" + t.failedKey(r, op, item)},
+			"Op":          &types.AttributeValueMemberS{Value: op},
+			"Item":        &types.AttributeValueMemberM{Value: item},
+			"Cause":       &types.AttributeValueMemberS{Value: errString(cause)},
+			"FailedAt":    &types.AttributeValueMemberN{Value: ts},
+			"ExpiresAt":   &types.AttributeValueMemberN{Value: ttl},
+		}}})
+	}
+	// No sink on the inner writer: a dead-letter write that fails is reported, not looped.
+	w := &BatchWriter{DDB: t.DDB, KeyAttrs: []string{"SourceTable", "FailedKey"}}
+	_, err := w.Write(ctx, t.Table, dl)
+	return err
+}
+
+// failedKey renders r's source key attributes as "a=v,b=v" followed by a hash of op
+// and the whole item.
+func (t *TableDeadLetter) failedKey(r types.WriteRequest, op string, item map[string]types.AttributeValue) string {
+	key := (&BatchWriter{KeyAttrs: t.KeyAttrs}).keyOf(r)
+	names := make([]string, 0, len(key))
+	for k := range key {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	parts := make([]string, 0, len(names))
+	for _, k := range names {
+		parts = append(parts, k+"="+key[k])
+	}
+	b, _ := json.Marshal(itemToJSON(item)) // map keys marshal sorted, so this is stable
+	h := fnv.New64a()
+	h.Write([]byte(op))
+	h.Write(b)
+	return fmt.Sprintf("%s#%016x", strings.Join(parts, ","), h.Sum64())
+}
+
+func errString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
+
+// itemToJSON converts an item to DynamoDB JSON.
+func itemToJSON(item map[string]types.AttributeValue) map[string]any {
+	out := make(map[string]any, len(item))
+	for k, v := range item {
+		out[k] = avToJSON(v)
+	}
+	return out
+}
+
+func avToJSON(v types.AttributeValue) map[string]any {
+	switch t := v.(type) {
+	case *types.AttributeValueMemberS:
+		return map[string]any{"S": t.Value}
+	case *types.AttributeValueMemberN:
+		return map[string]any{"N": t.Value}
+	case *types.AttributeValueMemberBOOL:
+		return map[string]any{"BOOL": t.Value}
+	case *types.AttributeValueMemberNULL:
+		return map[string]any{"NULL": true}
+	case *types.AttributeValueMemberB:
+		return map[string]any{"B": base64.StdEncoding.EncodeToString(t.Value)}
+	case *types.AttributeValueMemberSS:
+		return map[string]any{"SS": t.Value}
+	case *types.AttributeValueMemberNS:
+		return map[string]any{"NS": t.Value}
+	case *types.AttributeValueMemberL:
+		l := make([]any, 0, len(t.Value))
+		for _, e := range t.Value {
+			l = append(l, avToJSON(e))
+		}
+		return map[string]any{"L": l}
+	case *types.AttributeValueMemberM:
+		return map[string]any{"M": itemToJSON(t.Value)}
+	}
+	return map[string]any{"NULL": true}
+}
+
+func itemFromJSON(m map[string]any) (map[string]types.AttributeValue, error) {
+	out := make(map[string]types.AttributeValue, len(m))
+	for k, v := range m {
+		obj, ok := v.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("attribute %s: not a DynamoDB JSON value", k)
+		}
+		av, err := avFromJSON(obj)
+		if err != nil {
+			return nil, fmt.Errorf("attribute %s: %w", k, err)
+		}
+		out[k] = av
+	}
+	return out, nil
+}
+
+func avFromJSON(obj map[string]any) (types.AttributeValue, error) {
+	for typ, raw := range obj {
+		switch typ {
+		case "S":
+			s, _ := raw.(string)
+			return &types.AttributeValueMemberS{Value: s}, nil
+		case "N":
+			s, _ := raw.(string)
+			return &types.AttributeValueMemberN{Value: s}, nil
+		case "BOOL":
+			b, _ := raw.(bool)
+			return &types.AttributeValueMemberBOOL{Value: b}, nil
+		case "NULL":
+			return &types.AttributeValueMemberNULL{Value: true}, nil
+		case "B":
+			s, _ := raw.(string)
+			b, err := base64.StdEncoding.DecodeString(s)
+			return &types.AttributeValueMemberB{Value: b}, err
+		case "SS", "NS":
+			arr, _ := raw.([]any)
+			ss := make([]string, 0, len(arr))
+			for _, e := range arr {
+				s, _ := e.(string)
+				ss = append(ss, s)
+			}
+			if typ == "SS" {
+				return &types.AttributeValueMemberSS{Value: ss}, nil
+			}
+			return &types.AttributeValueMemberNS{Value: ss}, nil
+		case "L":
+			arr, _ := raw.([]any)
+			l := make([]types.AttributeValue, 0, len(arr))
+			for _, e := range arr {
+				eo, _ := e.(map[string]any)
+				av, err := avFromJSON(eo)
+				if err != nil {
+					return nil, err
+				}
+				l = append(l, av)
+			}
+			return &types.AttributeValueMemberL{Value: l}, nil
+		case "M":
+			mo, _ := raw.(map[string]any)
+			m, err := itemFromJSON(mo)
+			if err != nil {
+				return nil, err
+			}
+			return &types.AttributeValueMemberM{Value: m}, nil
+		}
+	}
+	return nil, fmt.Errorf("unsupported DynamoDB JSON value %v", obj)
+}
@@ -0,0 +1,251 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// PlayerTrends is the snap-trend snapshot kept on a players-table item
+// (see UpdatePlayerTrends and UpdatePlayerPoints).
+type PlayerTrends struct {
+	PlayerID     string
+	Team         string
+	Player       string
+	Pos          string
+	Age          int
+	Games        int
+	Last         float64
+	Slope3       float64
+	Slope5       float64
+	Change3      float64
+	EWMA         float64
+	Avg          float64
+	WeeksSince50 int // -1 when the player has no 50%+ game
+	FantasyPPG   float64
+}
+
+// LoadSeasonTrends reads every players-table item for a season through the
+// SeasonAllPlayers GSI, keyed by PlayerID. A player stored under several teams keeps
+// the item with the most games in the snap series.
+func LoadSeasonTrends(ctx context.Context, ddb DynamoDBReadAPI, playersTable, season string) (map[string]PlayerTrends, error) {
+	out := map[string]PlayerTrends{}
+	var lastKey map[string]types.AttributeValue
+	for {
+		page, err := ddb.Query(ctx, &dynamodb.QueryInput{
+			TableName:                 aws.String(playersTable),
+			IndexName:                 aws.String("SeasonAllPlayers"),
+			KeyConditionExpression:    aws.String("#s = :s"),
+			ExpressionAttributeNames:  map[string]string{"#s": "Season"},
+			ExpressionAttributeValues: map[string]types.AttributeValue{":s": &types.AttributeValueMemberS{Value: season}},
+			ExclusiveStartKey:         lastKey,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("query season players: %w", err)
+		}
+		for _, it := range page.Items {
+			t := trendsFromItem(it)
+			if t.PlayerID == "" {
+				continue
+			}
+			if prev, ok := out[t.PlayerID]; ok && prev.Games >= t.Games {
+				continue
+			}
+			out[t.PlayerID] = t
+		}
+		if len(page.LastEvaluatedKey) == 0 {
+			break
+		}
+		lastKey = page.LastEvaluatedKey
+	}
+	return out, nil
+}
+
+func trendsFromItem(it map[string]types.AttributeValue) PlayerTrends {
+	t := PlayerTrends{
+		PlayerID:     getStr(it, "PlayerID"),
+		Team:         getStr(it, "Team"),
+		Player:       getStr(it, "Player"),
+		Pos:          getStr(it, "Pos"),
+		Age:          getNum(it, "Age"),
+		Games:        getNum(it, "DefSnapGames"),
+		Last:         getFloat(it, "DefSnapPctLast"),
+		Slope3:       getFloat(it, "DefSnapPctSlope3"),
+		Slope5:       getFloat(it, "DefSnapPctSlope5"),
+		Change3:      getFloat(it, "DefSnapPctChange3"),
+		EWMA:         getFloat(it, "DefSnapPctEWMA"),
+		Avg:          getFloat(it, "DefSnapPctAvg"),
+		WeeksSince50: -1,
+		FantasyPPG:   getFloat(it, "FantasyPPG"),
+	}
+	if _, ok := it["DefSnapWeeksSince50"]; ok {
+		t.WeeksSince50 = getNum(it, "DefSnapWeeksSince50")
+	}
+	return t
+}
+
+// FreeAgent is one player in the league's free-agent pool.
+//
+// mfl_free_agents table: PK playerID (S, MFL id); GSI PositionIndex on position and
+// GSI PositionBySlope3 on position + DefSnapPctSlope3, so "available LBs by trend" is
+// a single Query. Trend attributes are only written for players matched to a
+// players-table item.
+type FreeAgent struct {
+	PlayerID string // MFL id
+	Name     string
+	Position string // MFL position
+	Group    string // DL/LB/DB, "" for offense
+	Team     string // MFL team code
+	PFRID    string // "" when unresolved
+	PFRTeam  string
+	ByeWeek  int
+	Season   string
+	Trends   *PlayerTrends
+}
+
+func (f FreeAgent) item(now string) map[string]types.AttributeValue {
+	it := map[string]types.AttributeValue{
+		"playerID":  &types.AttributeValueMemberS{Value: f.PlayerID},
+		"name":      &types.AttributeValueMemberS{Value: f.Name},
+		"position":  &types.AttributeValueMemberS{Value: f.Position},
+		"team":      &types.AttributeValueMemberS{Value: f.Team},
+		"Season":    &types.AttributeValueMemberS{Value: f.Season},
+		"UpdatedAt": &types.AttributeValueMemberN{Value: now},
+	}
+	putS := func(k, v string) {
+		if v != "" {
+			it[k] = &types.AttributeValueMemberS{Value: v}
+		}
+	}
+	putS("positionGroup", f.Group)
+	putS("PFRID", f.PFRID)
+	putS("PFRTeam", f.PFRTeam)
+	if f.ByeWeek > 0 {
+		it["byeWeek"] = &types.AttributeValueMemberN{Value: strconv.Itoa(f.ByeWeek)}
+	}
+	if t := f.Trends; t != nil {
+		num := func(k string, v float64, prec int) {
+			it[k] = &types.AttributeValueMemberN{Value: strconv.FormatFloat(v, 'f', prec, 64)}
+		}
+		num("DefSnapPctLast", t.Last, 1)
+		num("DefSnapPctSlope3", t.Slope3, 3)
+		num("DefSnapPctSlope5", t.Slope5, 3)
+		num("DefSnapPctChange3", t.Change3, 1)
+		num("DefSnapPctEWMA", t.EWMA, 1)
+		num("DefSnapPctAvg", t.Avg, 1)
+		it["DefSnapGames"] = &types.AttributeValueMemberN{Value: strconv.Itoa(t.Games)}
+		if t.WeeksSince50 >= 0 {
+			it["DefSnapWeeksSince50"] = &types.AttributeValueMemberN{Value: strconv.Itoa(t.WeeksSince50)}
+		}
+		if t.FantasyPPG != 0 {
+			num("FantasyPPG", t.FantasyPPG, 2)
+		}
+		if t.Age > 0 {
+			it["Age"] = &types.AttributeValueMemberN{Value: strconv.Itoa(t.Age)}
+		}
+	}
+	return it
+}
+
+// DynamoDBScanAPI is the surface ReplaceFreeAgents needs: batch writes plus a scan
+// of the current pool.
+type DynamoDBScanAPI interface {
+	DynamoDBAPI
+	Scan(ctx context.Context, params *dynamodb.ScanInput, optFns ...func(*dynamodb.Options)) (*dynamodb.ScanOutput, error)
+}
+
+// ReplaceFreeAgents writes the current pool and deletes players that are no longer
+// free agents (signed since the last run). positions are the MFL positions the pool
+// was fetched for: only stored players at those positions are candidates for
+// deletion, so a narrower run leaves the other positions alone. No positions, or
+// "*", means the pool covers every position.
+func ReplaceFreeAgents(ctx context.Context, ddb DynamoDBScanAPI, table string, positions []string, fas []FreeAgent) (WriteReport, error) {
+	now := strconv.FormatInt(time.Now().Unix(), 10)
+	keep := make(map[string]bool, len(fas))
+	reqs := make([]types.WriteRequest, 0, len(fas))
+	for _, f := range fas {
+		if f.PlayerID == "" {
+			continue
+		}
+		keep[f.PlayerID] = true
+		reqs = append(reqs, types.WriteRequest{PutRequest: &types.PutRequest{Item: f.item(now)}})
+	}
+	scope := map[string]bool{}
+	for _, p := range positions {
+		scope[strings.ToUpper(strings.TrimSpace(p))] = true
+	}
+	all := len(scope) == 0 || scope["*"]
+
+	var lastKey map[string]types.AttributeValue
+	for {
+		page, err := ddb.Scan(ctx, &dynamodb.ScanInput{
+			TableName:                aws.String(table),
+			ProjectionExpression:     aws.String("playerID, #pos"),
+			ExpressionAttributeNames: map[string]string{"#pos": "position"},
+			ExclusiveStartKey:        lastKey,
+		})
+		if err != nil {
+			return WriteReport{Table: table}, fmt.Errorf("scan free agents: %w", err)
+		}
+		for _, it := range page.Items {
+			if !all && !scope[strings.ToUpper(getStr(it, "position"))] {
+				continue
+			}
+			if id := getStr(it, "playerID"); id != "" && !keep[id] {
+				reqs = append(reqs, types.WriteRequest{DeleteRequest: &types.DeleteRequest{Key: map[string]types.AttributeValue{
+					"playerID": &types.AttributeValueMemberS{Value: id},
+				}}})
+			}
+		}
+		if len(page.LastEvaluatedKey) == 0 {
+			break
+		}
+		lastKey = page.LastEvaluatedKey
+	}
+	return NewBatchWriter(ddb, "playerID").Write(ctx, table, reqs)
+}
+
+// DynamoDBScanReadAPI is the read-only scan surface LoadFreeAgents needs.
+type DynamoDBScanReadAPI interface {
+	Scan(ctx context.Context, params *dynamodb.ScanInput, optFns ...func(*dynamodb.Options)) (*dynamodb.ScanOutput, error)
+}
+
+// LoadFreeAgents reads the whole free-agent pool. Trends are left nil; callers join
+// fresh ones from LoadSeasonTrends by PFRID.
+func LoadFreeAgents(ctx context.Context, ddb DynamoDBScanReadAPI, table string) ([]FreeAgent, error) {
+	var out []FreeAgent
+	var lastKey map[string]types.AttributeValue
+	for {
+		page, err := ddb.Scan(ctx, &dynamodb.ScanInput{
+			TableName:         aws.String(table),
+			ExclusiveStartKey: lastKey,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("scan free agents: %w", err)
+		}
+		for _, it := range page.Items {
+			out = append(out, FreeAgent{
+				PlayerID: getStr(it, "playerID"),
+				Name:     getStr(it, "name"),
+				Position: getStr(it, "position"),
+				Group:    getStr(it, "positionGroup"),
+				Team:     getStr(it, "team"),
+				PFRID:    getStr(it, "PFRID"),
+				PFRTeam:  getStr(it, "PFRTeam"),
+				ByeWeek:  getNum(it, "byeWeek"),
+				Season:   getStr(it, "Season"),
+			})
+		}
+		if len(page.LastEvaluatedKey) == 0 {
+			break
+		}
+		lastKey = page.LastEvaluatedKey
+	}
+	return out, nil
+}
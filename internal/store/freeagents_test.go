@@ -0,0 +1,63 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"testing"
+
+	ddb "github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// poolDDB serves its items as one scan page and records every write request.
+type poolDDB struct {
+	scanDDB
+}
+
+func (f *poolDDB) Scan(ctx context.Context, in *ddb.ScanInput, _ ...func(*ddb.Options)) (*ddb.ScanOutput, error) {
+	return &ddb.ScanOutput{Items: f.items}, nil
+}
+
+func TestReplaceFreeAgents_DeletesOnlyFetchedPositions(t *testing.T) {
+	stored := func(id, pos string) map[string]types.AttributeValue {
+		return map[string]types.AttributeValue{
+			"playerID": &types.AttributeValueMemberS{Value: id},
+			"position": &types.AttributeValueMemberS{Value: pos},
+		}
+	}
+	pool := func() *poolDDB {
+		return &poolDDB{scanDDB{items: []map[string]types.AttributeValue{
+			stored("1", "LB"), // still a free agent
+			stored("2", "LB"), // signed
+			stored("3", "CB"), // not fetched this run
+			stored("4", "S"),
+		}}}
+	}
+	fas := []FreeAgent{{PlayerID: "1", Position: "LB"}, {PlayerID: "5", Position: "LB"}}
+	deletes := func(f *poolDDB) string {
+		var ids []string
+		for _, r := range f.reqs {
+			if r.DeleteRequest != nil {
+				ids = append(ids, getStr(r.DeleteRequest.Key, "playerID"))
+			}
+		}
+		sort.Strings(ids)
+		return fmt.Sprint(ids)
+	}
+
+	f := pool()
+	if _, err := ReplaceFreeAgents(context.Background(), f, "fa", []string{"lb"}, fas); err != nil {
+		t.Fatal(err)
+	}
+	if got := deletes(f); got != "[2]" {
+		t.Errorf("LB run deleted %s, want only the signed LB", got)
+	}
+	f = pool()
+	if _, err := ReplaceFreeAgents(context.Background(), f, "fa", []string{"*"}, fas); err != nil {
+		t.Fatal(err)
+	}
+	if got := deletes(f); got != "[2 3 4]" {
+		t.Errorf("full run deleted %s", got)
+	}
+}
@@ -11,25 +11,31 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 
 	"github.com/tyler180/fantasy-football-backends/internal/pfr"
+	"github.com/tyler180/fantasy-football-backends/internal/starters"
 )
 
 type DynamoDBReadAPI interface {
 	Query(ctx context.Context, params *dynamodb.QueryInput, optFns ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error)
 }
 
-// Read roster rows for a season → group by PlayerID → filter under-25 & G==GS → []PlayerRow
+// Read roster rows for a season → group by PlayerID → []PlayerRow for every defensive
+// player of spec's positions, with StarterRank set (1-based within the team) on those
+// spec describes as starters and 0 on the rest. When spec windows the snap share
+// (LastNWeeks > 0) and snapTable is set, per-game DEF% is read from the snaps table,
+// one SeasonTeamWeek partition at a time for the teams of players that pass the other
+// checks; otherwise the season DEF% is used.
 func MaterializeDefenseFromRoster(
 	ctx context.Context,
 	ddb DynamoDBReadAPI,
 	rosterTable string,
+	snapTable string,
 	season string,
-	defPos []string,
-	_ int, // maxAge no longer used for filtering
+	spec starters.Spec,
 ) ([]pfr.PlayerRow, error) {
 
-	posAllow := make(map[string]struct{}, len(defPos))
-	for _, p := range defPos {
-		posAllow[strings.ToUpper(strings.TrimSpace(p))] = struct{}{}
+	posAllow := make(map[string]struct{})
+	for _, p := range spec.Positions() {
+		posAllow[p] = struct{}{}
 	}
 
 	type agg struct {
@@ -143,8 +149,9 @@ func MaterializeDefenseFromRoster(
 		lastKey = out.LastEvaluatedKey
 	}
 
-	// No filtering here: write every defensive player
 	rows := make([]pfr.PlayerRow, 0, len(byPlayer))
+	cands := make([]starters.Candidate, 0, len(byPlayer))
+	snapTeams := map[string]struct{}{}
 	for _, a := range byPlayer {
 		age := a.AgeMin
 		if age == 1<<30 {
@@ -152,7 +159,7 @@ func MaterializeDefenseFromRoster(
 		}
 		primary := pickPrimaryTeam(a.TeamGS, a.TeamG)
 
-		rows = append(rows, pfr.PlayerRow{
+		row := pfr.PlayerRow{
 			PlayerID:   a.PlayerID,
 			Player:     a.Player,
 			Team:       primary,
@@ -163,7 +170,37 @@ func MaterializeDefenseFromRoster(
 			Pos:        joinSortedKeys(a.PosSet, ","),
 			DefSnapNum: a.DefNumTotal,
 			DefSnapPct: a.TeamDefPct[primary],
-		})
+		}
+		c := starters.Candidate{
+			PlayerID: row.PlayerID, Team: row.Team, Pos: row.Pos,
+			Age: row.Age, G: row.G, GS: row.GS, SeasonPct: row.DefSnapPct,
+		}
+		if spec.LastNWeeks > 0 && snapTable != "" && spec.Eligible(c) {
+			for tm := range a.Teams {
+				snapTeams[tm] = struct{}{}
+			}
+		}
+		rows = append(rows, row)
+		cands = append(cands, c)
+	}
+
+	if len(snapTeams) > 0 {
+		series, maxWeek, err := loadTeamSnapSeries(ctx, ddb, snapTable, season, strings.Split(joinSortedKeys(snapTeams, ","), ","))
+		if err != nil {
+			return nil, err
+		}
+		for i := range cands {
+			if spec.Eligible(cands[i]) {
+				cands[i].Weekly, cands[i].MaxWeek = series[cands[i].PlayerID], maxWeek
+			}
+		}
+	}
+	rank := make(map[string]int, len(cands))
+	for _, c := range spec.Select(cands) {
+		rank[c.PlayerID] = c.Rank
+	}
+	for i := range rows {
+		rows[i].StarterRank = rank[rows[i].PlayerID]
 	}
 
 	sort.Slice(rows, func(i, j int) bool {
@@ -0,0 +1,80 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"testing"
+
+	ddb "github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"github.com/tyler180/fantasy-football-backends/internal/starters"
+)
+
+// materializeDDB serves the roster table by season and the snaps table by
+// SeasonTeamWeek partition, counting the queries of each kind.
+type materializeDDB struct {
+	roster      []map[string]types.AttributeValue
+	snaps       map[string][]map[string]types.AttributeValue
+	partitions  int
+	playerQuery int
+}
+
+func (f *materializeDDB) Query(ctx context.Context, in *ddb.QueryInput, _ ...func(*ddb.Options)) (*ddb.QueryOutput, error) {
+	switch {
+	case in.IndexName != nil:
+		f.playerQuery++
+		return &ddb.QueryOutput{}, nil
+	case *in.TableName == "roster":
+		return &ddb.QueryOutput{Items: f.roster}, nil
+	}
+	f.partitions++
+	pk := in.ExpressionAttributeValues[":pk"].(*types.AttributeValueMemberS).Value
+	return &ddb.QueryOutput{Items: f.snaps[pk]}, nil
+}
+
+func TestMaterializeDefenseFromRoster_WritesEveryPlayer(t *testing.T) {
+	s := func(v string) types.AttributeValue { return &types.AttributeValueMemberS{Value: v} }
+	n := func(v int) types.AttributeValue { return &types.AttributeValueMemberN{Value: strconv.Itoa(v)} }
+	player := func(id, name, pos string, age, g, gs int) map[string]types.AttributeValue {
+		return map[string]types.AttributeValue{
+			"PlayerID": s(id), "Player": s(name), "Team": s("SEA"), "Pos": s(pos),
+			"Age": n(age), "G": n(g), "GS": n(gs), "DefSnapPct": n(80),
+		}
+	}
+	f := &materializeDDB{
+		roster: []map[string]types.AttributeValue{
+			player("P1", "Young Starter", "CB", 23, 9, 9),
+			player("P2", "Veteran", "LB", 31, 9, 9),
+			player("P3", "Hurt Starter", "S", 22, 6, 6), // last game week 6
+			player("P4", "Rotation", "DE", 23, 9, 2),
+			player("P5", "Kicker", "K", 23, 9, 0),
+		},
+		snaps: map[string][]map[string]types.AttributeValue{},
+	}
+	for wk := 1; wk <= 9; wk++ {
+		pk := fmt.Sprintf("2024#SEA#%02d", wk)
+		f.snaps[pk] = append(f.snaps[pk], map[string]types.AttributeValue{"PlayerID": s("P1"), "DefSnapPct": n(95)})
+		if wk <= 6 {
+			f.snaps[pk] = append(f.snaps[pk], map[string]types.AttributeValue{"PlayerID": s("P3"), "DefSnapPct": n(99)})
+		}
+	}
+	spec := starters.Default()
+	spec.LastNWeeks = 3
+	rows, err := MaterializeDefenseFromRoster(context.Background(), f, "roster", "snaps", "2024", spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := map[string]int{}
+	for _, r := range rows {
+		got[r.Player] = r.StarterRank
+	}
+	want := map[string]int{"Young Starter": 1, "Veteran": 0, "Hurt Starter": 0, "Rotation": 0}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("starter ranks = %v, want %v", got, want)
+	}
+	if f.playerQuery != 0 || f.partitions != snapWeeks {
+		t.Errorf("queried %d players and %d partitions, want only SEA's %d weeks", f.playerQuery, f.partitions, snapWeeks)
+	}
+}
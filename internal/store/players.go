@@ -72,3 +72,41 @@ func LoadPlayerPositions(ctx context.Context, ddb *dynamodb.Client, playersTable
 	}
 	return idPos, namePos, nil
 }
+
+// FindPlayerSeasonTeams returns the teams a player is stored under in the players
+// table for a season, via the PlayerIDIndex GSI (PK PlayerID).
+func FindPlayerSeasonTeams(ctx context.Context, ddb DynamoDBReadAPI, playersTable, playerID, season string) ([]string, error) {
+	var teams []string
+	var lastKey map[string]types.AttributeValue
+	for {
+		out, err := ddb.Query(ctx, &dynamodb.QueryInput{
+			TableName:              aws.String(playersTable),
+			IndexName:              aws.String("PlayerIDIndex"),
+			KeyConditionExpression: aws.String("#pid = :pid"),
+			FilterExpression:       aws.String("#s = :s"),
+			ExpressionAttributeNames: map[string]string{
+				"#pid": "PlayerID",
+				"#s":   "Season",
+			},
+			ExpressionAttributeValues: map[string]types.AttributeValue{
+				":pid": &types.AttributeValueMemberS{Value: playerID},
+				":s":   &types.AttributeValueMemberS{Value: season},
+			},
+			ProjectionExpression: aws.String("PlayerID, Team"),
+			ExclusiveStartKey:    lastKey,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, it := range out.Items {
+			if tm := getStr(it, "Team"); tm != "" {
+				teams = append(teams, tm)
+			}
+		}
+		if len(out.LastEvaluatedKey) == 0 {
+			break
+		}
+		lastKey = out.LastEvaluatedKey
+	}
+	return teams, nil
+}
@@ -0,0 +1,48 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"github.com/tyler180/fantasy-football-backends/internal/scoring"
+)
+
+// UpdatePlayerPoints writes a player's IDP fantasy points next to the snap trends on
+// the players-table item:
+//
+//	FantasyPoints, FantasyPPG, FantasyGames, ScoringRuleset,
+//	FantasyPointsByWeek (M, "01" -> points)
+//
+// Like UpdatePlayerTrends it never creates items; a missing player surfaces as a
+// ConditionalCheckFailedException.
+func UpdatePlayerPoints(ctx context.Context, ddb DynamoDBAPI, table, season, team string, p scoring.PlayerPoints, ruleset string) error {
+	byWeek := make(map[string]types.AttributeValue, len(p.Weeks))
+	for _, w := range p.Weeks {
+		byWeek[fmt.Sprintf("%02d", w.Week)] = &types.AttributeValueMemberN{Value: strconv.FormatFloat(w.Points, 'f', 2, 64)}
+	}
+	_, err := ddb.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName: aws.String(table),
+		Key: map[string]types.AttributeValue{
+			"SeasonTeam": &types.AttributeValueMemberS{Value: season + "#" + team},
+			"PlayerID":   &types.AttributeValueMemberS{Value: p.PlayerID},
+		},
+		UpdateExpression: aws.String("SET FantasyPoints=:t, FantasyPPG=:ppg, FantasyGames=:g, " +
+			"FantasyPointsByWeek=:wk, ScoringRuleset=:rs, UpdatedAt=:now"),
+		ConditionExpression: aws.String("attribute_exists(SeasonTeam) AND attribute_exists(PlayerID)"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":t":   &types.AttributeValueMemberN{Value: strconv.FormatFloat(p.Total, 'f', 2, 64)},
+			":ppg": &types.AttributeValueMemberN{Value: strconv.FormatFloat(p.PPG, 'f', 2, 64)},
+			":g":   &types.AttributeValueMemberN{Value: strconv.Itoa(len(p.Weeks))},
+			":wk":  &types.AttributeValueMemberM{Value: byWeek},
+			":rs":  &types.AttributeValueMemberS{Value: ruleset},
+			":now": &types.AttributeValueMemberN{Value: strconv.FormatInt(time.Now().Unix(), 10)},
+		},
+	})
+	return err
+}
@@ -0,0 +1,106 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"github.com/tyler180/fantasy-football-backends/internal/projection"
+)
+
+// Projections table: PK SeasonWeek (S, "2024#05"), SK PlayerID (S, PFR id), with GSI
+// PlayerProjections (PK PlayerID, SK SeasonWeek) for a player's projection history.
+
+func seasonWeek(season string, week int) string { return fmt.Sprintf("%s#%02d", season, week) }
+
+// PutProjections upserts one week's projections. Model names the model version so
+// later comparisons know which numbers came from where.
+func PutProjections(ctx context.Context, ddb DynamoDBAPI, table, season, model string, ps []projection.Projection) (WriteReport, error) {
+	now := strconv.FormatInt(time.Now().Unix(), 10)
+	reqs := make([]types.WriteRequest, 0, len(ps))
+	for _, p := range ps {
+		num := func(v float64, prec int) types.AttributeValue {
+			return &types.AttributeValueMemberN{Value: strconv.FormatFloat(v, 'f', prec, 64)}
+		}
+		it := map[string]types.AttributeValue{
+			"SeasonWeek":    &types.AttributeValueMemberS{Value: seasonWeek(season, p.Week)},
+			"PlayerID":      &types.AttributeValueMemberS{Value: p.PlayerID},
+			"Season":        &types.AttributeValueMemberS{Value: season},
+			"Week":          &types.AttributeValueMemberN{Value: strconv.Itoa(p.Week)},
+			"Player":        &types.AttributeValueMemberS{Value: p.Player},
+			"Team":          &types.AttributeValueMemberS{Value: p.Team},
+			"Pos":           &types.AttributeValueMemberS{Value: p.Pos},
+			"Games":         &types.AttributeValueMemberN{Value: strconv.Itoa(p.Games)},
+			"SnapPct":       num(p.SnapPct, 1),
+			"SnapPctLo":     num(p.SnapPctLo, 1),
+			"SnapPctHi":     num(p.SnapPctHi, 1),
+			"Snaps":         num(p.Snaps, 1),
+			"SnapsLo":       num(p.SnapsLo, 1),
+			"SnapsHi":       num(p.SnapsHi, 1),
+			"PointsPerSnap": num(p.PointsPerSnap, 4),
+			"Points":        num(p.Points, 2),
+			"PointsLo":      num(p.PointsLo, 2),
+			"PointsHi":      num(p.PointsHi, 2),
+			"Model":         &types.AttributeValueMemberS{Value: model},
+			"UpdatedAt":     &types.AttributeValueMemberN{Value: now},
+		}
+		if p.Opponent != "" {
+			it["Opponent"] = &types.AttributeValueMemberS{Value: p.Opponent}
+		}
+		if p.Injury != "" {
+			it["Injury"] = &types.AttributeValueMemberS{Value: p.Injury}
+		}
+		reqs = append(reqs, types.WriteRequest{PutRequest: &types.PutRequest{Item: it}})
+	}
+	return NewBatchWriter(ddb, "SeasonWeek", "PlayerID").Write(ctx, table, reqs)
+}
+
+// LoadProjections reads one week's projections keyed by PFR player id.
+func LoadProjections(ctx context.Context, ddb DynamoDBReadAPI, table, season string, week int) (map[string]projection.Projection, error) {
+	out := map[string]projection.Projection{}
+	var lastKey map[string]types.AttributeValue
+	for {
+		page, err := ddb.Query(ctx, &dynamodb.QueryInput{
+			TableName:                 aws.String(table),
+			KeyConditionExpression:    aws.String("SeasonWeek = :sw"),
+			ExpressionAttributeValues: map[string]types.AttributeValue{":sw": &types.AttributeValueMemberS{Value: seasonWeek(season, week)}},
+			ExclusiveStartKey:         lastKey,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("query projections: %w", err)
+		}
+		for _, it := range page.Items {
+			p := projection.Projection{
+				PlayerID:      getStr(it, "PlayerID"),
+				Player:        getStr(it, "Player"),
+				Team:          getStr(it, "Team"),
+				Pos:           getStr(it, "Pos"),
+				Week:          getNum(it, "Week"),
+				Opponent:      getStr(it, "Opponent"),
+				Games:         getNum(it, "Games"),
+				SnapPct:       getFloat(it, "SnapPct"),
+				SnapPctLo:     getFloat(it, "SnapPctLo"),
+				SnapPctHi:     getFloat(it, "SnapPctHi"),
+				Snaps:         getFloat(it, "Snaps"),
+				SnapsLo:       getFloat(it, "SnapsLo"),
+				SnapsHi:       getFloat(it, "SnapsHi"),
+				PointsPerSnap: getFloat(it, "PointsPerSnap"),
+				Points:        getFloat(it, "Points"),
+				PointsLo:      getFloat(it, "PointsLo"),
+				PointsHi:      getFloat(it, "PointsHi"),
+				Injury:        getStr(it, "Injury"),
+			}
+			out[p.PlayerID] = p
+		}
+		if len(page.LastEvaluatedKey) == 0 {
+			break
+		}
+		lastKey = page.LastEvaluatedKey
+	}
+	return out, nil
+}
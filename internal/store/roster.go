@@ -7,6 +7,8 @@ import (
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"github.com/tyler180/fantasy-football-backends/internal/pfr"
 )
 
 // LoadRosterPositions builds a name->pos map from nfl_roster_rows (by SeasonTeam partitions).
@@ -47,3 +49,49 @@ func LoadRosterPositions(ctx context.Context, ddb *dynamodb.Client, rosterTable,
 	}
 	return namePos, nil
 }
+
+// LoadRosterRows reads every nfl_roster_rows item for a season (PK=Season). A player
+// traded mid-season has one row per team.
+func LoadRosterRows(ctx context.Context, ddb DynamoDBReadAPI, rosterTable, season string) ([]pfr.RosterRow, error) {
+	var rows []pfr.RosterRow
+	var lastKey map[string]types.AttributeValue
+	for {
+		out, err := ddb.Query(ctx, &dynamodb.QueryInput{
+			TableName:                 aws.String(rosterTable),
+			KeyConditionExpression:    aws.String("#S = :s"),
+			ExpressionAttributeNames:  map[string]string{"#S": "Season"},
+			ExpressionAttributeValues: map[string]types.AttributeValue{":s": &types.AttributeValueMemberS{Value: season}},
+			ExclusiveStartKey:         lastKey,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, it := range out.Items {
+			r := pfr.RosterRow{
+				Season:     season,
+				PlayerID:   getStr(it, "PlayerID"),
+				Player:     getStr(it, "Player"),
+				Team:       getStr(it, "Team"),
+				Age:        getNum(it, "Age"),
+				Pos:        getStr(it, "Pos"),
+				G:          getNum(it, "G"),
+				GS:         getNum(it, "GS"),
+				DefSnapNum: getNum(it, "DefSnapNum"),
+				DefSnapPct: getFloat(it, "DefSnapPct"),
+			}
+			if r.PlayerID == "" {
+				if sk := getStr(it, "SK"); sk != "" {
+					r.PlayerID, _, _ = strings.Cut(sk, "#")
+				}
+			}
+			if r.PlayerID != "" {
+				rows = append(rows, r)
+			}
+		}
+		if len(out.LastEvaluatedKey) == 0 {
+			break
+		}
+		lastKey = out.LastEvaluatedKey
+	}
+	return rows, nil
+}
@@ -4,14 +4,17 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"sort"
+	"strconv"
 	"strings"
-	"time"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 
 	// update to your module path
 	"github.com/tyler180/fantasy-football-backends/internal/pfr"
+	"github.com/tyler180/fantasy-football-backends/internal/trends"
 )
 
 // Env helpers
@@ -46,9 +49,9 @@ func snapsKeyAttrNames() (string, string) {
 //	SK: SeasonWeek (S)
 //
 // De-duplicates by (Season,Team,Week,PlayerID) to avoid duplicate-key ValidationException.
-func PutSnapGameRows(ctx context.Context, ddb *dynamodb.Client, tableName string, rows []pfr.SnapGameRow) error {
+func PutSnapGameRows(ctx context.Context, ddb DynamoDBAPI, tableName string, rows []pfr.SnapGameRow) (WriteReport, error) {
 	if len(rows) == 0 {
-		return nil
+		return WriteReport{Table: tableName}, nil
 	}
 
 	pkAttr, skAttr := snapsKeyAttrNames()
@@ -79,10 +82,17 @@ func PutSnapGameRows(ctx context.Context, ddb *dynamodb.Client, tableName string
 		})
 	}
 
-	if len(wreqs) == 0 {
-		return nil
+	rep, err := NewBatchWriter(ddb, pkAttr, skAttr).Write(ctx, tableName, wreqs)
+	if err != nil {
+		return rep, fmt.Errorf("batch write snap rows: %w", err)
 	}
-	return batchWriteAll(ctx, ddb, tableName, wreqs)
+	return rep, nil
+}
+
+// SnapItem builds the snaps-table item for r using the configured key attribute names.
+func SnapItem(r pfr.SnapGameRow) map[string]types.AttributeValue {
+	pkAttr, skAttr := snapsKeyAttrNames()
+	return buildSnapItem(r, pkAttr, skAttr)
 }
 
 // buildSnapItem converts a SnapGameRow to a DynamoDB item and uses the provided key attribute names.
@@ -117,52 +127,134 @@ func buildSnapItem(r pfr.SnapGameRow, pkAttr, skAttr string) map[string]types.At
 	return item
 }
 
-// batchWriteAll writes in chunks of 25 with exponential backoff for UnprocessedItems.
-func batchWriteAll(ctx context.Context, ddb *dynamodb.Client, table string, reqs []types.WriteRequest) error {
-	const chunk = 25
-	for start := 0; start < len(reqs); start += chunk {
-		end := start + chunk
-		if end > len(reqs) {
-			end = len(reqs)
+// QueryPlayerSnapSeries returns a player's per-game DEF% for a season in week order,
+// read from the PlayerGames GSI (PK PlayerID, SK SeasonWeek).
+func QueryPlayerSnapSeries(ctx context.Context, ddb DynamoDBReadAPI, snapTable, playerID, season string) ([]trends.Point, error) {
+	type row struct {
+		sw  string
+		pct float64
+	}
+	var tmp []row
+	var lastKey map[string]types.AttributeValue
+	for {
+		out, err := ddb.Query(ctx, &dynamodb.QueryInput{
+			TableName:              aws.String(snapTable),
+			IndexName:              aws.String("PlayerGames"),
+			KeyConditionExpression: aws.String("#pid = :pid AND begins_with(#sw, :pref)"),
+			ExpressionAttributeNames: map[string]string{
+				"#pid": "PlayerID",
+				"#sw":  "SeasonWeek",
+			},
+			ExpressionAttributeValues: map[string]types.AttributeValue{
+				":pid":  &types.AttributeValueMemberS{Value: playerID},
+				":pref": &types.AttributeValueMemberS{Value: season + "#"},
+			},
+			ProjectionExpression: aws.String("SeasonWeek, DefSnapPct"),
+			ExclusiveStartKey:    lastKey,
+		})
+		if err != nil {
+			return nil, err
 		}
-		batch := reqs[start:end]
-
-		requestItems := map[string][]types.WriteRequest{
-			table: batch,
+		for _, it := range out.Items {
+			tmp = append(tmp, row{sw: getStr(it, "SeasonWeek"), pct: getFloat(it, "DefSnapPct")})
 		}
+		if len(out.LastEvaluatedKey) == 0 {
+			break
+		}
+		lastKey = out.LastEvaluatedKey
+	}
+	sort.Slice(tmp, func(i, j int) bool { return tmp[i].sw < tmp[j].sw })
+	pts := make([]trends.Point, 0, len(tmp))
+	for _, r := range tmp {
+		// SeasonWeek is "2024#01"
+		wk, _ := strconv.Atoi(r.sw[strings.LastIndex(r.sw, "#")+1:])
+		pts = append(pts, trends.Point{Week: wk, Pct: r.pct})
+	}
+	return pts, nil
+}
+
+// snapWeeks is the last week LoadTeamSnapGames reads: the regular season and the
+// playoffs.
+const snapWeeks = 22
 
-		backoff := 100 * time.Millisecond
-		for attempt := 1; ; attempt++ {
-			out, err := ddb.BatchWriteItem(ctx, &dynamodb.BatchWriteItemInput{
-				RequestItems: requestItems,
-			})
-			if err != nil {
-				if attempt >= 8 {
-					return fmt.Errorf("batch write (attempt %d): %w", attempt, err)
+// LoadTeamSnapGames reads every game of teams in season from the snaps table, one
+// SeasonTeamWeek partition per team and week, in team then week order. Unlike the
+// players table it holds every defender who played, as of each game.
+func LoadTeamSnapGames(ctx context.Context, ddb DynamoDBReadAPI, snapTable, season string, teams []string) ([]pfr.SnapGameRow, error) {
+	pkAttr, skAttr := snapsKeyAttrNames()
+	var out []pfr.SnapGameRow
+	for _, team := range teams {
+		for wk := 1; wk <= snapWeeks; wk++ {
+			pk := fmt.Sprintf("%s#%s#%02d", season, team, wk)
+			var lastKey map[string]types.AttributeValue
+			for {
+				page, err := ddb.Query(ctx, &dynamodb.QueryInput{
+					TableName:                aws.String(snapTable),
+					KeyConditionExpression:   aws.String("#pk = :pk"),
+					ExpressionAttributeNames: map[string]string{"#pk": pkAttr},
+					ExpressionAttributeValues: map[string]types.AttributeValue{
+						":pk": &types.AttributeValueMemberS{Value: pk},
+					},
+					ExclusiveStartKey: lastKey,
+				})
+				if err != nil {
+					return nil, fmt.Errorf("snaps %s: %w", pk, err)
 				}
-				time.Sleep(backoff)
-				backoff = nextBackoff(backoff)
-				continue
-			}
-			if len(out.UnprocessedItems) == 0 || len(out.UnprocessedItems[table]) == 0 {
-				break
-			}
-			// retry only the unprocessed ones
-			requestItems = out.UnprocessedItems
-			if attempt >= 8 {
-				return fmt.Errorf("batch write: exhausted retries; %d unprocessed remain", len(out.UnprocessedItems[table]))
+				for _, it := range page.Items {
+					id := getStr(it, skAttr)
+					if id == "" {
+						continue
+					}
+					out = append(out, pfr.SnapGameRow{
+						Season:     season,
+						Team:       team,
+						Week:       wk,
+						PlayerID:   id,
+						Player:     getStr(it, "Player"),
+						Pos:        getStr(it, "Pos"),
+						DefSnapPct: getFloat(it, "DefSnapPct"),
+					})
+				}
+				if len(page.LastEvaluatedKey) == 0 {
+					break
+				}
+				lastKey = page.LastEvaluatedKey
 			}
-			time.Sleep(backoff)
-			backoff = nextBackoff(backoff)
 		}
 	}
-	return nil
+	return out, nil
 }
 
-func nextBackoff(cur time.Duration) time.Duration {
-	cur *= 2
-	if cur > 2*time.Second {
-		return 2 * time.Second
+// loadTeamSnapSeries is LoadTeamSnapGames as each player's per-game DEF% in week
+// order, with the latest week that had snaps. A player traded mid-season has the
+// games of every team read.
+func loadTeamSnapSeries(ctx context.Context, ddb DynamoDBReadAPI, snapTable, season string, teams []string) (map[string][]trends.Point, int, error) {
+	games, err := LoadTeamSnapGames(ctx, ddb, snapTable, season, teams)
+	if err != nil {
+		return nil, 0, err
+	}
+	series := map[string][]trends.Point{}
+	maxWeek := 0
+	for _, g := range games {
+		series[g.PlayerID] = append(series[g.PlayerID], trends.Point{Week: g.Week, Pct: g.DefSnapPct})
+		maxWeek = max(maxWeek, g.Week)
+	}
+	for _, pts := range series {
+		sort.SliceStable(pts, func(i, j int) bool { return pts[i].Week < pts[j].Week })
+	}
+	return series, maxWeek, nil
+}
+
+func getFloat(m map[string]types.AttributeValue, key string) float64 {
+	if v, ok := m[key]; ok {
+		switch t := v.(type) {
+		case *types.AttributeValueMemberN:
+			f, _ := strconv.ParseFloat(t.Value, 64)
+			return f
+		case *types.AttributeValueMemberS:
+			f, _ := strconv.ParseFloat(t.Value, 64)
+			return f
+		}
 	}
-	return cur
+	return 0
 }
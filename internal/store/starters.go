@@ -0,0 +1,177 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// StarterRow is one row of the Athena defensive_starters_allgames table. The athena
+// tags are its column names, so ath.Select decodes the CTAS output straight into it.
+type StarterRow struct {
+	PlayerID     string   `athena:"player_id"` // GSIS; empty when snap counts had none
+	PFRID        string   `athena:"pfr_id"`
+	Player       string   `athena:"player_name"`
+	Pos          string   `athena:"position"`
+	Age          *int     `athena:"age_yrs"`
+	Games        int      `athena:"games_with_snap"`
+	GamesTotal   int      `athena:"games_total"`
+	Starts       int      `athena:"games_started"`
+	AvgDefPct    *float64 `athena:"avg_def_pct"`
+	RecentDefPct *float64 `athena:"recent_def_pct"`
+	MinDefPct    *float64 `athena:"min_def_pct"`
+	MaxDefPct    *float64 `athena:"max_def_pct"`
+	StarterRank  int      `athena:"starter_rank"`
+	Season       int      `athena:"season"`
+	Team         string   `athena:"team"`
+}
+
+// ErrNoStarters is returned by ReplaceSeasonStarters when given no rows: an empty
+// CTAS is far more likely a broken upstream than a season without starters, and
+// syncing it would delete the whole season.
+var ErrNoStarters = errors.New("no starter rows to sync")
+
+// DynamoDBQueryAPI is batch writes plus queries: the surface of the replaces that
+// find stale items by querying (ReplaceSeasonStarters, ReplacePlayerAlerts).
+type DynamoDBQueryAPI interface {
+	DynamoDBAPI
+	DynamoDBReadAPI
+}
+
+// starterTeams are the nflverse team codes a season's starters can be filed under,
+// including relocated franchises' old codes, so a team missing from this run's rows
+// still has its stale items found.
+var starterTeams = []string{
+	"ARI", "ATL", "BAL", "BUF", "CAR", "CHI", "CIN", "CLE", "DAL", "DEN", "DET", "GB",
+	"HOU", "IND", "JAX", "KC", "LA", "LAC", "LAR", "LV", "MIA", "MIN", "NE", "NO",
+	"NYG", "NYJ", "OAK", "PHI", "PIT", "SD", "SEA", "SF", "STL", "TB", "TEN", "WAS",
+}
+
+// StarterSync is the outcome of ReplaceSeasonStarters.
+type StarterSync struct {
+	Season  string      `json:"season"`
+	Upserts int         `json:"upserts"`
+	Deletes int         `json:"deletes"` // items of the season no longer in the rows
+	Invalid int         `json:"invalid"` // rows without a team or any player id
+	Write   WriteReport `json:"write"`
+}
+
+// key is the row's PlayerID sort key: GSIS id, else "pfr:" + PFR id.
+func (r StarterRow) key() string {
+	if r.PlayerID != "" {
+		return r.PlayerID
+	}
+	if r.PFRID != "" {
+		return "pfr:" + r.PFRID
+	}
+	return ""
+}
+
+func (r StarterRow) item(season, now string) map[string]types.AttributeValue {
+	id := r.key()
+	it := map[string]types.AttributeValue{
+		"SeasonTeam":   &types.AttributeValueMemberS{Value: season + "#" + r.Team}, // PK
+		"PlayerID":     &types.AttributeValueMemberS{Value: id},                    // SK
+		"Season":       &types.AttributeValueMemberS{Value: season},
+		"Team":         &types.AttributeValueMemberS{Value: r.Team},
+		"Player":       &types.AttributeValueMemberS{Value: r.Player},
+		"Pos":          &types.AttributeValueMemberS{Value: r.Pos},
+		"G":            &types.AttributeValueMemberN{Value: strconv.Itoa(r.Games)},
+		"GamesTotal":   &types.AttributeValueMemberN{Value: strconv.Itoa(r.GamesTotal)},
+		"GS":           &types.AttributeValueMemberN{Value: strconv.Itoa(r.Starts)},
+		"StarterRank":  &types.AttributeValueMemberN{Value: strconv.Itoa(r.StarterRank)},
+		"TeamPlayerID": &types.AttributeValueMemberS{Value: r.Team + "#" + id},
+		"Source":       &types.AttributeValueMemberS{Value: "athena"},
+		"UpdatedAt":    &types.AttributeValueMemberN{Value: now},
+	}
+	if r.PFRID != "" {
+		it["PFRID"] = &types.AttributeValueMemberS{Value: r.PFRID}
+	}
+	if r.Age != nil {
+		it["Age"] = &types.AttributeValueMemberN{Value: strconv.Itoa(*r.Age)}
+	}
+	for name, v := range map[string]*float64{
+		"AvgDefPct":  r.AvgDefPct,
+		"DefSnapPct": r.RecentDefPct, // the share the starter rule used
+		"MinDefPct":  r.MinDefPct,
+		"MaxDefPct":  r.MaxDefPct,
+	} {
+		if v != nil {
+			it[name] = &types.AttributeValueMemberN{Value: strconv.FormatFloat(*v, 'f', 1, 64)}
+		}
+	}
+	return it
+}
+
+// ReplaceSeasonStarters makes season's items in the starters table (PK SeasonTeam =
+// season#team, SK PlayerID) match rows: every row is upserted, then items of that
+// season that are no longer in rows are deleted. Stale items are found by querying
+// each season#team partition of starterTeams and of the rows' teams. Rows without a
+// team or any player id are skipped. Other seasons are not touched.
+func ReplaceSeasonStarters(ctx context.Context, ddb DynamoDBQueryAPI, table, season string, rows []StarterRow) (StarterSync, error) {
+	now := strconv.FormatInt(time.Now().Unix(), 10)
+	res := StarterSync{Season: season, Write: WriteReport{Table: table}}
+	keep := make(map[[2]string]bool, len(rows))
+	teams := make(map[string]bool, len(starterTeams))
+	for _, t := range starterTeams {
+		teams[t] = true
+	}
+	reqs := make([]types.WriteRequest, 0, len(rows))
+	for _, r := range rows {
+		if r.Team == "" || r.key() == "" {
+			res.Invalid++
+			continue
+		}
+		teams[r.Team] = true
+		keep[[2]string{season + "#" + r.Team, r.key()}] = true
+		reqs = append(reqs, types.WriteRequest{PutRequest: &types.PutRequest{Item: r.item(season, now)}})
+	}
+	if len(reqs) == 0 {
+		return res, ErrNoStarters
+	}
+	res.Upserts = len(reqs)
+
+	pks := make([]string, 0, len(teams))
+	for t := range teams {
+		pks = append(pks, season+"#"+t)
+	}
+	sort.Strings(pks)
+	for _, pk := range pks {
+		var lastKey map[string]types.AttributeValue
+		for {
+			page, err := ddb.Query(ctx, &dynamodb.QueryInput{
+				TableName:                 aws.String(table),
+				KeyConditionExpression:    aws.String("SeasonTeam = :pk"),
+				ProjectionExpression:      aws.String("PlayerID"),
+				ExpressionAttributeValues: map[string]types.AttributeValue{":pk": &types.AttributeValueMemberS{Value: pk}},
+				ExclusiveStartKey:         lastKey,
+			})
+			if err != nil {
+				return res, fmt.Errorf("query starters %s: %w", pk, err)
+			}
+			for _, it := range page.Items {
+				if sk := getStr(it, "PlayerID"); sk != "" && !keep[[2]string{pk, sk}] {
+					reqs = append(reqs, types.WriteRequest{DeleteRequest: &types.DeleteRequest{Key: map[string]types.AttributeValue{
+						"SeasonTeam": &types.AttributeValueMemberS{Value: pk},
+						"PlayerID":   &types.AttributeValueMemberS{Value: sk},
+					}}})
+				}
+			}
+			if len(page.LastEvaluatedKey) == 0 {
+				break
+			}
+			lastKey = page.LastEvaluatedKey
+		}
+	}
+	res.Deletes = len(reqs) - res.Upserts
+	var err error
+	res.Write, err = NewBatchWriter(ddb, "SeasonTeam", "PlayerID").Write(ctx, table, reqs)
+	return res, err
+}
@@ -0,0 +1,186 @@
+package streamsim
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"github.com/tyler180/fantasy-football-backends/internal/pfr"
+	"github.com/tyler180/fantasy-football-backends/internal/store"
+)
+
+// Simulator produces DynamoDB Streams records (NEW_AND_OLD_IMAGES) for writes to
+// the snaps table, so the stream consumer can be exercised locally and in tests
+// without a real stream. It remembers item images to emit INSERT vs MODIFY the same
+// way DynamoDB does.
+type Simulator struct {
+	SourceARN string
+	seq       int64
+	images    map[string]map[string]events.DynamoDBAttributeValue
+}
+
+// New returns a simulator for the defensive_snaps_by_game stream.
+func New() *Simulator {
+	return &Simulator{
+		SourceARN: "arn:aws:dynamodb:us-west-2:000000000000:table/defensive_snaps_by_game/stream/local",
+		images:    map[string]map[string]events.DynamoDBAttributeValue{},
+	}
+}
+
+// Put simulates PutItem for each row and returns the resulting stream records.
+// Re-putting an identical item still emits MODIFY, as DynamoDB does.
+func (s *Simulator) Put(rows ...pfr.SnapGameRow) []events.DynamoDBEventRecord {
+	recs := make([]events.DynamoDBEventRecord, 0, len(rows))
+	for _, r := range rows {
+		img := FromSDK(store.SnapItem(r))
+		k := itemKey(r)
+		old, existed := s.images[k]
+		s.images[k] = img
+
+		name := events.DynamoDBOperationTypeInsert
+		if existed {
+			name = events.DynamoDBOperationTypeModify
+		}
+		recs = append(recs, s.record(name, keysOf(img), img, old))
+	}
+	return recs
+}
+
+// Delete simulates DeleteItem for each row that exists and returns REMOVE records.
+func (s *Simulator) Delete(rows ...pfr.SnapGameRow) []events.DynamoDBEventRecord {
+	var recs []events.DynamoDBEventRecord
+	for _, r := range rows {
+		k := itemKey(r)
+		old, ok := s.images[k]
+		if !ok {
+			continue
+		}
+		delete(s.images, k)
+		recs = append(recs, s.record(events.DynamoDBOperationTypeRemove, keysOf(old), nil, old))
+	}
+	return recs
+}
+
+// Event wraps records from one or more Put/Delete calls into a Lambda event.
+func Event(batches ...[]events.DynamoDBEventRecord) events.DynamoDBEvent {
+	var ev events.DynamoDBEvent
+	for _, b := range batches {
+		ev.Records = append(ev.Records, b...)
+	}
+	return ev
+}
+
+func (s *Simulator) record(op events.DynamoDBOperationType, keys, newImg, oldImg map[string]events.DynamoDBAttributeValue) events.DynamoDBEventRecord {
+	s.seq++
+	return events.DynamoDBEventRecord{
+		AWSRegion:      "us-west-2",
+		EventID:        fmt.Sprintf("sim-%d", s.seq),
+		EventName:      string(op),
+		EventSource:    "aws:dynamodb",
+		EventVersion:   "1.1",
+		EventSourceArn: s.SourceARN,
+		Change: events.DynamoDBStreamRecord{
+			ApproximateCreationDateTime: events.SecondsEpochTime{Time: time.Now()},
+			Keys:                        keys,
+			NewImage:                    newImg,
+			OldImage:                    oldImg,
+			SequenceNumber:              fmt.Sprintf("%021d", s.seq),
+			StreamViewType:              "NEW_AND_OLD_IMAGES",
+		},
+	}
+}
+
+func itemKey(r pfr.SnapGameRow) string {
+	return fmt.Sprintf("%s#%s#%02d|%s", r.Season, r.Team, r.Week, r.PlayerID)
+}
+
+func keysOf(img map[string]events.DynamoDBAttributeValue) map[string]events.DynamoDBAttributeValue {
+	keys := map[string]events.DynamoDBAttributeValue{}
+	for _, k := range []string{"SeasonTeamWeek", "PlayerID"} {
+		if v, ok := img[k]; ok {
+			keys[k] = v
+		}
+	}
+	return keys
+}
+
+// FromSDK converts an SDK item into the stream event attribute representation.
+func FromSDK(item map[string]types.AttributeValue) map[string]events.DynamoDBAttributeValue {
+	out := make(map[string]events.DynamoDBAttributeValue, len(item))
+	for k, v := range item {
+		out[k] = fromSDKValue(v)
+	}
+	return out
+}
+
+func fromSDKValue(v types.AttributeValue) events.DynamoDBAttributeValue {
+	switch t := v.(type) {
+	case *types.AttributeValueMemberS:
+		return events.NewStringAttribute(t.Value)
+	case *types.AttributeValueMemberN:
+		return events.NewNumberAttribute(t.Value)
+	case *types.AttributeValueMemberBOOL:
+		return events.NewBooleanAttribute(t.Value)
+	case *types.AttributeValueMemberB:
+		return events.NewBinaryAttribute(t.Value)
+	case *types.AttributeValueMemberSS:
+		return events.NewStringSetAttribute(t.Value)
+	case *types.AttributeValueMemberNS:
+		return events.NewNumberSetAttribute(t.Value)
+	case *types.AttributeValueMemberL:
+		l := make([]events.DynamoDBAttributeValue, 0, len(t.Value))
+		for _, e := range t.Value {
+			l = append(l, fromSDKValue(e))
+		}
+		return events.NewListAttribute(l)
+	case *types.AttributeValueMemberM:
+		m := make(map[string]events.DynamoDBAttributeValue, len(t.Value))
+		for k, e := range t.Value {
+			m[k] = fromSDKValue(e)
+		}
+		return events.NewMapAttribute(m)
+	default:
+		return events.NewNullAttribute()
+	}
+}
+
+// Rows returns the simulated table contents as snap rows sorted by key, so tests can
+// seed a fake table with exactly what the stream reported.
+func (s *Simulator) Rows() []pfr.SnapGameRow {
+	keys := make([]string, 0, len(s.images))
+	for k := range s.images {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	out := make([]pfr.SnapGameRow, 0, len(keys))
+	for _, k := range keys {
+		img := s.images[k]
+		str := func(a string) string {
+			if v, ok := img[a]; ok && v.DataType() == events.DataTypeString {
+				return v.String()
+			}
+			return ""
+		}
+		num := func(a string) float64 {
+			if v, ok := img[a]; ok && v.DataType() == events.DataTypeNumber {
+				f, _ := strconv.ParseFloat(v.Number(), 64)
+				return f
+			}
+			return 0
+		}
+		out = append(out, pfr.SnapGameRow{
+			Season:     str("Season"),
+			Team:       str("Team"),
+			Week:       int(num("Week")),
+			PlayerID:   str("PlayerID"),
+			Player:     str("Player"),
+			Pos:        str("Pos"),
+			DefSnapPct: num("DefSnapPct"),
+		})
+	}
+	return out
+}
@@ -0,0 +1,56 @@
+package trade
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Report is a trade evaluation with its header.
+type Report struct {
+	League      string    `json:"league"`
+	Franchise   string    `json:"franchise"`
+	Season      string    `json:"season"`
+	Weeks       []int     `json:"weeks"`
+	GeneratedAt time.Time `json:"generated_at"`
+	Result
+}
+
+// Markdown renders the verdict, the per-player breakdown and the group deltas.
+func (r Report) Markdown() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Trade: %s (%s), %s\n\n", r.League, r.Franchise, r.Season)
+	weeks := "none"
+	if n := len(r.Weeks); n > 0 {
+		weeks = fmt.Sprintf("weeks %d-%d", r.Weeks[0], r.Weeks[n-1])
+	}
+	fmt.Fprintf(&b, "_Generated %s over %s._\n\n", r.GeneratedAt.Format(time.RFC3339), weeks)
+	fmt.Fprintf(&b, "**Verdict: %s** (%+.2f starting points rest of season: %.2f -> %.2f)\n\n",
+		strings.ToUpper(r.Verdict), r.Delta, r.Before, r.After)
+	for _, n := range r.Notes {
+		fmt.Fprintf(&b, "- %s\n", n)
+	}
+	if len(r.Notes) > 0 {
+		b.WriteString("\n")
+	}
+
+	b.WriteString("## Players\n\n| Side | Player | Pos | Team | Games | Per game | Schedule | ROS pts | Over repl. | Lineup pts |\n" +
+		"|---|---|---|---|---:|---:|---:|---:|---:|---:|\n")
+	for _, p := range r.Players {
+		fmt.Fprintf(&b, "| %s | %s | %s | %s | %d | %.2f | %.2f | %.2f | %+.2f | %.2f |\n",
+			p.Side, strings.ReplaceAll(p.Name, "|", `\|`), p.Position, p.Team, p.Games, p.PerGame, p.Schedule,
+			p.ROSPoints, p.OverReplacement, p.LineupPoints)
+	}
+
+	groups := make([]string, 0, len(r.ByGroup))
+	for g := range r.ByGroup {
+		groups = append(groups, g)
+	}
+	sort.Strings(groups)
+	b.WriteString("\n## Starting points by group\n\n| Group | Delta |\n|---|---:|\n")
+	for _, g := range groups {
+		fmt.Fprintf(&b, "| %s | %+.2f |\n", g, r.ByGroup[g])
+	}
+	return b.String()
+}
@@ -0,0 +1,243 @@
+// Package trade values a proposed trade by what it does to our starting lineup over
+// the rest of the season. Every remaining week, the optimal lineup (lineup.Best) is
+// solved for the roster before and after the trade, with each player's projection
+// scaled by that week's matchup and zeroed on a bye. Positional scarcity falls out of
+// the league's starting requirements: a player only adds value by starting.
+package trade
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/tyler180/fantasy-football-backends/internal/lineup"
+	"github.com/tyler180/fantasy-football-backends/internal/starters"
+)
+
+// Player is a rostered or incoming player.
+type Player struct {
+	ID       string  `json:"id"`
+	Name     string  `json:"name"`
+	Position string  `json:"position"`
+	Team     string  `json:"team"`
+	PerGame  float64 `json:"per_game"` // projected points against a league-average opponent
+	ByeWeek  int     `json:"bye_week,omitempty"`
+	Injury   string  `json:"injury,omitempty"`
+}
+
+// Input is a proposed trade against our roster.
+type Input struct {
+	Roster []Player // our roster today, including the players we give
+	Give   []string // ids leaving
+	Get    []Player // players arriving
+	Weeks  []int    // remaining weeks to value
+	Rules  lineup.Rules
+	// Matchup scales PerGame for p's game in week (1 = neutral, 0 = bye). Nil uses 1
+	// except on ByeWeek.
+	Matchup func(p Player, week int) float64
+	// Replacement is the per-game points of the best available free agent by group
+	// (DL/LB/DB), the baseline for points over replacement.
+	Replacement map[string]float64
+}
+
+// Options tune the verdict.
+type Options struct {
+	// Margin is the rest-of-season lineup delta, in points, inside which the trade is
+	// called even (default 3).
+	Margin float64
+}
+
+// PlayerValue is one traded player's rest-of-season breakdown.
+type PlayerValue struct {
+	Player
+	Side            string  `json:"side"` // "give" or "get"
+	Games           int     `json:"games"`
+	ROSPoints       float64 `json:"ros_points"`       // matchup-adjusted points over the remaining weeks
+	OverReplacement float64 `json:"over_replacement"` // ROSPoints less a replacement free agent's
+	LineupPoints    float64 `json:"lineup_points"`    // what that side's lineup loses without the player
+	Schedule        float64 `json:"schedule"`         // mean matchup factor over games played (1 = neutral)
+}
+
+// Result is the verdict and its breakdown.
+type Result struct {
+	Verdict      string             `json:"verdict"` // "accept", "decline" or "even"
+	Before       float64            `json:"before"`  // rest-of-season starting points today
+	After        float64            `json:"after"`   // ... after the trade
+	Delta        float64            `json:"delta"`
+	ByGroup      map[string]float64 `json:"by_group"` // starting points delta by position group
+	Players      []PlayerValue      `json:"players"`
+	RosterChange int                `json:"roster_change"` // players gained (+) or lost (-)
+	Notes        []string           `json:"notes,omitempty"`
+}
+
+// Evaluate values the trade.
+func Evaluate(in Input, opt Options) (Result, error) {
+	if opt.Margin <= 0 {
+		opt.Margin = 3
+	}
+	give := map[string]bool{}
+	for _, id := range in.Give {
+		give[id] = true
+	}
+	var after []Player
+	found := map[string]bool{}
+	for _, p := range in.Roster {
+		if give[p.ID] {
+			found[p.ID] = true
+			continue
+		}
+		after = append(after, p)
+	}
+	for _, id := range in.Give {
+		if !found[id] {
+			return Result{}, fmt.Errorf("player %s is not on our roster", id)
+		}
+	}
+	for _, p := range in.Get {
+		for _, r := range in.Roster {
+			if r.ID == p.ID {
+				return Result{}, fmt.Errorf("player %s is already on our roster", p.ID)
+			}
+		}
+	}
+	after = append(after, in.Get...)
+	if len(in.Weeks) == 0 {
+		return Result{}, fmt.Errorf("no remaining weeks to value")
+	}
+
+	matchup := in.Matchup
+	if matchup == nil {
+		matchup = func(p Player, week int) float64 {
+			if p.ByeWeek == week {
+				return 0
+			}
+			return 1
+		}
+	}
+	season := func(roster []Player) (float64, map[string]float64) {
+		var total float64
+		byGroup := map[string]float64{}
+		for i, w := range in.Weeks {
+			lopt := lineup.DefaultOptions()
+			lopt.Week = w
+			res := lineup.Best(weekPlayers(roster, w, i == 0, matchup), in.Rules, lopt)
+			total += res.Total
+			for _, s := range res.Starters {
+				byGroup[groupOf(s.Player.Position)] += s.Expected
+			}
+		}
+		return total, byGroup
+	}
+	without := func(roster []Player, id string) []Player {
+		out := make([]Player, 0, len(roster))
+		for _, p := range roster {
+			if p.ID != id {
+				out = append(out, p)
+			}
+		}
+		return out
+	}
+
+	res := Result{RosterChange: len(in.Get) - len(in.Give), ByGroup: map[string]float64{}}
+	before, bgBefore := season(in.Roster)
+	afterTotal, bgAfter := season(after)
+	res.Before, res.After, res.Delta = round2(before), round2(afterTotal), round2(afterTotal-before)
+	for g, v := range bgAfter {
+		res.ByGroup[g] += v
+	}
+	for g, v := range bgBefore {
+		res.ByGroup[g] -= v
+	}
+	for g, v := range res.ByGroup {
+		res.ByGroup[g] = round2(v)
+	}
+
+	value := func(p Player, side string, roster []Player, total float64) PlayerValue {
+		v := PlayerValue{Player: p, Side: side}
+		var mSum float64
+		for _, w := range in.Weeks {
+			m := matchup(p, w)
+			if m > 0 {
+				v.Games++
+				mSum += m
+			}
+			v.ROSPoints += p.PerGame * m
+		}
+		if v.Games > 0 {
+			v.Schedule = round2(mSum / float64(v.Games))
+		}
+		v.OverReplacement = round2(v.ROSPoints - in.Replacement[groupOf(p.Position)]*mSum)
+		v.ROSPoints = round2(v.ROSPoints)
+		rest, _ := season(without(roster, p.ID))
+		v.LineupPoints = round2(total - rest)
+		return v
+	}
+	for _, p := range in.Roster {
+		if give[p.ID] {
+			res.Players = append(res.Players, value(p, "give", in.Roster, before))
+		}
+	}
+	for _, p := range in.Get {
+		res.Players = append(res.Players, value(p, "get", after, afterTotal))
+	}
+
+	switch {
+	case res.Delta >= opt.Margin:
+		res.Verdict = "accept"
+	case res.Delta <= -opt.Margin:
+		res.Verdict = "decline"
+	default:
+		res.Verdict = "even"
+	}
+	if res.RosterChange > 0 {
+		res.Notes = append(res.Notes, fmt.Sprintf("roster grows by %d; a drop is needed", res.RosterChange))
+	}
+	for _, pv := range res.Players {
+		if pv.Side == "get" && pv.LineupPoints == 0 {
+			res.Notes = append(res.Notes, fmt.Sprintf("%s would not crack our lineup", pv.Name))
+		}
+		if st := strings.TrimSpace(pv.Injury); st != "" {
+			res.Notes = append(res.Notes, fmt.Sprintf("%s is listed %s", pv.Name, st))
+		}
+	}
+	sort.SliceStable(res.Players, func(i, j int) bool {
+		if res.Players[i].Side != res.Players[j].Side {
+			return res.Players[i].Side == "give"
+		}
+		return res.Players[i].LineupPoints > res.Players[j].LineupPoints
+	})
+	return res, nil
+}
+
+// seasonLong are the injury statuses that keep a player out past the coming game.
+var seasonLong = map[string]bool{"IR": true, "IR-R": true, "IR-PUP": true, "IR-NFI": true, "SUSPENDED": true}
+
+// weekPlayers converts the roster to lineup players for one week. Game statuses
+// (Questionable, Doubtful, Out) describe the coming game only, so they apply to the
+// first week; IR and suspensions apply to every week.
+func weekPlayers(roster []Player, week int, first bool, matchup func(Player, int) float64) []lineup.Player {
+	out := make([]lineup.Player, 0, len(roster))
+	for _, p := range roster {
+		lp := lineup.Player{ID: p.ID, Name: p.Name, Position: p.Position}
+		if first || seasonLong[strings.ToUpper(strings.TrimSpace(p.Injury))] {
+			lp.Injury = p.Injury
+		}
+		if m := matchup(p, week); m > 0 {
+			lp.Projection = p.PerGame * m
+		} else {
+			lp.ByeWeek = week
+		}
+		out = append(out, lp)
+	}
+	return out
+}
+
+func groupOf(pos string) string {
+	if g := starters.GroupOf(pos); g != "" {
+		return g
+	}
+	return strings.ToUpper(pos)
+}
+
+func round2(v float64) float64 { return math.Round(v*100) / 100 }
@@ -0,0 +1,115 @@
+package trade
+
+import (
+	"testing"
+
+	"github.com/tyler180/fantasy-football-backends/internal/lineup"
+)
+
+// One LB and one DB start; no flex.
+var rules = lineup.Rules{Slots: []lineup.Slot{
+	{Name: "LB", Eligible: []string{"LB"}, Min: 1, Max: 1},
+	{Name: "DB", Eligible: []string{"DB"}, Min: 1, Max: 1},
+}}
+
+func roster() []Player {
+	return []Player{
+		{ID: "lb1", Name: "LB One", Position: "LB", PerGame: 10},
+		{ID: "lb2", Name: "LB Two", Position: "LB", PerGame: 8},
+		{ID: "cb1", Name: "CB One", Position: "CB", PerGame: 4},
+	}
+}
+
+func TestEvaluate_ScarcityDecides(t *testing.T) {
+	// Trading our bench LB for a better DB helps; the LB was not starting.
+	res, err := Evaluate(Input{
+		Roster: roster(), Give: []string{"lb2"},
+		Get:   []Player{{ID: "s1", Name: "S One", Position: "S", PerGame: 7}},
+		Weeks: []int{10, 11}, Rules: rules,
+		Replacement: map[string]float64{"DB": 3, "LB": 6},
+	}, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Verdict != "accept" || res.Delta != 6 || res.ByGroup["DB"] != 6 || res.ByGroup["LB"] != 0 {
+		t.Fatalf("res = %+v", res)
+	}
+	give, get := res.Players[0], res.Players[1]
+	if give.Side != "give" || give.LineupPoints != 0 || give.ROSPoints != 16 || give.OverReplacement != 4 {
+		t.Fatalf("give = %+v", give)
+	}
+	if get.Side != "get" || get.LineupPoints != 6 || get.ROSPoints != 14 {
+		t.Fatalf("get = %+v", get)
+	}
+
+	// The same swap for our starting LB when the DB is no better than ours loses points.
+	res, _ = Evaluate(Input{
+		Roster: roster(), Give: []string{"lb1"},
+		Get:   []Player{{ID: "s2", Name: "S Two", Position: "S", PerGame: 4}},
+		Weeks: []int{10, 11}, Rules: rules,
+	}, Options{})
+	if res.Verdict != "decline" || res.Delta != -4 {
+		t.Fatalf("res = %+v", res)
+	}
+}
+
+func TestEvaluate_ByesAndSchedule(t *testing.T) {
+	matchup := func(p Player, week int) float64 {
+		switch {
+		case p.ID == "s1" && week == 10:
+			return 0 // bye
+		case p.ID == "s1":
+			return 1.5
+		}
+		return 1
+	}
+	res, err := Evaluate(Input{
+		Roster: roster(), Give: []string{"lb2"},
+		Get:   []Player{{ID: "s1", Name: "S One", Position: "S", PerGame: 6}},
+		Weeks: []int{10, 11}, Rules: rules, Matchup: matchup,
+	}, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Week 10 the CB still starts (4); week 11 the safety scores 9 instead of 4.
+	if res.Delta != 5 || res.Verdict != "accept" {
+		t.Fatalf("res = %+v", res)
+	}
+	if get := res.Players[1]; get.Games != 1 || get.Schedule != 1.5 || get.ROSPoints != 9 {
+		t.Fatalf("get = %+v", get)
+	}
+}
+
+func TestEvaluate_Errors(t *testing.T) {
+	if _, err := Evaluate(Input{Roster: roster(), Give: []string{"nope"}, Weeks: []int{1}, Rules: rules}, Options{}); err == nil {
+		t.Fatal("giving a player we do not have should fail")
+	}
+	if _, err := Evaluate(Input{Roster: roster(), Get: []Player{{ID: "lb1"}}, Weeks: []int{1}, Rules: rules}, Options{}); err == nil {
+		t.Fatal("getting a player we already have should fail")
+	}
+}
+
+func TestEvaluate_InjuryStatuses(t *testing.T) {
+	eval := func(injury string) Result {
+		t.Helper()
+		res, err := Evaluate(Input{
+			Roster: roster(), Give: []string{"lb2"},
+			Get:   []Player{{ID: "s1", Name: "S One", Position: "S", PerGame: 7, Injury: injury}},
+			Weeks: []int{10, 11}, Rules: rules,
+		}, Options{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return res
+	}
+	// Questionable discounts the coming game only: 7*0.85-4 + 7-4.
+	if res := eval("Questionable"); res.Delta != 4.95 {
+		t.Errorf("questionable delta = %v, want 4.95", res.Delta)
+	}
+	// IR keeps the safety out of every remaining week, so the CB keeps starting.
+	for _, st := range []string{"IR", "ir-r", "Suspended"} {
+		if res := eval(st); res.Delta != 0 {
+			t.Errorf("%s delta = %v, want 0", st, res.Delta)
+		}
+	}
+}
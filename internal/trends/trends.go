@@ -0,0 +1,134 @@
+package trends
+
+import "math"
+
+// Point is one game in a player's season: the week and the DEF% for that game.
+type Point struct {
+	Week int
+	Pct  float64
+}
+
+// Options tune the derived metrics. Zero values select the defaults.
+type Options struct {
+	Alpha     float64 // EWMA smoothing factor in (0,1] (default 0.4)
+	StdWindow int     // games in the rolling standard deviation (default 4)
+	StreakPct float64 // DEF% a game must reach to extend a streak (default 70)
+	RolePct   float64 // DEF% of the first "real role" game (default 50)
+}
+
+func (o Options) withDefaults() Options {
+	if o.Alpha <= 0 || o.Alpha > 1 {
+		o.Alpha = 0.4
+	}
+	if o.StdWindow <= 1 {
+		o.StdWindow = 4
+	}
+	if o.StreakPct <= 0 {
+		o.StreakPct = 70
+	}
+	if o.RolePct <= 0 {
+		o.RolePct = 50
+	}
+	return o
+}
+
+// Metrics are the snap-share trend attributes stored on each player item.
+type Metrics struct {
+	Last    float64 // last game DEF%
+	Slope3  float64 // slope over last 3 games
+	Slope5  float64 // slope over last 5 games
+	Change3 float64 // last - avg of prior 2 (in a 3-window)
+
+	Series     []Point // every game in week order
+	EWMA       float64 // exponentially weighted mean, most recent game weighted Alpha
+	SeasonAvg  float64
+	RollingStd float64 // population std over the last StdWindow games
+	MaxStreak  int     // longest run of consecutive games at or above StreakPct
+	// WeeksSinceRole is the number of weeks from the first game at or above RolePct to
+	// the latest game (0 when that first game is the latest); -1 if there is none.
+	WeeksSinceRole int
+}
+
+// Compute derives trend metrics from a player's games, which must be in week order.
+// An empty series yields zero metrics with WeeksSinceRole -1.
+func Compute(pts []Point, opt Options) Metrics {
+	opt = opt.withDefaults()
+	m := Metrics{WeeksSinceRole: -1}
+	if len(pts) == 0 {
+		return m
+	}
+	vals := make([]float64, len(pts))
+	for i, p := range pts {
+		vals[i] = p.Pct
+	}
+	m.Series = append([]Point(nil), pts...)
+	m.Last = vals[len(vals)-1]
+	if len(vals) >= 3 {
+		m.Slope3 = Slope(vals[len(vals)-3:])
+		base := (vals[len(vals)-3] + vals[len(vals)-2]) / 2.0
+		m.Change3 = m.Last - base
+	}
+	if len(vals) >= 5 {
+		m.Slope5 = Slope(vals[len(vals)-5:])
+	}
+
+	m.EWMA = vals[0]
+	var sum float64
+	streak := 0
+	for i, v := range vals {
+		if i > 0 {
+			m.EWMA = opt.Alpha*v + (1-opt.Alpha)*m.EWMA
+		}
+		sum += v
+		if v >= opt.StreakPct {
+			streak++
+			m.MaxStreak = max(m.MaxStreak, streak)
+		} else {
+			streak = 0
+		}
+		if m.WeeksSinceRole < 0 && v >= opt.RolePct {
+			m.WeeksSinceRole = pts[len(pts)-1].Week - pts[i].Week
+		}
+	}
+	m.SeasonAvg = sum / float64(len(vals))
+	m.RollingStd = StdDev(vals[max(0, len(vals)-opt.StdWindow):])
+	return m
+}
+
+// Slope is the least-squares slope of vals against x = 1..n.
+func Slope(vals []float64) float64 {
+	n := float64(len(vals))
+	if n < 2 {
+		return 0
+	}
+	var sx, sy, sxx, sxy float64
+	for i, y := range vals {
+		x := float64(i + 1)
+		sx += x
+		sy += y
+		sxx += x * x
+		sxy += x * y
+	}
+	den := n*sxx - sx*sx
+	if den == 0 {
+		return 0
+	}
+	return (n*sxy - sx*sy) / den
+}
+
+// StdDev is the population standard deviation of vals (0 for fewer than 2 values).
+func StdDev(vals []float64) float64 {
+	if len(vals) < 2 {
+		return 0
+	}
+	var mean float64
+	for _, v := range vals {
+		mean += v
+	}
+	mean /= float64(len(vals))
+	var ss float64
+	for _, v := range vals {
+		ss += (v - mean) * (v - mean)
+	}
+	return math.Sqrt(ss / float64(len(vals)))
+}
@@ -0,0 +1,50 @@
+package trends
+
+import (
+	"math"
+	"testing"
+)
+
+func near(a, b float64) bool { return math.Abs(a-b) < 1e-9 }
+
+func TestCompute_SeriesMetrics(t *testing.T) {
+	// Week 5 is a bye; the role starts in week 3.
+	pts := []Point{{1, 20}, {2, 40}, {3, 55}, {4, 72}, {6, 80}, {7, 65}, {8, 75}}
+	m := Compute(pts, Options{Alpha: 0.5, StdWindow: 3})
+
+	if m.Last != 75 || len(m.Series) != 7 {
+		t.Fatalf("last=%v series=%d", m.Last, len(m.Series))
+	}
+	if !near(m.SeasonAvg, 407.0/7) {
+		t.Fatalf("season avg = %v, want %v", m.SeasonAvg, 407.0/7)
+	}
+	if m.MaxStreak != 2 {
+		t.Fatalf("max streak = %d, want 2 (weeks 4 and 6)", m.MaxStreak)
+	}
+	if m.WeeksSinceRole != 5 {
+		t.Fatalf("weeks since first 50%% game = %d, want 5", m.WeeksSinceRole)
+	}
+	ewma := 20.0
+	for _, p := range pts[1:] {
+		ewma = 0.5*p.Pct + 0.5*ewma
+	}
+	if !near(m.EWMA, ewma) {
+		t.Fatalf("ewma = %v, want %v", m.EWMA, ewma)
+	}
+	if !near(m.RollingStd, StdDev([]float64{80, 65, 75})) {
+		t.Fatalf("rolling std = %v", m.RollingStd)
+	}
+	if !near(m.Change3, 75-(80+65)/2.0) {
+		t.Fatalf("change3 = %v", m.Change3)
+	}
+}
+
+func TestCompute_NoRole(t *testing.T) {
+	m := Compute([]Point{{1, 10}, {2, 30}}, Options{})
+	if m.WeeksSinceRole != -1 || m.MaxStreak != 0 {
+		t.Fatalf("unexpected metrics %+v", m)
+	}
+	if m := Compute(nil, Options{}); m.WeeksSinceRole != -1 || m.Series != nil {
+		t.Fatalf("empty series metrics %+v", m)
+	}
+}
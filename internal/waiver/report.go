@@ -0,0 +1,81 @@
+package waiver
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Report is the weekly waiver-wire output.
+type Report struct {
+	League          string           `json:"league"`
+	Franchise       string           `json:"franchise"`
+	Season          string           `json:"season"`
+	Week            int              `json:"week,omitempty"`
+	GeneratedAt     time.Time        `json:"generated_at"`
+	PoolSize        int              `json:"pool_size"`
+	Needs           []Need           `json:"needs"`
+	Recommendations []Recommendation `json:"recommendations"`
+}
+
+// NewReport ranks the pool and fills in the header fields.
+func NewReport(league, franchise, season string, pool, roster []Candidate, slots map[string]int, opt Options, now time.Time) Report {
+	needs := Needs(slots, roster, opt.ReliablePct)
+	r := Report{
+		League:          league,
+		Franchise:       franchise,
+		Season:          season,
+		Week:            opt.Week,
+		GeneratedAt:     now.UTC(),
+		PoolSize:        len(pool),
+		Recommendations: Rank(pool, needs, opt),
+	}
+	for _, nd := range needs {
+		r.Needs = append(r.Needs, nd)
+	}
+	sort.Slice(r.Needs, func(i, j int) bool {
+		if r.Needs[i].Score != r.Needs[j].Score {
+			return r.Needs[i].Score > r.Needs[j].Score
+		}
+		return r.Needs[i].Group < r.Needs[j].Group
+	})
+	return r
+}
+
+// Markdown renders the report for humans: needs, the ranked table, then the reasons.
+func (r Report) Markdown() string {
+	var b strings.Builder
+	title := fmt.Sprintf("# Waiver wire: %s (%s), %s", r.League, r.Franchise, r.Season)
+	if r.Week > 0 {
+		title += fmt.Sprintf(" week %d", r.Week)
+	}
+	fmt.Fprintf(&b, "%s\n\n_Generated %s from %d free agents._\n\n", title, r.GeneratedAt.Format(time.RFC3339), r.PoolSize)
+
+	b.WriteString("## Position needs\n\n| Group | Slots | Rostered | Reliable | Need |\n|---|---:|---:|---:|---:|\n")
+	for _, nd := range r.Needs {
+		fmt.Fprintf(&b, "| %s | %d | %d | %d | %.2f |\n", nd.Group, nd.Slots, nd.Rostered, nd.Reliable, nd.Score)
+	}
+
+	b.WriteString("\n## Recommendations\n\n")
+	if len(r.Recommendations) == 0 {
+		b.WriteString("No free agent with a rising snap share this week.\n")
+		return b.String()
+	}
+	b.WriteString("| # | Player | Pos | Team | Last % | Slope3 | Change3 | Score |\n|---:|---|---|---|---:|---:|---:|---:|\n")
+	for _, rec := range r.Recommendations {
+		c := rec.Candidate
+		fmt.Fprintf(&b, "| %d | %s | %s | %s | %.0f | %+.1f | %+.1f | %.3f |\n",
+			rec.Rank, mdEscape(c.Name), c.Position, c.Team, c.Last, c.Slope3, c.Change3, rec.Score)
+	}
+	b.WriteString("\n## Why\n")
+	for _, rec := range r.Recommendations {
+		fmt.Fprintf(&b, "\n**%d. %s** (%s, %s)\n", rec.Rank, mdEscape(rec.Candidate.Name), rec.Candidate.Position, rec.Candidate.Team)
+		for _, why := range rec.Reasons {
+			fmt.Fprintf(&b, "- %s\n", why)
+		}
+	}
+	return b.String()
+}
+
+func mdEscape(s string) string { return strings.ReplaceAll(s, "|", `\|`) }
@@ -0,0 +1,171 @@
+// Package waiver ranks unrostered defenders whose snap share is rising, weighted by
+// how badly our franchise needs their position group, and explains each ranking.
+package waiver
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/tyler180/fantasy-football-backends/internal/mfl"
+	"github.com/tyler180/fantasy-football-backends/internal/starters"
+)
+
+// Candidate is a player with the snap-trend attributes the ranking reads. The same
+// shape describes our own rostered players when computing needs.
+type Candidate struct {
+	PlayerID     string  `json:"player_id"` // MFL id
+	PFRID        string  `json:"pfr_id,omitempty"`
+	Name         string  `json:"name"`
+	Position     string  `json:"position"`
+	Group        string  `json:"group"`
+	Team         string  `json:"team"`
+	ByeWeek      int     `json:"bye_week,omitempty"`
+	HasTrends    bool    `json:"has_trends"`
+	Last         float64 `json:"def_snap_pct_last"`
+	Slope3       float64 `json:"def_snap_pct_slope3"`
+	Change3      float64 `json:"def_snap_pct_change3"`
+	EWMA         float64 `json:"def_snap_pct_ewma"`
+	WeeksSince50 int     `json:"weeks_since_50"`
+	FantasyPPG   float64 `json:"fantasy_ppg,omitempty"`
+}
+
+// Need is how thin our roster is at one position group.
+type Need struct {
+	Group    string  `json:"group"`
+	Slots    int     `json:"slots"`    // max lineup spots for the group
+	Rostered int     `json:"rostered"` // our players in the group
+	Reliable int     `json:"reliable"` // of those, players at or above Options.ReliablePct
+	Score    float64 `json:"score"`    // 0 (covered) .. 1 (no reliable starter)
+}
+
+// Options tune filtering and weights.
+type Options struct {
+	MinLast     float64 // minimum last-game DEF%
+	MinSlope    float64 // Slope3 must exceed this (rising)
+	ReliablePct float64 // DEF% that counts a rostered player as a reliable starter
+	WLast       float64
+	WSlope      float64
+	WChange     float64
+	WNeed       float64 // need boost: score *= 1 + WNeed*need
+	ByePenalty  float64 // fraction removed when the player is on bye in Week
+	Week        int     // upcoming week; 0 skips the bye check
+	Top         int     // 0 keeps all
+}
+
+// DefaultOptions favors current role, then momentum.
+func DefaultOptions() Options {
+	return Options{
+		MinLast: 20, MinSlope: 0, ReliablePct: 65,
+		WLast: 0.4, WSlope: 0.35, WChange: 0.25, WNeed: 0.5,
+		ByePenalty: 0.1, Top: 25,
+	}
+}
+
+// SlotsByGroup sums the maximum lineup spots per position group from the league's
+// starter settings. Offensive slots are ignored.
+func SlotsByGroup(slots []mfl.StarterSlot) map[string]int {
+	out := map[string]int{}
+	for _, s := range slots {
+		if g := starters.GroupOf(s.Position); g != "" {
+			out[g] += max(s.Max, s.Min)
+		}
+	}
+	return out
+}
+
+// Needs compares lineup slots with our rostered players. A group with no slots has
+// no need; otherwise need is the share of slots without a reliable starter.
+func Needs(slots map[string]int, roster []Candidate, reliablePct float64) map[string]Need {
+	out := make(map[string]Need, len(slots))
+	for g, n := range slots {
+		out[g] = Need{Group: g, Slots: n}
+	}
+	for _, c := range roster {
+		nd, ok := out[c.Group]
+		if !ok {
+			continue
+		}
+		nd.Rostered++
+		if c.HasTrends && c.Last >= reliablePct {
+			nd.Reliable++
+		}
+		out[c.Group] = nd
+	}
+	for g, nd := range out {
+		if nd.Slots > 0 {
+			nd.Score = round3(clamp(1-float64(nd.Reliable)/float64(nd.Slots), 0, 1))
+		}
+		out[g] = nd
+	}
+	return out
+}
+
+// Recommendation is one ranked candidate.
+type Recommendation struct {
+	Rank      int       `json:"rank"`
+	Score     float64   `json:"score"`
+	Candidate Candidate `json:"candidate"`
+	Reasons   []string  `json:"reasons"`
+}
+
+// Rank filters to rising defenders with a real role and orders them by score.
+func Rank(cands []Candidate, needs map[string]Need, opt Options) []Recommendation {
+	var out []Recommendation
+	for _, c := range cands {
+		if c.Group == "" || !c.HasTrends || c.Slope3 <= opt.MinSlope || c.Last < opt.MinLast {
+			continue
+		}
+		score, reasons := opt.score(c, needs[c.Group])
+		out = append(out, Recommendation{Score: score, Candidate: c, Reasons: reasons})
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].Score != out[j].Score {
+			return out[i].Score > out[j].Score
+		}
+		return out[i].Candidate.PlayerID < out[j].Candidate.PlayerID
+	})
+	if opt.Top > 0 && len(out) > opt.Top {
+		out = out[:opt.Top]
+	}
+	for i := range out {
+		out[i].Rank = i + 1
+	}
+	return out
+}
+
+func (opt Options) score(c Candidate, nd Need) (float64, []string) {
+	trend := opt.WLast*c.Last/100 +
+		opt.WSlope*clamp(c.Slope3/10, -1, 1) +
+		opt.WChange*clamp(c.Change3/25, -1, 1)
+	score := trend * (1 + opt.WNeed*nd.Score)
+
+	reasons := []string{
+		fmt.Sprintf("Played %.0f%% of defensive snaps last game", c.Last),
+		fmt.Sprintf("Snap share rising %+.1f pts per game over the last 3", c.Slope3),
+	}
+	if c.Change3 >= 10 {
+		reasons = append(reasons, fmt.Sprintf("Up %.0f pts on the average of the two games before", c.Change3))
+	}
+	switch {
+	case nd.Slots == 0:
+	case nd.Score > 0:
+		reasons = append(reasons, fmt.Sprintf("Fills a %s need: %d reliable starter(s) for %d lineup slot(s), %d rostered",
+			nd.Group, nd.Reliable, nd.Slots, nd.Rostered))
+	default:
+		reasons = append(reasons, fmt.Sprintf("Depth only: %s already has %d reliable starter(s) for %d slot(s)",
+			nd.Group, nd.Reliable, nd.Slots))
+	}
+	if c.FantasyPPG > 0 {
+		reasons = append(reasons, fmt.Sprintf("%.1f IDP points per game", c.FantasyPPG))
+	}
+	if opt.Week > 0 && c.ByeWeek == opt.Week {
+		score *= 1 - opt.ByePenalty
+		reasons = append(reasons, fmt.Sprintf("On bye in week %d", c.ByeWeek))
+	}
+	return round3(score), reasons
+}
+
+func clamp(v, lo, hi float64) float64 { return math.Max(lo, math.Min(hi, v)) }
+
+func round3(v float64) float64 { return math.Round(v*1000) / 1000 }
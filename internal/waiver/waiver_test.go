@@ -0,0 +1,89 @@
+package waiver
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tyler180/fantasy-football-backends/internal/mfl"
+)
+
+func TestSlotsAndNeeds(t *testing.T) {
+	slots := SlotsByGroup([]mfl.StarterSlot{
+		{Position: "QB", Min: 1, Max: 1}, {Position: "DE", Min: 2, Max: 3}, {Position: "DT", Min: 0, Max: 1},
+		{Position: "LB", Min: 3, Max: 4}, {Position: "CB", Min: 2, Max: 2},
+	})
+	if slots["DL"] != 4 || slots["LB"] != 4 || slots["DB"] != 2 || len(slots) != 3 {
+		t.Fatalf("slots = %v", slots)
+	}
+	roster := []Candidate{
+		{Group: "LB", HasTrends: true, Last: 90}, {Group: "LB", HasTrends: true, Last: 40},
+		{Group: "DB", HasTrends: true, Last: 99}, {Group: "DB", HasTrends: true, Last: 70},
+		{Group: "DL"}, // no trends: rostered but not reliable
+	}
+	needs := Needs(slots, roster, 65)
+	if lb := needs["LB"]; lb.Rostered != 2 || lb.Reliable != 1 || lb.Score != 0.75 {
+		t.Fatalf("LB need = %+v", lb)
+	}
+	if needs["DB"].Score != 0 || needs["DL"].Score != 1 {
+		t.Fatalf("needs = %+v", needs)
+	}
+}
+
+func TestRank_FiltersScoresAndExplains(t *testing.T) {
+	needs := map[string]Need{
+		"LB": {Group: "LB", Slots: 3, Score: 1},
+		"DB": {Group: "DB", Slots: 2, Reliable: 2},
+	}
+	pool := []Candidate{
+		{PlayerID: "lb", Name: "Rising LB", Group: "LB", HasTrends: true, Last: 60, Slope3: 8, Change3: 20, ByeWeek: 9},
+		{PlayerID: "db", Name: "Rising DB", Group: "DB", HasTrends: true, Last: 60, Slope3: 8, Change3: 20},
+		{PlayerID: "flat", Group: "LB", HasTrends: true, Last: 80, Slope3: 0},
+		{PlayerID: "tiny", Group: "LB", HasTrends: true, Last: 10, Slope3: 5},
+		{PlayerID: "none", Group: "LB"},
+		{PlayerID: "wr", HasTrends: true, Last: 90, Slope3: 9},
+	}
+	opt := DefaultOptions()
+	got := Rank(pool, needs, opt)
+	if len(got) != 2 || got[0].Candidate.PlayerID != "lb" || got[0].Rank != 1 {
+		t.Fatalf("ranked = %+v", got)
+	}
+	// trend = 0.4*0.6 + 0.35*0.8 + 0.25*0.8 = 0.72; LB need boosts by 1.5
+	if got[0].Score != 1.08 || got[1].Score != 0.72 {
+		t.Fatalf("scores = %v, %v", got[0].Score, got[1].Score)
+	}
+	why := strings.Join(got[0].Reasons, "\n")
+	for _, frag := range []string{"60% of defensive snaps", "+8.0 pts per game", "Up 20 pts", "Fills a LB need"} {
+		if !strings.Contains(why, frag) {
+			t.Fatalf("reasons %q missing %q", why, frag)
+		}
+	}
+	if !strings.Contains(strings.Join(got[1].Reasons, "\n"), "Depth only") {
+		t.Fatalf("DB reasons = %v", got[1].Reasons)
+	}
+
+	opt.Week = 9
+	if byeWeek := Rank(pool, needs, opt); byeWeek[0].Score != 0.972 {
+		t.Fatalf("bye-week score = %v", byeWeek[0].Score)
+	}
+}
+
+func TestReport_JSONAndMarkdown(t *testing.T) {
+	pool := []Candidate{{PlayerID: "1", Name: "Pipe | Guy", Position: "LB", Group: "LB", Team: "SEA", HasTrends: true, Last: 55, Slope3: 4}}
+	r := NewReport("Stub", "0001", "2024", pool, nil, map[string]int{"LB": 3, "DB": 2}, DefaultOptions(),
+		time.Date(2024, 10, 1, 12, 0, 0, 0, time.UTC))
+	b, err := json.Marshal(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), `"def_snap_pct_slope3":4`) || len(r.Needs) != 2 {
+		t.Fatalf("json = %s", b)
+	}
+	md := r.Markdown()
+	for _, frag := range []string{"# Waiver wire: Stub (0001), 2024", "| DB | 2 | 0 | 0 | 1.00 |", `Pipe \| Guy`, "## Why"} {
+		if !strings.Contains(md, frag) {
+			t.Fatalf("markdown missing %q:\n%s", frag, md)
+		}
+	}
+}
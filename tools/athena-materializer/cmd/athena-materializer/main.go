@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"os"
@@ -10,16 +11,25 @@ import (
 	"time"
 
 	"github.com/aws/aws-lambda-go/lambda"
-	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
 	athena "github.com/aws/aws-sdk-go-v2/service/athena"
-	athenatypes "github.com/aws/aws-sdk-go-v2/service/athena/types"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+
+	"github.com/tyler180/fantasy-football-backends/internal/ath"
+	"github.com/tyler180/fantasy-football-backends/internal/dq"
+	"github.com/tyler180/fantasy-football-backends/internal/starters"
+	"github.com/tyler180/fantasy-football-backends/tools/athena-materializer/pkg/materializer"
 )
 
 type Event struct {
-	Season     int `json:"season"`
-	MaxAge     int `json:"max_age"`     // optional; 0 = ignore
-	StarterPct int `json:"starter_pct"` // e.g., 50
+	Season     int      `json:"season"`
+	MaxAge     int      `json:"max_age"`     // optional; overrides the starter spec
+	StarterPct int      `json:"starter_pct"` // optional; overrides the spec's min_snap_pct
+	Tables     []string `json:"tables"`      // optional; templates to run (default MATERIALIZE_TABLES, then all)
+	SkipDeps   bool     `json:"skip_deps"`   // run only the named tables, not the ones they read
+	SkipSync   bool     `json:"skip_sync"`   // leave the DynamoDB serving table as it is
+	SkipChecks bool     `json:"skip_checks"` // don't run the data-quality checks (backfills)
 }
 
 func getenv(k, def string) string {
@@ -47,171 +57,11 @@ func mustNonEmptyEnv(k string) string {
 	return v
 }
 
-func buildCTAS(db, table string, season, starterPct, maxAge int, outputLocation string) (dropStmt, ctasStmt string) {
-	seasonDate := fmt.Sprintf("%04d-09-01", season)
-
-	ageFilter := ""
-	if maxAge > 0 {
-		ageFilter = fmt.Sprintf("  AND age_yrs <= %d\n", maxAge)
-	}
-
-	queryBody := fmt.Sprintf(`
-WITH snaps AS (
-  SELECT
-    TRY_CAST(sc.season AS INTEGER)        AS season,     -- INT
-    sc.team                                AS team,      -- VARCHAR
-    TRY_CAST(sc.week   AS INTEGER)        AS week,       -- INT
-    COALESCE(sc.player_id, '')            AS player_id,  -- GSIS (VARCHAR)
-    sc.player                              AS player_name,
-    sc.defense_pct
-  FROM %s.snap_counts sc
-  WHERE TRY_CAST(sc.season AS INTEGER) = %d
-),
-roster AS (
-  SELECT
-    TRY_CAST(rw.season AS INTEGER)        AS season,     -- INT
-    rw.team                                AS team,      -- VARCHAR
-    TRY_CAST(rw.week   AS INTEGER)        AS week,       -- INT
-    COALESCE(rw.player_id, '')            AS player_id,  -- GSIS (VARCHAR)
-    rw.pfr_id                              AS pfr_id,
-    rw.full_name                           AS full_name,
-    UPPER(COALESCE(rw.position,''))       AS position
-  FROM %s.rosters_weekly rw
-  WHERE TRY_CAST(rw.season AS INTEGER) = %d
-    AND UPPER(COALESCE(rw.position,'')) IN ('DL','DE','DT','NT','EDGE','LB','ILB','OLB','MLB','CB','DB','S','FS','SS','SAF','NB')
-),
-joined AS (
-  SELECT
-    s.season, s.team, s.week,
-    COALESCE(s.player_id, r.player_id)       AS player_id,   -- GSIS
-    COALESCE(r.pfr_id, p.pfr_id)             AS pfr_id,      -- prefer roster PFR, else players via GSIS
-    COALESCE(s.player_name, r.full_name)     AS player_name,
-    r.position,
-    s.defense_pct,
-    CASE
-      WHEN p.birth_date IS NOT NULL AND TRY(date_parse(p.birth_date, '%%Y-%%m-%%d')) IS NOT NULL
-        THEN CAST(date_diff('year', TRY(date_parse(p.birth_date, '%%Y-%%m-%%d')), DATE '%s') AS integer)
-      ELSE NULL
-    END AS age_yrs
-  FROM snaps s
-  LEFT JOIN roster r
-    ON  CAST(s.season AS VARCHAR) = CAST(r.season AS VARCHAR)   -- force VARCHAR = VARCHAR
-    AND s.team = r.team                                         -- VARCHAR = VARCHAR
-    AND CAST(s.week   AS VARCHAR) = CAST(r.week   AS VARCHAR)   -- force VARCHAR = VARCHAR
-    AND (
-         (s.player_id <> '' AND s.player_id = r.player_id)
-      OR (s.player_id = ''  AND LOWER(s.player_name) = LOWER(r.full_name))
-    )
-  LEFT JOIN %s.players p
-    ON COALESCE(s.player_id, r.player_id) = p.gsis_id
-),
-agg AS (
-  SELECT
-    season, team, player_id, pfr_id, player_name,
-    MAX(position)                           AS position,
-    MAX(age_yrs)                            AS age_yrs,
-    COUNT_IF(defense_pct IS NOT NULL)       AS games_with_snap,
-    COUNT(*)                                AS games_total,
-    AVG(defense_pct)                        AS avg_def_pct,
-    MIN(defense_pct)                        AS min_def_pct,
-    MAX(defense_pct)                        AS max_def_pct
-  FROM joined
-  GROUP BY season, team, player_id, pfr_id, player_name
-)
-SELECT
-  -- non-partition columns FIRST:
-  player_id,
-  pfr_id,
-  player_name,
-  position,
-  age_yrs,
-  games_with_snap,
-  games_total,
-  avg_def_pct,
-  min_def_pct,
-  max_def_pct,
-  -- partition columns LAST in the same order as partitioned_by:
-  CAST(season AS INTEGER) AS season,
-  CAST(team   AS VARCHAR) AS team
-FROM agg
-WHERE games_with_snap = games_total
-  AND avg_def_pct >= CAST(%d AS DOUBLE)
-%s`, db, season, db, season, seasonDate, db, starterPct, ageFilter)
-
-	dropStmt = fmt.Sprintf(`DROP TABLE IF EXISTS %s.%s`, db, table)
-
-	ctasStmt = fmt.Sprintf(`
-CREATE TABLE %s.%s
-WITH (
-  format = 'PARQUET',
-  external_location = '%s',
-  partitioned_by = ARRAY['season','team']
-) AS
-%s
-`, db, table, strings.TrimRight(outputLocation, "/"), queryBody)
-
-	if strings.TrimSpace(os.Getenv("DEBUG")) == "1" {
-		log.Printf("DEBUG CTAS SQL:\n%s\n", ctasStmt)
-	}
-
-	return dropStmt, ctasStmt
-}
-
-type JobResult struct {
-	QueryExecutionID string `json:"query_execution_id"`
-	State            string `json:"state"`
-}
-
-func runAthena(ctx context.Context, cl *athena.Client, database, workgroup, output, sql string) (JobResult, error) {
-	start, err := cl.StartQueryExecution(ctx, &athena.StartQueryExecutionInput{
-		QueryString: aws.String(sql),
-		QueryExecutionContext: &athenatypes.QueryExecutionContext{
-			Database: aws.String(database), // or omit by setting this field nil
-			// Catalog: aws.String("AwsDataCatalog"), // optional
-		},
-		ResultConfiguration: &athenatypes.ResultConfiguration{
-			OutputLocation: aws.String(output),
-		},
-		WorkGroup: aws.String(workgroup),
-	})
-	if err != nil {
-		return JobResult{}, err
-	}
-
-	qid := aws.ToString(start.QueryExecutionId)
-
-	deadline := time.Now().Add(10 * time.Minute)
-	for time.Now().Before(deadline) {
-		time.Sleep(2 * time.Second)
-		desc, err := cl.GetQueryExecution(ctx, &athena.GetQueryExecutionInput{
-			QueryExecutionId: &qid,
-		})
-		if err != nil {
-			return JobResult{QueryExecutionID: qid}, err
-		}
-		state := desc.QueryExecution.Status.State
-		switch state {
-		case athenatypes.QueryExecutionStateSucceeded:
-			return JobResult{QueryExecutionID: qid, State: string(state)}, nil
-		case athenatypes.QueryExecutionStateFailed, athenatypes.QueryExecutionStateCancelled:
-			return JobResult{QueryExecutionID: qid, State: string(state)},
-				fmt.Errorf("athena failed: %s: %s",
-					aws.ToString(desc.QueryExecution.Status.StateChangeReason),
-					aws.ToString(desc.QueryExecution.Status.AthenaError.ErrorMessage))
-		default:
-			// keep polling
-		}
-	}
-	return JobResult{QueryExecutionID: qid}, fmt.Errorf("athena timeout waiting for query to finish")
-}
-
 func handler(ctx context.Context, e Event) (any, error) {
 	awsCfg, err := config.LoadDefaultConfig(ctx)
 	if err != nil {
 		return nil, err
 	}
-	cl := athena.NewFromConfig(awsCfg)
-
 	db := getenv("ATHENA_DB", "nflverse_curated")
 	wg := getenv("ATHENA_WORKGROUP", "primary")
 	out := mustNonEmptyEnv("ATHENA_OUTPUT") // e.g., s3://nflverse-athena-query-results/results/
@@ -222,45 +72,175 @@ func handler(ctx context.Context, e Event) (any, error) {
 	if season == 0 {
 		season = mustIntEnv("SEASON", 2024)
 	}
-	starterPct := e.StarterPct
-	if starterPct == 0 {
-		starterPct = mustIntEnv("STARTER_PCT", 50)
+	// Starter definition shared with the Go roster path; event fields override env.
+	spec, err := starters.FromEnv()
+	if err != nil {
+		return nil, err
+	}
+	if e.StarterPct != 0 {
+		spec.MinSnapPct = float64(e.StarterPct)
 	}
-	maxAge := e.MaxAge
-	if maxAge == 0 {
-		maxAge = mustIntEnv("MAX_AGE", 0) // 0 = ignore
+	if e.MaxAge != 0 {
+		spec.MaxAge = e.MaxAge
 	}
 
+	reg := materializer.Default()
+	if err := reg.SetTable(materializer.StartersAllGames, serveTable); err != nil {
+		return nil, err
+	}
+	names := e.Tables
+	if len(names) == 0 {
+		for _, n := range strings.Split(getenv("MATERIALIZE_TABLES", ""), ",") {
+			if n = strings.TrimSpace(n); n != "" {
+				names = append(names, n)
+			}
+		}
+	}
+	plan, err := reg.Plan(names, !e.SkipDeps)
+	if err != nil {
+		return nil, err
+	}
+	args := materializer.Args{"season": season}.Merge(materializer.StarterArgs(spec))
+
+	s3c := s3.NewFromConfig(awsCfg)
+	store := s3Store{cl: s3c}
 	// Write serving data to a friendly sub-prefix (optional but cleaner).
 	// If you prefer a separate bucket/prefix, set ATHENA_OUTPUT to that exact location.
-	servePrefix := strings.TrimRight(out, "/") + fmt.Sprintf("/serve/%s/season=%d/", serveTable, season)
+	base := strings.TrimRight(out, "/") + "/serve"
+	runID := time.Now().UTC().Format("20060102150405")
+
+	run := ath.New(athena.NewFromConfig(awsCfg), db, wg, out)
+	run.Timeout = 10 * time.Minute
+	run.Logger = log.Default()
+	run.Debug = strings.TrimSpace(os.Getenv("DEBUG")) == "1"
+	// A missing season filter would scan every season; stop queries that try.
+	run.Budget = ath.NewBudget(int64(mustIntEnv("ATHENA_QUERY_CAP_MB", 2048)), int64(mustIntEnv("ATHENA_RUN_BUDGET_MB", 8192)))
+	ledger := &ath.S3Ledger{API: s3c, URI: getenv("ATHENA_COST_LEDGER", strings.TrimRight(out, "/")+"/cost-ledger"), RunID: runID}
+	run.Ledger = ledger
+	defer func() {
+		uri, err := ledger.Flush(context.WithoutCancel(ctx))
+		if err != nil {
+			log.Printf("WARN cost ledger: %v", err)
+			return
+		}
+		log.Printf("materializer: %d queries scanned %.1fMB, ledger %s", run.Budget.Queries(), float64(run.Budget.Spent())/(1<<20), uri)
+	}()
+
+	var tables []any
+	for _, t := range plan {
+		table := reg.Table(t.Name)
+		ctx := ath.WithLabel(ctx, t.Name, season)
+		if t.Scoped() {
+			// Only this season's partitions change; other seasons stay queryable.
+			log.Printf("materializer: rebuilding %s.%s season=%d (%s, run %s)", db, table, season, t.Name, runID)
+			res, err := reg.RebuildSeason(ctx, run, store, t.Name, db, base, season, runID, args)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", t.Name, err)
+			}
+			rows, err := ath.One[int64](ctx, run, fmt.Sprintf("SELECT COUNT(*) FROM %s.%s WHERE season = ?", db, table), season)
+			if err != nil {
+				return nil, fmt.Errorf("%s: count rows: %w", t.Name, err)
+			}
+			log.Printf("materializer: %s season=%d rows=%d added=%d replaced=%d dropped=%d deleted_objects=%d",
+				res.Table, season, rows, len(res.Added), len(res.Replaced), len(res.Dropped), res.Deleted)
+			tables = append(tables, res)
+			continue
+		}
 
-	dropSQL, ctasSQL := buildCTAS(db, serveTable, season, starterPct, maxAge, servePrefix)
+		// Not partitioned by season: the whole table is rebuilt.
+		dropSQL, ctasSQL, err := reg.Statements(t.Name, db, reg.SeasonPrefix(t.Name, base, season), args)
+		if err != nil {
+			return nil, err
+		}
+		log.Printf("materializer: dropping table %s.%s (if exists)", db, table)
+		if _, err := run.Exec(ctx, dropSQL); errors.Is(err, ath.ErrBudget) {
+			return nil, fmt.Errorf("%s: %w", t.Name, err)
+		} else if err != nil {
+			// Not fatal if it fails because the table doesn't exist; Athena handles it, but keep message.
+			log.Printf("WARN drop table: %v", err)
+		}
 
-	log.Printf("materializer: dropping table %s.%s (if exists)", db, serveTable)
-	if _, err := runAthena(ctx, cl, db, wg, out, dropSQL); err != nil {
-		// Not fatal if it fails because the table doesn't exist; Athena handles it, but keep message.
-		log.Printf("WARN drop table: %v", err)
+		log.Printf("materializer: creating table %s.%s via CTAS (%s)", db, table, t.Name)
+		res, err := run.Exec(ctx, ctasSQL)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", t.Name, err)
+		}
+		rows, err := ath.One[int64](ctx, run, fmt.Sprintf("SELECT COUNT(*) FROM %s.%s", db, table))
+		if err != nil {
+			return nil, fmt.Errorf("%s: count rows: %w", t.Name, err)
+		}
+		log.Printf("materializer: %s.%s rows=%d", db, table, rows)
+		tables = append(tables, map[string]any{
+			"template":    t.Name,
+			"serve_table": fmt.Sprintf("%s.%s", db, table),
+			"query_id":    res.ID,
+			"rows":        rows,
+		})
 	}
 
-	log.Printf("materializer: creating table %s.%s via CTAS", db, serveTable)
-	res, err := runAthena(ctx, cl, db, wg, out, ctasSQL)
-	if err != nil {
-		return nil, err
+	// Data-quality checks on the starters table; a violation fails the run before the
+	// sync, so DynamoDB keeps serving the last good season.
+	var quality any
+	if !e.SkipChecks && planned(plan, materializer.StartersAllGames) {
+		rep := dq.Run(ctx, run, "athena-materializer", runID, dq.Starters(reg.Table(materializer.StartersAllGames)),
+			dq.Scope{DB: db, Season: season, Through: dq.CompletedWeek(season, time.Now())})
+		log.Printf("materializer: dq %s", rep)
+		if uri, err := dq.Save(context.WithoutCancel(ctx), s3c, getenv("DQ_RESULTS", strings.TrimRight(out, "/")+"/dq"), rep); err != nil {
+			log.Printf("WARN dq results: %v", err)
+		} else {
+			log.Printf("materializer: dq results %s", uri)
+		}
+		if err := rep.Err(); err != nil {
+			return nil, err
+		}
+		quality = rep.Results
+	}
+
+	// Copy the starters into DynamoDB for low-latency reads.
+	var synced any
+	if ddbTable := getenv("DDB_SYNC_TABLE", ""); ddbTable != "" && !e.SkipSync && planned(plan, materializer.StartersAllGames) {
+		table := reg.Table(materializer.StartersAllGames)
+		res, err := syncStarters(ath.WithLabel(ctx, "sync "+table, season), run, dynamodb.NewFromConfig(awsCfg), db, table, ddbTable, season)
+		if err != nil {
+			log.Printf("materializer: partial sync: %s", res.Write)
+			return nil, err
+		}
+		log.Printf("materializer: synced %s season=%d upserts=%d deletes=%d invalid=%d (%s)",
+			ddbTable, season, res.Upserts, res.Deletes, res.Invalid, res.Write)
+		synced = res
 	}
 
 	return map[string]any{
 		"ok":               true,
 		"season":           season,
-		"starter_pct":      starterPct,
-		"max_age":          maxAge,
+		"starter_spec":     spec,
 		"athena_workgroup": wg,
 		"athena_output":    out,
-		"serve_table":      fmt.Sprintf("%s.%s", db, serveTable),
-		"query_id":         res.QueryExecutionID,
+		"tables":           tables,
+		"ddb_sync":         synced,
+		"dq":               quality,
+		"scanned_bytes":    run.Budget.Spent(),
+		"cost_usd":         costUSD(ledger.Costs()),
 	}, nil
 }
 
+func planned(plan []materializer.Template, name string) bool {
+	for _, t := range plan {
+		if t.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func costUSD(costs []ath.Cost) float64 {
+	var usd float64
+	for _, c := range costs {
+		usd += c.USD
+	}
+	return usd
+}
+
 func main() {
 	lambda.Start(handler)
 	// (No local branch—this binary is for the Lambda runtime.)
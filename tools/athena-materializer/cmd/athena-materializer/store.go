@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// s3Store deletes replaced serving files.
+type s3Store struct{ cl *s3.Client }
+
+func (s s3Store) DeletePrefix(ctx context.Context, prefix, keep string) (int, error) {
+	bucket, key, err := splitS3(prefix)
+	if err != nil {
+		return 0, err
+	}
+	keepKey := ""
+	if keep != "" {
+		if _, keepKey, err = splitS3(keep); err != nil {
+			return 0, err
+		}
+	}
+	deleted := 0
+	var batch []s3types.ObjectIdentifier
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		out, err := s.cl.DeleteObjects(ctx, &s3.DeleteObjectsInput{
+			Bucket: aws.String(bucket),
+			Delete: &s3types.Delete{Objects: batch, Quiet: aws.Bool(true)},
+		})
+		if err != nil {
+			return err
+		}
+		if len(out.Errors) > 0 {
+			e := out.Errors[0]
+			return fmt.Errorf("delete s3://%s/%s: %s", bucket, aws.ToString(e.Key), aws.ToString(e.Message))
+		}
+		deleted += len(batch)
+		batch = batch[:0]
+		return nil
+	}
+	p := s3.NewListObjectsV2Paginator(s.cl, &s3.ListObjectsV2Input{Bucket: aws.String(bucket), Prefix: aws.String(key)})
+	for p.HasMorePages() {
+		page, err := p.NextPage(ctx)
+		if err != nil {
+			return deleted, err
+		}
+		for _, o := range page.Contents {
+			if k := aws.ToString(o.Key); keepKey == "" || !strings.HasPrefix(k, keepKey) {
+				batch = append(batch, s3types.ObjectIdentifier{Key: o.Key})
+			}
+			if len(batch) == 1000 {
+				if err := flush(); err != nil {
+					return deleted, err
+				}
+			}
+		}
+	}
+	return deleted, flush()
+}
+
+func splitS3(uri string) (bucket, key string, err error) {
+	rest, ok := strings.CutPrefix(uri, "s3://")
+	if !ok {
+		return "", "", fmt.Errorf("not an s3:// uri: %q", uri)
+	}
+	bucket, key, _ = strings.Cut(rest, "/")
+	if bucket == "" || key == "" {
+		return "", "", fmt.Errorf("refusing to delete a whole bucket: %q", uri)
+	}
+	return bucket, key, nil
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/tyler180/fantasy-football-backends/internal/ath"
+	"github.com/tyler180/fantasy-football-backends/internal/store"
+)
+
+// syncStarters copies one season of the Athena starters table into the DynamoDB
+// serving table: rows are read through paginated query results, upserted, and
+// players no longer in the season's output are deleted.
+func syncStarters(ctx context.Context, run *ath.Client, ddb store.DynamoDBQueryAPI, db, table, ddbTable string, season int) (store.StarterSync, error) {
+	rows, err := ath.Select[store.StarterRow](ctx, run, fmt.Sprintf(`
+SELECT player_id, pfr_id, player_name, position, age_yrs, games_with_snap, games_total,
+       games_started, avg_def_pct, recent_def_pct, min_def_pct, max_def_pct, starter_rank,
+       season, team
+FROM %s.%s
+WHERE season = ?`, db, table), season)
+	if err != nil {
+		return store.StarterSync{}, fmt.Errorf("read %s.%s: %w", db, table, err)
+	}
+	res, err := store.ReplaceSeasonStarters(ctx, ddb, ddbTable, strconv.Itoa(season), rows)
+	if err != nil {
+		return res, fmt.Errorf("sync %s: %w", ddbTable, err)
+	}
+	return res, nil
+}
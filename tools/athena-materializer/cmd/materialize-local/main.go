@@ -0,0 +1,65 @@
+// Command materialize-local runs the materializer's templates on DuckDB over a local
+// copy of the curated Parquet (same layout as the bucket), for testing template
+// changes without Athena, Glue or S3:
+//
+//	aws s3 sync s3://<curated>/<prefix>/ ./curated/
+//	materialize-local -data ./curated -out ./local-out -season 2024
+//
+// Sync the _manifests/ directory along with the data: like Athena, a table with
+// manifests reads only the files they list, not parts a compaction superseded.
+//
+// The starter definition comes from the same env vars as the Lambda (STARTER_SPEC,
+// MAX_AGE, STARTER_PCT, ...). Requires the duckdb CLI.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/tyler180/fantasy-football-backends/internal/starters"
+	"github.com/tyler180/fantasy-football-backends/tools/athena-materializer/pkg/materializer"
+	"github.com/tyler180/fantasy-football-backends/tools/athena-materializer/pkg/materializer/local"
+)
+
+func main() {
+	data := flag.String("data", "curated", "curated root holding players/, rosters_weekly/, snap_counts/")
+	out := flag.String("out", "local-out", "directory for materialized tables and the DuckDB file")
+	db := flag.String("db", "nflverse_curated", "schema the templates read from and write to")
+	season := flag.Int("season", 2024, "season to materialize")
+	tables := flag.String("tables", "", "comma-separated templates (default: all)")
+	skipDeps := flag.Bool("skip-deps", false, "run only the named templates, not the ones they read")
+	bin := flag.String("duckdb", "duckdb", "duckdb CLI")
+	flag.Parse()
+
+	spec, err := starters.FromEnv()
+	if err != nil {
+		log.Fatal(err)
+	}
+	var names []string
+	for _, n := range strings.Split(*tables, ",") {
+		if n = strings.TrimSpace(n); n != "" {
+			names = append(names, n)
+		}
+	}
+	e := &local.Engine{Bin: *bin, Data: *data, Out: *out}
+	if !e.Available() {
+		log.Fatalf("%s not found; install the duckdb CLI", *bin)
+	}
+	args := materializer.Args{"season": *season}.Merge(materializer.StarterArgs(spec))
+	res, err := e.Run(context.Background(), materializer.Default(), names, !*skipDeps, *db, args)
+	for _, t := range res {
+		log.Printf("OK %s: %d rows in %s", t.Table, t.Rows, t.Path)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(res); err != nil {
+		log.Fatal(err)
+	}
+}
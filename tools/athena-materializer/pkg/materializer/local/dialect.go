@@ -0,0 +1,224 @@
+package local
+
+import (
+	"strings"
+)
+
+// renames are Presto functions DuckDB spells differently but calls the same way.
+var renames = map[string]string{
+	"max_by":            "arg_max",
+	"min_by":            "arg_min",
+	"approx_percentile": "approx_quantile",
+	"cardinality":       "len",
+	"regexp_like":       "regexp_matches",
+	// Presto's date_diff counts whole units (an age); DuckDB's counts boundaries
+	// crossed. DuckDB's date_sub is the whole-unit one.
+	"date_diff": "date_sub",
+}
+
+// Translate rewrites Athena (Presto/Trino) SQL into DuckDB SQL. It covers what the
+// materializer templates use, not the whole dialect:
+//
+//   - date_parse(s, fmt) becomes strptime with the MySQL-style specifiers (%i, %s,
+//     %T, ...) mapped to strftime ones; TRY(date_parse(...)) becomes try_strptime
+//   - TRY(CAST(x AS t)) becomes TRY_CAST(x AS t)
+//   - regexp_replace replaces every match, as Presto does, with $1 backreferences
+//     written \1; the two-argument form deletes matches
+//   - the functions in renames are renamed
+//
+// String literals, quoted identifiers and comments are left alone.
+func Translate(sql string) string {
+	var b strings.Builder
+	for i := 0; i < len(sql); {
+		c := sql[i]
+		switch {
+		case c == '\'' || c == '"':
+			j := closing(sql, i+1, c)
+			b.WriteString(sql[i:j])
+			i = j
+		case strings.HasPrefix(sql[i:], "--"):
+			j := strings.IndexByte(sql[i:], '\n')
+			if j < 0 {
+				j = len(sql) - i
+			}
+			b.WriteString(sql[i : i+j])
+			i += j
+		case strings.HasPrefix(sql[i:], "/*"):
+			j := strings.Index(sql[i+2:], "*/")
+			end := len(sql)
+			if j >= 0 {
+				end = i + 2 + j + 2
+			}
+			b.WriteString(sql[i:end])
+			i = end
+		case isNameStart(c) && (i == 0 || (!isNamePart(sql[i-1]) && sql[i-1] != '.')):
+			j := i
+			for j < len(sql) && isNamePart(sql[j]) {
+				j++
+			}
+			name := sql[i:j]
+			k := j
+			for k < len(sql) && (sql[k] == ' ' || sql[k] == '\t' || sql[k] == '\n') {
+				k++
+			}
+			if k == len(sql) || sql[k] != '(' {
+				b.WriteString(name)
+				i = j
+				continue
+			}
+			end := matchParen(sql, k)
+			if end < 0 {
+				b.WriteString(sql[i:])
+				return b.String()
+			}
+			args := splitArgs(sql[k+1 : end])
+			for n := range args {
+				args[n] = Translate(args[n])
+			}
+			b.WriteString(call(name, sql[j:k], args))
+			i = end + 1
+		default:
+			b.WriteByte(c)
+			i++
+		}
+	}
+	return b.String()
+}
+
+// call renders one translated function call; args are already translated.
+func call(name, space string, args []string) string {
+	lower := strings.ToLower(name)
+	switch lower {
+	case "date_parse":
+		if len(args) == 2 {
+			return "strptime(" + args[0] + "," + prestoFormat(args[1]) + ")"
+		}
+	case "try":
+		if len(args) == 1 {
+			inner := strings.TrimSpace(args[0])
+			if rest, ok := cutPrefixFold(inner, "strptime("); ok {
+				return "try_strptime(" + rest
+			}
+			if rest, ok := cutPrefixFold(inner, "cast("); ok {
+				return "TRY_CAST(" + rest
+			}
+		}
+	case "regexp_replace":
+		switch len(args) {
+		case 2:
+			return "regexp_replace(" + args[0] + "," + args[1] + ", '', 'g')"
+		case 3:
+			return "regexp_replace(" + args[0] + "," + args[1] + "," + backrefs(args[2]) + ", 'g')"
+		}
+	}
+	if to, ok := renames[lower]; ok {
+		name = to
+	}
+	return name + space + "(" + strings.Join(args, ",") + ")"
+}
+
+// prestoFormat maps a date_parse format literal's MySQL-style specifiers to the
+// strftime ones DuckDB's strptime takes. Non-literal formats pass through.
+func prestoFormat(arg string) string {
+	lit := strings.TrimSpace(arg)
+	if len(lit) < 2 || lit[0] != '\'' || lit[len(lit)-1] != '\'' {
+		return arg
+	}
+	r := strings.NewReplacer(
+		"%i", "%M", "%s", "%S", "%T", "%H:%M:%S", "%h", "%I", "%r", "%I:%M:%S %p",
+		"%c", "%-m", "%e", "%-d", "%k", "%-H", "%l", "%-I", "%%", "%%",
+	)
+	return leading(arg) + r.Replace(lit)
+}
+
+// backrefs rewrites $1 in a replacement literal to DuckDB's \1.
+func backrefs(arg string) string {
+	lit := strings.TrimSpace(arg)
+	if len(lit) < 2 || lit[0] != '\'' {
+		return arg
+	}
+	var b strings.Builder
+	for i := 0; i < len(lit); i++ {
+		if lit[i] == '$' && i+1 < len(lit) && lit[i+1] >= '0' && lit[i+1] <= '9' {
+			b.WriteByte('\\')
+			continue
+		}
+		b.WriteByte(lit[i])
+	}
+	return leading(arg) + b.String()
+}
+
+// leading is s's leading whitespace.
+func leading(s string) string {
+	return s[:len(s)-len(strings.TrimLeft(s, " \t\n"))]
+}
+
+// matchParen returns the index of the ')' closing the '(' at open, or -1.
+func matchParen(sql string, open int) int {
+	depth := 0
+	for i := open; i < len(sql); i++ {
+		switch sql[i] {
+		case '\'', '"':
+			i = closing(sql, i+1, sql[i]) - 1
+		case '(':
+			depth++
+		case ')':
+			if depth--; depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// splitArgs splits a call's argument list on top-level commas, keeping each
+// argument's surrounding whitespace.
+func splitArgs(s string) []string {
+	var out []string
+	depth, start := 0, 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\'', '"':
+			i = closing(s, i+1, s[i]) - 1
+		case '(', '[':
+			depth++
+		case ')', ']':
+			depth--
+		case ',':
+			if depth == 0 {
+				out = append(out, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(out, s[start:])
+}
+
+// closing returns the index just past the quote closing the literal opened before
+// start; a doubled quote is an escaped one.
+func closing(sql string, start int, q byte) int {
+	for i := start; i < len(sql); i++ {
+		if sql[i] != q {
+			continue
+		}
+		if i+1 < len(sql) && sql[i+1] == q {
+			i++
+			continue
+		}
+		return i + 1
+	}
+	return len(sql)
+}
+
+func cutPrefixFold(s, prefix string) (string, bool) {
+	if len(s) >= len(prefix) && strings.EqualFold(s[:len(prefix)], prefix) {
+		return s[len(prefix):], true
+	}
+	return s, false
+}
+
+func isNameStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isNamePart(c byte) bool { return isNameStart(c) || (c >= '0' && c <= '9') }
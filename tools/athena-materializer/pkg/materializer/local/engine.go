@@ -0,0 +1,278 @@
+// Package local runs the materializer's templates on DuckDB over local Parquet, laid
+// out like the curated bucket: players/, rosters_weekly/season=/week=/ and
+// snap_counts/season=/team=/, with the symlink manifests under _manifests/. Templates
+// are rendered exactly as for Athena and passed through Translate, so the same SQL
+// can be unit-tested and run offline.
+//
+// The engine drives the duckdb CLI rather than linking DuckDB, so the Lambda build
+// stays cgo-free; statements share state through a database file.
+package local
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/tyler180/fantasy-football-backends/internal/ath"
+	"github.com/tyler180/fantasy-football-backends/tools/athena-materializer/pkg/materializer"
+)
+
+// Sources are the curated tables the templates read.
+var Sources = []string{"players", "rosters_weekly", "snap_counts"}
+
+// manifestDir holds one manifest per partition,
+// <Data>/_manifests/<source>/<partition>/manifest, as the curator's compaction
+// writes them.
+const manifestDir = "_manifests"
+
+// null is what the CLI prints for NULL (PostgreSQL's COPY convention).
+const null = `\N`
+
+// Engine runs statements through the duckdb CLI.
+type Engine struct {
+	Bin  string // duckdb executable; default "duckdb" on PATH
+	DB   string // database file holding the views; default <Out>/local.duckdb
+	Data string // curated root: <Data>/<source>/[season=/...]/*.parquet and <Data>/_manifests/
+	Out  string // materialized tables go to <Out>/<table>/
+}
+
+// Available reports whether the duckdb CLI can be found.
+func (e *Engine) Available() bool {
+	_, err := exec.LookPath(e.bin())
+	return err == nil
+}
+
+// Attach creates schema db with one view per source over its Parquet files; hive
+// partition directories become columns, as partition projection does in Athena. A
+// source with manifests reads only the files they list, as Athena does, so parts a
+// compaction superseded are not counted twice; one without reads every Parquet file
+// under its directory. Sources with neither, or whose manifests list no files, are
+// skipped.
+func (e *Engine) Attach(ctx context.Context, db string) error {
+	stmts := []string{"CREATE SCHEMA IF NOT EXISTS " + db}
+	for _, src := range Sources {
+		files, err := e.Listed(src)
+		if err != nil {
+			return err
+		}
+		if files != nil {
+			if len(files) > 0 {
+				stmts = append(stmts, viewFiles(db+"."+src, files))
+			}
+			continue
+		}
+		dir := filepath.Join(e.Data, src)
+		if _, err := os.Stat(dir); err != nil {
+			continue
+		}
+		stmts = append(stmts, view(db+"."+src, dir))
+	}
+	_, err := e.Exec(ctx, strings.Join(stmts, ";\n"))
+	return err
+}
+
+// Listed returns the local paths of the files src's manifests list, each mapped from
+// its s3:// URI to <Data>/<source>/<partition>/<file>. It returns nil when src has no
+// manifests, and an error when a manifest lists a file outside its partition or one
+// that was not synced.
+func (e *Engine) Listed(src string) ([]string, error) {
+	root := filepath.Join(e.Data, manifestDir, src)
+	if _, err := os.Stat(root); err != nil {
+		return nil, nil
+	}
+	files := []string{}
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || d.Name() != "manifest" {
+			return err
+		}
+		rel, err := filepath.Rel(root, filepath.Dir(p))
+		if err != nil {
+			return err
+		}
+		part := path.Join(src, filepath.ToSlash(rel))
+		b, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		for _, uri := range strings.Split(string(b), "\n") {
+			if uri = strings.TrimSpace(uri); uri == "" {
+				continue
+			}
+			if !strings.HasSuffix(path.Dir(uri), "/"+part) {
+				return fmt.Errorf("%s: %s is not in partition %s", p, uri, part)
+			}
+			f := filepath.Join(e.Data, filepath.FromSlash(part), path.Base(uri))
+			if _, err := os.Stat(f); err != nil {
+				return fmt.Errorf("%s lists %s: %w", p, uri, err)
+			}
+			files = append(files, f)
+		}
+		return nil
+	})
+	return files, err
+}
+
+// Table is one materialized template.
+type Table struct {
+	Template string `json:"template"`
+	Table    string `json:"table"`
+	Path     string `json:"path"`
+	Rows     int64  `json:"rows"`
+}
+
+// Materialize renders name with args, writes its rows as Parquet under
+// <Out>/<table>/ (hive-partitioned like the Athena CTAS) and replaces the view
+// db.<table> over them, so templates that read it see the new rows.
+func (e *Engine) Materialize(ctx context.Context, reg *materializer.Registry, name, db string, args materializer.Args) (Table, error) {
+	t, ok := reg.Get(name)
+	if !ok {
+		return Table{}, fmt.Errorf("unknown template %q", name)
+	}
+	body, err := reg.Render(name, db, args)
+	if err != nil {
+		return Table{}, err
+	}
+	table := reg.Table(name)
+	dir := filepath.Join(e.Out, table)
+	if err := os.RemoveAll(dir); err != nil {
+		return Table{}, err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return Table{}, err
+	}
+	target, opts := filepath.Join(dir, "data.parquet"), "FORMAT PARQUET"
+	if len(t.PartitionedBy) > 0 {
+		target, opts = dir, fmt.Sprintf("FORMAT PARQUET, PARTITION_BY (%s), OVERWRITE_OR_IGNORE", strings.Join(t.PartitionedBy, ", "))
+	}
+	copySQL := fmt.Sprintf("COPY (\n%s\n) TO %s (%s)", Translate(body), literal(target), opts)
+	if _, err := e.Exec(ctx, copySQL+";\n"+view(db+"."+table, dir)); err != nil {
+		return Table{}, fmt.Errorf("%s: %w", name, err)
+	}
+	res, err := e.Result(ctx, "SELECT COUNT(*) FROM "+db+"."+table)
+	if err != nil {
+		return Table{}, err
+	}
+	rows, err := ath.Decode[int64](res)
+	if err != nil || len(rows) != 1 {
+		return Table{}, fmt.Errorf("%s: count rows: %v", name, err)
+	}
+	return Table{Template: name, Table: db + "." + table, Path: dir, Rows: rows[0]}, nil
+}
+
+// Run materializes the plan for names (see Registry.Plan) in dependency order.
+func (e *Engine) Run(ctx context.Context, reg *materializer.Registry, names []string, withDeps bool, db string, args materializer.Args) ([]Table, error) {
+	plan, err := reg.Plan(names, withDeps)
+	if err != nil {
+		return nil, err
+	}
+	if err := e.Attach(ctx, db); err != nil {
+		return nil, err
+	}
+	var out []Table
+	for _, t := range plan {
+		tbl, err := e.Materialize(ctx, reg, t.Name, db, args)
+		if err != nil {
+			return out, err
+		}
+		out = append(out, tbl)
+	}
+	return out, nil
+}
+
+// Query translates and runs sql and returns its rows, NULL as "". It satisfies
+// materializer.Runner.
+func (e *Engine) Query(ctx context.Context, sql string) ([][]string, error) {
+	res, err := e.Result(ctx, Translate(sql))
+	if err != nil {
+		return nil, err
+	}
+	return res.Strings(), nil
+}
+
+// Result runs one statement (already DuckDB SQL) and returns its columns and rows in
+// the shape ath.Decode reads, so local results decode into the same structs as
+// Athena's.
+func (e *Engine) Result(ctx context.Context, sql string) (ath.Result, error) {
+	out, err := e.Exec(ctx, sql)
+	if err != nil {
+		return ath.Result{}, err
+	}
+	recs, err := csv.NewReader(bytes.NewReader(out)).ReadAll()
+	if err != nil {
+		return ath.Result{}, fmt.Errorf("duckdb output: %w", err)
+	}
+	var res ath.Result
+	if len(recs) == 0 {
+		return res, nil
+	}
+	res.Columns = recs[0]
+	for _, rec := range recs[1:] {
+		row := make([]*string, len(rec))
+		for i, v := range rec {
+			if v != null {
+				row[i] = &rec[i]
+			}
+		}
+		res.Rows = append(res.Rows, row)
+	}
+	return res, nil
+}
+
+// Exec runs sql (one or more statements, DuckDB dialect) and returns the CLI's CSV
+// output.
+func (e *Engine) Exec(ctx context.Context, sql string) ([]byte, error) {
+	db := e.DB
+	if db == "" {
+		if err := os.MkdirAll(e.Out, 0o755); err != nil {
+			return nil, err
+		}
+		db = filepath.Join(e.Out, "local.duckdb")
+	}
+	cmd := exec.CommandContext(ctx, e.bin(), "-bail", "-csv", "-nullvalue", null, db)
+	cmd.Stdin = strings.NewReader(sql + ";\n")
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		var exit *exec.ExitError
+		if errors.As(err, &exit) {
+			return nil, fmt.Errorf("duckdb: %s", strings.TrimSpace(stderr.String()))
+		}
+		return nil, fmt.Errorf("duckdb: %w", err)
+	}
+	return stdout.Bytes(), nil
+}
+
+func (e *Engine) bin() string {
+	if e.Bin != "" {
+		return e.Bin
+	}
+	return "duckdb"
+}
+
+// view is a CREATE OR REPLACE VIEW name over every Parquet file under dir.
+func view(name, dir string) string {
+	return fmt.Sprintf("CREATE OR REPLACE VIEW %s AS SELECT * FROM read_parquet(%s, hive_partitioning = true, union_by_name = true)",
+		name, literal(filepath.Join(dir, "**", "*.parquet")))
+}
+
+// viewFiles is a CREATE OR REPLACE VIEW name over exactly files.
+func viewFiles(name string, files []string) string {
+	lits := make([]string, len(files))
+	for i, f := range files {
+		lits[i] = literal(f)
+	}
+	return fmt.Sprintf("CREATE OR REPLACE VIEW %s AS SELECT * FROM read_parquet([%s], hive_partitioning = true, union_by_name = true)",
+		name, strings.Join(lits, ", "))
+}
+
+func literal(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
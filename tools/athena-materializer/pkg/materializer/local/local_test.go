@@ -0,0 +1,247 @@
+package local
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/tyler180/fantasy-football-backends/internal/ath"
+	"github.com/tyler180/fantasy-football-backends/internal/starters"
+	"github.com/tyler180/fantasy-football-backends/tools/athena-materializer/pkg/materializer"
+)
+
+func TestTranslate(t *testing.T) {
+	for _, c := range []struct{ name, in, want string }{
+		// renames
+		{"max_by", "SELECT MAX_BY(team, week) FROM t", "SELECT arg_max(team, week) FROM t"},
+		{"min_by", "min_by(a, b)", "arg_min(a, b)"},
+		{"approx_percentile", "approx_percentile(x, 0.5)", "approx_quantile(x, 0.5)"},
+		{"cardinality", "cardinality(split(s, ','))", "len(split(s, ','))"},
+		{"regexp_like", "WHERE regexp_like(pos, '^(DE|DT)$')", "WHERE regexp_matches(pos, '^(DE|DT)$')"},
+		{"date_diff", "date_diff('year', a, b)", "date_sub('year', a, b)"},
+		{"unknown function kept", "COUNT_IF(x > 1)", "COUNT_IF(x > 1)"},
+
+		// regexp_replace
+		{"regexp_replace deletes", "REGEXP_REPLACE(name, '[^a-z]')", "regexp_replace(name, '[^a-z]', '', 'g')"},
+		{"regexp_replace backrefs", "regexp_replace(name, '(\\w+) (\\w+)', '$2 $1')", "regexp_replace(name, '(\\w+) (\\w+)', '\\2 \\1', 'g')"},
+		{"regexp_replace column replacement", "regexp_replace(name, 'x', repl)", "regexp_replace(name, 'x', repl, 'g')"},
+
+		// date_parse and TRY
+		{"date_parse", "date_parse(d, '%Y-%m-%d %H:%i:%s')", "strptime(d, '%Y-%m-%d %H:%M:%S')"},
+		{"date_parse %T", "date_parse(d, '%Y-%m-%d %T')", "strptime(d, '%Y-%m-%d %H:%M:%S')"},
+		{"date_parse %r", "date_parse(d, '%r')", "strptime(d, '%I:%M:%S %p')"},
+		{"date_parse unpadded", "date_parse(d, '%c/%e %k:%l %h')", "strptime(d, '%-m/%-d %-H:%-I %I')"},
+		{"date_parse column format", "date_parse(d, fmt)", "strptime(d, fmt)"},
+		{"try date_parse", "TRY(date_parse(d, '%Y-%m-%d'))", "try_strptime(d, '%Y-%m-%d')"},
+		{"try cast", "TRY(CAST(x AS INTEGER))", "TRY_CAST(x AS INTEGER)"},
+		{"try other", "TRY(a / b)", "TRY(a / b)"},
+		{"nested", "max_by(TRY(CAST(x AS DOUBLE)), min_by(a, b))", "arg_max(TRY_CAST(x AS DOUBLE), arg_min(a, b))"},
+
+		// left alone
+		{"literals and comments", "COALESCE(MAX_BY(a, b), 'max_by(x)') -- max_by(y)", "COALESCE(arg_max(a, b), 'max_by(x)') -- max_by(y)"},
+		{"block comment", "/* min_by(a, b) */ min_by(a, b)", "/* min_by(a, b) */ arg_min(a, b)"},
+		{"quoted identifier", `SELECT "max_by"(x) FROM t`, `SELECT "max_by"(x) FROM t`},
+		{"escaped quote", "SELECT 'it''s max_by(x)'", "SELECT 'it''s max_by(x)'"},
+		{"qualified column", "SELECT s.max_by FROM t WHERE x IN ('a', 'b')", "SELECT s.max_by FROM t WHERE x IN ('a', 'b')"},
+		{"unclosed call", "SELECT max_by(a, b", "SELECT max_by(a, b"},
+	} {
+		if got := Translate(c.in); got != c.want {
+			t.Errorf("%s: Translate(%q)\n got %q\nwant %q", c.name, c.in, got, c.want)
+		}
+	}
+}
+
+func TestTranslateStartersTemplate(t *testing.T) {
+	reg := materializer.Default()
+	body, err := reg.Render(materializer.StartersAllGames, "nflverse_curated",
+		materializer.Args{"season": 2024}.Merge(materializer.StarterArgs(starters.Default())))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := Translate(body)
+	for _, want := range []string{"try_strptime(p.birth_date, '%Y-%m-%d')", "date_sub('year',", "COUNT_IF(", "ROW_NUMBER() OVER (PARTITION BY team"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("translated starters query lacks %q", want)
+		}
+	}
+	if strings.Contains(strings.ToLower(got), "date_parse") {
+		t.Error("date_parse left in translated query")
+	}
+}
+
+// fakeCLI is a duckdb stand-in that prints out whatever it is asked.
+func fakeCLI(t *testing.T, out string) string {
+	t.Helper()
+	bin := filepath.Join(t.TempDir(), "duckdb")
+	script := "#!/bin/sh\ncat >/dev/null\ncat <<'EOF'\n" + out + "EOF\n"
+	if err := os.WriteFile(bin, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	return bin
+}
+
+func TestResultDecodes(t *testing.T) {
+	e := &Engine{Bin: fakeCLI(t, "player_id,age_yrs,recent_def_pct\n00-1,24,88.5\n00-2,\\N,\"71.0\"\n"), Out: t.TempDir()}
+	res, err := e.Result(context.Background(), "SELECT 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	type row struct {
+		PlayerID string   `athena:"player_id"`
+		Age      *int     `athena:"age_yrs"`
+		Pct      float64  `athena:"recent_def_pct"`
+		Missing  *float64 `athena:"other"`
+	}
+	rows, err := ath.Decode[row](res)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 2 || rows[0].PlayerID != "00-1" || *rows[0].Age != 24 || rows[1].Age != nil || rows[1].Pct != 71 {
+		t.Errorf("rows = %+v", rows)
+	}
+}
+
+func TestExecReportsCLIErrors(t *testing.T) {
+	bin := filepath.Join(t.TempDir(), "duckdb")
+	if err := os.WriteFile(bin, []byte("#!/bin/sh\necho 'Parser Error: syntax error at or near \"SELEC\"' >&2\nexit 1\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	e := &Engine{Bin: bin, Out: t.TempDir()}
+	if _, err := e.Exec(context.Background(), "SELEC 1"); err == nil || !strings.Contains(err.Error(), "Parser Error") {
+		t.Errorf("err = %v, want the CLI's message", err)
+	}
+}
+
+func TestListedMapsManifestsToSyncedFiles(t *testing.T) {
+	data := t.TempDir()
+	e := &Engine{Data: data}
+	write := func(rel, body string) {
+		t.Helper()
+		p := filepath.Join(data, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// A compacted partition keeps its superseded part on disk until the grace passes.
+	write("snap_counts/season=2024/team=SEA/part-1.parquet", "")
+	write("snap_counts/season=2024/team=SEA/compact-20240916T030500Z.parquet", "")
+	write("snap_counts/season=2024/team=KC/part-1.parquet", "")
+	write("_manifests/snap_counts/season=2024/team=SEA/manifest",
+		"s3://curated/nflverse_curated/snap_counts/season=2024/team=SEA/compact-20240916T030500Z.parquet\n")
+	write("_manifests/snap_counts/season=2024/team=KC/manifest",
+		"s3://curated/nflverse_curated/snap_counts/season=2024/team=KC/part-1.parquet\n")
+	write("players/p.parquet", "")
+
+	got, err := e.Listed("snap_counts")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		filepath.Join(data, "snap_counts", "season=2024", "team=KC", "part-1.parquet"),
+		filepath.Join(data, "snap_counts", "season=2024", "team=SEA", "compact-20240916T030500Z.parquet"),
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("listed = %v, want %v", got, want)
+	}
+	if got, err := e.Listed("players"); got != nil || err != nil {
+		t.Errorf("players without manifests = %v, %v; want nil to read the directory", got, err)
+	}
+
+	write("_manifests/snap_counts/season=2024/team=KC/manifest",
+		"s3://curated/nflverse_curated/snap_counts/season=2024/team=KC/part-2.parquet\n")
+	if _, err := e.Listed("snap_counts"); err == nil || !strings.Contains(err.Error(), "part-2.parquet") {
+		t.Errorf("unsynced file: err = %v", err)
+	}
+	write("_manifests/snap_counts/season=2024/team=KC/manifest",
+		"s3://curated/nflverse_curated/snap_counts/season=2024/team=SEA/part-1.parquet\n")
+	if _, err := e.Listed("snap_counts"); err == nil || !strings.Contains(err.Error(), "not in partition") {
+		t.Errorf("file from another partition: err = %v", err)
+	}
+}
+
+// TestStartersOnDuckDB runs the real starters template end to end when the duckdb CLI
+// is installed; with DUCKDB_REQUIRED set (make test-duckdb) a missing CLI fails it.
+func TestStartersOnDuckDB(t *testing.T) {
+	data, out := t.TempDir(), t.TempDir()
+	e := &Engine{Data: data, Out: out}
+	if !e.Available() {
+		if os.Getenv("DUCKDB_REQUIRED") != "" {
+			t.Fatal("duckdb CLI not on PATH (DUCKDB_REQUIRED is set)")
+		}
+		t.Skip("duckdb CLI not on PATH")
+	}
+	ctx := context.Background()
+	for _, dir := range []string{"players", "rosters_weekly/season=2024/week=1", "rosters_weekly/season=2024/week=2",
+		"snap_counts/season=2024/team=SEA"} {
+		if err := os.MkdirAll(filepath.Join(data, dir), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	seed := []string{
+		"COPY (SELECT * FROM (VALUES ('00-1', 'P1', '2000-01-15'), ('00-2', 'P2', '1990-05-01'), ('00-3', 'P3', NULL)) v(gsis_id, pfr_id, birth_date)) TO " +
+			literal(filepath.Join(data, "players", "p.parquet")) + " (FORMAT PARQUET)",
+	}
+	for _, wk := range []int{1, 2} {
+		seed = append(seed,
+			"COPY (SELECT * FROM (VALUES ('SEA', '00-1', NULL, 'Young Corner', 'CB'), ('SEA', '00-2', NULL, 'Old Backer', 'LB'), ('SEA', '00-3', NULL, 'Rookie Safety', 'S'), ('SEA', '00-4', NULL, 'Nickel Back', 'CB'), ('SEA', '00-5', 'P1', 'Stale Entry', 'CB')) v(team, player_id, pfr_id, full_name, position)) TO "+
+				literal(filepath.Join(data, "rosters_weekly", "season=2024", "week="+strconv.Itoa(wk), "r.parquet"))+" (FORMAT PARQUET)")
+	}
+	// Snap counts carry PFR ids (mapped to GSIS through players), except the safety's
+	// week 2 row, which joins on name. The nickel back's PFR id is in neither players
+	// nor the roster, so that row joins on name too. The roster's stale entry carries
+	// the corner's PFR id under another GSIS id; the corner joins on GSIS alone.
+	seed = append(seed,
+		"COPY (SELECT * FROM (VALUES (1, NULL, 'P1', 'Young Corner', 95.0), (2, NULL, 'P1', 'Young Corner', 90.0), (1, NULL, 'P2', 'Old Backer', 99.0), (2, NULL, 'P2', 'Old Backer', 98.0), (1, NULL, 'P3', 'Rookie Safety', 20.0), (2, NULL, NULL, 'Rookie Safety Jr.', 30.0), (1, NULL, 'P4', 'Nickel Back', 10.0), (2, NULL, 'P9', 'Practice Squad', 5.0)) v(week, player_id, pfr_id, player, defense_pct)) TO "+
+			literal(filepath.Join(data, "snap_counts", "season=2024", "team=SEA", "s.parquet"))+" (FORMAT PARQUET)")
+	// A superseded part the manifest no longer lists; reading it would double the
+	// corner's games.
+	seed = append(seed,
+		"COPY (SELECT * FROM (VALUES (1, NULL, 'P1', 'Young Corner', 10.0)) v(week, player_id, pfr_id, player, defense_pct)) TO "+
+			literal(filepath.Join(data, "snap_counts", "season=2024", "team=SEA", "old.parquet"))+" (FORMAT PARQUET)")
+	if _, err := e.Exec(ctx, strings.Join(seed, ";\n")); err != nil {
+		t.Fatal(err)
+	}
+	manifest := filepath.Join(data, "_manifests", "snap_counts", "season=2024", "team=SEA", "manifest")
+	if err := os.MkdirAll(filepath.Dir(manifest), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(manifest, []byte("s3://curated/nflverse_curated/snap_counts/season=2024/team=SEA/s.parquet\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	spec := starters.Default()
+	spec.MaxAge = 26
+	reg := materializer.Default()
+	tables, err := e.Run(ctx, reg, []string{materializer.StartersByTeam}, true, "nflverse_curated",
+		materializer.Args{"season": 2024}.Merge(materializer.StarterArgs(spec)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tables) != 2 || tables[0].Rows != 1 {
+		t.Fatalf("tables = %+v, want only the young full-time corner as a starter", tables)
+	}
+	got, err := e.Result(ctx, "SELECT player_id, age_yrs, games_total FROM nflverse_curated."+materializer.StartersAllGames)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := got.Strings(); len(s) != 1 || s[0][0] != "00-1" || s[0][1] != "24" || s[0][2] != "2" {
+		t.Errorf("starters = %v", s)
+	}
+
+	if _, err := e.Materialize(ctx, reg, materializer.StartersMatchQuality, "nflverse_curated", materializer.Args{"season": 2024}); err != nil {
+		t.Fatal(err)
+	}
+	got, err = e.Result(ctx, "SELECT player_name, match_method FROM nflverse_curated."+materializer.StartersMatchQuality+" ORDER BY player_name")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := got.Strings(); len(s) != 3 || s[0][0] != "Nickel Back" || s[0][1] != "name" || s[1][0] != "Practice Squad" || s[1][1] != "none" || s[2][1] != "name" {
+		t.Errorf("match quality = %v", s)
+	}
+}
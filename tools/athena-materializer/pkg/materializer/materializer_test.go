@@ -0,0 +1,364 @@
+package materializer
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/tyler180/fantasy-football-backends/internal/starters"
+)
+
+func TestParamRender(t *testing.T) {
+	cases := []struct {
+		p    Param
+		v    any
+		want string
+		err  bool
+	}{
+		{Param{Kind: Int}, 2024, "2024", false},
+		{Param{Kind: Int}, float64(2024), "2024", false}, // from event JSON
+		{Param{Kind: Int}, "2024", "2024", false},
+		{Param{Kind: Int}, 20.5, "", true},
+		{Param{Kind: Int}, "2024; DROP TABLE x", "", true},
+		{Param{Kind: Int, Min: 1999, Max: 2100}, 1990, "", true},
+		{Param{Kind: String}, "O'Neil", "'O''Neil'", false},
+		{Param{Kind: String}, "a\nb", "", true},
+		{Param{Kind: String, Pattern: `[A-Z]{2,3}`}, "sea", "", true},
+		{Param{Kind: Ident}, "nflverse_curated.players", "nflverse_curated.players", false},
+		{Param{Kind: Ident}, "players; --", "", true},
+		{Param{Kind: Ident}, "a.b.c", "", true},
+		{Param{Kind: Date}, "2024-09-01", "DATE '2024-09-01'", false},
+		{Param{Kind: Date}, "2024-13-01", "", true},
+		{Param{Kind: StringList}, []string{"CB", "S"}, "'CB','S'", false},
+		{Param{Kind: StringList}, "CB, S", "'CB','S'", false},
+		{Param{Kind: StringList}, []any{"CB", 3}, "", true},
+		{Param{Kind: StringList, Pattern: `[A-Z]+`}, []string{"CB", "X') OR ('1"}, "", true},
+		{Param{Kind: Expr}, SQL("AVG(x)"), "AVG(x)", false},
+		{Param{Kind: Expr}, "AVG(x)", "", true}, // plain strings (event JSON) are refused
+	}
+	for _, c := range cases {
+		got, err := c.p.render(c.v)
+		if (err != nil) != c.err || got != c.want {
+			t.Errorf("%v %#v: got %q err=%v, want %q err=%v", c.p.Kind, c.v, got, err, c.want, c.err)
+		}
+	}
+}
+
+func testRegistry(t *testing.T) *Registry {
+	t.Helper()
+	r := NewRegistry()
+	for _, tm := range []Template{
+		{Name: "a", Params: []Param{seasonParam}, Query: "SELECT 1 AS x, {{.season}} AS season FROM {{.db}}.src"},
+		{Name: "b", DependsOn: []string{"a"}, Query: `SELECT x FROM {{table "a"}}`},
+		{Name: "c", DependsOn: []string{"b", "a"}, Query: `SELECT * FROM {{table "b"}} JOIN {{table "a"}} USING (x)`},
+		{Name: "d", Query: "SELECT 2 AS y"},
+	} {
+		if err := r.Register(tm); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return r
+}
+
+func names(ts []Template) string {
+	var out []string
+	for _, t := range ts {
+		out = append(out, t.Name)
+	}
+	return strings.Join(out, ",")
+}
+
+func TestPlan(t *testing.T) {
+	r := testRegistry(t)
+	for _, c := range []struct {
+		names    []string
+		withDeps bool
+		want     string
+	}{
+		{[]string{"c"}, true, "a,b,c"},
+		{[]string{"c"}, false, "c"},
+		{[]string{"c", "a"}, false, "a,c"},
+		{[]string{"d", "b"}, true, "a,b,d"},
+		{nil, false, "a,b,c,d"},
+	} {
+		plan, err := r.Plan(c.names, c.withDeps)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := names(plan); got != c.want {
+			t.Errorf("Plan(%v, %v) = %s, want %s", c.names, c.withDeps, got, c.want)
+		}
+	}
+	if _, err := r.Plan([]string{"nope"}, true); err == nil {
+		t.Error("unknown template planned")
+	}
+
+	r.MustRegister(Template{Name: "x", DependsOn: []string{"y"}, Query: "SELECT 1"})
+	r.MustRegister(Template{Name: "y", DependsOn: []string{"x"}, Query: "SELECT 1"})
+	if _, err := r.Plan([]string{"x"}, true); err == nil || !strings.Contains(err.Error(), "cycle") {
+		t.Errorf("cycle not reported: %v", err)
+	}
+	r.MustRegister(Template{Name: "z", DependsOn: []string{"missing"}, Query: "SELECT 1"})
+	if _, err := r.Plan([]string{"z"}, true); err == nil {
+		t.Error("missing dependency not reported")
+	}
+}
+
+func TestRegisterValidates(t *testing.T) {
+	r := NewRegistry()
+	bad := []Template{
+		{Name: "bad name", Query: "SELECT 1"},
+		{Name: "db.t", Query: "SELECT 1"},
+		{Name: "t", Params: []Param{{Name: "Season", Kind: Int}}, Query: "SELECT 1"},
+		{Name: "t", Params: []Param{{Name: "s", Kind: Int}, {Name: "s", Kind: Int}}, Query: "SELECT 1"},
+		{Name: "t", Params: []Param{{Name: "s", Kind: Int, Default: "x"}}, Query: "SELECT 1"},
+		{Name: "t", DependsOn: []string{"t"}, Query: "SELECT 1"},
+		{Name: "t", Query: "SELECT {{.x"},
+	}
+	for _, tm := range bad {
+		if err := r.Register(tm); err == nil {
+			t.Errorf("registered %+v", tm)
+		}
+	}
+	r.MustRegister(Template{Name: "t", Query: "SELECT 1"})
+	if err := r.Register(Template{Name: "t", Query: "SELECT 2"}); err == nil {
+		t.Error("duplicate registered")
+	}
+}
+
+func TestRenderAndStatements(t *testing.T) {
+	r := testRegistry(t)
+	if _, err := r.Render("a", "curated", Args{}); err == nil {
+		t.Error("missing season accepted")
+	}
+	if _, err := r.Render("a", "curated; DROP", Args{"season": 2024}); err == nil {
+		t.Error("bad database accepted")
+	}
+	if err := r.SetTable("a", "a_v2"); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.SetTable("a", "x.y"); err == nil {
+		t.Error("qualified table override accepted")
+	}
+	body, err := r.Render("c", "curated", Args{"season": 2024, "unused": true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(body, "FROM curated.b JOIN curated.a_v2") {
+		t.Errorf("body = %s", body)
+	}
+
+	r.MustRegister(Template{Name: "sneaky", Query: `SELECT * FROM {{table "a"}}`})
+	if _, err := r.Render("sneaky", "curated", nil); err == nil {
+		t.Error("undeclared table read accepted")
+	}
+
+	drop, ctas, err := r.Statements("a", "curated", "s3://bucket/serve/a_v2/season=2024/", Args{"season": 2024})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if drop != "DROP TABLE IF EXISTS curated.a_v2" {
+		t.Errorf("drop = %q", drop)
+	}
+	for _, want := range []string{"CREATE TABLE curated.a_v2", "external_location = 's3://bucket/serve/a_v2/season=2024'", "2024 AS season FROM curated.src"} {
+		if !strings.Contains(ctas, want) {
+			t.Errorf("ctas missing %q:\n%s", want, ctas)
+		}
+	}
+	if strings.Contains(ctas, "partitioned_by") {
+		t.Errorf("unpartitioned template got partitioned_by:\n%s", ctas)
+	}
+}
+
+func TestDefaultTemplates(t *testing.T) {
+	r := Default()
+	plan, err := r.Plan([]string{StartersByTeam}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := names(plan); got != StartersAllGames+","+StartersByTeam {
+		t.Fatalf("plan = %s", got)
+	}
+	spec := starters.Default()
+	args := Args{"season": float64(2024)}.Merge(StarterArgs(spec))
+	_, ctas, err := r.Statements(StartersAllGames, "nflverse_curated", "s3://out/serve/", args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"partitioned_by = ARRAY['season','team']",
+		"WHERE TRY_CAST(sc.season AS INTEGER) = 2024",
+		"DATE '2024-09-01'",
+		"'%Y-%m-%d'",
+		"IN (" + spec.PositionList() + ")",
+		"FROM agg\nWHERE " + spec.Where(starterColumns) + "\n",
+	} {
+		if !strings.Contains(ctas, want) {
+			t.Errorf("starters CTAS missing %q", want)
+		}
+	}
+	// Every starter filter comes from the spec, as in the Go roster path.
+	if strings.Contains(ctas, "games_with_snap = games_total") {
+		t.Error("starters CTAS filters on games outside the spec")
+	}
+	// The PFR id joins only rows the GSIS id did not; names join every row no id
+	// joined, but only onto a name unique that week and a roster entry without a
+	// conflicting id.
+	for _, want := range []string{
+		"AND s.gsis_id = g.gsis_id\n",
+		"ON  g.week IS NULL\n",
+		"ON  i.roster_week IS NULL\n",
+		"AND i.name_key = n.name_key\n    AND n.name_count = 1\n",
+		"AND (i.pfr_id IS NULL OR n.pfr_id IS NULL)\n",
+	} {
+		if !strings.Contains(ctas, want) {
+			t.Errorf("starters CTAS name fallback missing %q", want)
+		}
+	}
+	quality, err := r.Render(StartersMatchQuality, "nflverse_curated", args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(quality, "WHERE match_method IN ('name', 'none')") || !strings.Contains(quality, "LEFT JOIN xwalk x ON") {
+		t.Errorf("match-quality body = %s", quality)
+	}
+	body, err := r.Render(StartersByTeam, "nflverse_curated", args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(body, "FROM nflverse_curated.defensive_starters_allgames") || !strings.Contains(body, "'CB','DB'") {
+		t.Errorf("summary body = %s", body)
+	}
+	// The event could otherwise smuggle SQL through a spec field: Expr params refuse strings.
+	if _, err := r.Render(StartersAllGames, "nflverse_curated", args.Merge(Args{"where": "TRUE) --"})); err == nil {
+		t.Error("string accepted for an Expr parameter")
+	}
+}
+
+// fakeAthena answers the statements RebuildSeason issues from canned partitions.
+type fakeAthena struct {
+	tables  map[string][]string // table -> partitions
+	failOn  string
+	queries []string
+}
+
+func (f *fakeAthena) Query(_ context.Context, sql string) ([][]string, error) {
+	f.queries = append(f.queries, sql)
+	if f.failOn != "" && strings.Contains(sql, f.failOn) {
+		return nil, errors.New("boom")
+	}
+	var rows [][]string
+	switch {
+	case strings.HasPrefix(sql, "SHOW PARTITIONS "):
+		for _, p := range f.tables[strings.TrimPrefix(sql, "SHOW PARTITIONS ")] {
+			rows = append(rows, []string{p})
+		}
+	case strings.HasPrefix(sql, "SHOW TABLES IN "):
+		for t := range f.tables {
+			if strings.HasSuffix(sql, "'"+strings.SplitN(t, ".", 2)[1]+"'") {
+				rows = append(rows, []string{t})
+			}
+		}
+	}
+	return rows, nil
+}
+
+func (f *fakeAthena) ran(prefix string) []string {
+	var out []string
+	for _, q := range f.queries {
+		if strings.HasPrefix(strings.TrimSpace(q), prefix) {
+			out = append(out, q)
+		}
+	}
+	return out
+}
+
+type fakeStore struct{ calls [][2]string }
+
+func (s *fakeStore) DeletePrefix(_ context.Context, prefix, keep string) (int, error) {
+	s.calls = append(s.calls, [2]string{prefix, keep})
+	return 7, nil
+}
+
+func TestRebuildSeasonSwapsPartitions(t *testing.T) {
+	r := testRegistry(t)
+	r.MustRegister(Template{Name: "p", Params: []Param{seasonParam}, PartitionedBy: []string{"season", "team"},
+		Query: "SELECT x, {{.season}} AS season, team FROM {{.db}}.src"})
+	fa := &fakeAthena{tables: map[string][]string{
+		"db.p":                 {"season=2023/team=SEA", "season=2024/team=OLD", "season=2024/team=SEA"},
+		"db.p__stg_20250101":   {"season=2024/team=NEW", "season=2024/team=SEA"},
+		"db.unrelated_p_table": nil,
+	}}
+	st := &fakeStore{}
+	res, err := r.RebuildSeason(context.Background(), fa, st, "p", "db", "s3://b/out/serve/", 2024, "20250101", Args{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	runLoc := "s3://b/out/serve/p/season=2024/run=20250101/"
+	if res.Location != runLoc || strings.Join(res.Added, ",") != "season=2024/team=NEW" ||
+		strings.Join(res.Replaced, ",") != "season=2024/team=SEA" || strings.Join(res.Dropped, ",") != "season=2024/team=OLD" {
+		t.Fatalf("result = %+v", res)
+	}
+	if ctas := fa.ran("CREATE TABLE db.p__stg_20250101"); len(ctas) != 1 || !strings.Contains(ctas[0], "external_location = '"+strings.TrimRight(runLoc, "/")+"'") {
+		t.Fatalf("staging CTAS = %v", ctas)
+	}
+	if len(fa.ran("CREATE TABLE db.p\n")) != 0 {
+		t.Fatal("existing target recreated")
+	}
+	if drops := fa.ran("DROP TABLE IF EXISTS db.p\n"); len(drops) != 0 || len(fa.ran("DROP TABLE IF EXISTS db.p__stg_20250101")) != 2 {
+		t.Fatalf("drops = %v", fa.ran("DROP TABLE"))
+	}
+	want := []string{
+		"ALTER TABLE db.p ADD IF NOT EXISTS\n  PARTITION (season='2024', team='NEW') LOCATION '" + runLoc + "season=2024/team=NEW/'",
+		"ALTER TABLE db.p PARTITION (season='2024', team='SEA') SET LOCATION '" + runLoc + "season=2024/team=SEA/'",
+		"ALTER TABLE db.p DROP IF EXISTS PARTITION (season='2024', team='OLD')",
+	}
+	if got := fa.ran("ALTER TABLE"); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("alters:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	for _, q := range fa.queries {
+		if strings.Contains(q, "2023") {
+			t.Fatalf("touched another season: %s", q)
+		}
+	}
+	if len(st.calls) != 1 || st.calls[0] != [2]string{"s3://b/out/serve/p/season=2024/", runLoc} || res.Deleted != 7 {
+		t.Fatalf("deletes = %v (%d)", st.calls, res.Deleted)
+	}
+}
+
+func TestRebuildSeasonCreatesTargetAndCleansUpFailures(t *testing.T) {
+	r := testRegistry(t)
+	r.MustRegister(Template{Name: "p", Params: []Param{seasonParam}, PartitionedBy: []string{"season"},
+		Query: "SELECT x, {{.season}} AS season FROM {{.db}}.src"})
+	fa := &fakeAthena{tables: map[string][]string{"db.p__stg_1": {"season=2024"}}}
+	res, err := r.RebuildSeason(context.Background(), fa, &fakeStore{}, "p", "db", "s3://b/serve", 2024, "1", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c := fa.ran("CREATE TABLE db.p\n"); len(c) != 1 || !strings.Contains(c[0], "WITH NO DATA") || !strings.Contains(c[0], "'s3://b/serve/p/_schema'") {
+		t.Fatalf("target create = %v", c)
+	}
+	if strings.Join(res.Added, ",") != "season=2024" {
+		t.Fatalf("added = %v", res.Added)
+	}
+
+	fa = &fakeAthena{tables: map[string][]string{}, failOn: "CREATE TABLE db.p__stg_2"}
+	st := &fakeStore{}
+	if _, err := r.RebuildSeason(context.Background(), fa, st, "p", "db", "s3://b/serve", 2024, "2", nil); err == nil {
+		t.Fatal("CTAS failure not reported")
+	}
+	if len(fa.ran("ALTER TABLE")) != 0 {
+		t.Fatal("target altered after a failed CTAS")
+	}
+	if len(st.calls) != 1 || st.calls[0] != [2]string{"s3://b/serve/p/season=2024/run=2/", ""} {
+		t.Fatalf("cleanup deletes = %v", st.calls)
+	}
+
+	if _, err := r.RebuildSeason(context.Background(), fa, st, "a", "db", "s3://b/serve", 2024, "3", nil); err == nil {
+		t.Fatal("unpartitioned template rebuilt by season")
+	}
+	if _, err := r.RebuildSeason(context.Background(), fa, st, "p", "db", "s3://b/serve", 2024, "x;y", nil); err == nil {
+		t.Fatal("bad run id accepted")
+	}
+}
@@ -0,0 +1,239 @@
+package materializer
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Kind is a parameter's SQL type. It decides how a value is validated and rendered.
+type Kind int
+
+const (
+	Int        Kind = iota // integer literal: 2024
+	String                 // quoted string literal: 'SEA'
+	Ident                  // identifier, optionally schema-qualified: nflverse_curated.players
+	Date                   // DATE literal from YYYY-MM-DD: DATE '2024-09-01'
+	StringList             // comma list of string literals for IN (...): 'CB','DB'
+	Expr                   // trusted SQL fragment; values must be of type SQL
+)
+
+func (k Kind) String() string {
+	switch k {
+	case Int:
+		return "int"
+	case String:
+		return "string"
+	case Ident:
+		return "ident"
+	case Date:
+		return "date"
+	case StringList:
+		return "string_list"
+	case Expr:
+		return "expr"
+	}
+	return fmt.Sprintf("kind(%d)", int(k))
+}
+
+// SQL is a SQL fragment built by Go code (for example a starters.Spec expression).
+// Expr parameters accept only this type, so a value decoded from event JSON, which is
+// always a plain string, can never be spliced in unescaped.
+type SQL string
+
+// Param declares one template parameter.
+type Param struct {
+	Name     string
+	Kind     Kind
+	Required bool
+	Default  any    // used when the argument is missing; nil = no default
+	Min, Max int    // Int bounds, checked when Max > Min
+	Pattern  string // String / StringList values must match (anchored), optional
+}
+
+// Args are template arguments by parameter name. Values may be Go values or the
+// types encoding/json produces (float64, string, []any).
+type Args map[string]any
+
+// Merge returns a copy of a with b's entries added, b winning on conflicts.
+func (a Args) Merge(b Args) Args {
+	out := make(Args, len(a)+len(b))
+	for k, v := range a {
+		out[k] = v
+	}
+	for k, v := range b {
+		out[k] = v
+	}
+	return out
+}
+
+var (
+	identPart = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+	paramName = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+)
+
+// render validates v and returns it as SQL text.
+func (p Param) render(v any) (string, error) {
+	switch p.Kind {
+	case Int:
+		n, err := toInt(v)
+		if err != nil {
+			return "", err
+		}
+		if p.Max > p.Min && (n < p.Min || n > p.Max) {
+			return "", fmt.Errorf("%d outside [%d, %d]", n, p.Min, p.Max)
+		}
+		return strconv.Itoa(n), nil
+	case String:
+		s, ok := v.(string)
+		if !ok {
+			return "", fmt.Errorf("want string, got %T", v)
+		}
+		if err := p.match(s); err != nil {
+			return "", err
+		}
+		return quote(s)
+	case Ident:
+		s, ok := v.(string)
+		if !ok {
+			return "", fmt.Errorf("want identifier string, got %T", v)
+		}
+		return ident(s)
+	case Date:
+		s, ok := v.(string)
+		if !ok {
+			if t, isTime := v.(time.Time); isTime {
+				s = t.Format(time.DateOnly)
+			} else {
+				return "", fmt.Errorf("want YYYY-MM-DD, got %T", v)
+			}
+		}
+		if _, err := time.Parse(time.DateOnly, s); err != nil {
+			return "", fmt.Errorf("want YYYY-MM-DD: %w", err)
+		}
+		return "DATE '" + s + "'", nil
+	case StringList:
+		items, err := toStrings(v)
+		if err != nil {
+			return "", err
+		}
+		if len(items) == 0 {
+			return "", fmt.Errorf("empty list")
+		}
+		out := make([]string, len(items))
+		for i, s := range items {
+			if err := p.match(s); err != nil {
+				return "", err
+			}
+			if out[i], err = quote(s); err != nil {
+				return "", err
+			}
+		}
+		return strings.Join(out, ","), nil
+	case Expr:
+		s, ok := v.(SQL)
+		if !ok {
+			return "", fmt.Errorf("want materializer.SQL built in Go, got %T", v)
+		}
+		if strings.TrimSpace(string(s)) == "" {
+			return "", fmt.Errorf("empty expression")
+		}
+		return string(s), nil
+	}
+	return "", fmt.Errorf("unknown kind %v", p.Kind)
+}
+
+func (p Param) match(s string) error {
+	if p.Pattern == "" {
+		return nil
+	}
+	ok, err := regexp.MatchString("^(?:"+p.Pattern+")$", s)
+	if err != nil {
+		return fmt.Errorf("pattern %q: %w", p.Pattern, err)
+	}
+	if !ok {
+		return fmt.Errorf("%q does not match %s", s, p.Pattern)
+	}
+	return nil
+}
+
+// quote renders a string literal, doubling single quotes and rejecting control
+// characters.
+func quote(s string) (string, error) {
+	for _, r := range s {
+		if r < 0x20 || r == 0x7f {
+			return "", fmt.Errorf("control character in %q", s)
+		}
+	}
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'", nil
+}
+
+// ident validates a bare or schema-qualified identifier.
+func ident(s string) (string, error) {
+	parts := strings.Split(s, ".")
+	if len(parts) > 2 {
+		return "", fmt.Errorf("identifier %q has more than one dot", s)
+	}
+	for _, p := range parts {
+		if !identPart.MatchString(p) {
+			return "", fmt.Errorf("invalid identifier %q", s)
+		}
+	}
+	return s, nil
+}
+
+func toInt(v any) (int, error) {
+	switch n := v.(type) {
+	case int:
+		return n, nil
+	case int64:
+		return int(n), nil
+	case int32:
+		return int(n), nil
+	case float64:
+		if n != math.Trunc(n) {
+			return 0, fmt.Errorf("%v is not an integer", n)
+		}
+		return int(n), nil
+	case json.Number:
+		i, err := n.Int64()
+		return int(i), err
+	case string:
+		i, err := strconv.Atoi(strings.TrimSpace(n))
+		if err != nil {
+			return 0, fmt.Errorf("%q is not an integer", n)
+		}
+		return i, nil
+	}
+	return 0, fmt.Errorf("want integer, got %T", v)
+}
+
+func toStrings(v any) ([]string, error) {
+	switch l := v.(type) {
+	case []string:
+		return l, nil
+	case string:
+		var out []string
+		for _, s := range strings.Split(l, ",") {
+			if s = strings.TrimSpace(s); s != "" {
+				out = append(out, s)
+			}
+		}
+		return out, nil
+	case []any:
+		out := make([]string, len(l))
+		for i, x := range l {
+			s, ok := x.(string)
+			if !ok {
+				return nil, fmt.Errorf("list item %d: want string, got %T", i, x)
+			}
+			out[i] = s
+		}
+		return out, nil
+	}
+	return nil, fmt.Errorf("want string list, got %T", v)
+}
@@ -0,0 +1,283 @@
+package materializer
+
+import (
+	"github.com/tyler180/fantasy-football-backends/internal/starters"
+)
+
+// Built-in template names.
+const (
+	StartersAllGames     = "defensive_starters_allgames"
+	StartersByTeam       = "defensive_starters_team_summary"
+	StartersMatchQuality = "defensive_starters_match_quality"
+)
+
+// seasonParam is the season every built-in template filters on.
+var seasonParam = Param{Name: "season", Kind: Int, Required: true, Min: 1999, Max: 2100}
+
+// starterColumns are the column names the starters query aggregates into; the spec's
+// SQL fragments refer to them.
+var starterColumns = starters.SQLColumns{
+	Week:      "week",
+	Pct:       "defense_pct",
+	MaxWeek:   "max_week",
+	Team:      "team",
+	PlayerID:  "player_id",
+	Age:       "age_yrs",
+	Games:     "games_with_snap",
+	Starts:    "games_started",
+	RecentPct: "recent_def_pct",
+}
+
+// StarterArgs renders spec (internal/starters, the same definition the Go roster path
+// applies) into the starters template's arguments.
+func StarterArgs(spec starters.Spec) Args {
+	return Args{
+		"positions":  spec.Positions(),
+		"starts":     SQL(spec.StartsExpr(starterColumns)),
+		"recent_pct": SQL(spec.RecentPctExpr(starterColumns)),
+		"rank":       SQL(spec.Rank(starterColumns)),
+		"where":      SQL(spec.Where(starterColumns)),
+	}
+}
+
+// Default returns a registry holding the built-in templates.
+func Default() *Registry {
+	r := NewRegistry()
+	r.MustRegister(startersAllGames)
+	r.MustRegister(startersByTeam)
+	r.MustRegister(startersMatchQuality)
+	return r
+}
+
+// snapJoin is the WITH list the starters and match-quality templates share: every
+// snap-count row of the season next to its roster entry for that week. Snap counts
+// carry PFR ids; the players crosswalk adds the GSIS id. Rows join on the GSIS id,
+// and on the PFR id only when the GSIS id found no one, so ids pointing at two
+// roster entries still yield one row. A row no id joins falls back to its normalized
+// name when the name is unique on the team's roster that week, so same-name
+// teammates never fan out, and the two sides carry no conflicting ids. match_method
+// records how each row joined: gsis, pfr, name or none.
+const snapJoin = `
+WITH xwalk AS (
+  SELECT pfr_id, MAX(gsis_id) AS gsis_id
+  FROM {{.db}}.players
+  WHERE COALESCE(pfr_id, '') <> '' AND COALESCE(gsis_id, '') <> ''
+  GROUP BY pfr_id
+),
+snaps AS (
+  SELECT
+    TRY_CAST(sc.season AS INTEGER)        AS season,     -- INT
+    sc.team                                AS team,      -- VARCHAR
+    TRY_CAST(sc.week   AS INTEGER)        AS week,       -- INT
+    COALESCE(NULLIF(sc.player_id, ''), x.gsis_id) AS gsis_id,
+    NULLIF(sc.pfr_id, '')                 AS pfr_id,
+    sc.player                              AS player_name,
+    regexp_replace(regexp_replace(LOWER(sc.player), '\s+(jr|sr|ii|iii|iv|v)\.?$'), '[^a-z]') AS name_key,
+    sc.defense_pct
+  FROM {{.db}}.snap_counts sc
+  LEFT JOIN xwalk x ON NULLIF(sc.pfr_id, '') = x.pfr_id
+  WHERE TRY_CAST(sc.season AS INTEGER) = {{.season}}
+),
+roster AS (
+  SELECT
+    TRY_CAST(rw.season AS INTEGER)        AS season,     -- INT
+    rw.team                                AS team,      -- VARCHAR
+    TRY_CAST(rw.week   AS INTEGER)        AS week,       -- INT
+    NULLIF(rw.player_id, '')              AS gsis_id,
+    NULLIF(rw.pfr_id, '')                 AS pfr_id,
+    rw.full_name                           AS full_name,
+    UPPER(COALESCE(rw.position,''))       AS position,
+    regexp_replace(regexp_replace(LOWER(rw.full_name), '\s+(jr|sr|ii|iii|iv|v)\.?$'), '[^a-z]') AS name_key
+  FROM {{.db}}.rosters_weekly rw
+  WHERE TRY_CAST(rw.season AS INTEGER) = {{.season}}
+),
+named AS (
+  SELECT r.*, COUNT(*) OVER (PARTITION BY team, week, name_key) AS name_count
+  FROM roster r
+),
+by_id AS (
+  SELECT
+    s.*,
+    COALESCE(g.week, p.week)           AS roster_week,
+    COALESCE(g.gsis_id, p.gsis_id)     AS roster_gsis_id,
+    COALESCE(g.pfr_id, p.pfr_id)       AS roster_pfr_id,
+    COALESCE(g.full_name, p.full_name) AS roster_name,
+    COALESCE(g.position, p.position)   AS position,
+    CASE
+      WHEN g.week IS NOT NULL THEN 'gsis'
+      WHEN p.week IS NOT NULL THEN 'pfr'
+    END AS id_method
+  FROM snaps s
+  LEFT JOIN named g
+    ON  s.team = g.team
+    AND s.week = g.week
+    AND s.gsis_id = g.gsis_id
+  LEFT JOIN named p
+    ON  g.week IS NULL
+    AND s.team = p.team
+    AND s.week = p.week
+    AND s.pfr_id = p.pfr_id
+),
+joined AS (
+  SELECT
+    i.season, i.team, i.week,
+    i.gsis_id      AS snap_gsis_id,
+    i.pfr_id       AS snap_pfr_id,
+    i.player_name,
+    i.defense_pct,
+    COALESCE(i.roster_gsis_id, n.gsis_id)  AS roster_gsis_id,
+    COALESCE(i.roster_pfr_id, n.pfr_id)    AS roster_pfr_id,
+    COALESCE(i.roster_name, n.full_name)   AS roster_name,
+    COALESCE(i.position, n.position)       AS position,
+    CASE
+      WHEN i.roster_week IS NOT NULL THEN i.id_method
+      WHEN n.week IS NOT NULL        THEN 'name'
+      ELSE 'none'
+    END AS match_method
+  FROM by_id i
+  LEFT JOIN named n
+    ON  i.roster_week IS NULL
+    AND i.team = n.team
+    AND i.week = n.week
+    AND i.name_key = n.name_key
+    AND n.name_count = 1
+    AND (i.gsis_id IS NULL OR n.gsis_id IS NULL)
+    AND (i.pfr_id IS NULL OR n.pfr_id IS NULL)
+)`
+
+// startersAllGames keeps the starters described by a starters.Spec, and filters on
+// nothing else so it agrees with store.MaterializeDefenseFromRoster. GS is not in the
+// nflverse tables, so starts are games at or above the spec's start threshold.
+var startersAllGames = Template{
+	Name:        StartersAllGames,
+	Description: "season starters per team from nflverse snap counts, rosters and players",
+	Params: []Param{
+		seasonParam,
+		{Name: "positions", Kind: StringList, Required: true, Pattern: `[A-Z]{1,4}`},
+		{Name: "starts", Kind: Expr, Required: true},
+		{Name: "recent_pct", Kind: Expr, Required: true},
+		{Name: "rank", Kind: Expr, Required: true},
+		{Name: "where", Kind: Expr, Required: true},
+	},
+	PartitionedBy: []string{"season", "team"},
+	Query: snapJoin + `,
+bounds AS (
+  SELECT MAX(week) AS max_week FROM snaps
+),
+weekly AS (
+  SELECT
+    j.season, j.team, j.week,
+    COALESCE(j.snap_gsis_id, j.roster_gsis_id, '')      AS player_id,   -- GSIS
+    COALESCE(j.snap_pfr_id, j.roster_pfr_id, p.pfr_id)  AS pfr_id,
+    COALESCE(j.player_name, j.roster_name)              AS player_name,
+    COALESCE(j.snap_gsis_id, j.roster_gsis_id, j.snap_pfr_id, j.roster_pfr_id, j.roster_name) AS player_key,
+    j.position,
+    j.defense_pct,
+    CASE
+      WHEN p.birth_date IS NOT NULL AND TRY(date_parse(p.birth_date, '%Y-%m-%d')) IS NOT NULL
+        THEN CAST(date_diff('year', TRY(date_parse(p.birth_date, '%Y-%m-%d')), DATE '{{.season}}-09-01') AS integer)
+      ELSE NULL
+    END AS age_yrs
+  FROM joined j
+  LEFT JOIN {{.db}}.players p
+    ON COALESCE(j.snap_gsis_id, j.roster_gsis_id) = p.gsis_id
+  WHERE j.match_method <> 'none'
+    AND j.position IN ({{.positions}})
+),
+agg AS (
+  SELECT
+    season, team,
+    MAX(player_id)                          AS player_id,
+    MAX(pfr_id)                             AS pfr_id,
+    MAX(player_name)                        AS player_name,
+    MAX(position)                           AS position,
+    MAX(age_yrs)                            AS age_yrs,
+    COUNT_IF(defense_pct IS NOT NULL)       AS games_with_snap,
+    COUNT(*)                                AS games_total,
+    {{.starts}} AS games_started,
+    AVG(defense_pct)                        AS avg_def_pct,
+    {{.recent_pct}} AS recent_def_pct,
+    MIN(defense_pct)                        AS min_def_pct,
+    MAX(defense_pct)                        AS max_def_pct
+  FROM weekly CROSS JOIN bounds
+  GROUP BY season, team, player_key -- one row per player even if the snap name varies
+)
+SELECT
+  -- non-partition columns FIRST:
+  player_id,
+  pfr_id,
+  player_name,
+  position,
+  age_yrs,
+  games_with_snap,
+  games_total,
+  games_started,
+  avg_def_pct,
+  recent_def_pct,
+  min_def_pct,
+  max_def_pct,
+  {{.rank}} AS starter_rank,
+  -- partition columns LAST in the same order as partitioned_by:
+  CAST(season AS INTEGER) AS season,
+  CAST(team   AS VARCHAR) AS team
+FROM agg
+WHERE {{.where}}
+`,
+}
+
+// startersMatchQuality lists the defensive snap-count rows the starters join could
+// not tie to a roster entry by id: those joined on name and those not joined at all.
+var startersMatchQuality = Template{
+	Name:          StartersMatchQuality,
+	Description:   "defensive snap-count rows joined to rosters by name or not at all",
+	Params:        []Param{seasonParam},
+	PartitionedBy: []string{"season"},
+	Query: snapJoin + `
+SELECT
+  team,
+  week,
+  player_name,
+  snap_gsis_id   AS player_id,
+  snap_pfr_id    AS pfr_id,
+  defense_pct,
+  match_method,
+  roster_gsis_id,
+  roster_pfr_id,
+  roster_name,
+  position,
+  CAST(season AS INTEGER) AS season
+FROM joined
+WHERE match_method IN ('name', 'none')
+  AND defense_pct > 0
+`,
+}
+
+// startersByTeam rolls the starters table up to one row per team: how many starters,
+// how young and how much of the defense they play.
+var startersByTeam = Template{
+	Name:        StartersByTeam,
+	Description: "per-team starter counts, ages and snap shares from the starters table",
+	Params: []Param{
+		seasonParam,
+		{Name: "dl_positions", Kind: StringList, Default: starters.Groups["DL"]},
+		{Name: "lb_positions", Kind: StringList, Default: starters.Groups["LB"]},
+		{Name: "db_positions", Kind: StringList, Default: starters.Groups["DB"]},
+	},
+	DependsOn:     []string{StartersAllGames},
+	PartitionedBy: []string{"season"},
+	Query: `
+SELECT
+  team,
+  COUNT(*)                          AS starters,
+  ROUND(AVG(age_yrs), 1)            AS avg_age,
+  MIN(age_yrs)                      AS min_age,
+  ROUND(AVG(recent_def_pct), 2)     AS avg_recent_def_pct,
+  COUNT_IF(position IN ({{.dl_positions}})) AS dl_starters,
+  COUNT_IF(position IN ({{.lb_positions}})) AS lb_starters,
+  COUNT_IF(position IN ({{.db_positions}})) AS db_starters,
+  CAST(season AS INTEGER)           AS season
+FROM {{table "defensive_starters_allgames"}}
+WHERE season = {{.season}}
+GROUP BY team, season
+`,
+}
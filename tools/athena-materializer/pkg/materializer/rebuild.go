@@ -0,0 +1,249 @@
+package materializer
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Runner runs one Athena statement and returns its result rows without the header.
+type Runner interface {
+	Query(ctx context.Context, sql string) ([][]string, error)
+}
+
+// ObjectStore removes the objects a rebuild replaced.
+type ObjectStore interface {
+	// DeletePrefix deletes every object under prefix (an s3:// URI) except those under
+	// keep, and returns how many it deleted.
+	DeletePrefix(ctx context.Context, prefix, keep string) (int, error)
+}
+
+// Rebuild is the outcome of one season's partition-scoped rebuild.
+type Rebuild struct {
+	Template string   `json:"template"`
+	Table    string   `json:"table"`
+	Season   int      `json:"season"`
+	Location string   `json:"location"`
+	Added    []string `json:"added,omitempty"`
+	Replaced []string `json:"replaced,omitempty"`
+	Dropped  []string `json:"dropped,omitempty"`
+	Deleted  int      `json:"deleted_objects"`
+}
+
+// Scoped reports whether t can be rebuilt one season at a time: its first partition
+// column is season.
+func (t Template) Scoped() bool {
+	return len(t.PartitionedBy) > 0 && t.PartitionedBy[0] == "season"
+}
+
+// SeasonPrefix is where every run of name's season lives:
+// <base>/<table>/season=<season>/.
+func (r *Registry) SeasonPrefix(name, base string, season int) string {
+	return fmt.Sprintf("%s/%s/season=%d/", strings.TrimRight(base, "/"), r.Table(name), season)
+}
+
+// RebuildSeason replaces one season of name's table without touching other seasons.
+//
+//  1. CTAS the season into a staging table at <base>/<table>/season=<s>/run=<runID>/.
+//  2. Create the target table (schema only) if it does not exist yet.
+//  3. Point each season/team partition at the staged files: new partitions are
+//     added, existing ones switch location in place, and partitions the new output
+//     no longer has are dropped. Readers see the old or the new files for each
+//     partition, never a missing season.
+//  4. Drop the staging table (external, so its files stay) and delete everything
+//     under the season prefix that is not the new run.
+//
+// A failure before step 3 drops the staging table and its files and leaves the target
+// as it was. runID must be letters, digits or underscores; it names the staging table.
+func (r *Registry) RebuildSeason(ctx context.Context, run Runner, store ObjectStore, name, db, base string, season int, runID string, args Args) (Rebuild, error) {
+	t, ok := r.templates[name]
+	if !ok {
+		return Rebuild{}, fmt.Errorf("unknown template %q", name)
+	}
+	if !t.Scoped() {
+		return Rebuild{}, fmt.Errorf("%s: not partitioned by season first (%v)", name, t.PartitionedBy)
+	}
+	table := r.Table(name)
+	staging := table + "__stg_" + runID
+	if _, err := ident(staging); err != nil {
+		return Rebuild{}, fmt.Errorf("run id %q: %w", runID, err)
+	}
+	seasonPrefix := r.SeasonPrefix(name, base, season)
+	runLoc := seasonPrefix + "run=" + runID + "/"
+	res := Rebuild{Template: name, Table: db + "." + table, Season: season, Location: runLoc}
+
+	ctas, err := r.CTAS(name, db, staging, runLoc, args.Merge(Args{"season": season}))
+	if err != nil {
+		return res, err
+	}
+	cleanup := func(cause error) (Rebuild, error) {
+		if _, err := run.Query(ctx, "DROP TABLE IF EXISTS "+db+"."+staging); err != nil {
+			cause = fmt.Errorf("%w (and drop staging: %v)", cause, err)
+		}
+		if _, err := store.DeletePrefix(ctx, runLoc, ""); err != nil {
+			cause = fmt.Errorf("%w (and delete staged files: %v)", cause, err)
+		}
+		return res, cause
+	}
+	if _, err := run.Query(ctx, "DROP TABLE IF EXISTS "+db+"."+staging); err != nil {
+		return res, fmt.Errorf("drop stale staging table: %w", err)
+	}
+	if _, err := run.Query(ctx, ctas); err != nil {
+		return cleanup(fmt.Errorf("staging CTAS: %w", err))
+	}
+	staged, err := showPartitions(ctx, run, db+"."+staging)
+	if err != nil {
+		return cleanup(err)
+	}
+
+	rows, err := run.Query(ctx, fmt.Sprintf("SHOW TABLES IN %s '%s'", db, table))
+	if err != nil {
+		return cleanup(fmt.Errorf("show tables: %w", err))
+	}
+	existing := map[string]bool{}
+	if len(rows) == 0 {
+		loc, err := quote(strings.TrimRight(base, "/") + "/" + table + "/_schema")
+		if err != nil {
+			return cleanup(err)
+		}
+		schema := fmt.Sprintf("CREATE TABLE %s.%s\nWITH (\n  format = 'PARQUET',\n  external_location = %s,\n  partitioned_by = ARRAY[%s]\n) AS\nSELECT * FROM %s.%s\nWITH NO DATA",
+			db, table, loc, quoteCols(t.PartitionedBy), db, staging)
+		if _, err := run.Query(ctx, schema); err != nil {
+			return cleanup(fmt.Errorf("create %s: %w", table, err))
+		}
+	} else {
+		current, err := showPartitions(ctx, run, db+"."+table)
+		if err != nil {
+			return cleanup(err)
+		}
+		for _, p := range current {
+			if strings.HasPrefix(p, fmt.Sprintf("season=%d/", season)) || p == fmt.Sprintf("season=%d", season) {
+				existing[p] = true
+			}
+		}
+	}
+
+	// From here on the target changes; a failure leaves staged files referenced, so
+	// they are kept for the next run to clean up.
+	var add []string
+	for _, p := range staged {
+		if existing[p] {
+			res.Replaced = append(res.Replaced, p)
+		} else {
+			add = append(add, p)
+		}
+	}
+	if len(add) > 0 {
+		var specs []string
+		for _, p := range add {
+			spec, loc, err := partitionAt(p, runLoc)
+			if err != nil {
+				return res, err
+			}
+			specs = append(specs, fmt.Sprintf("PARTITION (%s) LOCATION %s", spec, loc))
+		}
+		if _, err := run.Query(ctx, fmt.Sprintf("ALTER TABLE %s.%s ADD IF NOT EXISTS\n  %s", db, table, strings.Join(specs, "\n  "))); err != nil {
+			return res, fmt.Errorf("add partitions: %w", err)
+		}
+		res.Added = add
+	}
+	for _, p := range res.Replaced {
+		spec, loc, err := partitionAt(p, runLoc)
+		if err != nil {
+			return res, err
+		}
+		if _, err := run.Query(ctx, fmt.Sprintf("ALTER TABLE %s.%s PARTITION (%s) SET LOCATION %s", db, table, spec, loc)); err != nil {
+			return res, fmt.Errorf("swap partition %s: %w", p, err)
+		}
+	}
+	stagedSet := map[string]bool{}
+	for _, p := range staged {
+		stagedSet[p] = true
+	}
+	var drop []string
+	for p := range existing {
+		if !stagedSet[p] {
+			drop = append(drop, p)
+		}
+	}
+	sort.Strings(drop)
+	if len(drop) > 0 {
+		var specs []string
+		for _, p := range drop {
+			spec, err := partitionSpec(p)
+			if err != nil {
+				return res, err
+			}
+			specs = append(specs, "PARTITION ("+spec+")")
+		}
+		if _, err := run.Query(ctx, fmt.Sprintf("ALTER TABLE %s.%s DROP IF EXISTS %s", db, table, strings.Join(specs, ", "))); err != nil {
+			return res, fmt.Errorf("drop partitions: %w", err)
+		}
+		res.Dropped = drop
+	}
+
+	if _, err := run.Query(ctx, "DROP TABLE IF EXISTS "+db+"."+staging); err != nil {
+		return res, fmt.Errorf("drop staging table: %w", err)
+	}
+	if res.Deleted, err = store.DeletePrefix(ctx, seasonPrefix, runLoc); err != nil {
+		return res, fmt.Errorf("delete replaced files: %w", err)
+	}
+	return res, nil
+}
+
+// showPartitions lists table's partitions as Hive paths (season=2024/team=SEA).
+func showPartitions(ctx context.Context, run Runner, table string) ([]string, error) {
+	rows, err := run.Query(ctx, "SHOW PARTITIONS "+table)
+	if err != nil {
+		return nil, fmt.Errorf("show partitions %s: %w", table, err)
+	}
+	var out []string
+	for _, row := range rows {
+		if len(row) > 0 && strings.TrimSpace(row[0]) != "" {
+			out = append(out, strings.TrimSpace(row[0]))
+		}
+	}
+	sort.Strings(out)
+	return out, nil
+}
+
+// partitionSpec turns season=2024/team=SEA into season='2024', team='SEA'.
+func partitionSpec(path string) (string, error) {
+	var parts []string
+	for _, kv := range strings.Split(path, "/") {
+		k, v, ok := strings.Cut(kv, "=")
+		if !ok {
+			return "", fmt.Errorf("partition %q: want key=value segments", path)
+		}
+		if _, err := ident(k); err != nil {
+			return "", fmt.Errorf("partition %q: %w", path, err)
+		}
+		q, err := quote(v)
+		if err != nil {
+			return "", fmt.Errorf("partition %q: %w", path, err)
+		}
+		parts = append(parts, k+"="+q)
+	}
+	return strings.Join(parts, ", "), nil
+}
+
+// partitionAt is path's spec and its quoted location under the run prefix, where the
+// staging CTAS wrote it.
+func partitionAt(path, runLoc string) (spec, loc string, err error) {
+	if spec, err = partitionSpec(path); err != nil {
+		return "", "", err
+	}
+	if loc, err = quote(runLoc + path + "/"); err != nil {
+		return "", "", err
+	}
+	return spec, loc, nil
+}
+
+func quoteCols(cols []string) string {
+	q := make([]string, len(cols))
+	for i, c := range cols {
+		q[i] = "'" + c + "'"
+	}
+	return strings.Join(q, ",")
+}
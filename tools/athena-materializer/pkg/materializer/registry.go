@@ -0,0 +1,266 @@
+// Package materializer is a registry of named Athena CTAS templates. Each template
+// declares typed parameters, which are validated and rendered to SQL before they reach
+// the query text, and the tables it reads, so a run can build dependencies first.
+package materializer
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+// Template is one materialized table.
+type Template struct {
+	Name          string   // registry key and default table name
+	Description   string   // one line, shown in listings
+	Params        []Param  // typed parameters the query uses as {{.name}}
+	DependsOn     []string // registered templates the query reads via {{table "name"}}
+	PartitionedBy []string // CTAS partition columns, last in the SELECT list
+	Query         string   // text/template SELECT body
+
+	tmpl *template.Template
+}
+
+// Registry holds templates by name and the physical table each one writes.
+type Registry struct {
+	templates map[string]*Template
+	tables    map[string]string
+}
+
+// NewRegistry returns an empty registry.
+func NewRegistry() *Registry {
+	return &Registry{templates: map[string]*Template{}, tables: map[string]string{}}
+}
+
+// Register validates t and adds it. Dependencies may be registered later; Plan checks
+// they exist.
+func (r *Registry) Register(t Template) error {
+	if _, err := ident(t.Name); err != nil || strings.Contains(t.Name, ".") {
+		return fmt.Errorf("template name %q: must be a bare identifier", t.Name)
+	}
+	if _, dup := r.templates[t.Name]; dup {
+		return fmt.Errorf("template %s: already registered", t.Name)
+	}
+	seen := map[string]bool{}
+	for _, p := range t.Params {
+		if !paramName.MatchString(p.Name) {
+			return fmt.Errorf("template %s: parameter name %q must be lower_snake_case", t.Name, p.Name)
+		}
+		if seen[p.Name] {
+			return fmt.Errorf("template %s: duplicate parameter %s", t.Name, p.Name)
+		}
+		seen[p.Name] = true
+		if p.Default != nil {
+			if _, err := p.render(p.Default); err != nil {
+				return fmt.Errorf("template %s: default for %s: %w", t.Name, p.Name, err)
+			}
+		}
+	}
+	for _, c := range t.PartitionedBy {
+		if _, err := ident(c); err != nil {
+			return fmt.Errorf("template %s: partition column: %w", t.Name, err)
+		}
+	}
+	for _, d := range t.DependsOn {
+		if d == t.Name {
+			return fmt.Errorf("template %s: depends on itself", t.Name)
+		}
+	}
+	tmpl, err := template.New(t.Name).Option("missingkey=error").Funcs(template.FuncMap{
+		"table": func(string) (string, error) { return "", nil }, // replaced per render
+	}).Parse(t.Query)
+	if err != nil {
+		return fmt.Errorf("template %s: %w", t.Name, err)
+	}
+	t.tmpl = tmpl
+	r.templates[t.Name] = &t
+	return nil
+}
+
+// MustRegister is Register for built-in templates; it panics on error.
+func (r *Registry) MustRegister(t Template) {
+	if err := r.Register(t); err != nil {
+		panic(err)
+	}
+}
+
+// SetTable makes name write to (and be read from) table instead of its own name.
+func (r *Registry) SetTable(name, table string) error {
+	if _, ok := r.templates[name]; !ok {
+		return fmt.Errorf("unknown template %q", name)
+	}
+	if _, err := ident(table); err != nil || strings.Contains(table, ".") {
+		return fmt.Errorf("table for %s: %q must be a bare identifier", name, table)
+	}
+	r.tables[name] = table
+	return nil
+}
+
+// Table returns the physical table that name writes to.
+func (r *Registry) Table(name string) string {
+	if t, ok := r.tables[name]; ok {
+		return t
+	}
+	return name
+}
+
+// Get returns the template registered as name.
+func (r *Registry) Get(name string) (Template, bool) {
+	t, ok := r.templates[name]
+	if !ok {
+		return Template{}, false
+	}
+	return *t, true
+}
+
+// Names lists registered templates alphabetically.
+func (r *Registry) Names() []string {
+	out := make([]string, 0, len(r.templates))
+	for n := range r.templates {
+		out = append(out, n)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// Plan orders the named templates so every template follows the ones it reads. With
+// withDeps, dependencies not named are added; without, they are assumed current and
+// only order the named ones. No names plans every template.
+func (r *Registry) Plan(names []string, withDeps bool) ([]Template, error) {
+	if len(names) == 0 {
+		names, withDeps = r.Names(), true
+	}
+	want := map[string]bool{}
+	for _, n := range names {
+		if _, ok := r.templates[n]; !ok {
+			return nil, fmt.Errorf("unknown template %q (have %s)", n, strings.Join(r.Names(), ", "))
+		}
+		want[n] = true
+	}
+
+	const (
+		visiting = 1
+		done     = 2
+	)
+	state := map[string]int{}
+	var out []Template
+	var visit func(n string, path []string) error
+	visit = func(n string, path []string) error {
+		switch state[n] {
+		case done:
+			return nil
+		case visiting:
+			return fmt.Errorf("dependency cycle: %s", strings.Join(append(path, n), " -> "))
+		}
+		t, ok := r.templates[n]
+		if !ok {
+			return fmt.Errorf("%s depends on unknown template %q", path[len(path)-1], n)
+		}
+		state[n] = visiting
+		for _, d := range t.DependsOn {
+			if err := visit(d, append(path, n)); err != nil {
+				return err
+			}
+		}
+		state[n] = done
+		if withDeps || want[n] {
+			out = append(out, *t)
+		}
+		return nil
+	}
+	sorted := append([]string(nil), names...)
+	sort.Strings(sorted)
+	for _, n := range sorted {
+		if err := visit(n, nil); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+// Render validates args against name's parameters and returns its SELECT body. db
+// qualifies {{table "x"}} references. Arguments the template does not declare are
+// ignored, so one Args can serve a whole plan.
+func (r *Registry) Render(name, db string, args Args) (string, error) {
+	t, ok := r.templates[name]
+	if !ok {
+		return "", fmt.Errorf("unknown template %q", name)
+	}
+	if _, err := ident(db); err != nil {
+		return "", fmt.Errorf("database: %w", err)
+	}
+	vals := map[string]string{"db": db}
+	for _, p := range t.Params {
+		v, ok := args[p.Name]
+		if !ok || v == nil {
+			if p.Default == nil {
+				if p.Required {
+					return "", fmt.Errorf("%s: missing required parameter %s (%s)", name, p.Name, p.Kind)
+				}
+				vals[p.Name] = ""
+				continue
+			}
+			v = p.Default
+		}
+		s, err := p.render(v)
+		if err != nil {
+			return "", fmt.Errorf("%s: parameter %s (%s): %w", name, p.Name, p.Kind, err)
+		}
+		vals[p.Name] = s
+	}
+	deps := map[string]bool{}
+	for _, d := range t.DependsOn {
+		deps[d] = true
+	}
+	tmpl, err := t.tmpl.Clone()
+	if err != nil {
+		return "", err
+	}
+	tmpl.Funcs(template.FuncMap{
+		"table": func(ref string) (string, error) {
+			if ref != name && !deps[ref] {
+				return "", fmt.Errorf("%s reads %q without declaring it in DependsOn", name, ref)
+			}
+			return db + "." + r.Table(ref), nil
+		},
+	})
+	var b bytes.Buffer
+	if err := tmpl.Execute(&b, vals); err != nil {
+		return "", fmt.Errorf("render %s: %w", name, err)
+	}
+	return b.String(), nil
+}
+
+// Statements renders name as a DROP TABLE IF EXISTS and a Parquet CTAS writing to
+// location.
+func (r *Registry) Statements(name, db, location string, args Args) (drop, ctas string, err error) {
+	table := r.Table(name)
+	if ctas, err = r.CTAS(name, db, table, location, args); err != nil {
+		return "", "", err
+	}
+	return "DROP TABLE IF EXISTS " + db + "." + table, ctas, nil
+}
+
+// CTAS renders name as a Parquet CREATE TABLE AS into db.table (which need not be
+// name's own table, e.g. a staging table) writing to location.
+func (r *Registry) CTAS(name, db, table, location string, args Args) (string, error) {
+	body, err := r.Render(name, db, args)
+	if err != nil {
+		return "", err
+	}
+	if _, err := ident(table); err != nil {
+		return "", fmt.Errorf("table: %w", err)
+	}
+	t := r.templates[name]
+	loc, err := quote(strings.TrimRight(location, "/"))
+	if err != nil {
+		return "", fmt.Errorf("location: %w", err)
+	}
+	with := []string{"format = 'PARQUET'", "external_location = " + loc}
+	if len(t.PartitionedBy) > 0 {
+		with = append(with, "partitioned_by = ARRAY["+quoteCols(t.PartitionedBy)+"]")
+	}
+	return fmt.Sprintf("\nCREATE TABLE %s.%s\nWITH (\n  %s\n) AS\n%s\n", db, table, strings.Join(with, ",\n  "), body), nil
+}
@@ -0,0 +1,14 @@
+package main
+
+import (
+	"log"
+
+	"github.com/aws/aws-lambda-go/lambda"
+
+	appfa "github.com/tyler180/fantasy-football-backends/tools/mfl-free-agents/internal/app/freeagents"
+)
+
+func main() {
+	log.SetFlags(0)
+	lambda.Start(appfa.LambdaEntrypoint)
+}
@@ -0,0 +1,129 @@
+package freeagents
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/tyler180/fantasy-football-backends/internal/crosswalk"
+	"github.com/tyler180/fantasy-football-backends/internal/mfl"
+	"github.com/tyler180/fantasy-football-backends/internal/snaps"
+	"github.com/tyler180/fantasy-football-backends/internal/store"
+)
+
+// CrosswalkAPI is the DynamoDB surface the crosswalk table needs.
+type CrosswalkAPI interface {
+	store.DynamoDBAPI
+	store.DynamoDBScanReadAPI
+}
+
+// Crosswalk resolves MFL players to PFR ids through the persistent crosswalk table,
+// matching players it has not seen (or could not match before) and storing the result.
+type Crosswalk struct {
+	DDB   CrosswalkAPI
+	Table string
+	// Fetched lazily and only when something needs matching; tests set them directly.
+	PFRPlayers []crosswalk.Person
+	IDMap      map[string]string
+}
+
+// Resolve returns MFL id -> PFR id for players. With rebuild, every non-override row is
+// re-matched; otherwise only players missing from the table or previously unmatched.
+// Unmatched players are logged on every run.
+func (x *Crosswalk) Resolve(ctx context.Context, players []mfl.Player, rebuild bool) (map[string]string, error) {
+	existing, err := store.LoadCrosswalk(ctx, x.DDB, x.Table)
+	if err != nil {
+		return nil, err
+	}
+	overrides := crosswalk.Overrides(existing)
+	if f := envStr("CROSSWALK_OVERRIDES_FILE", ""); f != "" {
+		fileOv, err := crosswalk.LoadOverridesFile(f)
+		if err != nil {
+			return nil, err
+		}
+		for k, v := range fileOv {
+			if _, ok := overrides[k]; !ok { // table overrides win over the file
+				overrides[k] = v
+			}
+		}
+	}
+	known := make(map[string]crosswalk.Entry, len(existing))
+	for _, e := range existing {
+		known[e.MFLID] = e
+	}
+
+	var todo []crosswalk.Person
+	entries := make([]crosswalk.Entry, 0, len(players))
+	for _, p := range players {
+		e, ok := known[p.ID]
+		_, overridden := overrides[p.ID]
+		if ok && !rebuild && !overridden && e.Matched() {
+			entries = append(entries, e)
+			continue
+		}
+		todo = append(todo, Person(p))
+	}
+
+	if len(todo) > 0 {
+		if err := x.loadSources(ctx); err != nil {
+			return nil, err
+		}
+		built := crosswalk.Build(todo, x.PFRPlayers, overrides, x.IDMap, crosswalk.DefaultOptions())
+		rep, err := store.PutCrosswalk(ctx, x.DDB, x.Table, built)
+		if err != nil {
+			return nil, fmt.Errorf("write crosswalk: %w", err)
+		}
+		log.Printf("crosswalk: matched %d player(s) (%s)", len(built)-len(crosswalk.Unmatched(built)), rep)
+		entries = append(entries, built...)
+	}
+
+	unmatched := crosswalk.Unmatched(entries)
+	sort.Slice(unmatched, func(i, j int) bool { return unmatched[i].Name < unmatched[j].Name })
+	for _, e := range unmatched {
+		log.Printf("crosswalk: UNMATCHED mfl=%s %q %s %s: %s", e.MFLID, e.Name, e.Position, e.Team, e.Reason)
+	}
+	if len(unmatched) > 0 {
+		log.Printf("crosswalk: %d unmatched; add overrides to %s (Method=override) or CROSSWALK_OVERRIDES_FILE", len(unmatched), x.Table)
+	}
+	return crosswalk.Map(entries), nil
+}
+
+func (x *Crosswalk) loadSources(ctx context.Context) error {
+	if x.PFRPlayers == nil {
+		nv, err := snaps.FetchNflversePlayers(ctx, envStr("IDS_URL", ""))
+		if err != nil {
+			return fmt.Errorf("nflverse players: %w", err)
+		}
+		x.PFRPlayers = make([]crosswalk.Person, 0, len(nv))
+		for _, p := range nv {
+			x.PFRPlayers = append(x.PFRPlayers, crosswalk.Person{
+				ID: p.PFRID, Name: p.Name, Position: p.Position, Team: p.Team, BirthDate: p.BirthDate,
+			})
+		}
+	}
+	if x.IDMap == nil {
+		m, err := snaps.FetchMFLPlayerIDs(ctx, envStr("MFL_IDS_URL", ""))
+		if err != nil {
+			log.Printf("crosswalk: WARN mfl id list unavailable, matching by name only: %v", err)
+			m = map[string]string{}
+		}
+		x.IDMap = m
+	}
+	return nil
+}
+
+// Person converts an MFL player to the crosswalk's shape (nflverse team code).
+func Person(p mfl.Player) crosswalk.Person {
+	out := crosswalk.Person{
+		ID:       p.ID,
+		Name:     p.DisplayName(),
+		Position: strings.ToUpper(p.Position),
+		Team:     mfl.NflverseTeam(p.Team),
+	}
+	if !p.Birthdate.IsZero() {
+		out.BirthDate = p.Birthdate.Format("2006-01-02")
+	}
+	return out
+}
@@ -0,0 +1,235 @@
+package freeagents
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+
+	"github.com/tyler180/fantasy-football-backends/internal/mfl"
+	"github.com/tyler180/fantasy-football-backends/internal/snaps"
+	"github.com/tyler180/fantasy-football-backends/internal/starters"
+	"github.com/tyler180/fantasy-football-backends/internal/store"
+)
+
+// Event is the Lambda payload; fields fall back to env.
+type Event struct {
+	Mode      string `json:"mode"`      // free_agents (default) | crosswalk
+	Season    string `json:"season"`    // trend season, e.g. "2024" (env SEASON)
+	Positions string `json:"positions"` // CSV of MFL positions, "*" for all (env FA_POSITIONS)
+}
+
+func envStr(k, def string) string {
+	v := strings.TrimSpace(os.Getenv(k))
+	if v == "" {
+		return def
+	}
+	return v
+}
+
+func envBool(k string, def bool) bool {
+	switch strings.ToLower(strings.TrimSpace(os.Getenv(k))) {
+	case "1", "true", "t", "yes", "y", "on":
+		return true
+	case "0", "false", "f", "no", "n", "off":
+		return false
+	default:
+		return def
+	}
+}
+
+// playersBatch bounds the PLAYERS= list per players export.
+const playersBatch = 200
+
+// LambdaEntrypoint runs one mode:
+//
+//	free_agents: pull the league's free-agent pool, resolve PFR ids through the
+//	             crosswalk, and replace the contents of mfl_free_agents
+//	crosswalk:   rebuild the MFL -> PFR crosswalk for every MFL player at the
+//	             configured positions
+func LambdaEntrypoint(ctx context.Context, raw json.RawMessage) (string, error) {
+	var e Event
+	_ = json.Unmarshal(raw, &e)
+	if e.Season == "" {
+		e.Season = envStr("SEASON", "2024")
+	}
+	if e.Positions == "" {
+		e.Positions = envStr("FA_POSITIONS", "DE,DT,LB,CB,S")
+	}
+	mode := strings.TrimSpace(e.Mode)
+	if mode == "" {
+		mode = envStr("MODE", "free_agents")
+	}
+	debug := envBool("DEBUG", false)
+
+	awsCfg, err := config.LoadDefaultConfig(ctx)
+	if err != nil {
+		return "", fmt.Errorf("aws config: %w", err)
+	}
+	ddb := dynamodb.NewFromConfig(awsCfg)
+	cfg, err := mfl.LoadConfig(ctx, secretsmanager.NewFromConfig(awsCfg))
+	if err != nil {
+		return "", err
+	}
+	client := mfl.New(cfg)
+
+	switch mode {
+	case "free_agents":
+		return runFreeAgents(ctx, ddb, client, e, debug)
+	case "crosswalk":
+		return runCrosswalk(ctx, ddb, client, e)
+	default:
+		return "", fmt.Errorf("unknown mode %q", mode)
+	}
+}
+
+func runFreeAgents(ctx context.Context, ddb *dynamodb.Client, client *mfl.Client, e Event, debug bool) (string, error) {
+	pool, err := client.FreeAgents(ctx, "")
+	if err != nil {
+		return "", fmt.Errorf("free agents: %w", err)
+	}
+	ids := make([]string, 0, len(pool))
+	for _, p := range pool {
+		ids = append(ids, p.ID)
+	}
+	players, err := fetchPlayers(ctx, client, ids)
+	if err != nil {
+		return "", err
+	}
+	players = filterPositions(players, splitCSV(e.Positions))
+
+	xw := &Crosswalk{DDB: ddb, Table: envStr("CROSSWALK_TABLE_NAME", "mfl_pfr_crosswalk")}
+	pfrIDs, err := xw.Resolve(ctx, players, false)
+	if err != nil {
+		return "", err
+	}
+
+	seasonInt, _ := strconv.Atoi(e.Season)
+	in := Inputs{Season: e.Season, PFRIDs: pfrIDs}
+	if in.Byes, err = snaps.FetchNflverseByeWeeks(ctx, seasonInt, envStr("SCHEDULE_URL", "")); err != nil {
+		log.Printf("freeagents: WARN bye weeks unavailable: %v", err)
+	}
+	playersTable := envStr("TABLE_NAME", "defensive_players_by_team")
+	if in.Trends, err = store.LoadSeasonTrends(ctx, ddb, playersTable, e.Season); err != nil {
+		return "", err
+	}
+
+	fas, st := Build(players, in)
+	table := envStr("FREE_AGENTS_TABLE_NAME", "mfl_free_agents")
+	rep, err := store.ReplaceFreeAgents(ctx, ddb, table, splitCSV(e.Positions), fas)
+	if err != nil {
+		return "", err
+	}
+	if debug {
+		log.Printf("freeagents: pool=%d kept=%d resolved=%d with_trends=%d", len(pool), len(fas), st.Resolved, st.WithTrends)
+	}
+	log.Printf("OK freeagents: %d free agents in %s (%s) at %s", len(fas), table, rep, time.Now().UTC().Format(time.RFC3339))
+	return fmt.Sprintf("free_agents=%d resolved=%d with_trends=%d", len(fas), st.Resolved, st.WithTrends), nil
+}
+
+func runCrosswalk(ctx context.Context, ddb *dynamodb.Client, client *mfl.Client, e Event) (string, error) {
+	players, err := client.Players(ctx)
+	if err != nil {
+		return "", fmt.Errorf("players: %w", err)
+	}
+	players = filterPositions(players, splitCSV(e.Positions))
+
+	xw := &Crosswalk{DDB: ddb, Table: envStr("CROSSWALK_TABLE_NAME", "mfl_pfr_crosswalk")}
+	pfrIDs, err := xw.Resolve(ctx, players, true)
+	if err != nil {
+		return "", err
+	}
+	log.Printf("OK crosswalk: %d/%d MFL players matched in %s", len(pfrIDs), len(players), xw.Table)
+	return fmt.Sprintf("players=%d matched=%d", len(players), len(pfrIDs)), nil
+}
+
+func fetchPlayers(ctx context.Context, client *mfl.Client, ids []string) ([]mfl.Player, error) {
+	var players []mfl.Player
+	for i := 0; i < len(ids); i += playersBatch {
+		batch, err := client.Players(ctx, ids[i:min(i+playersBatch, len(ids))]...)
+		if err != nil {
+			return nil, fmt.Errorf("players: %w", err)
+		}
+		players = append(players, batch...)
+	}
+	return players, nil
+}
+
+// filterPositions keeps players at the given MFL positions; empty or "*" keeps all.
+func filterPositions(players []mfl.Player, positions []string) []mfl.Player {
+	keep := map[string]bool{}
+	for _, p := range positions {
+		keep[p] = true
+	}
+	if len(keep) == 0 || keep["*"] {
+		return players
+	}
+	out := players[:0]
+	for _, p := range players {
+		if keep[strings.ToUpper(p.Position)] {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// Inputs are the lookups Build joins the pool against.
+type Inputs struct {
+	Season string
+	PFRIDs map[string]string             // MFL id -> PFR id (crosswalk)
+	Byes   map[string]int                // nflverse team -> bye week
+	Trends map[string]store.PlayerTrends // PFR id -> players-table snapshot
+}
+
+// Stats counts how the pool resolved.
+type Stats struct {
+	Resolved   int
+	WithTrends int
+}
+
+// Build turns MFL player records into free-agent items.
+func Build(players []mfl.Player, in Inputs) ([]store.FreeAgent, Stats) {
+	var st Stats
+	out := make([]store.FreeAgent, 0, len(players))
+	for _, p := range players {
+		pos := strings.ToUpper(p.Position)
+		fa := store.FreeAgent{
+			PlayerID: p.ID,
+			Name:     p.DisplayName(),
+			Position: pos,
+			Group:    starters.GroupOf(pos),
+			Team:     p.Team,
+			PFRID:    in.PFRIDs[p.ID],
+			PFRTeam:  mfl.PFRTeam(p.Team),
+			ByeWeek:  in.Byes[mfl.NflverseTeam(p.Team)],
+			Season:   in.Season,
+		}
+		if fa.PFRID != "" {
+			st.Resolved++
+			if t, ok := in.Trends[fa.PFRID]; ok {
+				fa.Trends = &t
+				st.WithTrends++
+			}
+		}
+		out = append(out, fa)
+	}
+	return out, st
+}
+
+func splitCSV(s string) []string {
+	var out []string
+	for _, p := range strings.Split(s, ",") {
+		if p = strings.ToUpper(strings.TrimSpace(p)); p != "" {
+			out = append(out, p)
+		}
+	}
+	return out
+}
@@ -0,0 +1,110 @@
+package freeagents
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"github.com/tyler180/fantasy-football-backends/internal/crosswalk"
+	"github.com/tyler180/fantasy-football-backends/internal/mfl"
+	"github.com/tyler180/fantasy-football-backends/internal/store"
+)
+
+func TestBuild_JoinsCrosswalkByesAndTrends(t *testing.T) {
+	players := filterPositions([]mfl.Player{
+		{ID: "1", Name: "Doe, John", Position: "LB", Team: "KCC"},
+		{ID: "2", Name: "St. Brown, A.J.", Position: "S", Team: "GBP"},
+		{ID: "3", Name: "Nobody, Nick", Position: "CB", Team: "FA"},
+		{ID: "4", Name: "Arm, Strong", Position: "QB", Team: "SEA"},
+	}, []string{"LB", "S", "CB"})
+	in := Inputs{
+		Season: "2024",
+		PFRIDs: map[string]string{"1": "DoeJo00", "2": "StBrAJ00"},
+		Byes:   map[string]int{"KC": 6, "GB": 10},
+		Trends: map[string]store.PlayerTrends{"DoeJo00": {PlayerID: "DoeJo00", Slope3: 7.5, WeeksSince50: -1}},
+	}
+	fas, st := Build(players, in)
+	if len(fas) != 3 {
+		t.Fatalf("kept %d, want 3 (QB filtered)", len(fas))
+	}
+	doe := fas[0]
+	if doe.PFRID != "DoeJo00" || doe.PFRTeam != "KAN" || doe.ByeWeek != 6 || doe.Group != "LB" || doe.Trends == nil || doe.Trends.Slope3 != 7.5 {
+		t.Fatalf("doe = %+v", doe)
+	}
+	if fas[1].Name != "A.J. St. Brown" || fas[1].ByeWeek != 10 || fas[1].Trends != nil {
+		t.Fatalf("second = %+v", fas[1])
+	}
+	if st.Resolved != 2 || st.WithTrends != 1 || fas[2].PFRTeam != "" {
+		t.Fatalf("stats = %+v, nobody = %+v", st, fas[2])
+	}
+}
+
+// fakeXW is an in-memory crosswalk table.
+type fakeXW struct {
+	items map[string]map[string]types.AttributeValue
+}
+
+func (f *fakeXW) Scan(_ context.Context, _ *dynamodb.ScanInput, _ ...func(*dynamodb.Options)) (*dynamodb.ScanOutput, error) {
+	out := &dynamodb.ScanOutput{}
+	for _, it := range f.items {
+		out.Items = append(out.Items, it)
+	}
+	return out, nil
+}
+
+func (f *fakeXW) BatchWriteItem(_ context.Context, in *dynamodb.BatchWriteItemInput, _ ...func(*dynamodb.Options)) (*dynamodb.BatchWriteItemOutput, error) {
+	for _, reqs := range in.RequestItems {
+		for _, r := range reqs {
+			id := r.PutRequest.Item["MFLID"].(*types.AttributeValueMemberS).Value
+			f.items[id] = r.PutRequest.Item
+		}
+	}
+	return &dynamodb.BatchWriteItemOutput{}, nil
+}
+
+func (f *fakeXW) UpdateItem(context.Context, *dynamodb.UpdateItemInput, ...func(*dynamodb.Options)) (*dynamodb.UpdateItemOutput, error) {
+	return &dynamodb.UpdateItemOutput{}, nil
+}
+
+func s(v string) types.AttributeValue { return &types.AttributeValueMemberS{Value: v} }
+
+func TestCrosswalk_ResolveKeepsOverridesAndStoresMatches(t *testing.T) {
+	db := &fakeXW{items: map[string]map[string]types.AttributeValue{
+		"2": {"MFLID": s("2"), "PFRID": s("SmitJo01"), "Method": s("override")},
+	}}
+	x := &Crosswalk{DDB: db, Table: "xw", IDMap: map[string]string{}, PFRPlayers: []crosswalk.Person{
+		{ID: "DoeJo00", Name: "John Doe", Position: "OLB", Team: "KC", BirthDate: "2000-01-01"},
+		{ID: "SmitJo00", Name: "John Smith", Position: "LB", Team: "SEA"},
+		{ID: "SmitJo01", Name: "John Smith", Position: "LB", Team: "DAL"},
+	}}
+	players := []mfl.Player{
+		{ID: "1", Name: "Doe, John", Position: "LB", Team: "KCC", Birthdate: time.Date(2000, 1, 1, 6, 0, 0, 0, time.UTC)},
+		{ID: "2", Name: "Smith, John", Position: "LB"},
+		{ID: "3", Name: "Smith, John", Position: "LB"},
+	}
+	got, err := x.Resolve(context.Background(), players, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got["1"] != "DoeJo00" || got["2"] != "SmitJo01" || got["3"] != "" || len(got) != 2 {
+		t.Fatalf("resolved = %v", got)
+	}
+	if m := db.items["1"]["Method"].(*types.AttributeValueMemberS).Value; m != "name+dob+team+pos" {
+		t.Fatalf("stored method = %q", m)
+	}
+	if _, ok := db.items["3"]["PFRID"]; ok || db.items["3"]["Reason"] == nil {
+		t.Fatalf("unmatched row = %v", db.items["3"])
+	}
+	if db.items["2"]["Score"] != nil {
+		t.Fatal("override row was overwritten")
+	}
+
+	// A second run reuses the stored match without needing the sources.
+	x.PFRPlayers = []crosswalk.Person{}
+	if got, _ := x.Resolve(context.Background(), players[:1], false); got["1"] != "DoeJo00" {
+		t.Fatalf("cached = %v", got)
+	}
+}
@@ -0,0 +1,74 @@
+// glue-schema writes the curated Glue column lists Terraform reads
+// (infra/terraform/curated_schema.tf.json) from the row structs in internal/schema.
+// A change that existing Parquet files could not be read under is refused unless
+// -allow-incompatible is given; -check only reports whether the file is current.
+//
+//	go run ./cmd/glue-schema -out ../../infra/terraform/curated_schema.tf.json
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+
+	"github.com/tyler180/fantasy-football-backends/tools/nflverse-curator/internal/schema"
+)
+
+func main() {
+	out := flag.String("out", "../../"+schema.TerraformFile, "Terraform JSON file to write")
+	check := flag.Bool("check", false, "exit 1 if the file is out of date instead of writing it")
+	allow := flag.Bool("allow-incompatible", false, "write even if a change breaks existing files")
+	flag.Parse()
+
+	next, err := schema.Terraform(schema.Datasets)
+	if err != nil {
+		log.Fatal(err)
+	}
+	cur, err := os.ReadFile(*out)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		log.Fatal(err)
+	}
+	if bytes.Equal(cur, next) {
+		fmt.Printf("%s is up to date\n", *out)
+		return
+	}
+	if *check {
+		log.Fatalf("%s is out of date; run go run ./cmd/glue-schema", *out)
+	}
+
+	if cur != nil {
+		old, err := schema.ParseTerraform(cur)
+		if err != nil {
+			log.Fatal(err)
+		}
+		for _, d := range schema.Datasets {
+			t, ok := old[d.Name]
+			if !ok {
+				continue
+			}
+			want, err := d.Table()
+			if err != nil {
+				log.Fatal(err)
+			}
+			added, err := schema.Evolve(t, want)
+			if err != nil {
+				if !*allow {
+					log.Fatalf("%s: %v (use -allow-incompatible to write anyway)", d.Name, err)
+				}
+				log.Printf("WARN %s: %v", d.Name, err)
+				continue
+			}
+			for _, c := range added {
+				fmt.Printf("%s: adds %s %s\n", d.Name, c.Name, c.Type)
+			}
+		}
+	}
+	if err := os.WriteFile(*out, next, 0o644); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("wrote %s\n", *out)
+}
@@ -0,0 +1,85 @@
+// nflverse-compact compacts curated partitions outside the curator run: each
+// partition's Parquet parts are merged into one deduplicated, sorted file and its
+// manifest swapped to it (see internal/compact). Run it once over every season to
+// create the manifests before applying the Terraform that points the Glue tables at
+// them, and whenever a backfill skipped compaction.
+//
+//	go run ./cmd/nflverse-compact -bucket nflverse-curated-datasets -season 2024
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+
+	"github.com/tyler180/fantasy-football-backends/tools/nflverse-curator/internal/compact"
+	"github.com/tyler180/fantasy-football-backends/tools/nflverse-curator/internal/schema"
+)
+
+func getenv(k, def string) string {
+	if v := strings.TrimSpace(os.Getenv(k)); v != "" {
+		return v
+	}
+	return def
+}
+
+func main() {
+	var names []string
+	for _, d := range schema.Datasets {
+		names = append(names, d.Name)
+	}
+	bucket := flag.String("bucket", getenv("CURATED_BUCKET", ""), "curated bucket")
+	prefix := flag.String("prefix", getenv("CURATED_PREFIX", "nflverse_curated"), "curated key prefix")
+	datasets := flag.String("datasets", strings.Join(names, ","), "comma-separated datasets")
+	season := flag.Int("season", 0, "only this season's partitions (0 = all)")
+	grace := flag.Duration("grace", compact.DefaultGrace, "keep superseded parts this long after the swap (0 = delete now)")
+	dryRun := flag.Bool("dry-run", false, "list partitions and their file counts without compacting")
+	flag.Parse()
+	if *bucket == "" {
+		log.Fatal("-bucket (or CURATED_BUCKET) is required")
+	}
+
+	ctx := context.Background()
+	cfg, err := config.LoadDefaultConfig(ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
+	store := &compact.Store{API: s3.NewFromConfig(cfg), Bucket: *bucket, Prefix: strings.Trim(*prefix, "/"), Grace: *grace}
+
+	enc := json.NewEncoder(os.Stdout)
+	failed := false
+	for _, ds := range strings.Split(*datasets, ",") {
+		parts, err := store.Partitions(ctx, strings.TrimSpace(ds), *season)
+		if err != nil {
+			log.Fatal(err)
+		}
+		for _, p := range parts {
+			if *dryRun {
+				m, err := store.Manifest(ctx, p)
+				if err != nil {
+					log.Fatal(err)
+				}
+				_ = enc.Encode(map[string]any{"partition": p.String(), "files": len(m.Files), "manifest": m.ETag != ""})
+				continue
+			}
+			res, err := store.Compact(ctx, p)
+			if err != nil {
+				// A conflict means a writer got there first; rerunning picks it up.
+				log.Printf("WARN %s: %v", p, err)
+				failed = failed || !errors.Is(err, compact.ErrConflict)
+				continue
+			}
+			_ = enc.Encode(res)
+		}
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
@@ -20,23 +20,32 @@ import (
 	"github.com/aws/aws-lambda-go/lambda"
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/athena"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
 
+	"github.com/tyler180/fantasy-football-backends/internal/ath"
+	"github.com/tyler180/fantasy-football-backends/internal/dq"
 	// update this import path to your module path
+	"github.com/tyler180/fantasy-football-backends/tools/nflverse-curator/internal/compact"
 	"github.com/tyler180/fantasy-football-backends/tools/nflverse-curator/internal/nflverse"
+	"github.com/tyler180/fantasy-football-backends/tools/nflverse-curator/internal/schema"
 )
 
 /* ---------- Types & util ---------- */
 
 type Event struct {
-	Datasets []string `json:"datasets"`
-	Season   int      `json:"season"`
+	Datasets    []string `json:"datasets"`
+	Season      int      `json:"season"`
+	SkipChecks  bool     `json:"skip_checks"`  // don't run the data-quality checks (backfills)
+	SkipCompact bool     `json:"skip_compact"` // leave the written partitions' parts uncompacted
 }
 
 type Handler struct {
-	S3     *s3.Client
-	Bucket string
-	Prefix string
+	S3      *s3.Client
+	Athena  *ath.Client    // Glue schema checks and data-quality queries
+	Curated *compact.Store // partition manifests Athena reads through, and compaction
+	Bucket  string
+	Prefix  string
 }
 
 func getenv(k, def string) string {
@@ -52,6 +61,7 @@ func nowStamp() string { return time.Now().UTC().Format("20060102T150405Z") }
 type s3uploader struct {
 	cl     *s3.Client
 	bucket string
+	keys   []string // written so far, in order
 }
 
 func (u *s3uploader) put(ctx context.Context, key string, body []byte) error {
@@ -60,6 +70,9 @@ func (u *s3uploader) put(ctx context.Context, key string, body []byte) error {
 		Key:    aws.String(key),
 		Body:   bytes.NewReader(body),
 	})
+	if err == nil {
+		u.keys = append(u.keys, key)
+	}
 	return err
 }
 
@@ -122,38 +135,6 @@ func strPtr(s string) *string {
 	return &s
 }
 
-/* ---------- Parquet row types ---------- */
-
-type PlayersRow struct {
-	PfrID     *string `parquet:"pfr_id,optional"`
-	GsisID    *string `parquet:"gsis_id,optional"`
-	FullName  *string `parquet:"full_name,optional"`
-	BirthDate *string `parquet:"birth_date,optional"`
-	Position  *string `parquet:"position,optional"`
-}
-
-type RostersWeeklyRow struct {
-	Season   string  `parquet:"season"`
-	Week     string  `parquet:"week"`
-	Team     string  `parquet:"team"`
-	PlayerID *string `parquet:"player_id,optional"`
-	PfrID    *string `parquet:"pfr_id,optional"`
-	FullName *string `parquet:"full_name,optional"`
-	Position *string `parquet:"position,optional"`
-	Status   *string `parquet:"status,optional"`
-}
-
-type SnapCountsRow struct {
-	Season     string   `parquet:"season"`
-	Week       string   `parquet:"week"`
-	Team       string   `parquet:"team"`
-	Player     *string  `parquet:"player,optional"`
-	PlayerID   *string  `parquet:"player_id,optional"`
-	OffensePct *float64 `parquet:"offense_pct,optional"`
-	DefensePct *float64 `parquet:"defense_pct,optional"`
-	STPct      *float64 `parquet:"st_pct,optional"`
-}
-
 /* ---------- Parquet writer ---------- */
 
 func writeParquetAndUpload[T any](ctx context.Context, rows []T, key string, schema *parquet.Schema, up *s3uploader) error {
@@ -212,9 +193,9 @@ func ingestPlayers(ctx context.Context, up *s3uploader, prefix, url string) (int
 	iBirth := idxOf(h, "birth_date")
 	iPos := idxOf(h, "position")
 
-	out := make([]PlayersRow, 0, len(rows)-1)
+	out := make([]schema.PlayersRow, 0, len(rows)-1)
 	for _, rec := range rows[1:] {
-		out = append(out, PlayersRow{
+		out = append(out, schema.PlayersRow{
 			PfrID:     strPtr(get(rec, iPfr)),
 			GsisID:    strPtr(get(rec, iGsis)),
 			FullName:  strPtr(get(rec, iName)),
@@ -223,7 +204,7 @@ func ingestPlayers(ctx context.Context, up *s3uploader, prefix, url string) (int
 		})
 	}
 	key := fmt.Sprintf("%s/players/players-%s.parquet", prefix, nowStamp())
-	return len(out), writeParquetAndUpload(ctx, out, key, parquet.SchemaOf(new(PlayersRow)), up)
+	return len(out), writeParquetAndUpload(ctx, out, key, parquet.SchemaOf(new(schema.PlayersRow)), up)
 }
 
 func ingestRostersWeekly(ctx context.Context, up *s3uploader, prefix, url string) (int, error) {
@@ -251,7 +232,7 @@ func ingestRostersWeekly(ctx context.Context, up *s3uploader, prefix, url string
 
 	// partition: season/week
 	type key struct{ season, week string }
-	buckets := map[key][]RostersWeeklyRow{}
+	buckets := map[key][]schema.RostersWeeklyRow{}
 
 	for _, rec := range rows[1:] {
 		season := get(rec, iSeason)
@@ -261,7 +242,7 @@ func ingestRostersWeekly(ctx context.Context, up *s3uploader, prefix, url string
 		}
 		week = fmt.Sprintf("%02s", week)
 		k := key{season, week}
-		row := RostersWeeklyRow{
+		row := schema.RostersWeeklyRow{
 			Season:   season,
 			Week:     week,
 			Team:     strings.ToUpper(get(rec, iTeam)),
@@ -274,11 +255,11 @@ func ingestRostersWeekly(ctx context.Context, up *s3uploader, prefix, url string
 		buckets[k] = append(buckets[k], row)
 	}
 
-	schema := parquet.SchemaOf(new(RostersWeeklyRow))
+	ps := parquet.SchemaOf(new(schema.RostersWeeklyRow))
 	total := 0
 	for k, part := range buckets {
 		key := fmt.Sprintf("%s/rosters_weekly/season=%s/week=%s/part-%s.parquet", prefix, k.season, k.week, nowStamp())
-		if err := writeParquetAndUpload(ctx, part, key, schema, up); err != nil {
+		if err := writeParquetAndUpload(ctx, part, key, ps, up); err != nil {
 			return total, err
 		}
 		total += len(part)
@@ -302,13 +283,22 @@ func ingestSnapCounts(ctx context.Context, up *s3uploader, prefix, url string) (
 	iTeam := is("team")
 	iPlayer := is("player")
 	iPlayerID := is("player_id")
+	if iPlayerID < 0 {
+		iPlayerID = is("gsis_id")
+	}
+	// nflverse snap counts are keyed by PFR id; the materializer maps it to GSIS
+	// through players.
+	iPfr := is("pfr_player_id")
+	if iPfr < 0 {
+		iPfr = is("pfr_id")
+	}
 	iOff := is("offense_pct")
 	iDef := is("defense_pct")
 	iST := is("st_pct")
 
 	// partition: season/team
 	type key struct{ season, team string }
-	buckets := map[key][]SnapCountsRow{}
+	buckets := map[key][]schema.SnapCountsRow{}
 
 	for _, rec := range rows[1:] {
 		season := get(rec, iSeason)
@@ -318,12 +308,13 @@ func ingestSnapCounts(ctx context.Context, up *s3uploader, prefix, url string) (
 			continue
 		}
 		week = fmt.Sprintf("%02s", week)
-		row := SnapCountsRow{
+		row := schema.SnapCountsRow{
 			Season:     season,
 			Week:       week,
 			Team:       team,
 			Player:     strPtr(get(rec, iPlayer)),
 			PlayerID:   strPtr(get(rec, iPlayerID)),
+			PfrID:      strPtr(get(rec, iPfr)),
 			OffensePct: parseFloat(rec, iOff),
 			DefensePct: parseFloat(rec, iDef),
 			STPct:      parseFloat(rec, iST),
@@ -332,11 +323,11 @@ func ingestSnapCounts(ctx context.Context, up *s3uploader, prefix, url string) (
 		buckets[k] = append(buckets[k], row)
 	}
 
-	schema := parquet.SchemaOf(new(SnapCountsRow))
+	ps := parquet.SchemaOf(new(schema.SnapCountsRow))
 	total := 0
 	for k, part := range buckets {
 		key := fmt.Sprintf("%s/snap_counts/season=%s/team=%s/part-%s.parquet", prefix, k.season, k.team, nowStamp())
-		if err := writeParquetAndUpload(ctx, part, key, schema, up); err != nil {
+		if err := writeParquetAndUpload(ctx, part, key, ps, up); err != nil {
 			return total, err
 		}
 		total += len(part)
@@ -380,8 +371,7 @@ func buildFetchPlans(ctx context.Context, datasets []string, season int) ([]Fetc
 	return plans, nil
 }
 
-func (h *Handler) fetchAndWrite(ctx context.Context, p FetchPlan) (int64, error) {
-	up := &s3uploader{cl: h.S3, bucket: h.Bucket}
+func (h *Handler) fetchAndWrite(ctx context.Context, up *s3uploader, p FetchPlan) (int64, error) {
 	switch strings.ToLower(p.Dataset) {
 	case "players":
 		if p.Format != "csv" {
@@ -446,37 +436,161 @@ func handler(ctx context.Context, e Event) (any, error) {
 		datasets = strings.Split(getenv("DATASETS", "players,rosters_weekly,snap_counts"), ",")
 	}
 
+	out := getenv("ATHENA_OUTPUT", "")
+	if out == "" {
+		return nil, errors.New("ATHENA_OUTPUT is required")
+	}
+	run := ath.New(athena.NewFromConfig(awsCfg), getenv("GLUE_DATABASE", "nflverse_curated"), getenv("ATHENA_WORKGROUP", "primary"), out)
+	run.Timeout = 5 * time.Minute
+	run.Logger = log.Default()
+
+	grace, err := time.ParseDuration(getenv("COMPACT_GRACE", compact.DefaultGrace.String()))
+	if err != nil {
+		return nil, fmt.Errorf("COMPACT_GRACE: %w", err)
+	}
+	s3c := s3.NewFromConfig(awsCfg)
 	h := &Handler{
-		S3:     s3.NewFromConfig(awsCfg),
-		Bucket: bucket,
-		Prefix: prefix,
+		S3:      s3c,
+		Athena:  run,
+		Curated: &compact.Store{API: s3c, Bucket: bucket, Prefix: prefix, Grace: grace},
+		Bucket:  bucket,
+		Prefix:  prefix,
 	}
 
 	plans, err := buildFetchPlans(ctx, datasets, season)
 	if err != nil {
 		return nil, err
 	}
+	// Refuse a schema the Glue tables can't take before any Parquet is written.
+	for _, p := range plans {
+		if err := h.prepare(ctx, p); err != nil {
+			return nil, err
+		}
+	}
 
 	stats := map[string]int64{}
 	var total int64
+	var touched []compact.Partition
 	for _, p := range plans {
-		w, err := h.fetchAndWrite(ctx, p)
+		up := &s3uploader{cl: h.S3, bucket: h.Bucket}
+		w, err := h.fetchAndWrite(ctx, up, p)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", p.Dataset, err)
+		}
+		// Athena only sees a dataset's new parts once all of them are written.
+		parts, err := h.publish(ctx, up.keys)
 		if err != nil {
 			return nil, fmt.Errorf("%s: %w", p.Dataset, err)
 		}
 		stats[p.Dataset] = w
 		total += w
+		touched = append(touched, parts...)
+	}
+
+	var compacted []compact.Result
+	if !e.SkipCompact {
+		if compacted, err = h.compact(ctx, touched); err != nil {
+			return nil, err
+		}
+	}
+
+	var quality any
+	if !e.SkipChecks {
+		rep, err := h.check(ctx, season, datasets, getenv("DQ_RESULTS", strings.TrimRight(out, "/")+"/dq"))
+		if err != nil {
+			return nil, err
+		}
+		quality = rep.Results
 	}
 
 	return map[string]any{
-		"ok":       true,
-		"season":   season,
-		"datasets": datasets,
-		"written":  stats,
-		"s3":       fmt.Sprintf("s3://%s/%s/", bucket, prefix),
+		"ok":        true,
+		"season":    season,
+		"datasets":  datasets,
+		"written":   stats,
+		"s3":        fmt.Sprintf("s3://%s/%s/", bucket, prefix),
+		"compacted": compacted,
+		"dq":        quality,
 	}, nil
 }
 
+// prepare brings p's Glue table up to its row struct (internal/schema): new nullable
+// columns are added, anything else that differs fails the run.
+func (h *Handler) prepare(ctx context.Context, p FetchPlan) error {
+	d, ok := schema.Get(strings.ToLower(p.Dataset))
+	if !ok || p.Format != "csv" {
+		return nil // unknown datasets fail later; raw copies write no curated Parquet
+	}
+	added, err := schema.Prepare(ctx, h.Athena, h.Athena.Database, d)
+	if err != nil {
+		return err
+	}
+	for _, c := range added {
+		log.Printf("curator: added column %s.%s %s", d.Name, c.Name, c.Type)
+	}
+	return nil
+}
+
+// publish lists the curated Parquet files in keys in their partitions' manifests
+// (internal/compact) and returns the partitions touched; raw copies are skipped.
+func (h *Handler) publish(ctx context.Context, keys []string) ([]compact.Partition, error) {
+	var order []compact.Partition
+	uris := map[compact.Partition][]string{}
+	for _, k := range keys {
+		p, ok := h.Curated.PartitionOf(k)
+		if !ok {
+			continue
+		}
+		if _, seen := uris[p]; !seen {
+			order = append(order, p)
+		}
+		uris[p] = append(uris[p], fmt.Sprintf("s3://%s/%s", h.Bucket, k))
+	}
+	for _, p := range order {
+		if err := h.Curated.Add(ctx, p, uris[p]...); err != nil {
+			return nil, err
+		}
+	}
+	return order, nil
+}
+
+// compact merges each touched partition's parts into one file, so re-ingesting a
+// season replaces its rows instead of duplicating them. A partition another writer
+// changed mid-compaction is left for the next run.
+func (h *Handler) compact(ctx context.Context, parts []compact.Partition) ([]compact.Result, error) {
+	var out []compact.Result
+	for _, p := range parts {
+		res, err := h.Curated.Compact(ctx, p)
+		if errors.Is(err, compact.ErrConflict) {
+			log.Printf("WARN compact %s: %v", p, err)
+			continue
+		}
+		if err != nil {
+			return out, fmt.Errorf("compact %s: %w", p, err)
+		}
+		if res.File != "" {
+			log.Printf("curator: compacted %s: %d parts, %d -> %d rows, %d deleted", p, res.Parts, res.RowsIn, res.Rows, res.Deleted)
+		}
+		out = append(out, res)
+	}
+	return out, nil
+}
+
+// check runs the data-quality suite for the datasets just written and saves the
+// results to results. Any violation is returned as an error, failing the run.
+func (h *Handler) check(ctx context.Context, season int, datasets []string, results string) (dq.Report, error) {
+	runID := time.Now().UTC().Format("20060102150405")
+	rep := dq.Run(ctx, h.Athena, "nflverse-curator", runID, dq.Curated(datasets...),
+		dq.Scope{DB: h.Athena.Database, Season: season, Through: dq.CompletedWeek(season, time.Now())})
+	log.Printf("curator: dq %s", rep)
+	if uri, err := dq.Save(context.WithoutCancel(ctx), h.S3, results, rep); err != nil {
+		log.Printf("WARN dq results: %v", err)
+	} else {
+		log.Printf("curator: dq results %s", uri)
+	}
+	return rep, rep.Err()
+}
+
 // func main() {
 // 	if os.Getenv("_LAMBDA_SERVER_PORT") != "" {
 // 		lambda.Start(handler)
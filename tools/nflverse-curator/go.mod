@@ -6,8 +6,11 @@ require (
 	github.com/aws/aws-lambda-go v1.49.0
 	github.com/aws/aws-sdk-go-v2 v1.39.0
 	github.com/aws/aws-sdk-go-v2/config v1.31.7
+	github.com/aws/aws-sdk-go-v2/service/athena v1.55.3
 	github.com/aws/aws-sdk-go-v2/service/s3 v1.88.0
+	github.com/aws/smithy-go v1.23.0
 	github.com/parquet-go/parquet-go v0.25.1
+	github.com/tyler180/fantasy-football-backends v0.0.0
 )
 
 require (
@@ -26,9 +29,10 @@ require (
 	github.com/aws/aws-sdk-go-v2/service/sso v1.29.2 // indirect
 	github.com/aws/aws-sdk-go-v2/service/ssooidc v1.34.3 // indirect
 	github.com/aws/aws-sdk-go-v2/service/sts v1.38.3 // indirect
-	github.com/aws/smithy-go v1.23.0 // indirect
 	github.com/google/uuid v1.6.0 // indirect
 	github.com/klauspost/compress v1.17.9 // indirect
 	github.com/pierrec/lz4/v4 v4.1.21 // indirect
 	golang.org/x/sys v0.21.0 // indirect
 )
+
+replace github.com/tyler180/fantasy-football-backends => ../..
@@ -0,0 +1,539 @@
+// Package compact merges each curated partition's Parquet parts into one
+// deduplicated, sorted file.
+//
+// Athena reads the curated tables through symlink manifests rather than by listing
+// the data directories: every partition has one text object,
+// <prefix>/_manifests/<dataset>/<partition>/manifest, naming its files one s3:// URI
+// per line. The curator appends each part it writes (Add), and compaction writes the
+// merged file first, then replaces the manifest with a conditional PUT, and only then
+// retires the parts it superseded. A query sees either the old parts or the compacted
+// file, never both and never neither; a writer that raced the swap makes it fail with
+// ErrConflict instead of being lost.
+package compact
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	parquet "github.com/parquet-go/parquet-go"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/smithy-go"
+
+	"github.com/tyler180/fantasy-football-backends/tools/nflverse-curator/internal/schema"
+)
+
+// ErrConflict is wrapped when a manifest changed between reading and replacing it.
+var ErrConflict = errors.New("manifest changed concurrently")
+
+// DefaultGrace is how long superseded parts are kept after the manifest swap, so
+// queries planned against the old manifest can still read them.
+const DefaultGrace = time.Hour
+
+const (
+	manifestDir  = "_manifests"
+	retiredDir   = "_retired" // under manifestDir: parts awaiting deletion, per swap
+	manifestName = "manifest"
+	compacted    = "compact-" // file name prefix of a compacted partition
+	addAttempts  = 5
+)
+
+// S3API is the subset of *s3.Client the store uses.
+type S3API interface {
+	GetObject(ctx context.Context, in *s3.GetObjectInput, optFns ...func(*s3.Options)) (*s3.GetObjectOutput, error)
+	PutObject(ctx context.Context, in *s3.PutObjectInput, optFns ...func(*s3.Options)) (*s3.PutObjectOutput, error)
+	DeleteObjects(ctx context.Context, in *s3.DeleteObjectsInput, optFns ...func(*s3.Options)) (*s3.DeleteObjectsOutput, error)
+	ListObjectsV2(ctx context.Context, in *s3.ListObjectsV2Input, optFns ...func(*s3.Options)) (*s3.ListObjectsV2Output, error)
+}
+
+// Store is the curated bucket.
+type Store struct {
+	API    S3API
+	Bucket string
+	Prefix string // curated root, e.g. nflverse_curated
+	// Grace is how long superseded parts outlive the swap (see DefaultGrace); 0
+	// deletes them as soon as the manifest no longer lists them.
+	Grace time.Duration
+	Now   func() time.Time // nil means time.Now
+}
+
+// Partition is one hive partition of a dataset, e.g. snap_counts
+// season=2024/team=SEA. Path is "" for an unpartitioned dataset.
+type Partition struct {
+	Dataset string
+	Path    string
+}
+
+func (p Partition) String() string {
+	if p.Path == "" {
+		return p.Dataset
+	}
+	return p.Dataset + "/" + p.Path
+}
+
+func (s *Store) now() time.Time {
+	if s.Now != nil {
+		return s.Now()
+	}
+	return time.Now()
+}
+
+func (s *Store) join(elem ...string) string {
+	var parts []string
+	for _, e := range append([]string{s.Prefix}, elem...) {
+		if e = strings.Trim(e, "/"); e != "" {
+			parts = append(parts, e)
+		}
+	}
+	return strings.Join(parts, "/")
+}
+
+func (s *Store) dataPrefix(p Partition) string { return s.join(p.Dataset, p.Path) + "/" }
+
+func (s *Store) manifestKey(p Partition) string {
+	return s.join(manifestDir, p.Dataset, p.Path, manifestName)
+}
+
+func (s *Store) retiredPrefix(p Partition) string {
+	return s.join(manifestDir, retiredDir, p.Dataset, p.Path) + "/"
+}
+
+func (s *Store) uri(key string) string { return "s3://" + s.Bucket + "/" + key }
+
+// key returns the object key of uri when it is in the store's bucket.
+func (s *Store) key(uri string) (string, bool) {
+	return strings.CutPrefix(uri, "s3://"+s.Bucket+"/")
+}
+
+// PartitionOf maps the key of a curated Parquet file to its partition; ok is false
+// for anything else (raw copies, manifests, unknown datasets).
+func (s *Store) PartitionOf(key string) (p Partition, ok bool) {
+	rest, ok := strings.CutPrefix(key, s.join()+"/")
+	if !ok {
+		return Partition{}, false
+	}
+	segs := strings.Split(rest, "/")
+	d, ok := schema.Get(segs[0])
+	if !ok || len(segs) != len(d.PartitionKeys)+2 || !strings.HasSuffix(key, ".parquet") {
+		return Partition{}, false
+	}
+	for i, k := range d.PartitionKeys {
+		if !strings.HasPrefix(segs[i+1], k+"=") {
+			return Partition{}, false
+		}
+	}
+	return Partition{Dataset: d.Name, Path: strings.Join(segs[1:len(segs)-1], "/")}, true
+}
+
+// Partitions lists the partitions of dataset found under its data root, only those of
+// season when it is non-zero.
+func (s *Store) Partitions(ctx context.Context, dataset string, season int) ([]Partition, error) {
+	d, ok := schema.Get(dataset)
+	if !ok {
+		return nil, fmt.Errorf("unknown dataset %q", dataset)
+	}
+	paths := []string{""}
+	for i, k := range d.PartitionKeys {
+		if i == 0 && k == "season" && season != 0 {
+			paths = []string{fmt.Sprintf("season=%d", season)}
+			continue
+		}
+		var next []string
+		for _, p := range paths {
+			dirs, err := s.dirs(ctx, s.dataPrefix(Partition{dataset, p}))
+			if err != nil {
+				return nil, err
+			}
+			for _, dir := range dirs {
+				if strings.HasPrefix(dir, k+"=") {
+					next = append(next, strings.TrimPrefix(p+"/"+dir, "/"))
+				}
+			}
+		}
+		paths = next
+	}
+	out := make([]Partition, len(paths))
+	for i, p := range paths {
+		out[i] = Partition{Dataset: dataset, Path: p}
+	}
+	return out, nil
+}
+
+// dirs lists the immediate "directories" under prefix.
+func (s *Store) dirs(ctx context.Context, prefix string) ([]string, error) {
+	var out []string
+	pg := s3.NewListObjectsV2Paginator(s.API, &s3.ListObjectsV2Input{
+		Bucket: aws.String(s.Bucket), Prefix: aws.String(prefix), Delimiter: aws.String("/"),
+	})
+	for pg.HasMorePages() {
+		page, err := pg.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, cp := range page.CommonPrefixes {
+			out = append(out, strings.TrimSuffix(strings.TrimPrefix(aws.ToString(cp.Prefix), prefix), "/"))
+		}
+	}
+	return out, nil
+}
+
+/* ---------- Manifests ---------- */
+
+// Manifest is a partition's file list as read.
+type Manifest struct {
+	Files []string // s3:// URIs, oldest first
+	ETag  string   // "" when no manifest exists yet
+}
+
+// Manifest reads p's manifest. A partition without one (written before manifests
+// existed) is bootstrapped from the Parquet files under its data directory, oldest
+// first; replacing it then creates the manifest.
+func (s *Store) Manifest(ctx context.Context, p Partition) (Manifest, error) {
+	out, err := s.API.GetObject(ctx, &s3.GetObjectInput{Bucket: aws.String(s.Bucket), Key: aws.String(s.manifestKey(p))})
+	var nsk *s3types.NoSuchKey
+	if errors.As(err, &nsk) {
+		return s.bootstrap(ctx, p)
+	}
+	if err != nil {
+		return Manifest{}, fmt.Errorf("%s manifest: %w", p, err)
+	}
+	defer out.Body.Close()
+	b, err := io.ReadAll(out.Body)
+	if err != nil {
+		return Manifest{}, fmt.Errorf("%s manifest: %w", p, err)
+	}
+	m := Manifest{ETag: aws.ToString(out.ETag)}
+	for _, line := range strings.Split(string(b), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			m.Files = append(m.Files, line)
+		}
+	}
+	return m, nil
+}
+
+func (s *Store) bootstrap(ctx context.Context, p Partition) (Manifest, error) {
+	type obj struct {
+		key string
+		mod time.Time
+	}
+	var objs []obj
+	pg := s3.NewListObjectsV2Paginator(s.API, &s3.ListObjectsV2Input{
+		Bucket: aws.String(s.Bucket), Prefix: aws.String(s.dataPrefix(p)), Delimiter: aws.String("/"),
+	})
+	for pg.HasMorePages() {
+		page, err := pg.NextPage(ctx)
+		if err != nil {
+			return Manifest{}, fmt.Errorf("%s: list parts: %w", p, err)
+		}
+		for _, o := range page.Contents {
+			if k := aws.ToString(o.Key); strings.HasSuffix(k, ".parquet") {
+				objs = append(objs, obj{k, aws.ToTime(o.LastModified)})
+			}
+		}
+	}
+	sort.Slice(objs, func(i, j int) bool {
+		if !objs[i].mod.Equal(objs[j].mod) {
+			return objs[i].mod.Before(objs[j].mod)
+		}
+		return objs[i].key < objs[j].key
+	})
+	var m Manifest
+	for _, o := range objs {
+		m.Files = append(m.Files, s.uri(o.key))
+	}
+	return m, nil
+}
+
+// swap replaces the manifest m was read from with files, failing with ErrConflict if
+// it changed (or was created) since.
+func (s *Store) swap(ctx context.Context, p Partition, m Manifest, files []string) error {
+	in := &s3.PutObjectInput{
+		Bucket:      aws.String(s.Bucket),
+		Key:         aws.String(s.manifestKey(p)),
+		Body:        strings.NewReader(strings.Join(files, "\n") + "\n"),
+		ContentType: aws.String("text/plain"),
+	}
+	if m.ETag != "" {
+		in.IfMatch = aws.String(m.ETag)
+	} else {
+		in.IfNoneMatch = aws.String("*")
+	}
+	_, err := s.API.PutObject(ctx, in)
+	var ae smithy.APIError
+	if errors.As(err, &ae) && (ae.ErrorCode() == "PreconditionFailed" || ae.ErrorCode() == "ConditionalRequestConflict") {
+		return fmt.Errorf("%s: %w", p, ErrConflict)
+	}
+	if err != nil {
+		return fmt.Errorf("%s manifest: %w", p, err)
+	}
+	return nil
+}
+
+// Add lists uris, files just written to p, in its manifest so Athena starts reading
+// them. It retries when another writer replaced the manifest in between.
+func (s *Store) Add(ctx context.Context, p Partition, uris ...string) error {
+	for range addAttempts {
+		m, err := s.Manifest(ctx, p)
+		if err != nil {
+			return err
+		}
+		files := slices.Clone(m.Files)
+		for _, u := range uris {
+			if !slices.Contains(files, u) {
+				files = append(files, u)
+			}
+		}
+		if m.ETag != "" && len(files) == len(m.Files) {
+			return nil
+		}
+		if err := s.swap(ctx, p, m, files); !errors.Is(err, ErrConflict) {
+			return err
+		}
+	}
+	return fmt.Errorf("%s: gave up after %d attempts: %w", p, addAttempts, ErrConflict)
+}
+
+/* ---------- Compaction ---------- */
+
+// Result is what compacting one partition did.
+type Result struct {
+	Partition string `json:"partition"`
+	Parts     int    `json:"parts"`   // files the manifest listed
+	RowsIn    int    `json:"rows_in"` // rows read from them
+	Rows      int    `json:"rows"`    // rows written after dropping duplicates
+	File      string `json:"file,omitempty"`
+	Deleted   int    `json:"deleted"`           // superseded parts removed, this or earlier swaps
+	Skipped   string `json:"skipped,omitempty"` // why nothing was written
+}
+
+// Compact merges p's listed parts into one Parquet file, keeping the newest row per
+// key (schema.Dataset.Key) sorted by key, and swaps the manifest to it. Parts retired
+// by earlier swaps whose grace has passed are deleted first. A partition already
+// compacted to a single file is left alone.
+func (s *Store) Compact(ctx context.Context, p Partition) (Result, error) {
+	d, ok := schema.Get(p.Dataset)
+	if !ok {
+		return Result{Partition: p.String()}, fmt.Errorf("unknown dataset %q", p.Dataset)
+	}
+	switch d.Row.(type) {
+	case schema.PlayersRow:
+		return compact[schema.PlayersRow](ctx, s, d, p)
+	case schema.RostersWeeklyRow:
+		return compact[schema.RostersWeeklyRow](ctx, s, d, p)
+	case schema.SnapCountsRow:
+		return compact[schema.SnapCountsRow](ctx, s, d, p)
+	}
+	return Result{Partition: p.String()}, fmt.Errorf("%s: no row type to compact %T", p, d.Row)
+}
+
+func compact[T any](ctx context.Context, s *Store, d schema.Dataset, p Partition) (Result, error) {
+	res := Result{Partition: p.String()}
+	n, err := s.expire(ctx, p)
+	res.Deleted = n
+	if err != nil {
+		return res, err
+	}
+	m, err := s.Manifest(ctx, p)
+	if err != nil {
+		return res, err
+	}
+	res.Parts = len(m.Files)
+	switch {
+	case len(m.Files) == 0:
+		res.Skipped = "no files"
+		return res, nil
+	case len(m.Files) == 1 && m.ETag != "" && strings.HasPrefix(path.Base(m.Files[0]), compacted):
+		res.Skipped = "already compacted"
+		return res, nil
+	}
+
+	keyOf, err := d.KeyFunc()
+	if err != nil {
+		return res, err
+	}
+	type keyed struct {
+		key []string
+		row T
+	}
+	var rows []keyed
+	at := map[string]int{}
+	for _, uri := range m.Files { // oldest first, so a later part's row replaces an earlier one
+		part, err := readParquet[T](ctx, s, uri)
+		if err != nil {
+			return res, fmt.Errorf("%s: %w", p, err)
+		}
+		res.RowsIn += len(part)
+		for _, r := range part {
+			k := keyOf(r)
+			id := strings.Join(k, "\x00")
+			if i, ok := at[id]; ok {
+				rows[i] = keyed{k, r}
+				continue
+			}
+			at[id] = len(rows)
+			rows = append(rows, keyed{k, r})
+		}
+	}
+	sort.SliceStable(rows, func(i, j int) bool { return slices.Compare(rows[i].key, rows[j].key) < 0 })
+	out := make([]T, len(rows))
+	for i, r := range rows {
+		out[i] = r.row
+	}
+
+	var buf bytes.Buffer
+	if err := parquet.Write(&buf, out, parquet.Compression(&parquet.Snappy)); err != nil {
+		return res, fmt.Errorf("%s: write: %w", p, err)
+	}
+	// The key is unique to this call and written only if absent, so a compaction
+	// racing this one never shares it and the cleanup below deletes only our file.
+	key := s.dataPrefix(p) + compacted + s.now().UTC().Format("20060102T150405.000000000Z") + "-" + runID() + ".parquet"
+	if _, err := s.API.PutObject(ctx, &s3.PutObjectInput{
+		Bucket: aws.String(s.Bucket), Key: aws.String(key), Body: bytes.NewReader(buf.Bytes()),
+		IfNoneMatch: aws.String("*"),
+	}); err != nil {
+		return res, fmt.Errorf("%s: put %s: %w", p, key, err)
+	}
+	if err := s.swap(ctx, p, m, []string{s.uri(key)}); err != nil {
+		// Nothing lists the new file; drop it and leave the partition to the next run.
+		if _, derr := s.delete(ctx, []string{key}); derr != nil {
+			return res, errors.Join(err, derr)
+		}
+		return res, err
+	}
+	res.File, res.Rows = s.uri(key), len(out)
+
+	n, err = s.retire(ctx, p, m.Files)
+	res.Deleted += n
+	return res, err
+}
+
+// runID is a random tag that tells apart files written in the same instant.
+func runID() string {
+	b := make([]byte, 6)
+	rand.Read(b) // never returns an error as of Go 1.24
+	return hex.EncodeToString(b)
+}
+
+func readParquet[T any](ctx context.Context, s *Store, uri string) ([]T, error) {
+	key, ok := s.key(uri)
+	if !ok {
+		return nil, fmt.Errorf("%s is not in s3://%s", uri, s.Bucket)
+	}
+	out, err := s.API.GetObject(ctx, &s3.GetObjectInput{Bucket: aws.String(s.Bucket), Key: aws.String(key)})
+	if err != nil {
+		return nil, fmt.Errorf("get %s: %w", uri, err)
+	}
+	defer out.Body.Close()
+	b, err := io.ReadAll(out.Body)
+	if err != nil {
+		return nil, fmt.Errorf("get %s: %w", uri, err)
+	}
+	rows, err := parquet.Read[T](bytes.NewReader(b), int64(len(b)))
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", uri, err)
+	}
+	return rows, nil
+}
+
+// retire schedules the files a swap superseded for deletion once the grace has
+// passed, or deletes them now when there is none. Only files under p's data
+// directory are ever deleted.
+func (s *Store) retire(ctx context.Context, p Partition, uris []string) (int, error) {
+	var keys []string
+	for _, u := range uris {
+		if k, ok := s.key(u); ok && strings.HasPrefix(k, s.dataPrefix(p)) {
+			keys = append(keys, k)
+		}
+	}
+	if len(keys) == 0 {
+		return 0, nil
+	}
+	if s.Grace <= 0 {
+		return s.delete(ctx, keys)
+	}
+	key := s.retiredPrefix(p) + s.now().UTC().Format("20060102T150405.000000000Z")
+	_, err := s.API.PutObject(ctx, &s3.PutObjectInput{
+		Bucket: aws.String(s.Bucket), Key: aws.String(key), Body: strings.NewReader(strings.Join(keys, "\n") + "\n"),
+	})
+	if err != nil {
+		return 0, fmt.Errorf("%s: record retired parts: %w", p, err)
+	}
+	return 0, nil
+}
+
+// expire deletes the parts retired from p more than Grace ago, then their lists.
+func (s *Store) expire(ctx context.Context, p Partition) (int, error) {
+	cutoff := s.now().Add(-s.Grace)
+	var lists, keys []string
+	pg := s3.NewListObjectsV2Paginator(s.API, &s3.ListObjectsV2Input{
+		Bucket: aws.String(s.Bucket), Prefix: aws.String(s.retiredPrefix(p)), Delimiter: aws.String("/"),
+	})
+	for pg.HasMorePages() {
+		page, err := pg.NextPage(ctx)
+		if err != nil {
+			return 0, fmt.Errorf("%s: list retired: %w", p, err)
+		}
+		for _, o := range page.Contents {
+			if aws.ToTime(o.LastModified).After(cutoff) {
+				continue
+			}
+			out, err := s.API.GetObject(ctx, &s3.GetObjectInput{Bucket: aws.String(s.Bucket), Key: o.Key})
+			if err != nil {
+				return 0, fmt.Errorf("%s: read retired: %w", p, err)
+			}
+			b, err := io.ReadAll(out.Body)
+			out.Body.Close()
+			if err != nil {
+				return 0, fmt.Errorf("%s: read retired: %w", p, err)
+			}
+			for _, k := range strings.Fields(string(b)) {
+				if strings.HasPrefix(k, s.dataPrefix(p)) {
+					keys = append(keys, k)
+				}
+			}
+			lists = append(lists, aws.ToString(o.Key))
+		}
+	}
+	n, err := s.delete(ctx, keys)
+	if err != nil {
+		return n, err
+	}
+	_, err = s.delete(ctx, lists)
+	return n, err
+}
+
+// delete removes keys in batches of 1000 (the DeleteObjects limit).
+func (s *Store) delete(ctx context.Context, keys []string) (int, error) {
+	deleted := 0
+	for batch := range slices.Chunk(keys, 1000) {
+		objs := make([]s3types.ObjectIdentifier, len(batch))
+		for i, k := range batch {
+			objs[i] = s3types.ObjectIdentifier{Key: aws.String(k)}
+		}
+		out, err := s.API.DeleteObjects(ctx, &s3.DeleteObjectsInput{
+			Bucket: aws.String(s.Bucket),
+			Delete: &s3types.Delete{Objects: objs, Quiet: aws.Bool(true)},
+		})
+		if err != nil {
+			return deleted, err
+		}
+		if len(out.Errors) > 0 {
+			e := out.Errors[0]
+			return deleted, fmt.Errorf("delete s3://%s/%s: %s", s.Bucket, aws.ToString(e.Key), aws.ToString(e.Message))
+		}
+		deleted += len(batch)
+	}
+	return deleted, nil
+}
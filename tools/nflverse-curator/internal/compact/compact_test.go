@@ -0,0 +1,335 @@
+package compact
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	parquet "github.com/parquet-go/parquet-go"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/smithy-go"
+
+	"github.com/tyler180/fantasy-football-backends/tools/nflverse-curator/internal/schema"
+)
+
+type object struct {
+	body []byte
+	etag string
+	mod  time.Time
+}
+
+// bucket is an in-memory S3 bucket honouring conditional writes.
+type bucket struct {
+	objs  map[string]object
+	now   time.Time
+	n     int
+	onPut func(key string) // called before each put is applied
+}
+
+func newBucket() *bucket {
+	return &bucket{objs: map[string]object{}, now: time.Date(2024, 9, 10, 12, 0, 0, 0, time.UTC)}
+}
+
+func (b *bucket) tick() time.Time { b.now = b.now.Add(time.Second); return b.now }
+
+func (b *bucket) GetObject(_ context.Context, in *s3.GetObjectInput, _ ...func(*s3.Options)) (*s3.GetObjectOutput, error) {
+	o, ok := b.objs[aws.ToString(in.Key)]
+	if !ok {
+		return nil, &s3types.NoSuchKey{}
+	}
+	return &s3.GetObjectOutput{Body: io.NopCloser(bytes.NewReader(o.body)), ETag: aws.String(o.etag)}, nil
+}
+
+func (b *bucket) PutObject(_ context.Context, in *s3.PutObjectInput, _ ...func(*s3.Options)) (*s3.PutObjectOutput, error) {
+	key := aws.ToString(in.Key)
+	if b.onPut != nil {
+		b.onPut(key)
+	}
+	cur, exists := b.objs[key]
+	if (in.IfNoneMatch != nil && exists) || (in.IfMatch != nil && (!exists || cur.etag != *in.IfMatch)) {
+		return nil, &smithy.GenericAPIError{Code: "PreconditionFailed"}
+	}
+	body, _ := io.ReadAll(in.Body)
+	b.n++
+	b.objs[key] = object{body: body, etag: fmt.Sprintf(`"%d"`, b.n), mod: b.tick()}
+	return &s3.PutObjectOutput{}, nil
+}
+
+func (b *bucket) DeleteObjects(_ context.Context, in *s3.DeleteObjectsInput, _ ...func(*s3.Options)) (*s3.DeleteObjectsOutput, error) {
+	for _, o := range in.Delete.Objects {
+		delete(b.objs, aws.ToString(o.Key))
+	}
+	return &s3.DeleteObjectsOutput{}, nil
+}
+
+func (b *bucket) ListObjectsV2(_ context.Context, in *s3.ListObjectsV2Input, _ ...func(*s3.Options)) (*s3.ListObjectsV2Output, error) {
+	prefix, delim := aws.ToString(in.Prefix), aws.ToString(in.Delimiter)
+	out := &s3.ListObjectsV2Output{}
+	seen := map[string]bool{}
+	for _, k := range b.keys(prefix) {
+		rest := strings.TrimPrefix(k, prefix)
+		if i := strings.Index(rest, delim); delim != "" && i >= 0 {
+			if cp := prefix + rest[:i+1]; !seen[cp] {
+				seen[cp] = true
+				out.CommonPrefixes = append(out.CommonPrefixes, s3types.CommonPrefix{Prefix: aws.String(cp)})
+			}
+			continue
+		}
+		out.Contents = append(out.Contents, s3types.Object{Key: aws.String(k), LastModified: aws.Time(b.objs[k].mod)})
+	}
+	return out, nil
+}
+
+func (b *bucket) keys(prefix string) []string {
+	var out []string
+	for k := range b.objs {
+		if strings.HasPrefix(k, prefix) {
+			out = append(out, k)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+func (b *bucket) putRows(t *testing.T, key string, rows []schema.SnapCountsRow) string {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := parquet.Write(&buf, rows); err != nil {
+		t.Fatal(err)
+	}
+	b.PutObject(context.Background(), &s3.PutObjectInput{Key: aws.String(key), Body: &buf})
+	return "s3://curated/" + key
+}
+
+func snap(week, id string, def float64) schema.SnapCountsRow {
+	return schema.SnapCountsRow{Season: "2024", Week: week, Team: "SEA", PlayerID: aws.String(id), DefensePct: &def}
+}
+
+var sea = Partition{Dataset: "snap_counts", Path: "season=2024/team=SEA"}
+
+func newStore(b *bucket) *Store {
+	return &Store{API: b, Bucket: "curated", Prefix: "nflverse_curated", Now: func() time.Time { return b.now }}
+}
+
+func TestPartitionOf(t *testing.T) {
+	s := newStore(newBucket())
+	for key, want := range map[string]string{
+		"nflverse_curated/snap_counts/season=2024/team=SEA/part-1.parquet":   "snap_counts/season=2024/team=SEA",
+		"nflverse_curated/rosters_weekly/season=2024/week=01/part-1.parquet": "rosters_weekly/season=2024/week=01",
+		"nflverse_curated/players/players-1.parquet":                         "players",
+		"nflverse_curated/raw/players/players.parquet":                       "",
+		"nflverse_curated/snap_counts/season=2024/part-1.parquet":            "",
+		"nflverse_curated/_manifests/players/manifest":                       "",
+		"other/players/players-1.parquet":                                    "",
+	} {
+		p, ok := s.PartitionOf(key)
+		if got := map[bool]string{true: p.String()}[ok]; got != want {
+			t.Errorf("PartitionOf(%s) = %q, want %q", key, got, want)
+		}
+	}
+}
+
+func TestAddBootstrapsManifest(t *testing.T) {
+	b := newBucket()
+	s := newStore(b)
+	old := b.putRows(t, "nflverse_curated/snap_counts/season=2024/team=SEA/part-b.parquet", []schema.SnapCountsRow{snap("01", "A", 0.5)})
+	b.putRows(t, "nflverse_curated/snap_counts/season=2024/team=SEA/notes.txt", nil)
+	next := b.putRows(t, "nflverse_curated/snap_counts/season=2024/team=SEA/part-a.parquet", []schema.SnapCountsRow{snap("02", "A", 0.5)})
+
+	if err := s.Add(context.Background(), sea, next); err != nil {
+		t.Fatal(err)
+	}
+	m, err := s.Manifest(context.Background(), sea)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Listed oldest first, whatever the names sort as.
+	if want := []string{old, next}; !reflect.DeepEqual(m.Files, want) || m.ETag == "" {
+		t.Errorf("manifest = %+v, want files %v", m, want)
+	}
+	if err := s.Add(context.Background(), sea, next); err != nil || b.n != 4 {
+		t.Errorf("re-adding a listed file rewrote the manifest (err %v)", err)
+	}
+}
+
+func TestCompact(t *testing.T) {
+	b := newBucket()
+	s := newStore(b)
+	ctx := context.Background()
+	p1 := b.putRows(t, "nflverse_curated/snap_counts/season=2024/team=SEA/part-1.parquet",
+		[]schema.SnapCountsRow{snap("02", "B", 0.4), snap("01", "A", 0.5), snap("01", "B", 0.9)})
+	p2 := b.putRows(t, "nflverse_curated/snap_counts/season=2024/team=SEA/part-2.parquet",
+		[]schema.SnapCountsRow{snap("01", "A", 0.75), snap("02", "A", 1)})
+	if err := s.Add(ctx, sea, p1, p2); err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := s.Compact(ctx, sea)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Parts != 2 || res.RowsIn != 5 || res.Rows != 4 || res.Deleted != 2 || res.File == "" {
+		t.Fatalf("result = %+v", res)
+	}
+	m, _ := s.Manifest(ctx, sea)
+	if !reflect.DeepEqual(m.Files, []string{res.File}) {
+		t.Errorf("manifest = %v, want only %s", m.Files, res.File)
+	}
+	if keys := b.keys("nflverse_curated/snap_counts/"); len(keys) != 1 {
+		t.Errorf("data files left: %v", keys)
+	}
+	rows, err := readParquet[schema.SnapCountsRow](ctx, s, res.File)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, r := range rows {
+		got = append(got, fmt.Sprintf("%s/%s=%g", r.Week, *r.PlayerID, *r.DefensePct))
+	}
+	// Sorted by key; the newer part's row wins for week 1 player A.
+	if want := []string{"01/A=0.75", "01/B=0.9", "02/A=1", "02/B=0.4"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("rows = %v, want %v", got, want)
+	}
+
+	if res, err := s.Compact(ctx, sea); err != nil || res.Skipped == "" {
+		t.Errorf("second compaction = %+v, %v; want skipped", res, err)
+	}
+}
+
+func TestCompactConflict(t *testing.T) {
+	b := newBucket()
+	s := newStore(b)
+	ctx := context.Background()
+	p1 := b.putRows(t, "nflverse_curated/snap_counts/season=2024/team=SEA/part-1.parquet", []schema.SnapCountsRow{snap("01", "A", 0.5)})
+	p2 := b.putRows(t, "nflverse_curated/snap_counts/season=2024/team=SEA/part-2.parquet", []schema.SnapCountsRow{snap("01", "A", 0.6)})
+	if err := s.Add(ctx, sea, p1, p2); err != nil {
+		t.Fatal(err)
+	}
+
+	// The curator lists a new part while the compacted file is being written.
+	p3 := b.putRows(t, "nflverse_curated/snap_counts/season=2024/team=SEA/part-3.parquet", []schema.SnapCountsRow{snap("02", "A", 0.7)})
+	b.onPut = func(key string) {
+		if strings.Contains(key, "/"+compacted) {
+			b.onPut = nil
+			if err := s.Add(ctx, sea, p3); err != nil {
+				t.Error(err)
+			}
+		}
+	}
+	if _, err := s.Compact(ctx, sea); !errors.Is(err, ErrConflict) {
+		t.Fatalf("err = %v, want ErrConflict", err)
+	}
+	m, _ := s.Manifest(ctx, sea)
+	if want := []string{p1, p2, p3}; !reflect.DeepEqual(m.Files, want) {
+		t.Errorf("manifest = %v, want %v", m.Files, want)
+	}
+	if keys := b.keys("nflverse_curated/snap_counts/"); len(keys) != 3 {
+		t.Errorf("data files = %v, want the three parts and no compacted file", keys)
+	}
+}
+
+// TestCompactRace runs a second compaction of the partition while the first is
+// writing its compacted file, in the same instant. The loser must leave the winner's
+// file, which the manifest now lists, in place.
+func TestCompactRace(t *testing.T) {
+	b := newBucket()
+	s := newStore(b)
+	ctx := context.Background()
+	p1 := b.putRows(t, "nflverse_curated/snap_counts/season=2024/team=SEA/part-1.parquet", []schema.SnapCountsRow{snap("01", "A", 0.5)})
+	p2 := b.putRows(t, "nflverse_curated/snap_counts/season=2024/team=SEA/part-2.parquet", []schema.SnapCountsRow{snap("02", "A", 0.6)})
+	if err := s.Add(ctx, sea, p1, p2); err != nil {
+		t.Fatal(err)
+	}
+
+	var won Result
+	b.onPut = func(key string) {
+		if strings.Contains(key, "/"+compacted) {
+			b.onPut = nil
+			res, err := s.Compact(ctx, sea)
+			if err != nil {
+				t.Errorf("racing compaction: %v", err)
+			}
+			won = res
+		}
+	}
+	if _, err := s.Compact(ctx, sea); !errors.Is(err, ErrConflict) {
+		t.Fatalf("err = %v, want ErrConflict", err)
+	}
+	m, _ := s.Manifest(ctx, sea)
+	if won.File == "" || !reflect.DeepEqual(m.Files, []string{won.File}) {
+		t.Fatalf("manifest = %v, want the racing compaction's %s", m.Files, won.File)
+	}
+	keys := b.keys("nflverse_curated/snap_counts/")
+	if len(keys) != 1 || "s3://curated/"+keys[0] != won.File {
+		t.Errorf("data files = %v, want only %s", keys, won.File)
+	}
+	if rows, err := readParquet[schema.SnapCountsRow](ctx, s, won.File); err != nil || len(rows) != 2 {
+		t.Errorf("compacted rows = %d, %v", len(rows), err)
+	}
+}
+
+func TestCompactGrace(t *testing.T) {
+	b := newBucket()
+	s := newStore(b)
+	s.Grace = time.Hour
+	ctx := context.Background()
+	p1 := b.putRows(t, "nflverse_curated/snap_counts/season=2024/team=SEA/part-1.parquet", []schema.SnapCountsRow{snap("01", "A", 0.5)})
+	p2 := b.putRows(t, "nflverse_curated/snap_counts/season=2024/team=SEA/part-2.parquet", []schema.SnapCountsRow{snap("02", "A", 0.5)})
+	if err := s.Add(ctx, sea, p1, p2); err != nil {
+		t.Fatal(err)
+	}
+	if res, err := s.Compact(ctx, sea); err != nil || res.Deleted != 0 {
+		t.Fatalf("compact = %+v, %v", res, err)
+	}
+	if keys := b.keys("nflverse_curated/snap_counts/"); len(keys) != 3 {
+		t.Fatalf("superseded parts deleted within the grace: %v", keys)
+	}
+
+	b.now = b.now.Add(30 * time.Minute)
+	if res, _ := s.Compact(ctx, sea); res.Deleted != 0 {
+		t.Errorf("deleted %d parts 30m after the swap", res.Deleted)
+	}
+	b.now = b.now.Add(time.Hour)
+	if res, err := s.Compact(ctx, sea); err != nil || res.Deleted != 2 {
+		t.Errorf("compact after the grace = %+v, %v; want 2 deleted", res, err)
+	}
+	if keys := b.keys("nflverse_curated/"); len(keys) != 2 {
+		t.Errorf("objects left = %v, want the compacted file and its manifest", keys)
+	}
+}
+
+func TestPartitions(t *testing.T) {
+	b := newBucket()
+	s := newStore(b)
+	for _, k := range []string{
+		"nflverse_curated/snap_counts/season=2023/team=SEA/part-1.parquet",
+		"nflverse_curated/snap_counts/season=2024/team=SEA/part-1.parquet",
+		"nflverse_curated/snap_counts/season=2024/team=ARI/part-1.parquet",
+	} {
+		b.putRows(t, k, nil)
+	}
+	got, err := s.Partitions(context.Background(), "snap_counts", 2024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Partition{{"snap_counts", "season=2024/team=ARI"}, {"snap_counts", "season=2024/team=SEA"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("partitions = %v, want %v", got, want)
+	}
+	if all, _ := s.Partitions(context.Background(), "snap_counts", 0); len(all) != 3 {
+		t.Errorf("all seasons = %v", all)
+	}
+	if pl, _ := s.Partitions(context.Background(), "players", 0); !reflect.DeepEqual(pl, []Partition{{Dataset: "players"}}) {
+		t.Errorf("players = %v", pl)
+	}
+}
@@ -0,0 +1,156 @@
+package schema
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrIncompatible is wrapped by every refused schema change.
+var ErrIncompatible = errors.New("incompatible schema change")
+
+// widens lists the type changes Parquet readers accept for existing files.
+var widens = map[[2]string]bool{
+	{"int", "bigint"}:   true,
+	{"float", "double"}: true,
+}
+
+// Evolve checks that next can replace cur without breaking files already written
+// with cur and returns the columns next adds. Allowed: new nullable columns and
+// widening int to bigint or float to double. Refused: dropped or retyped columns, new
+// required columns and any change to the partition keys. Names compare
+// case-insensitively, as Glue stores them lower-cased.
+func Evolve(cur, next Table) ([]Column, error) {
+	var problems []string
+	if !sameKeys(cur.PartitionKeys, next.PartitionKeys) {
+		problems = append(problems, fmt.Sprintf("partition keys %s -> %s", names(cur.PartitionKeys), names(next.PartitionKeys)))
+	}
+	have := map[string]Column{}
+	for _, c := range next.Columns {
+		have[strings.ToLower(c.Name)] = c
+	}
+	old := map[string]bool{}
+	for _, c := range cur.Columns {
+		name := strings.ToLower(c.Name)
+		old[name] = true
+		n, ok := have[name]
+		switch {
+		case !ok:
+			problems = append(problems, "drops "+c.Name)
+		case n.Type != c.Type && !widens[[2]string{c.Type, n.Type}]:
+			problems = append(problems, fmt.Sprintf("retypes %s %s -> %s", c.Name, c.Type, n.Type))
+		}
+	}
+	var added []Column
+	for _, c := range next.Columns {
+		if old[strings.ToLower(c.Name)] {
+			continue
+		}
+		if !c.Nullable {
+			problems = append(problems, "adds required "+c.Name+" (existing files have no value for it)")
+			continue
+		}
+		added = append(added, c)
+	}
+	if len(problems) > 0 {
+		return nil, fmt.Errorf("%w: %s", ErrIncompatible, strings.Join(problems, "; "))
+	}
+	return added, nil
+}
+
+func sameKeys(a, b []Column) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !strings.EqualFold(a[i].Name, b[i].Name) || a[i].Type != b[i].Type {
+			return false
+		}
+	}
+	return true
+}
+
+func names(cols []Column) string {
+	out := make([]string, len(cols))
+	for i, c := range cols {
+		out[i] = c.Name
+	}
+	return "(" + strings.Join(out, ", ") + ")"
+}
+
+// Querier runs one Athena statement; *ath.Client satisfies it.
+type Querier interface {
+	Query(ctx context.Context, sql string) ([][]string, error)
+}
+
+// Live reads db.table's Glue columns through Athena's information_schema. ok is false
+// when the table does not exist.
+func Live(ctx context.Context, q Querier, db, table string) (t Table, ok bool, err error) {
+	rows, err := q.Query(ctx, fmt.Sprintf(`SELECT column_name, data_type, extra_info
+FROM information_schema.columns
+WHERE table_schema = '%s' AND table_name = '%s'
+ORDER BY ordinal_position`, db, table))
+	if err != nil {
+		return Table{}, false, fmt.Errorf("read %s.%s columns: %w", db, table, err)
+	}
+	for _, r := range rows {
+		if len(r) < 3 {
+			continue
+		}
+		c := Column{Name: r[0], Type: hiveType(r[1])}
+		if strings.Contains(r[2], "partition key") {
+			t.PartitionKeys = append(t.PartitionKeys, c)
+		} else {
+			t.Columns = append(t.Columns, c)
+		}
+	}
+	return t, len(rows) > 0, nil
+}
+
+// hiveType maps the Presto type information_schema reports back to the Glue one.
+func hiveType(presto string) string {
+	switch p := strings.ToLower(presto); {
+	case p == "varchar" || strings.HasPrefix(p, "varchar("):
+		return "string"
+	case p == "integer":
+		return "int"
+	case p == "real":
+		return "float"
+	default:
+		return p
+	}
+}
+
+// Prepare brings the live Glue table up to d before any of its Parquet is written:
+// columns the registry added (nullable ones, see Evolve) are appended with ALTER TABLE
+// ADD COLUMNS and returned; an incompatible difference is refused with an error
+// wrapping ErrIncompatible, and so is a missing table, which Terraform creates.
+func Prepare(ctx context.Context, q Querier, db string, d Dataset) ([]Column, error) {
+	want, err := d.Table()
+	if err != nil {
+		return nil, err
+	}
+	cur, ok, err := Live(ctx, q, db, d.Name)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, fmt.Errorf("%s.%s: no such table; apply infra/terraform first", db, d.Name)
+	}
+	added, err := Evolve(cur, want)
+	if err != nil {
+		return nil, fmt.Errorf("%s.%s: %w", db, d.Name, err)
+	}
+	if len(added) == 0 {
+		return nil, nil
+	}
+	defs := make([]string, len(added))
+	for i, c := range added {
+		defs[i] = c.Name + " " + c.Type
+	}
+	if _, err := q.Query(ctx, fmt.Sprintf("ALTER TABLE %s.%s ADD COLUMNS (%s)", db, d.Name, strings.Join(defs, ", "))); err != nil {
+		return nil, fmt.Errorf("%s.%s: add columns: %w", db, d.Name, err)
+	}
+	return added, nil
+}
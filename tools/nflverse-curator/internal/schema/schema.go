@@ -0,0 +1,204 @@
+// Package schema is the registry of the curated Parquet datasets: the row structs the
+// curator writes and the Glue tables Athena reads them through. Glue columns are
+// derived from the structs' parquet tags, so the Terraform definitions (generated by
+// cmd/glue-schema) and the live catalog (see Prepare) are checked against one source.
+package schema
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// PlayersRow is one row of players/ (unpartitioned).
+type PlayersRow struct {
+	PfrID     *string `parquet:"pfr_id,optional"`
+	GsisID    *string `parquet:"gsis_id,optional"`
+	FullName  *string `parquet:"full_name,optional"`
+	BirthDate *string `parquet:"birth_date,optional"` // kept as a string; cast in SQL
+	Position  *string `parquet:"position,optional"`
+}
+
+// RostersWeeklyRow is one row of rosters_weekly/season=/week=/.
+type RostersWeeklyRow struct {
+	Season   string  `parquet:"season"`
+	Week     string  `parquet:"week"`
+	Team     string  `parquet:"team"`
+	PlayerID *string `parquet:"player_id,optional"`
+	PfrID    *string `parquet:"pfr_id,optional"`
+	FullName *string `parquet:"full_name,optional"`
+	Position *string `parquet:"position,optional"`
+	Status   *string `parquet:"status,optional"`
+}
+
+// SnapCountsRow is one row of snap_counts/season=/team=/.
+type SnapCountsRow struct {
+	Season     string   `parquet:"season"`
+	Week       string   `parquet:"week"`
+	Team       string   `parquet:"team"`
+	Player     *string  `parquet:"player,optional"`
+	PlayerID   *string  `parquet:"player_id,optional"` // GSIS, when the source has it
+	PfrID      *string  `parquet:"pfr_id,optional"`
+	OffensePct *float64 `parquet:"offense_pct,optional"`
+	DefensePct *float64 `parquet:"defense_pct,optional"`
+	STPct      *float64 `parquet:"st_pct,optional"`
+}
+
+// Datasets are the curated datasets, in the order Terraform lists them.
+var Datasets = []Dataset{
+	{
+		Name: "players", Row: PlayersRow{},
+		Key: []string{"gsis_id", "pfr_id", "full_name"},
+	},
+	{
+		Name: "rosters_weekly", Row: RostersWeeklyRow{}, PartitionKeys: []string{"season", "week"},
+		Key: []string{"season", "week", "team", "player_id", "pfr_id", "full_name"},
+	},
+	{
+		Name: "snap_counts", Row: SnapCountsRow{}, PartitionKeys: []string{"season", "team"},
+		Key: []string{"season", "week", "team", "player_id", "pfr_id", "player"},
+	},
+}
+
+// Get returns the dataset called name.
+func Get(name string) (Dataset, bool) {
+	for _, d := range Datasets {
+		if d.Name == name {
+			return d, true
+		}
+	}
+	return Dataset{}, false
+}
+
+// Dataset is one curated dataset and its Glue table.
+type Dataset struct {
+	Name string
+	Row  any // zero value of the row struct written to Parquet
+	// PartitionKeys are row columns that are also the hive partition directories
+	// (season=/week=/); Glue declares them as partition keys, not columns.
+	PartitionKeys []string
+	// Key are the columns identifying a row, most significant first. Compaction keeps
+	// the newest row per key and writes the partition sorted by it.
+	Key []string
+}
+
+// Column is one Glue column.
+type Column struct {
+	Name     string `json:"name"`
+	Type     string `json:"type"` // Glue (Hive) type: string, double, bigint, ...
+	Nullable bool   `json:"-"`    // optional in Parquet; only these may be added later
+}
+
+// Table is a dataset's Glue table shape.
+type Table struct {
+	Columns       []Column `json:"columns"`
+	PartitionKeys []Column `json:"partition_keys"`
+}
+
+// Table derives the Glue table from the row struct's parquet tags.
+func (d Dataset) Table() (Table, error) {
+	t := reflect.TypeOf(d.Row)
+	if t.Kind() != reflect.Struct {
+		return Table{}, fmt.Errorf("%s: row is a %s, want a struct", d.Name, t.Kind())
+	}
+	cols := map[string]Column{}
+	var order []string
+	for i := range t.NumField() {
+		f := t.Field(i)
+		tag := f.Tag.Get("parquet")
+		name, opts, _ := strings.Cut(tag, ",")
+		if name == "-" || !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(f.Name)
+		}
+		ft, nullable := f.Type, strings.Contains(","+opts+",", ",optional,")
+		if ft.Kind() == reflect.Pointer {
+			ft, nullable = ft.Elem(), true
+		}
+		typ, err := glueType(ft)
+		if err != nil {
+			return Table{}, fmt.Errorf("%s.%s: %w", d.Name, name, err)
+		}
+		cols[name] = Column{Name: name, Type: typ, Nullable: nullable}
+		order = append(order, name)
+	}
+	var out Table
+	isKey := map[string]bool{}
+	for _, k := range d.PartitionKeys {
+		c, ok := cols[k]
+		if !ok {
+			return Table{}, fmt.Errorf("%s: partition key %s is not a row column", d.Name, k)
+		}
+		// Partition values are directory names, so they are strings in Glue whatever the
+		// row holds.
+		out.PartitionKeys = append(out.PartitionKeys, Column{Name: k, Type: "string", Nullable: c.Nullable})
+		isKey[k] = true
+	}
+	for _, n := range order {
+		if !isKey[n] {
+			out.Columns = append(out.Columns, cols[n])
+		}
+	}
+	return out, nil
+}
+
+// KeyFunc returns a function reading d's Key values from a row struct (or a pointer
+// to one); a nil optional column reads as "".
+func (d Dataset) KeyFunc() (func(row any) []string, error) {
+	t := reflect.TypeOf(d.Row)
+	idx := make([]int, len(d.Key))
+	for i, k := range d.Key {
+		idx[i] = -1
+		for j := range t.NumField() {
+			if columnName(t.Field(j)) == k {
+				idx[i] = j
+			}
+		}
+		if idx[i] < 0 {
+			return nil, fmt.Errorf("%s: key %s is not a row column", d.Name, k)
+		}
+	}
+	return func(row any) []string {
+		v := reflect.Indirect(reflect.ValueOf(row))
+		out := make([]string, len(idx))
+		for i, j := range idx {
+			f := v.Field(j)
+			if f.Kind() == reflect.Pointer {
+				if f.IsNil() {
+					continue
+				}
+				f = f.Elem()
+			}
+			out[i] = fmt.Sprint(f.Interface())
+		}
+		return out
+	}, nil
+}
+
+func columnName(f reflect.StructField) string {
+	name, _, _ := strings.Cut(f.Tag.Get("parquet"), ",")
+	if name == "" {
+		name = strings.ToLower(f.Name)
+	}
+	return name
+}
+
+func glueType(t reflect.Type) (string, error) {
+	switch t.Kind() {
+	case reflect.String:
+		return "string", nil
+	case reflect.Bool:
+		return "boolean", nil
+	case reflect.Int32, reflect.Int16, reflect.Int8, reflect.Uint8, reflect.Uint16:
+		return "int", nil
+	case reflect.Int, reflect.Int64, reflect.Uint32:
+		return "bigint", nil
+	case reflect.Float32:
+		return "float", nil
+	case reflect.Float64:
+		return "double", nil
+	}
+	return "", fmt.Errorf("no Glue type for %s", t)
+}
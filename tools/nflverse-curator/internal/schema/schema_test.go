@@ -0,0 +1,148 @@
+package schema
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestTableFromRowStruct(t *testing.T) {
+	d, _ := Get("snap_counts")
+	tbl, err := d.Table()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Table{
+		Columns: []Column{
+			{"week", "string", false},
+			{"player", "string", true},
+			{"player_id", "string", true},
+			{"pfr_id", "string", true},
+			{"offense_pct", "double", true},
+			{"defense_pct", "double", true},
+			{"st_pct", "double", true},
+		},
+		PartitionKeys: []Column{{"season", "string", false}, {"team", "string", false}},
+	}
+	if !reflect.DeepEqual(tbl, want) {
+		t.Errorf("table =\n%+v\nwant\n%+v", tbl, want)
+	}
+
+	type bad struct {
+		When struct{} `parquet:"when"`
+	}
+	if _, err := (Dataset{Name: "x", Row: bad{}}).Table(); err == nil {
+		t.Error("struct column accepted")
+	}
+	if _, err := (Dataset{Name: "x", Row: PlayersRow{}, PartitionKeys: []string{"season"}}).Table(); err == nil {
+		t.Error("partition key missing from the row accepted")
+	}
+}
+
+func TestKeyFunc(t *testing.T) {
+	for _, d := range Datasets {
+		if _, err := d.KeyFunc(); err != nil || len(d.Key) == 0 {
+			t.Errorf("%s: key %v: %v", d.Name, d.Key, err)
+		}
+	}
+	d, _ := Get("snap_counts")
+	keyOf, _ := d.KeyFunc()
+	id := "00-0036"
+	got := keyOf(&SnapCountsRow{Season: "2024", Week: "03", Team: "SEA", PlayerID: &id})
+	if want := []string{"2024", "03", "SEA", "00-0036", "", ""}; !reflect.DeepEqual(got, want) {
+		t.Errorf("key = %q, want %q", got, want)
+	}
+	if _, err := (Dataset{Name: "x", Row: PlayersRow{}, Key: []string{"season"}}).KeyFunc(); err == nil {
+		t.Error("key missing from the row accepted")
+	}
+}
+
+func TestEvolve(t *testing.T) {
+	cur := Table{
+		Columns:       []Column{{Name: "player", Type: "string"}, {Name: "snaps", Type: "int"}},
+		PartitionKeys: []Column{{Name: "season", Type: "string"}},
+	}
+	next := Table{
+		Columns:       []Column{{"Player", "string", true}, {"snaps", "bigint", false}, {"pfr_id", "string", true}},
+		PartitionKeys: cur.PartitionKeys,
+	}
+	added, err := Evolve(cur, next)
+	if err != nil || len(added) != 1 || added[0].Name != "pfr_id" {
+		t.Fatalf("added = %v, err = %v; want pfr_id added and int widened", added, err)
+	}
+
+	for name, next := range map[string]Table{
+		"drop":      {Columns: cur.Columns[:1], PartitionKeys: cur.PartitionKeys},
+		"retype":    {Columns: []Column{{Name: "player", Type: "bigint"}, cur.Columns[1]}, PartitionKeys: cur.PartitionKeys},
+		"narrow":    {Columns: []Column{cur.Columns[0], {Name: "snaps", Type: "string"}}, PartitionKeys: cur.PartitionKeys},
+		"required":  {Columns: append(append([]Column{}, cur.Columns...), Column{Name: "team", Type: "string"}), PartitionKeys: cur.PartitionKeys},
+		"partition": {Columns: cur.Columns, PartitionKeys: []Column{{Name: "season", Type: "string"}, {Name: "week", Type: "string"}}},
+	} {
+		if _, err := Evolve(cur, next); !errors.Is(err, ErrIncompatible) {
+			t.Errorf("%s: err = %v, want ErrIncompatible", name, err)
+		}
+	}
+}
+
+// catalog answers information_schema queries from canned columns and records DDL.
+type catalog struct {
+	cols [][]string
+	ddl  []string
+}
+
+func (c *catalog) Query(_ context.Context, sql string) ([][]string, error) {
+	if strings.Contains(sql, "information_schema.columns") {
+		return c.cols, nil
+	}
+	c.ddl = append(c.ddl, sql)
+	return nil, nil
+}
+
+func TestPrepare(t *testing.T) {
+	d, _ := Get("snap_counts")
+	live := &catalog{cols: [][]string{
+		{"week", "varchar", ""}, {"player", "varchar", ""}, {"player_id", "varchar", ""},
+		{"offense_pct", "double", ""}, {"defense_pct", "double", ""}, {"st_pct", "double", ""},
+		{"season", "varchar", "partition key"}, {"team", "varchar", "partition key"},
+	}}
+	added, err := Prepare(context.Background(), live, "nflverse_curated", d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(added) != 1 || len(live.ddl) != 1 || live.ddl[0] != "ALTER TABLE nflverse_curated.snap_counts ADD COLUMNS (pfr_id string)" {
+		t.Fatalf("added = %v, ddl = %v", added, live.ddl)
+	}
+
+	// The live table has a column the struct dropped: refused, nothing altered.
+	live = &catalog{cols: append(live.cols, []string{"pfr_id", "varchar", ""}, []string{"status", "varchar", ""})}
+	if _, err := Prepare(context.Background(), live, "nflverse_curated", d); !errors.Is(err, ErrIncompatible) || len(live.ddl) != 0 {
+		t.Errorf("err = %v, ddl = %v", err, live.ddl)
+	}
+	if _, err := Prepare(context.Background(), &catalog{}, "nflverse_curated", d); err == nil {
+		t.Error("missing table accepted")
+	}
+}
+
+// TestTerraformUpToDate fails when a row struct changed without regenerating the Glue
+// columns Terraform applies (go run ./cmd/glue-schema).
+func TestTerraformUpToDate(t *testing.T) {
+	want, err := Terraform(Datasets)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := os.ReadFile(filepath.Join("..", "..", "..", "..", TerraformFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != string(want) {
+		t.Errorf("%s is out of date; run go run ./cmd/glue-schema in tools/nflverse-curator", TerraformFile)
+	}
+	tables, err := ParseTerraform(got)
+	if err != nil || len(tables) != len(Datasets) {
+		t.Fatalf("parsed %d tables, err %v", len(tables), err)
+	}
+}
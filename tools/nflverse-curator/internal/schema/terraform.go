@@ -0,0 +1,53 @@
+package schema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// TerraformFile is where the generated Glue column lists live, relative to the repo
+// root; infra/terraform/s3_athena_glue.tf reads them from local.curated_schema.
+const TerraformFile = "infra/terraform/curated_schema.tf.json"
+
+type tfFile struct {
+	Comment string `json:"//"`
+	Locals  struct {
+		CuratedSchema map[string]Table `json:"curated_schema"`
+	} `json:"locals"`
+}
+
+// Terraform renders ds as a Terraform JSON file defining local.curated_schema, one
+// {columns, partition_keys} object per dataset.
+func Terraform(ds []Dataset) ([]byte, error) {
+	var f tfFile
+	f.Comment = "Generated by tools/nflverse-curator/cmd/glue-schema from the curated row structs; do not edit."
+	f.Locals.CuratedSchema = map[string]Table{}
+	for _, d := range ds {
+		t, err := d.Table()
+		if err != nil {
+			return nil, err
+		}
+		if t.PartitionKeys == nil {
+			t.PartitionKeys = []Column{}
+		}
+		f.Locals.CuratedSchema[d.Name] = t
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(f); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// ParseTerraform reads the tables from a file Terraform wrote. Nullability is not
+// recorded there, so every column reads as required.
+func ParseTerraform(b []byte) (map[string]Table, error) {
+	var f tfFile
+	if err := json.Unmarshal(b, &f); err != nil {
+		return nil, fmt.Errorf("%s: %w", TerraformFile, err)
+	}
+	return f.Locals.CuratedSchema, nil
+}
@@ -0,0 +1,76 @@
+package snaps
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+
+	"github.com/tyler180/fantasy-football-backends/internal/backtest"
+	"github.com/tyler180/fantasy-football-backends/internal/reportout"
+)
+
+// runBacktest replays each season week by week and compares the snap-share signals
+// (last, slope3, slope5, ewma, alerts, projection) on next-game DEF%: MAE, RMSE, rank
+// correlation and breakout-flag hit rate. Flag thresholds come from BACKTEST_SLOPE3,
+// BACKTEST_SLOPE5 and BACKTEST_EWMA so they can be tuned between runs. The report
+// goes to REPORT_BUCKET/REPORT_PREFIX and/or REPORT_DIR, else to the log.
+func runBacktest(ctx context.Context, ddb *dynamodb.Client, e Event, seasonStr string, debug bool) (string, error) {
+	list := e.Seasons
+	if list == "" {
+		list = envStr("BACKTEST_SEASONS", seasonStr)
+	}
+	var seasons []backtest.Season
+	for _, s := range strings.Split(list, ",") {
+		if s = strings.TrimSpace(s); s == "" {
+			continue
+		}
+		in, err := loadProjectionInputs(ctx, ddb, s, debug)
+		if err != nil {
+			return "", fmt.Errorf("season %s: %w", s, err)
+		}
+		seasons = append(seasons, backtest.Season{Season: s, Players: in.players, Env: in.env})
+	}
+
+	opt := backtest.Options{FromWeek: e.FromWeek}
+	if opt.FromWeek <= 0 {
+		opt.FromWeek = envInt("BACKTEST_FROM_WEEK", 4)
+	}
+	th := backtest.Thresholds{
+		Slope3: envFloat("BACKTEST_SLOPE3", 0),
+		Slope5: envFloat("BACKTEST_SLOPE5", 0),
+		EWMA:   envFloat("BACKTEST_EWMA", 0),
+	}
+	preds := backtest.Run(seasons, backtest.DefaultSignals(th), opt)
+	rep := backtest.NewReport(preds, opt, th, time.Now())
+
+	js, err := json.MarshalIndent(rep, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	md := []byte(rep.Markdown())
+	where, err := writeReport(ctx, envStr("REPORT_PREFIX", "reports/backtest"), strings.Join(rep.Seasons, "-"), "backtest", js, md)
+	if err != nil {
+		return "", err
+	}
+	for _, s := range rep.Overall {
+		log.Printf("backtest %-10s n=%d mae=%.2f rmse=%.2f spearman=%.3f flags=%d hit_rate=%.3f recall=%.3f",
+			s.Signal, s.N, s.MAE, s.RMSE, s.Spearman, s.Flags, s.HitRate, s.Recall)
+	}
+	log.Printf("OK backtest: %d predictions over %s -> %s", len(preds), strings.Join(rep.Seasons, ","), strings.Join(where, ", "))
+	return fmt.Sprintf("predictions=%d signals=%d", len(preds), len(rep.Overall)), nil
+}
+
+// writeReport writes <name>.json and <name>.md under <prefix>/<sub>/ in REPORT_BUCKET
+// and/or REPORT_DIR, else logs the Markdown (see reportout).
+func writeReport(ctx context.Context, prefix, sub, name string, js, md []byte) ([]string, error) {
+	w, err := reportout.FromEnv(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return w.Write(ctx, prefix, sub, name, js, md)
+}
@@ -0,0 +1,169 @@
+package snaps
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+
+	"github.com/tyler180/fantasy-football-backends/internal/dynasty"
+	"github.com/tyler180/fantasy-football-backends/internal/pfr"
+	"github.com/tyler180/fantasy-football-backends/internal/snaps"
+	"github.com/tyler180/fantasy-football-backends/internal/store"
+)
+
+// runDynasty refits the age curves on the backfilled seasons and values every
+// defender active through the season. History is the roster table (age, games, season
+// DEF%) joined to points per game scored from the season's nflverse weekly stats with
+// the league ruleset, for every age (the players table only holds starter-eligible
+// players, so its points would fit the curves on the young alone); the seasons are Event.Seasons, else DYNASTY_SEASONS, else the DYNASTY_HISTORY (default 6)
+// seasons ending with season. The report goes to REPORT_BUCKET under DYNASTY_PREFIX
+// and/or REPORT_DIR, else to the log.
+func runDynasty(ctx context.Context, ddb *dynamodb.Client, e Event, seasonStr string, debug bool) (string, error) {
+	season, err := strconv.Atoi(seasonStr)
+	if err != nil {
+		return "", fmt.Errorf("season %q: %w", seasonStr, err)
+	}
+	list := e.Seasons
+	if list == "" {
+		list = envStr("DYNASTY_SEASONS", "")
+	}
+	var seasons []string
+	if list != "" {
+		for _, s := range strings.Split(list, ",") {
+			if s = strings.TrimSpace(s); s != "" {
+				seasons = append(seasons, s)
+			}
+		}
+	} else {
+		for y := season - envInt("DYNASTY_HISTORY", 6) + 1; y <= season; y++ {
+			seasons = append(seasons, strconv.Itoa(y))
+		}
+	}
+
+	rosterTable := envStr("ROSTER_TABLE_NAME", "nfl_roster_rows")
+	rules, err := loadRuleset()
+	if err != nil {
+		return "", err
+	}
+	gsis2pfr, name2pfr, err := snaps.FetchNflversePlayerIDs(ctx, envStr("IDS_URL", ""))
+	if err != nil {
+		return "", fmt.Errorf("fetch player ids: %w", err)
+	}
+	byID := map[string]*dynasty.Player{}
+	for _, s := range seasons {
+		yr, err := strconv.Atoi(s)
+		if err != nil {
+			return "", fmt.Errorf("season %q: %w", s, err)
+		}
+		rows, err := store.LoadRosterRows(ctx, ddb, rosterTable, s)
+		if err != nil {
+			return "", fmt.Errorf("roster rows %s: %w", s, err)
+		}
+		if len(rows) == 0 {
+			log.Printf("dynasty: WARN no roster rows for %s in %s (not backfilled?)", s, rosterTable)
+			continue
+		}
+		stats, _, err := fetchDefStatsPFR(ctx, yr, gsis2pfr, name2pfr)
+		if err != nil {
+			return "", fmt.Errorf("season %s: %w", s, err)
+		}
+		ppg := map[string]float64{}
+		for _, p := range rules.Season(stats) {
+			ppg[p.PlayerID] = p.PPG
+		}
+		merged := mergeDynastySeason(byID, yr, rows, ppg)
+		if debug {
+			log.Printf("dynasty: %s roster_rows=%d players=%d with_points=%d", s, len(rows), merged, len(ppg))
+		}
+	}
+
+	players := make([]dynasty.Player, 0, len(byID))
+	for _, p := range byID {
+		sort.Slice(p.Seasons, func(i, j int) bool { return p.Seasons[i].Season < p.Seasons[j].Season })
+		players = append(players, *p)
+	}
+	opt := dynasty.Options{
+		MinGames: envInt("DYNASTY_MIN_GAMES", 0),
+		Horizon:  envInt("DYNASTY_HORIZON", 0),
+		Discount: envFloat("DYNASTY_DISCOUNT", 0),
+	}
+	model := dynasty.Fit(players, opt)
+	if len(model.Curves) == 0 {
+		return "", fmt.Errorf("dynasty: no qualifying history in %s for %s", rosterTable, strings.Join(seasons, ","))
+	}
+	rep := dynasty.Report{
+		Season:      season,
+		GeneratedAt: time.Now().UTC(),
+		Model:       model,
+		Values:      model.Values(players, season),
+		Top:         envInt("DYNASTY_TOP", 25),
+	}
+
+	js, err := json.MarshalIndent(rep, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	where, err := writeReport(ctx, envStr("DYNASTY_PREFIX", "reports/dynasty"), seasonStr, "dynasty", js, []byte(rep.Markdown()))
+	if err != nil {
+		return "", err
+	}
+	for _, g := range []string{"DL", "LB", "DB"} {
+		if c, ok := model.Curves[g]; ok {
+			log.Printf("dynasty %s peak_age=%d ppg_per_snap_pct=%.4f", g, c.PeakAge, c.PPGPer)
+		}
+	}
+	log.Printf("OK dynasty: %d players valued from %d seasons -> %s", len(rep.Values), len(model.Seasons), strings.Join(where, ", "))
+	return fmt.Sprintf("valued=%d curves=%d", len(rep.Values), len(model.Curves)), nil
+}
+
+// mergeDynastySeason appends one season of roster rows to byID and returns the number
+// of players it covered. A player traded mid-season has a row per team: games are
+// summed, DEF% is weighted by games and the team is the one with the most starts.
+// ppg is points per game by PFR id; players without it are left unscored.
+func mergeDynastySeason(byID map[string]*dynasty.Player, yr int, rows []pfr.RosterRow, ppg map[string]float64) int {
+	type agg struct {
+		dynasty.Season
+		pct  float64
+		best int
+	}
+	merged := map[string]*agg{}
+	for _, r := range rows {
+		a := merged[r.PlayerID]
+		if a == nil {
+			a = &agg{Season: dynasty.Season{Season: yr}, best: -1}
+			merged[r.PlayerID] = a
+			p := byID[r.PlayerID]
+			if p == nil {
+				p = &dynasty.Player{PlayerID: r.PlayerID}
+				byID[r.PlayerID] = p
+			}
+			p.Player, p.Pos = r.Player, r.Pos
+		}
+		if r.Age > 0 && (a.Age == 0 || r.Age < a.Age) {
+			a.Age = r.Age
+		}
+		a.G += r.G
+		a.GS += r.GS
+		a.pct += r.DefSnapPct * float64(r.G)
+		if r.GS > a.best {
+			a.best, a.Team = r.GS, r.Team
+		}
+	}
+	for pid, a := range merged {
+		if a.G > 0 {
+			a.SnapPct = a.pct / float64(a.G)
+		}
+		if v, ok := ppg[pid]; ok && v > 0 {
+			a.PPG, a.Scored = v, true
+		}
+		byID[pid].Seasons = append(byID[pid].Seasons, a.Season)
+	}
+	return len(merged)
+}
@@ -0,0 +1,71 @@
+package snaps
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"testing"
+
+	"github.com/tyler180/fantasy-football-backends/internal/dynasty"
+	"github.com/tyler180/fantasy-football-backends/internal/pfr"
+)
+
+// The curves are fitted on every age on the roster, not just the starter-eligible
+// (MAX_AGE, default 24) players the players table holds.
+func TestMergeDynastySeason_FitsPastMaxAge(t *testing.T) {
+	byID := map[string]*dynasty.Player{}
+	for yr := 2018; yr <= 2024; yr++ {
+		var rows []pfr.RosterRow
+		ppg := map[string]float64{}
+		for start := 21; start <= 30; start++ {
+			for j := 0; j < 6; j++ {
+				id := fmt.Sprintf("LB%02d%d", start, j)
+				age := start + yr - 2018
+				snap := 80 - 4*math.Abs(float64(age-26))
+				rows = append(rows, pfr.RosterRow{PlayerID: id, Player: id, Team: "SEA", Age: age, Pos: "LB", G: 16, GS: 16, DefSnapPct: snap})
+				ppg[id] = snap / 10
+			}
+		}
+		if n := mergeDynastySeason(byID, yr, rows, ppg); n != 60 {
+			t.Fatalf("%d merged %d", yr, n)
+		}
+	}
+
+	players := make([]dynasty.Player, 0, len(byID))
+	oldest := 0
+	for _, p := range byID {
+		sort.Slice(p.Seasons, func(i, j int) bool { return p.Seasons[i].Season < p.Seasons[j].Season })
+		for _, s := range p.Seasons {
+			if s.Scored {
+				oldest = max(oldest, s.Age)
+			}
+		}
+		players = append(players, *p)
+	}
+	if oldest != 36 {
+		t.Fatalf("oldest scored age = %d, want 36", oldest)
+	}
+	c, ok := dynasty.Fit(players, dynasty.Options{Prior: 1}).Curves["LB"]
+	if !ok {
+		t.Fatal("no LB curve")
+	}
+	if c.PeakAge != 26 {
+		t.Fatalf("peak age = %d, want 26", c.PeakAge)
+	}
+	if math.Abs(c.PPGPer-0.1) > 1e-3 {
+		t.Fatalf("ppg per snap pct = %.4f, want 0.1", c.PPGPer)
+	}
+}
+
+func TestMergeDynastySeason_TradedPlayer(t *testing.T) {
+	byID := map[string]*dynasty.Player{}
+	rows := []pfr.RosterRow{
+		{PlayerID: "x", Player: "X", Team: "SEA", Age: 29, Pos: "LB", G: 4, GS: 1, DefSnapPct: 20},
+		{PlayerID: "x", Player: "X", Team: "KAN", Age: 29, Pos: "LB", G: 12, GS: 12, DefSnapPct: 80},
+	}
+	mergeDynastySeason(byID, 2024, rows, map[string]float64{"x": 7.5})
+	s := byID["x"].Seasons[0]
+	if s.G != 16 || s.GS != 13 || s.Team != "KAN" || s.SnapPct != 65 || s.PPG != 7.5 || !s.Scored {
+		t.Fatalf("season = %+v", s)
+	}
+}
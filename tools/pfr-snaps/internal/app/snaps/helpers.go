@@ -48,6 +48,17 @@ func envInt(k string, def int) int {
 	}
 	return i
 }
+func envFloat(k string, def float64) float64 {
+	v := strings.TrimSpace(os.Getenv(k))
+	if v == "" {
+		return def
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return def
+	}
+	return f
+}
 func pickInt(ev *int, env int) int {
 	if ev != nil {
 		return *ev
@@ -179,7 +190,7 @@ func teamDelay() time.Duration {
 	return time.Duration(envInt("TEAM_DELAY_MS", 500)) * time.Millisecond
 }
 
-// ------------------ trends helpers (DDB read + slope) ------------------
+// ------------------ trends helpers (DDB read) ------------------
 
 type playerKey struct {
 	PlayerID string
@@ -213,43 +224,6 @@ func listPlayersForSeasonTeam(ctx context.Context, ddb *dynamodb.Client, players
 	return res, nil
 }
 
-func queryPlayerSnaps(ctx context.Context, ddb *dynamodb.Client, snapTable, playerID, season string) ([]float64, error) {
-	prefix := season + "#"
-	out, err := ddb.Query(ctx, &dynamodb.QueryInput{
-		TableName:              aws.String(snapTable),
-		IndexName:              aws.String("PlayerGames"),
-		KeyConditionExpression: aws.String("#pid = :pid AND begins_with(#sw, :pref)"),
-		ExpressionAttributeNames: map[string]string{
-			"#pid": "PlayerID",
-			"#sw":  "SeasonWeek",
-		},
-		ExpressionAttributeValues: map[string]types.AttributeValue{
-			":pid":  &types.AttributeValueMemberS{Value: playerID},
-			":pref": &types.AttributeValueMemberS{Value: prefix},
-		},
-		ProjectionExpression: aws.String("SeasonWeek, DefSnapPct"),
-	})
-	if err != nil {
-		return nil, err
-	}
-	type row struct {
-		sw  string
-		pct float64
-	}
-	tmp := make([]row, 0, len(out.Items))
-	for _, it := range out.Items {
-		sw := getStr(it, "SeasonWeek")
-		pct := getFloat(it, "DefSnapPct")
-		tmp = append(tmp, row{sw: sw, pct: pct})
-	}
-	sort.Slice(tmp, func(i, j int) bool { return tmp[i].sw < tmp[j].sw })
-	vals := make([]float64, 0, len(tmp))
-	for _, r := range tmp {
-		vals = append(vals, r.pct)
-	}
-	return vals, nil
-}
-
 func getStr(m map[string]types.AttributeValue, key string) string {
 	if v, ok := m[key]; ok {
 		switch t := v.(type) {
@@ -261,34 +235,6 @@ func getStr(m map[string]types.AttributeValue, key string) string {
 	}
 	return ""
 }
-func getFloat(m map[string]types.AttributeValue, key string) float64 {
-	s := getStr(m, key)
-	if s == "" {
-		return 0
-	}
-	f, _ := strconv.ParseFloat(s, 64)
-	return f
-}
-
-func slope(vals []float64) float64 {
-	n := float64(len(vals))
-	if n < 2 {
-		return 0
-	}
-	var sx, sy, sxx, sxy float64
-	for i, y := range vals {
-		x := float64(i + 1)
-		sx += x
-		sy += y
-		sxx += x * x
-		sxy += x * y
-	}
-	den := n*sxx - sx*sx
-	if den == 0 {
-		return 0
-	}
-	return (n*sxy - sx*sy) / den
-}
 
 // ---------- name normalization (for backfilling by name) ----------
 
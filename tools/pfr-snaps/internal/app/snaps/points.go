@@ -0,0 +1,136 @@
+package snaps
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"github.com/tyler180/fantasy-football-backends/internal/scoring"
+	"github.com/tyler180/fantasy-football-backends/internal/snaps"
+	"github.com/tyler180/fantasy-football-backends/internal/store"
+)
+
+// runScoreIDP scores every defender's season from nflverse weekly stats and writes the
+// points onto their players-table items.
+func runScoreIDP(ctx context.Context, ddb *dynamodb.Client, seasonStr string, debug bool) (string, error) {
+	seasonInt, err := strconv.Atoi(seasonStr)
+	if err != nil {
+		return "", fmt.Errorf("season %q: %w", seasonStr, err)
+	}
+	playersTable := envStr("TABLE_NAME", "defensive_players_by_team")
+
+	rules, err := loadRuleset()
+	if err != nil {
+		return "", err
+	}
+
+	gsis2pfr, name2pfr, err := snaps.FetchNflversePlayerIDs(ctx, envStr("IDS_URL", ""))
+	if err != nil {
+		return "", fmt.Errorf("fetch player ids: %w", err)
+	}
+	kept, unmatched, err := fetchDefStatsPFR(ctx, seasonInt, gsis2pfr, name2pfr)
+	if err != nil {
+		return "", err
+	}
+
+	written, missing := 0, 0
+	for _, p := range rules.Season(kept) {
+		err := store.UpdatePlayerPoints(ctx, ddb, playersTable, seasonStr, p.Team, p, rules.Name)
+		var ccf *types.ConditionalCheckFailedException
+		if errors.As(err, &ccf) {
+			// Traded players live under another SeasonTeam partition.
+			n, ferr := updatePointsAnyTeam(ctx, ddb, playersTable, seasonStr, p, rules.Name)
+			if ferr != nil {
+				return "", ferr
+			}
+			if n == 0 {
+				missing++
+				continue
+			}
+			written += n
+			continue
+		}
+		if err != nil {
+			return "", fmt.Errorf("write points %s: %w", p.PlayerID, err)
+		}
+		written++
+	}
+	if debug {
+		log.Printf("score_idp: ruleset=%s games=%d unmatched_ids=%d not_in_players_table=%d", rules.Name, len(kept), unmatched, missing)
+	}
+	log.Printf("OK score_idp: wrote points for %d players in %s for %s", written, playersTable, seasonStr)
+	return fmt.Sprintf("points_updated=%d", written), nil
+}
+
+// fetchDefStatsPFR downloads nflverse weekly defensive stats and translates them to
+// the players table's PFR ids (by GSIS id, then name) and team codes; unmatched counts
+// rows with no PFR id.
+func fetchDefStatsPFR(ctx context.Context, season int, gsis2pfr, name2pfr map[string]string) ([]scoring.GameStats, int, error) {
+	games, err := snaps.FetchNflverseDefStats(ctx, season, envStr("DEF_STATS_URL", ""))
+	if err != nil {
+		return nil, 0, fmt.Errorf("fetch def stats: %w", err)
+	}
+
+	unmatched := 0
+	kept := games[:0]
+	for _, g := range games {
+		pid := gsis2pfr[g.PlayerID]
+		if pid == "" {
+			pid = name2pfr[normName(g.Player)]
+		}
+		if pid == "" {
+			unmatched++
+			continue
+		}
+		g.PlayerID = pid
+		if t, ok := nflverseToPFR[g.Team]; ok {
+			g.Team = t
+		}
+		kept = append(kept, g)
+	}
+	return kept, unmatched, nil
+}
+
+func updatePointsAnyTeam(ctx context.Context, ddb *dynamodb.Client, table, season string, p scoring.PlayerPoints, ruleset string) (int, error) {
+	teams, err := store.FindPlayerSeasonTeams(ctx, ddb, table, p.PlayerID, season)
+	if err != nil {
+		return 0, fmt.Errorf("find teams %s: %w", p.PlayerID, err)
+	}
+	n := 0
+	for _, t := range teams {
+		if t == p.Team {
+			continue
+		}
+		if err := store.UpdatePlayerPoints(ctx, ddb, table, season, t, p, ruleset); err != nil {
+			return n, fmt.Errorf("write points %s: %w", p.PlayerID, err)
+		}
+		n++
+	}
+	return n, nil
+}
+
+// loadRuleset reads SCORING_RULES_FILE (JSON, YAML or an MFL rules export) or the
+// inline JSON in SCORING_RULES, and falls back to scoring.Default.
+func loadRuleset() (scoring.Ruleset, error) {
+	if f := envStr("SCORING_RULES_FILE", ""); f != "" {
+		return scoring.LoadRuleset(f)
+	}
+	if js := strings.TrimSpace(os.Getenv("SCORING_RULES")); js != "" {
+		r, err := scoring.ParseRuleset([]byte(js), "json")
+		if err != nil {
+			return scoring.Ruleset{}, err
+		}
+		if r.Name == "" {
+			r.Name = "custom"
+		}
+		return r, nil
+	}
+	return scoring.Default(), nil
+}
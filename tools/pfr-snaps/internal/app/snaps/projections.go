@@ -0,0 +1,189 @@
+package snaps
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+
+	"github.com/tyler180/fantasy-football-backends/internal/projection"
+	"github.com/tyler180/fantasy-football-backends/internal/snaps"
+	"github.com/tyler180/fantasy-football-backends/internal/store"
+)
+
+// projectionModel names the projection method stored with every row.
+const projectionModel = "snapshare-v1"
+
+// projectionInputs is everything the projection model reads for a season, in PFR ids
+// and team codes.
+type projectionInputs struct {
+	players  []projection.Player
+	env      *projection.Env
+	gsis2pfr map[string]string
+	lastWeek int // latest week with team stats
+}
+
+// loadProjectionInputs joins per-game DEF% (snaps table), per-game IDP points (nflverse
+// stats scored with the league ruleset) and team offense/schedule into model inputs,
+// for every defender with a game in the snaps table.
+func loadProjectionInputs(ctx context.Context, ddb *dynamodb.Client, seasonStr string, debug bool) (projectionInputs, error) {
+	var in projectionInputs
+	seasonInt, err := strconv.Atoi(seasonStr)
+	if err != nil {
+		return in, fmt.Errorf("season %q: %w", seasonStr, err)
+	}
+	rules, err := loadRuleset()
+	if err != nil {
+		return in, err
+	}
+
+	gsis2pfr, name2pfr, err := snaps.FetchNflversePlayerIDs(ctx, envStr("IDS_URL", ""))
+	if err != nil {
+		return in, fmt.Errorf("fetch player ids: %w", err)
+	}
+	in.gsis2pfr = gsis2pfr
+	stats, unmatched, err := fetchDefStatsPFR(ctx, seasonInt, gsis2pfr, name2pfr)
+	if err != nil {
+		return in, err
+	}
+	points := map[string]map[int]float64{}
+	for _, g := range stats {
+		if points[g.PlayerID] == nil {
+			points[g.PlayerID] = map[int]float64{}
+		}
+		points[g.PlayerID][g.Week] += rules.Score(g)
+	}
+
+	offense, err := snaps.FetchNflverseTeamOffense(ctx, seasonInt, envStr("DEF_STATS_URL", ""))
+	if err != nil {
+		return in, fmt.Errorf("fetch team offense: %w", err)
+	}
+	for i := range offense {
+		offense[i].Team = pfrTeam(offense[i].Team)
+		offense[i].Opponent = pfrTeam(offense[i].Opponent)
+		in.lastWeek = max(in.lastWeek, offense[i].Week)
+	}
+	in.env = projection.NewEnv(offense)
+	sched, err := snaps.FetchNflverseSchedule(ctx, seasonInt, envStr("SCHEDULE_URL", ""))
+	if err != nil {
+		return in, fmt.Errorf("fetch schedule: %w", err)
+	}
+	for _, g := range sched {
+		in.env.AddGame(g.Week, pfrTeam(g.Home), pfrTeam(g.Away))
+	}
+
+	// The population is every defender in the per-game snaps table, not the players
+	// table, so a replay only knows who played each week, not who ended up mattering.
+	teams := make([]string, 0, len(nflverseToPFR))
+	for _, t := range nflverseToPFR {
+		teams = append(teams, t)
+	}
+	sort.Strings(teams)
+	games, err := store.LoadTeamSnapGames(ctx, ddb, envStr("SNAP_TABLE_NAME", "defensive_snaps_by_game"), seasonStr, teams)
+	if err != nil {
+		return in, err
+	}
+	// Games arrive in team then week order; name, position and team come from each
+	// player's latest game.
+	sort.SliceStable(games, func(i, j int) bool { return games[i].Week < games[j].Week })
+	byID := map[string]int{}
+	for _, g := range games {
+		i, ok := byID[g.PlayerID]
+		if !ok {
+			i = len(in.players)
+			byID[g.PlayerID] = i
+			in.players = append(in.players, projection.Player{PlayerID: g.PlayerID})
+		}
+		p := &in.players[i]
+		p.Player, p.Pos, p.Team = g.Player, g.Pos, g.Team
+		p.Games = append(p.Games, projection.Game{Week: g.Week, Team: g.Team, SnapPct: g.DefSnapPct, Points: points[g.PlayerID][g.Week]})
+	}
+	if debug {
+		log.Printf("projections: players=%d stat_rows=%d unmatched_ids=%d team_games=%d last_week=%d",
+			len(in.players), len(stats), unmatched, len(offense), in.lastWeek)
+	}
+	return in, nil
+}
+
+func pfrTeam(nflverse string) string {
+	if t, ok := nflverseToPFR[nflverse]; ok {
+		return t
+	}
+	return nflverse
+}
+
+// runProjectIDP projects next week's snaps and IDP points for every defender with a
+// game this season and writes them to PROJECTIONS_TABLE_NAME. Injury statuses come
+// from the nflverse injury report for the target week.
+func runProjectIDP(ctx context.Context, ddb *dynamodb.Client, e Event, seasonStr string, debug bool) (string, error) {
+	in, err := loadProjectionInputs(ctx, ddb, seasonStr, debug)
+	if err != nil {
+		return "", err
+	}
+	week := e.Week
+	if week <= 0 {
+		week = in.lastWeek + 1
+	}
+
+	injuries := map[string]string{}
+	seasonInt, _ := strconv.Atoi(seasonStr)
+	reports, err := snaps.FetchNflverseInjuries(ctx, seasonInt, envStr("INJURIES_URL", ""))
+	if err != nil {
+		// Projections still help without statuses; say so rather than fail.
+		log.Printf("project_idp: WARN injuries: %v", err)
+	}
+	for _, r := range reports {
+		if pid := in.gsis2pfr[r.GSISID]; pid != "" && r.Week == week {
+			injuries[pid] = r.Status
+		}
+	}
+
+	priors := projection.Priors(in.players, in.env, week)
+	out := make([]projection.Projection, 0, len(in.players))
+	for _, p := range in.players {
+		pr, ok := projection.Project(p, week, in.env.Opponent(p.Team, week), injuries[p.PlayerID], in.env, priors, projection.Options{})
+		if ok {
+			out = append(out, pr)
+		}
+	}
+
+	table := envStr("PROJECTIONS_TABLE_NAME", "defensive_projections")
+	rep, err := store.PutProjections(ctx, ddb, table, seasonStr, projectionModel, out)
+	if err != nil {
+		return "", fmt.Errorf("write projections: %w (%s)", err, rep)
+	}
+	log.Printf("OK project_idp: %d projections for %s week %d (%d with injury status) -> %s", len(out), seasonStr, week, len(injuries), table)
+	return fmt.Sprintf("projections=%d week=%d", len(out), week), nil
+}
+
+// runBacktestProjections replays the season: each week from from_week (default 4) is
+// projected from earlier games only and scored against what happened. Results are
+// logged per week and overall; nothing is written.
+func runBacktestProjections(ctx context.Context, ddb *dynamodb.Client, e Event, seasonStr string, debug bool) (string, error) {
+	in, err := loadProjectionInputs(ctx, ddb, seasonStr, debug)
+	if err != nil {
+		return "", err
+	}
+	from := e.FromWeek
+	if from <= 0 {
+		from = envInt("BACKTEST_FROM_WEEK", 4)
+	}
+	outs := projection.Backtest(in.players, in.env, from, in.lastWeek, projection.Options{})
+
+	byWeek := map[int][]projection.Outcome{}
+	for _, o := range outs {
+		byWeek[o.Week] = append(byWeek[o.Week], o)
+	}
+	for w := from; w <= in.lastWeek; w++ {
+		pts, sn := projection.Score(byWeek[w])
+		log.Printf("backtest %s week %02d: n=%d points mae=%.2f rmse=%.2f bias=%+.2f cover=%.2f | snaps mae=%.1f cover=%.2f",
+			seasonStr, w, pts.N, pts.MAE, pts.RMSE, pts.Bias, pts.Coverage, sn.MAE, sn.Coverage)
+	}
+	pts, sn := projection.Score(outs)
+	log.Printf("OK backtest_projections: %s weeks %d-%d n=%d points mae=%.2f rmse=%.2f bias=%+.2f cover=%.2f | snaps mae=%.1f rmse=%.1f cover=%.2f",
+		seasonStr, from, in.lastWeek, pts.N, pts.MAE, pts.RMSE, pts.Bias, pts.Coverage, sn.MAE, sn.RMSE, sn.Coverage)
+	return fmt.Sprintf("n=%d points_mae=%.3f points_rmse=%.3f points_coverage=%.3f snaps_mae=%.3f", pts.N, pts.MAE, pts.RMSE, pts.Coverage, sn.MAE), nil
+}
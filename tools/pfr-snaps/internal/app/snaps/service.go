@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"sort"
 	"strings"
 	"time"
 
@@ -14,6 +15,7 @@ import (
 
 	// update to your module path
 	"github.com/tyler180/fantasy-football-backends/internal/pfr"
+	"github.com/tyler180/fantasy-football-backends/internal/refresh"
 	"github.com/tyler180/fantasy-football-backends/internal/snaps"
 	"github.com/tyler180/fantasy-football-backends/internal/store"
 )
@@ -51,6 +53,16 @@ func LambdaEntrypoint(ctx context.Context, raw Raw) (string, error) {
 		return runIngestSnapsByGame(ctx, ddb, e, seasonStr, debug)
 	case "materialize_snap_trends":
 		return runMaterializeTrends(ctx, ddb, seasonStr, debug)
+	case "score_idp":
+		return runScoreIDP(ctx, ddb, seasonStr, debug)
+	case "project_idp":
+		return runProjectIDP(ctx, ddb, e, seasonStr, debug)
+	case "backtest_projections":
+		return runBacktestProjections(ctx, ddb, e, seasonStr, debug)
+	case "backtest":
+		return runBacktest(ctx, ddb, e, seasonStr, debug)
+	case "dynasty":
+		return runDynasty(ctx, ddb, e, seasonStr, debug)
 	default:
 		return "", fmt.Errorf("unknown mode %q", mode)
 	}
@@ -245,13 +257,34 @@ func runIngestSnapsByGame(ctx context.Context, ddb *dynamodb.Client, e Event, se
 			kept, filledEmpty, filledByName, filledDefault, canonicalized, dropped)
 	}
 
-	if len(out) > 0 {
-		if err := store.PutSnapGameRows(ctx, ddb, snapTable, out); err != nil {
-			return "", fmt.Errorf("write snap rows: %w", err)
+	// Write per team so a timed-out run resumes with the teams it had not reached.
+	byTeam := make(map[string][]pfr.SnapGameRow)
+	teams := make([]string, 0, 32)
+	for _, r := range out {
+		if _, ok := byTeam[r.Team]; !ok {
+			teams = append(teams, r.Team)
 		}
+		byTeam[r.Team] = append(byTeam[r.Team], r)
 	}
-	log.Printf("OK snaps[nflverse]: wrote %d rows to %s for %s", len(out), snapTable, seasonStr)
-	return fmt.Sprintf("snaps=%d", len(out)), nil
+	sort.Strings(teams)
+
+	var rep store.WriteReport
+	job := checkpointJob("ingest_snaps_by_game", seasonStr, "nflverse", teamListCSV)
+	ran, resumed, err := store.RunUnits(ctx, store.CheckpointsFromEnv(ddb), job, teams, checkpointMargin(),
+		func(ctx context.Context, team string) error {
+			r, err := store.PutSnapGameRows(ctx, ddb, snapTable, byTeam[team])
+			rep.Add(r)
+			if err != nil {
+				return fmt.Errorf("write snap rows for %s: %w", team, err)
+			}
+			return nil
+		})
+	log.Printf("snaps[nflverse]: teams written=%d resumed=%d; %s", ran, resumed, rep)
+	if err != nil {
+		return "", err
+	}
+	log.Printf("OK snaps[nflverse]: wrote %d rows to %s for %s", rep.Written, snapTable, seasonStr)
+	return fmt.Sprintf("snaps=%d", rep.Written), nil
 }
 
 // ---- PFR fallback kept for completeness (unchanged) ----
@@ -272,82 +305,97 @@ func runIngestSnapsByGamePFR(ctx context.Context, ddb *dynamodb.Client, e Event,
 
 	snapTable := envStr("SNAP_TABLE_NAME", "defensive_snaps_by_game")
 	referer := fmt.Sprintf("https://www.pro-football-reference.com/years/%s/", seasonStr)
-	total := 0
-	for _, t := range subset {
-		rows, err := pfr.FetchTeamDefSnapPctsByGame(ctx, t.Path, t.Abbr, seasonStr, referer)
-		if err != nil {
-			if debug {
-				log.Printf("snaps[pfr]: %s failed: %v", t.Abbr, err)
+	paths := make(map[string]string, len(subset))
+	abbrs := make([]string, len(subset))
+	for i, t := range subset {
+		paths[t.Abbr] = t.Path
+		abbrs[i] = t.Abbr
+	}
+
+	var rep store.WriteReport
+	job := checkpointJob("ingest_snaps_by_game", seasonStr, "pfr", strings.Join(abbrs, ","))
+	ran, resumed, err := store.RunUnits(ctx, store.CheckpointsFromEnv(ddb), job, abbrs, checkpointMargin(),
+		func(ctx context.Context, team string) error {
+			defer time.Sleep(teamDelay())
+			rows, err := pfr.FetchTeamDefSnapPctsByGame(ctx, paths[team], team, seasonStr, referer)
+			if err != nil {
+				// Scrape failures are not retried; the team is marked done.
+				if debug {
+					log.Printf("snaps[pfr]: %s failed: %v", team, err)
+				}
+				return nil
 			}
-			time.Sleep(teamDelay())
-			continue
-		}
-		if len(rows) > 0 {
-			if err := store.PutSnapGameRows(ctx, ddb, snapTable, rows); err != nil {
-				return "", fmt.Errorf("write snap rows: %w", err)
+			r, err := store.PutSnapGameRows(ctx, ddb, snapTable, rows)
+			rep.Add(r)
+			if err != nil {
+				return fmt.Errorf("write snap rows for %s: %w", team, err)
 			}
-			total += len(rows)
-		}
-		time.Sleep(teamDelay())
+			return nil
+		})
+	log.Printf("snaps[pfr]: teams written=%d resumed=%d; %s", ran, resumed, rep)
+	if err != nil {
+		return "", err
 	}
-	log.Printf("OK snaps[pfr]: wrote %d rows to %s for %s", total, snapTable, seasonStr)
-	return fmt.Sprintf("snaps=%d", total), nil
+	log.Printf("OK snaps[pfr]: wrote %d rows to %s for %s", rep.Written, snapTable, seasonStr)
+	return fmt.Sprintf("snaps=%d", rep.Written), nil
 }
 
 func runMaterializeTrends(ctx context.Context, ddb *dynamodb.Client, seasonStr string, debug bool) (string, error) {
 	snapTable := envStr("SNAP_TABLE_NAME", "defensive_snaps_by_game")
 	playersTable := envStr("TABLE_NAME", "defensive_players_by_team")
 
-	allTeams := pfr.AllTeams()
+	ref := &refresh.Refresher{
+		DDB:          ddb,
+		SnapTable:    snapTable,
+		PlayersTable: playersTable,
+		AlertsTable:  envStr("ALERTS_TABLE_NAME", ""),
+	}
+	abbrs := make([]string, 0, 32)
+	for _, t := range pfr.AllTeams() {
+		abbrs = append(abbrs, t.Abbr)
+	}
 	updated := 0
 
-	for _, t := range allTeams {
-		players, err := listPlayersForSeasonTeam(ctx, ddb, playersTable, seasonStr, t.Abbr)
-		if err != nil {
-			if debug {
-				log.Printf("trends: list %s err: %v", t.Abbr, err)
-			}
-			continue
-		}
-		if len(players) == 0 {
-			continue
-		}
-
-		for _, pk := range players {
-			vals, err := queryPlayerSnaps(ctx, ddb, snapTable, pk.PlayerID, seasonStr)
+	job := checkpointJob("materialize_snap_trends", seasonStr, playersTable, "")
+	_, resumed, err := store.RunUnits(ctx, store.CheckpointsFromEnv(ddb), job, abbrs, checkpointMargin(),
+		func(ctx context.Context, team string) error {
+			players, err := listPlayersForSeasonTeam(ctx, ddb, playersTable, seasonStr, team)
 			if err != nil {
 				if debug {
-					log.Printf("trends: query %s %s err: %v", t.Abbr, pk.PlayerID, err)
+					log.Printf("trends: list %s err: %v", team, err)
 				}
-				continue
-			}
-			if len(vals) == 0 {
-				_ = store.UpdatePlayerTrends(ctx, ddb, playersTable, seasonStr, t.Abbr, pk.PlayerID, 0, 0, 0, 0)
-				continue
-			}
-
-			last := vals[len(vals)-1]
-			var s3, s5, c3 float64
-			if len(vals) >= 3 {
-				s3 = slope(vals[len(vals)-3:])
-				base := (vals[len(vals)-3] + vals[len(vals)-2]) / 2.0
-				c3 = last - base
-			}
-			if len(vals) >= 5 {
-				s5 = slope(vals[len(vals)-5:])
+				return nil
 			}
-
-			if err := store.UpdatePlayerTrends(ctx, ddb, playersTable, seasonStr, t.Abbr, pk.PlayerID, last, s3, s5, c3); err != nil {
-				if debug {
-					log.Printf("trends: update %s %s err: %v", t.Abbr, pk.PlayerID, err)
+			for _, pk := range players {
+				if err := ref.RefreshPlayer(ctx, seasonStr, team, pk.PlayerID); err != nil {
+					if debug {
+						log.Printf("trends: refresh %s %s err: %v", team, pk.PlayerID, err)
+					}
+					continue
 				}
-				continue
+				updated++
 			}
-			updated++
-		}
-		time.Sleep(150 * time.Millisecond)
+			if len(players) > 0 {
+				time.Sleep(150 * time.Millisecond)
+			}
+			return nil
+		})
+	if err != nil {
+		log.Printf("trends: stopped after %d players (resumed past %d teams): %v", updated, resumed, err)
+		return "", err
 	}
 
-	log.Printf("OK trends: updated %d players in %s for %s", updated, playersTable, seasonStr)
+	log.Printf("OK trends: updated %d players in %s for %s (resumed past %d teams)", updated, playersTable, seasonStr, resumed)
 	return fmt.Sprintf("trends_updated=%d", updated), nil
 }
+
+// checkpointJob names a resumable run; the scope (team list, table) keeps runs over
+// different subsets from sharing progress.
+func checkpointJob(mode, season, source, scope string) string {
+	return strings.Join([]string{"pfr-snaps", mode, season, source, strings.ToUpper(scope)}, "#")
+}
+
+// checkpointMargin is how close to the Lambda deadline a run stops and checkpoints.
+func checkpointMargin() time.Duration {
+	return time.Duration(envInt("CHECKPOINT_MARGIN_SEC", 30)) * time.Second
+}
@@ -4,11 +4,14 @@ import "encoding/json"
 
 // Event is the Lambda payload.
 type Event struct {
-	Mode           string `json:"mode"`             // ingest_snaps_by_game | materialize_snap_trends
+	Mode           string `json:"mode"`             // ingest_snaps_by_game | materialize_snap_trends | score_idp | project_idp | backtest_projections | backtest | dynasty
 	Season         string `json:"season"`           // e.g., "2024"
 	TeamChunkTotal *int   `json:"team_chunk_total"` // PFR fallback only
 	TeamChunkIndex *int   `json:"team_chunk_index"` // PFR fallback only
 	TeamList       string `json:"team_list"`        // CSV ("SEA,TB") - accepts PFR or NFLverse codes
+	Week           int    `json:"week"`             // project_idp target week (default: the week after the latest stats)
+	FromWeek       int    `json:"from_week"`        // backtest_projections / backtest first week (default 4)
+	Seasons        string `json:"seasons"`          // backtest / dynasty: CSV of seasons (defaults: BACKTEST_SEASONS, DYNASTY_SEASONS)
 	// You can add fields here later (e.g., keep_all_pos)
 }
 
@@ -14,6 +14,7 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
 
 	"github.com/tyler180/fantasy-football-backends/internal/pfr"
+	"github.com/tyler180/fantasy-football-backends/internal/starters"
 	"github.com/tyler180/fantasy-football-backends/internal/store"
 )
 
@@ -26,13 +27,6 @@ type Event struct {
 	SnapCounts     *bool  `json:"snap_counts"`
 }
 
-func envInt(key string, def int) int {
-	if v, err := strconv.Atoi(strings.TrimSpace(os.Getenv(key))); err == nil {
-		return v
-	}
-	return def
-}
-
 func applyEventOverrides(e Event) {
 	if e.Season != "" {
 		os.Setenv("SEASON", e.Season)
@@ -92,19 +86,31 @@ func handler(ctx context.Context, raw json.RawMessage) (string, error) {
 			outTable = "defensive_players_" + season
 		}
 
-		defPos := pfr.ParsePositions(os.Getenv("POSITIONS"))
-		maxAge := envInt("MAX_AGE", 24) // not used in filtering anymore, but signature needs it
+		// Starter definition shared with the Athena CTAS (internal/starters)
+		spec, err := starters.FromEnv()
+		if err != nil {
+			return "", err
+		}
+		// POSITIONS (raw codes) still applies when the spec does not name groups itself.
+		if os.Getenv("POSITION_GROUPS") == "" && os.Getenv("STARTER_SPEC") == "" && os.Getenv("STARTER_SPEC_FILE") == "" {
+			if defPos := pfr.ParsePositions(os.Getenv("POSITIONS")); len(defPos) > 0 {
+				spec.Groups = defPos
+			}
+		}
+		snapTable := strings.TrimSpace(os.Getenv("SNAP_TABLE_NAME"))
 
-		rows, err := store.MaterializeDefenseFromRoster(ctx, ddb, rosterTable, season, defPos, maxAge)
+		rows, err := store.MaterializeDefenseFromRoster(ctx, ddb, rosterTable, snapTable, season, spec)
 		if err != nil {
 			return "", fmt.Errorf("materialize from roster: %w", err)
 		}
 
-		if err := store.PutRows(ctx, ddb, outTable, season, rows); err != nil {
+		rep, err := store.PutRows(ctx, ddb, outTable, season, rows)
+		if err != nil {
+			log.Printf("materialize: partial write: %s", rep)
 			return "", fmt.Errorf("write defensive rows: %w", err)
 		}
-		log.Printf("OK materialize: %d defensive rows into %s for season %s", len(rows), outTable, season)
-		return fmt.Sprintf("materialized %d rows", len(rows)), nil
+		log.Printf("OK materialize: %d defensive rows into %s for season %s (%s)", rep.Written, outTable, season, rep)
+		return fmt.Sprintf("materialized %d rows", rep.Written), nil
 
 	case "ingest_roster":
 		// (your existing ingest path that hits PFR goes here)
@@ -0,0 +1,14 @@
+package main
+
+import (
+	"log"
+
+	"github.com/aws/aws-lambda-go/lambda"
+
+	appstream "github.com/tyler180/fantasy-football-backends/tools/snaps-stream/internal/app/stream"
+)
+
+func main() {
+	log.SetFlags(0)
+	lambda.Start(appstream.LambdaEntrypoint)
+}
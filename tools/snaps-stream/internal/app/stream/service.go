@@ -0,0 +1,156 @@
+package stream
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+
+	"github.com/tyler180/fantasy-football-backends/internal/refresh"
+)
+
+func envStr(k, def string) string {
+	v := strings.TrimSpace(os.Getenv(k))
+	if v == "" {
+		return def
+	}
+	return v
+}
+
+func envBool(k string, def bool) bool {
+	switch strings.ToLower(strings.TrimSpace(os.Getenv(k))) {
+	case "1", "true", "t", "yes", "y", "on":
+		return true
+	case "0", "false", "f", "no", "n", "off":
+		return false
+	default:
+		return def
+	}
+}
+
+// LambdaEntrypoint consumes the defensive_snaps_by_game stream and recomputes trends
+// for the players whose games changed. Failed players are reported as batch item
+// failures so Lambda retries from the earliest affected record.
+func LambdaEntrypoint(ctx context.Context, ev events.DynamoDBEvent) (events.DynamoDBEventResponse, error) {
+	awsCfg, err := config.LoadDefaultConfig(ctx)
+	if err != nil {
+		return events.DynamoDBEventResponse{}, fmt.Errorf("aws config: %w", err)
+	}
+	p := &Processor{
+		Refresher: &refresh.Refresher{
+			DDB:          dynamodb.NewFromConfig(awsCfg),
+			SnapTable:    envStr("SNAP_TABLE_NAME", "defensive_snaps_by_game"),
+			PlayersTable: envStr("TABLE_NAME", "defensive_players_by_team"),
+			AlertsTable:  envStr("ALERTS_TABLE_NAME", ""),
+		},
+		Debug: envBool("DEBUG", false),
+	}
+	return p.Handle(ctx, ev), nil
+}
+
+// Processor turns a batch of stream records into per-player trend refreshes.
+type Processor struct {
+	Refresher *refresh.Refresher
+	Debug     bool
+}
+
+// Handle refreshes every changed player once and returns the records to retry.
+func (p *Processor) Handle(ctx context.Context, ev events.DynamoDBEvent) events.DynamoDBEventResponse {
+	changes := ChangedPlayers(ev.Records)
+	var resp events.DynamoDBEventResponse
+	updated, missing := 0, 0
+	for _, c := range changes {
+		n, err := p.Refresher.RefreshPlayerAnyTeam(ctx, c.Season, c.Team, c.PlayerID)
+		if err != nil {
+			log.Printf("stream: refresh %s %s failed: %v", c.Season, c.PlayerID, err)
+			resp.BatchItemFailures = append(resp.BatchItemFailures, events.DynamoDBBatchItemFailure{ItemIdentifier: c.SequenceNumber})
+			continue
+		}
+		if n == 0 {
+			missing++
+			if p.Debug {
+				log.Printf("stream: %s %s has no players-table item yet; skipped", c.Season, c.PlayerID)
+			}
+		}
+		updated += n
+	}
+	log.Printf("OK stream: records=%d players=%d updated=%d missing=%d failed=%d",
+		len(ev.Records), len(changes), updated, missing, len(resp.BatchItemFailures))
+	return resp
+}
+
+// PlayerChange is one player-season whose snap rows changed in a batch.
+type PlayerChange struct {
+	Season   string
+	Team     string // team on the snap row; a hint for the players-table partition
+	PlayerID string
+	// SequenceNumber is the earliest record in the batch for this player, reported
+	// back to Lambda when the refresh fails.
+	SequenceNumber string
+}
+
+// ChangedPlayers collapses stream records to distinct (Season, PlayerID) pairs in
+// first-seen order. MODIFY records that leave Week and DefSnapPct untouched are
+// ignored since they cannot change trends.
+func ChangedPlayers(records []events.DynamoDBEventRecord) []PlayerChange {
+	type key struct{ season, pid string }
+	seen := map[key]struct{}{}
+	var out []PlayerChange
+	for _, rec := range records {
+		img := rec.Change.NewImage
+		switch events.DynamoDBOperationType(rec.EventName) {
+		case events.DynamoDBOperationTypeRemove:
+			img = rec.Change.OldImage
+		case events.DynamoDBOperationTypeModify:
+			old := rec.Change.OldImage
+			if old != nil && attrStr(old, "DefSnapPct") == attrStr(img, "DefSnapPct") &&
+				attrStr(old, "Week") == attrStr(img, "Week") {
+				continue
+			}
+		}
+		if img == nil {
+			img = rec.Change.Keys
+		}
+		season, team, pid := attrStr(img, "Season"), attrStr(img, "Team"), attrStr(img, "PlayerID")
+		if season == "" || team == "" {
+			// Keys-only images: SeasonTeamWeek = "2024#SEA#01"
+			if parts := strings.Split(attrStr(img, "SeasonTeamWeek"), "#"); len(parts) == 3 {
+				season, team = parts[0], parts[1]
+			}
+		}
+		if season == "" || pid == "" {
+			continue
+		}
+		k := key{season, pid}
+		if _, ok := seen[k]; ok {
+			continue
+		}
+		seen[k] = struct{}{}
+		out = append(out, PlayerChange{
+			Season:         season,
+			Team:           team,
+			PlayerID:       pid,
+			SequenceNumber: rec.Change.SequenceNumber,
+		})
+	}
+	return out
+}
+
+func attrStr(img map[string]events.DynamoDBAttributeValue, k string) string {
+	v, ok := img[k]
+	if !ok {
+		return ""
+	}
+	switch v.DataType() {
+	case events.DataTypeString:
+		return v.String()
+	case events.DataTypeNumber:
+		return v.Number()
+	}
+	return ""
+}
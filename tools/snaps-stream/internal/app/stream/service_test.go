@@ -0,0 +1,146 @@
+package stream
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	ddb "github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"github.com/tyler180/fantasy-football-backends/internal/pfr"
+	"github.com/tyler180/fantasy-football-backends/internal/refresh"
+	"github.com/tyler180/fantasy-football-backends/internal/store"
+	"github.com/tyler180/fantasy-football-backends/internal/streamsim"
+)
+
+// fakeDDB serves the PlayerGames and PlayerIDIndex GSIs from in-memory rows and
+// records trend updates keyed by SeasonTeam|PlayerID.
+type fakeDDB struct {
+	snaps   []pfr.SnapGameRow
+	players map[string]string // PlayerID -> Team (season 2024)
+	updates map[string]string // SeasonTeam|PlayerID -> DefSnapPctLast
+	failPID string
+}
+
+func (f *fakeDDB) Query(ctx context.Context, in *ddb.QueryInput, _ ...func(*ddb.Options)) (*ddb.QueryOutput, error) {
+	pid := in.ExpressionAttributeValues[":pid"].(*types.AttributeValueMemberS).Value
+	if pid == f.failPID {
+		return nil, errors.New("throttled")
+	}
+	out := &ddb.QueryOutput{}
+	switch aws.ToString(in.IndexName) {
+	case "PlayerGames":
+		for _, r := range f.snaps {
+			if r.PlayerID == pid {
+				out.Items = append(out.Items, store.SnapItem(r))
+			}
+		}
+	case "PlayerIDIndex":
+		if tm, ok := f.players[pid]; ok {
+			out.Items = append(out.Items, map[string]types.AttributeValue{
+				"PlayerID": &types.AttributeValueMemberS{Value: pid},
+				"Team":     &types.AttributeValueMemberS{Value: tm},
+			})
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeDDB) UpdateItem(ctx context.Context, in *ddb.UpdateItemInput, _ ...func(*ddb.Options)) (*ddb.UpdateItemOutput, error) {
+	st := in.Key["SeasonTeam"].(*types.AttributeValueMemberS).Value
+	pid := in.Key["PlayerID"].(*types.AttributeValueMemberS).Value
+	if tm, ok := f.players[pid]; !ok || "2024#"+tm != st {
+		return nil, &types.ConditionalCheckFailedException{Message: aws.String("missing")}
+	}
+	f.updates[st+"|"+pid] = in.ExpressionAttributeValues[":l"].(*types.AttributeValueMemberN).Value
+	return &ddb.UpdateItemOutput{}, nil
+}
+
+func (f *fakeDDB) BatchWriteItem(ctx context.Context, in *ddb.BatchWriteItemInput, _ ...func(*ddb.Options)) (*ddb.BatchWriteItemOutput, error) {
+	return nil, fmt.Errorf("not implemented")
+}
+
+func row(pid, team string, week int, pct float64) pfr.SnapGameRow {
+	return pfr.SnapGameRow{Season: "2024", Team: team, Week: week, PlayerID: pid, Player: pid, Pos: "LB", DefSnapPct: pct}
+}
+
+func newProcessor(f *fakeDDB) *Processor {
+	return &Processor{Refresher: &refresh.Refresher{DDB: f, SnapTable: "snaps", PlayersTable: "players"}}
+}
+
+func TestHandle_RecomputesOnlyChangedPlayers(t *testing.T) {
+	sim := streamsim.New()
+	// Weeks 1-2 were ingested earlier; their records are not part of this batch.
+	sim.Put(row("AaaaAa00", "SEA", 1, 40), row("AaaaAa00", "SEA", 2, 55), row("BbbbBb00", "SEA", 1, 90))
+
+	batch := streamsim.Event(
+		sim.Put(row("AaaaAa00", "SEA", 3, 80)),
+		sim.Put(row("BbbbBb00", "SEA", 1, 90)), // MODIFY with identical pct: ignored
+		sim.Put(row("CcccCc00", "TAM", 3, 35)), // traded: players table lists him under SEA
+		sim.Put(row("DdddDd00", "SEA", 3, 10)), // not materialized yet
+	)
+
+	f := &fakeDDB{
+		snaps:   sim.Rows(),
+		players: map[string]string{"AaaaAa00": "SEA", "BbbbBb00": "SEA", "CcccCc00": "SEA"},
+		updates: map[string]string{},
+	}
+	resp := newProcessor(f).Handle(context.Background(), batch)
+
+	if len(resp.BatchItemFailures) != 0 {
+		t.Fatalf("unexpected failures: %+v", resp.BatchItemFailures)
+	}
+	want := map[string]string{
+		"2024#SEA|AaaaAa00": "80.0",
+		"2024#SEA|CcccCc00": "35.0",
+	}
+	if len(f.updates) != len(want) {
+		t.Fatalf("updates = %v, want %v", f.updates, want)
+	}
+	for k, v := range want {
+		if f.updates[k] != v {
+			t.Errorf("update %s = %q, want %q", k, f.updates[k], v)
+		}
+	}
+}
+
+func TestHandle_RemoveTriggersRecompute(t *testing.T) {
+	sim := streamsim.New()
+	sim.Put(row("AaaaAa00", "SEA", 1, 40), row("AaaaAa00", "SEA", 2, 55))
+	batch := streamsim.Event(sim.Delete(row("AaaaAa00", "SEA", 2, 55)))
+
+	f := &fakeDDB{snaps: sim.Rows(), players: map[string]string{"AaaaAa00": "SEA"}, updates: map[string]string{}}
+	newProcessor(f).Handle(context.Background(), batch)
+
+	if got := f.updates["2024#SEA|AaaaAa00"]; got != "40.0" {
+		t.Fatalf("last after delete = %q, want 40.0", got)
+	}
+}
+
+func TestHandle_ReportsEarliestRecordOfFailedPlayer(t *testing.T) {
+	sim := streamsim.New()
+	first := sim.Put(row("AaaaAa00", "SEA", 1, 40))
+	batch := streamsim.Event(first, sim.Put(row("AaaaAa00", "SEA", 2, 60)), sim.Put(row("BbbbBb00", "SEA", 1, 70)))
+
+	f := &fakeDDB{
+		snaps:   sim.Rows(),
+		players: map[string]string{"AaaaAa00": "SEA", "BbbbBb00": "SEA"},
+		updates: map[string]string{},
+		failPID: "AaaaAa00",
+	}
+	resp := newProcessor(f).Handle(context.Background(), batch)
+
+	if len(resp.BatchItemFailures) != 1 {
+		t.Fatalf("failures = %+v, want 1", resp.BatchItemFailures)
+	}
+	if got, want := resp.BatchItemFailures[0].ItemIdentifier, first[0].Change.SequenceNumber; got != want {
+		t.Errorf("failure seq = %s, want %s", got, want)
+	}
+	if !strings.HasPrefix(f.updates["2024#SEA|BbbbBb00"], "70") {
+		t.Errorf("other players should still refresh; updates=%v", f.updates)
+	}
+}
@@ -0,0 +1,14 @@
+package main
+
+import (
+	"log"
+
+	"github.com/aws/aws-lambda-go/lambda"
+
+	appreport "github.com/tyler180/fantasy-football-backends/tools/waiver-report/internal/app/report"
+)
+
+func main() {
+	log.SetFlags(0)
+	lambda.Start(appreport.LambdaEntrypoint)
+}
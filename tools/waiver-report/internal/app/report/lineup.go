@@ -0,0 +1,127 @@
+package report
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+
+	"github.com/tyler180/fantasy-football-backends/internal/lineup"
+	"github.com/tyler180/fantasy-football-backends/internal/mfl"
+	"github.com/tyler180/fantasy-football-backends/internal/projection"
+	"github.com/tyler180/fantasy-football-backends/internal/snaps"
+	"github.com/tyler180/fantasy-football-backends/internal/store"
+)
+
+// runLineup recommends this week's IDP starters for our franchise (lineup.json /
+// lineup.md under LINEUP_PREFIX). Projections come from PROJECTIONS_TABLE_NAME for the
+// week when set, else from the stored fantasy points per game scaled by snap-share
+// trend; byes come from the nflverse schedule and injury
+// statuses from MFL's injury report. LINEUP_IDP_ONLY=false optimizes every slot;
+// LINEUP_TOTAL overrides the derived IDP starter count.
+func runLineup(ctx context.Context, e Event) (string, error) {
+	awsCfg, err := config.LoadDefaultConfig(ctx)
+	if err != nil {
+		return "", fmt.Errorf("aws config: %w", err)
+	}
+	ddb := dynamodb.NewFromConfig(awsCfg)
+	cfg, err := mfl.LoadConfig(ctx, secretsmanager.NewFromConfig(awsCfg))
+	if err != nil {
+		return "", err
+	}
+	if e.FranchiseID == "" {
+		e.FranchiseID = cfg.FranchiseID
+	}
+	client := mfl.New(cfg)
+
+	league, err := client.League(ctx)
+	if err != nil {
+		return "", fmt.Errorf("league: %w", err)
+	}
+	rules := lineup.RulesFromMFL(league, envStr("LINEUP_IDP_ONLY", "true") != "false")
+	rules.Total = envInt("LINEUP_TOTAL", rules.Total)
+
+	trends, err := store.LoadSeasonTrends(ctx, ddb, envStr("TABLE_NAME", "defensive_players_by_team"), e.Season)
+	if err != nil {
+		return "", err
+	}
+	roster, err := ourRoster(ctx, client, ddb, e.FranchiseID, trends)
+	if err != nil {
+		return "", err
+	}
+	injuries, err := client.Injuries(ctx, e.Week)
+	if err != nil {
+		// The lineup is still useful without injuries; say so rather than fail.
+		log.Printf("lineup: WARN injuries: %v", err)
+	}
+	var projs map[string]projection.Projection
+	if table := envStr("PROJECTIONS_TABLE_NAME", ""); table != "" && e.Week > 0 {
+		if projs, err = store.LoadProjections(ctx, ddb, table, e.Season, e.Week); err != nil {
+			log.Printf("lineup: WARN projections: %v", err)
+		}
+	}
+	var byes map[string]int
+	if e.Week > 0 {
+		season, _ := strconv.Atoi(e.Season)
+		if byes, err = snaps.FetchNflverseByeWeeks(ctx, season, envStr("SCHEDULE_URL", "")); err != nil {
+			log.Printf("lineup: WARN bye weeks: %v", err)
+		}
+	}
+
+	opt := lineup.DefaultOptions()
+	opt.Week = e.Week
+	players := make([]lineup.Player, 0, len(roster))
+	for _, r := range roster {
+		if r.Status == "TAXI_SQUAD" {
+			continue // taxi players cannot start
+		}
+		p := lineup.Player{
+			ID: r.PlayerID, Name: r.Name, Position: r.Position,
+			ByeWeek: byes[mfl.NflverseTeam(r.Team)], Injury: injuries[r.PlayerID].Status,
+		}
+		if r.Status == "INJURED_RESERVE" {
+			p.Injury = "IR"
+		}
+		if pr, ok := projs[r.PFRID]; ok && r.PFRID != "" {
+			p.Projection = pr.Points
+			// The projection already discounts a doubtful or questionable tag; only
+			// let the lineup rule players out.
+			if pr.Injury != "" && opt.InjuryFactors[strings.ToUpper(p.Injury)] != 0 {
+				p.Injury = ""
+			}
+		} else if t, ok := trends[r.PFRID]; ok && r.PFRID != "" {
+			p.Projection = lineup.Project(t.FantasyPPG, t.EWMA, t.Avg)
+		}
+		players = append(players, p)
+	}
+
+	franchise := e.FranchiseID
+	for _, f := range league.Franchises {
+		if f.ID == e.FranchiseID && f.Name != "" {
+			franchise = f.Name
+		}
+	}
+	rep := lineup.Report{
+		League: league.Name, Franchise: franchise, Season: e.Season, Week: e.Week,
+		GeneratedAt: time.Now().UTC(), Rules: rules,
+		Result: lineup.Optimize(players, rules, opt),
+	}
+
+	js, err := json.MarshalIndent(rep, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	where, err := writeOutputs(ctx, awsCfg, envStr("LINEUP_PREFIX", "reports/lineup"), "lineup", e.Season, e.Week, js, []byte(rep.Markdown()))
+	if err != nil {
+		return "", err
+	}
+	log.Printf("OK lineup: %d starters, expected %.2f, unfilled=%d -> %s", len(rep.Starters), rep.Total, len(rep.Unfilled), strings.Join(where, ", "))
+	return fmt.Sprintf("starters=%d expected=%.2f", len(rep.Starters), rep.Total), nil
+}
@@ -0,0 +1,244 @@
+package report
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+
+	"github.com/tyler180/fantasy-football-backends/internal/crosswalk"
+	"github.com/tyler180/fantasy-football-backends/internal/mfl"
+	"github.com/tyler180/fantasy-football-backends/internal/reportout"
+	"github.com/tyler180/fantasy-football-backends/internal/starters"
+	"github.com/tyler180/fantasy-football-backends/internal/store"
+	"github.com/tyler180/fantasy-football-backends/internal/waiver"
+)
+
+// Event is the Lambda payload; every field falls back to env.
+type Event struct {
+	Mode        string   `json:"mode"`         // "waiver" (default), "lineup" or "trade"
+	Season      string   `json:"season"`       // env SEASON
+	Week        int      `json:"week"`         // upcoming week for the bye check (env WEEK)
+	FranchiseID string   `json:"franchise_id"` // defaults to the secret's franchise_id
+	Top         int      `json:"top"`          // env WAIVER_TOP
+	Give        []string `json:"give"`         // trade: MFL ids we send
+	Get         []string `json:"get"`          // trade: MFL ids we receive
+	LastWeek    int      `json:"last_week"`    // trade: last week to value (env LAST_WEEK, default 17)
+}
+
+func envStr(k, def string) string {
+	v := strings.TrimSpace(os.Getenv(k))
+	if v == "" {
+		return def
+	}
+	return v
+}
+
+func envInt(k string, def int) int {
+	if v, err := strconv.Atoi(strings.TrimSpace(os.Getenv(k))); err == nil {
+		return v
+	}
+	return def
+}
+
+// LambdaEntrypoint dispatches on mode. Both reports write <name>.json and <name>.md
+// under REPORT_BUCKET/<prefix>/<season>/week<NN>/ (and REPORT_DIR when set, for
+// local runs).
+func LambdaEntrypoint(ctx context.Context, raw json.RawMessage) (string, error) {
+	var e Event
+	if len(bytes.TrimSpace(raw)) > 0 {
+		if err := json.Unmarshal(raw, &e); err != nil {
+			return "", fmt.Errorf("decode event: %w", err)
+		}
+	}
+	if e.Season == "" {
+		e.Season = envStr("SEASON", "2024")
+	}
+	if e.Week == 0 {
+		e.Week = envInt("WEEK", 0)
+	}
+	switch strings.ToLower(strings.TrimSpace(e.Mode)) {
+	case "", "waiver":
+		return runWaiver(ctx, e)
+	case "lineup":
+		return runLineup(ctx, e)
+	case "trade":
+		return runTrade(ctx, e)
+	default:
+		return "", fmt.Errorf("unknown mode %q", e.Mode)
+	}
+}
+
+// runWaiver builds the weekly waiver-wire report (report.json / report.md under
+// REPORT_PREFIX).
+func runWaiver(ctx context.Context, e Event) (string, error) {
+	opt := waiver.DefaultOptions()
+	opt.Week = e.Week
+	opt.Top = envInt("WAIVER_TOP", opt.Top)
+	if e.Top > 0 {
+		opt.Top = e.Top
+	}
+
+	awsCfg, err := config.LoadDefaultConfig(ctx)
+	if err != nil {
+		return "", fmt.Errorf("aws config: %w", err)
+	}
+	ddb := dynamodb.NewFromConfig(awsCfg)
+	cfg, err := mfl.LoadConfig(ctx, secretsmanager.NewFromConfig(awsCfg))
+	if err != nil {
+		return "", err
+	}
+	if e.FranchiseID == "" {
+		e.FranchiseID = cfg.FranchiseID
+	}
+	client := mfl.New(cfg)
+
+	league, err := client.League(ctx)
+	if err != nil {
+		return "", fmt.Errorf("league: %w", err)
+	}
+	trends, err := store.LoadSeasonTrends(ctx, ddb, envStr("TABLE_NAME", "defensive_players_by_team"), e.Season)
+	if err != nil {
+		return "", err
+	}
+	faRows, err := store.LoadFreeAgents(ctx, ddb, envStr("FREE_AGENTS_TABLE_NAME", "mfl_free_agents"))
+	if err != nil {
+		return "", err
+	}
+	pool := make([]waiver.Candidate, 0, len(faRows))
+	for _, fa := range faRows {
+		pool = append(pool, candidate(fa.PlayerID, fa.Name, fa.Position, fa.Team, fa.PFRID, fa.ByeWeek, trends))
+	}
+
+	rostered, err := ourRoster(ctx, client, ddb, e.FranchiseID, trends)
+	if err != nil {
+		return "", err
+	}
+	roster := make([]waiver.Candidate, 0, len(rostered))
+	for _, r := range rostered {
+		roster = append(roster, r.Candidate)
+	}
+
+	franchise := e.FranchiseID
+	for _, f := range league.Franchises {
+		if f.ID == e.FranchiseID && f.Name != "" {
+			franchise = f.Name
+		}
+	}
+	rep := waiver.NewReport(league.Name, franchise, e.Season, pool, roster, waiver.SlotsByGroup(league.Starters), opt, time.Now())
+
+	js, err := json.MarshalIndent(rep, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	md := []byte(rep.Markdown())
+	where, err := writeOutputs(ctx, awsCfg, envStr("REPORT_PREFIX", "reports/waiver"), "report", e.Season, e.Week, js, md)
+	if err != nil {
+		return "", err
+	}
+	log.Printf("OK waiver-report: %d recommendations from %d free agents -> %s", len(rep.Recommendations), len(pool), strings.Join(where, ", "))
+	return fmt.Sprintf("recommendations=%d pool=%d", len(rep.Recommendations), len(pool)), nil
+}
+
+// candidate joins one player with the players-table trends by PFR id.
+func candidate(mflID, name, pos, team, pfrID string, bye int, trends map[string]store.PlayerTrends) waiver.Candidate {
+	pos = strings.ToUpper(pos)
+	c := waiver.Candidate{
+		PlayerID: mflID, PFRID: pfrID, Name: name, Position: pos, Group: starters.GroupOf(pos),
+		Team: team, ByeWeek: bye, WeeksSince50: -1,
+	}
+	if t, ok := trends[pfrID]; ok && pfrID != "" {
+		c.HasTrends = true
+		c.Last, c.Slope3, c.Change3, c.EWMA = t.Last, t.Slope3, t.Change3, t.EWMA
+		c.WeeksSince50, c.FantasyPPG = t.WeeksSince50, t.FantasyPPG
+	}
+	return c
+}
+
+// rostered is one of our players with their MFL roster status (ROSTER, TAXI_SQUAD,
+// INJURED_RESERVE).
+type rostered struct {
+	waiver.Candidate
+	Status string
+}
+
+// ourRoster returns our franchise's players joined to trends (see lookupPlayers).
+func ourRoster(ctx context.Context, client *mfl.Client, ddb *dynamodb.Client, franchiseID string, trends map[string]store.PlayerTrends) ([]rostered, error) {
+	if franchiseID == "" {
+		return nil, fmt.Errorf("franchise_id is required (event or mfl secret)")
+	}
+	rosters, err := client.Rosters(ctx, franchiseID)
+	if err != nil {
+		return nil, fmt.Errorf("rosters: %w", err)
+	}
+	var ids []string
+	status := map[string]string{}
+	for _, r := range rosters {
+		if r.FranchiseID != franchiseID {
+			continue
+		}
+		for _, p := range r.Players {
+			ids = append(ids, p.ID)
+			status[p.ID] = p.Status
+		}
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	cands, err := lookupPlayers(ctx, client, ddb, ids, trends)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]rostered, 0, len(cands))
+	for _, c := range cands {
+		out = append(out, rostered{Candidate: c, Status: status[c.PlayerID]})
+	}
+	return out, nil
+}
+
+// lookupPlayers fetches MFL player records for ids and joins them to trends through
+// the MFL -> PFR crosswalk maintained by the mfl-free-agents Lambda.
+func lookupPlayers(ctx context.Context, client *mfl.Client, ddb *dynamodb.Client, ids []string, trends map[string]store.PlayerTrends) ([]waiver.Candidate, error) {
+	players, err := client.Players(ctx, ids...)
+	if err != nil {
+		return nil, fmt.Errorf("players: %w", err)
+	}
+	entries, err := store.LoadCrosswalk(ctx, ddb, envStr("CROSSWALK_TABLE_NAME", "mfl_pfr_crosswalk"))
+	if err != nil {
+		return nil, err
+	}
+	pfrIDs := crosswalk.Map(entries)
+	out := make([]waiver.Candidate, 0, len(players))
+	for _, p := range players {
+		if pfrIDs[p.ID] == "" && starters.GroupOf(p.Position) != "" {
+			log.Printf("waiver-report: WARN defender %s (%s) has no crosswalk match", p.DisplayName(), p.ID)
+		}
+		out = append(out, candidate(p.ID, p.DisplayName(), p.Position, p.Team, pfrIDs[p.ID], 0, trends))
+	}
+	return out, nil
+}
+
+// writeOutputs writes a report under <prefix>/<season>/weekNN (or latest) to
+// REPORT_BUCKET and/or REPORT_DIR (see reportout).
+func writeOutputs(ctx context.Context, awsCfg aws.Config, prefix, name, season string, week int, js, md []byte) ([]string, error) {
+	sub := season + "/latest"
+	if week > 0 {
+		sub = fmt.Sprintf("%s/week%02d", season, week)
+	}
+	w := reportout.Writer{Bucket: envStr("REPORT_BUCKET", ""), Dir: envStr("REPORT_DIR", "")}
+	if w.Bucket != "" {
+		w.S3 = s3.NewFromConfig(awsCfg)
+	}
+	return w.Write(ctx, prefix, sub, name, js, md)
+}
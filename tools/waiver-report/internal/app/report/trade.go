@@ -0,0 +1,219 @@
+package report
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+
+	"github.com/tyler180/fantasy-football-backends/internal/lineup"
+	"github.com/tyler180/fantasy-football-backends/internal/mfl"
+	"github.com/tyler180/fantasy-football-backends/internal/projection"
+	"github.com/tyler180/fantasy-football-backends/internal/snaps"
+	"github.com/tyler180/fantasy-football-backends/internal/starters"
+	"github.com/tyler180/fantasy-football-backends/internal/store"
+	"github.com/tyler180/fantasy-football-backends/internal/trade"
+	"github.com/tyler180/fantasy-football-backends/internal/waiver"
+)
+
+// runTrade values a proposed trade (give/get MFL ids) by our rest-of-season starting
+// points and writes trade-<give>-for-<get>.json/.md under TRADE_PREFIX. Per-game
+// projections are the stored projection model at a league-average opponent when
+// PROJECTIONS_TABLE_NAME has the week, else fantasy points per game scaled by
+// snap-share trend. Matchups and byes come from nflverse team stats and schedule;
+// replacement level is the best free agent at each position group.
+func runTrade(ctx context.Context, e Event) (string, error) {
+	if len(e.Give) == 0 && len(e.Get) == 0 {
+		return "", fmt.Errorf("trade needs give and/or get player ids")
+	}
+	season, err := strconv.Atoi(e.Season)
+	if err != nil {
+		return "", fmt.Errorf("season %q: %w", e.Season, err)
+	}
+
+	awsCfg, err := config.LoadDefaultConfig(ctx)
+	if err != nil {
+		return "", fmt.Errorf("aws config: %w", err)
+	}
+	ddb := dynamodb.NewFromConfig(awsCfg)
+	cfg, err := mfl.LoadConfig(ctx, secretsmanager.NewFromConfig(awsCfg))
+	if err != nil {
+		return "", err
+	}
+	if e.FranchiseID == "" {
+		e.FranchiseID = cfg.FranchiseID
+	}
+	client := mfl.New(cfg)
+	league, err := client.League(ctx)
+	if err != nil {
+		return "", fmt.Errorf("league: %w", err)
+	}
+	rules := lineup.RulesFromMFL(league, envStr("LINEUP_IDP_ONLY", "true") != "false")
+	rules.Total = envInt("LINEUP_TOTAL", rules.Total)
+
+	// League context: team offense so far and the schedule, in nflverse codes.
+	offense, err := snaps.FetchNflverseTeamOffense(ctx, season, envStr("DEF_STATS_URL", ""))
+	if err != nil {
+		return "", fmt.Errorf("team offense: %w", err)
+	}
+	env := projection.NewEnv(offense)
+	lastPlayed := 0
+	for _, g := range offense {
+		lastPlayed = max(lastPlayed, g.Week)
+	}
+	sched, err := snaps.FetchNflverseSchedule(ctx, season, envStr("SCHEDULE_URL", ""))
+	if err != nil {
+		return "", fmt.Errorf("schedule: %w", err)
+	}
+	for _, g := range sched {
+		env.AddGame(g.Week, g.Home, g.Away)
+	}
+	if e.Week == 0 {
+		e.Week = lastPlayed + 1
+	}
+	if e.LastWeek == 0 {
+		e.LastWeek = envInt("LAST_WEEK", 17)
+	}
+	var weeks []int
+	for w := e.Week; w <= e.LastWeek; w++ {
+		weeks = append(weeks, w)
+	}
+
+	trends, err := store.LoadSeasonTrends(ctx, ddb, envStr("TABLE_NAME", "defensive_players_by_team"), e.Season)
+	if err != nil {
+		return "", err
+	}
+	var projs map[string]projection.Projection
+	if table := envStr("PROJECTIONS_TABLE_NAME", ""); table != "" {
+		if projs, err = store.LoadProjections(ctx, ddb, table, e.Season, e.Week); err != nil {
+			log.Printf("trade: WARN projections: %v", err)
+		}
+	}
+	leaguePlays, _ := env.League(e.Week)
+	perGame := func(c waiver.Candidate) float64 {
+		if pr, ok := projs[c.PFRID]; ok && c.PFRID != "" {
+			return pr.PointsPerSnap * pr.SnapPct / 100 * leaguePlays
+		}
+		if t, ok := trends[c.PFRID]; ok && c.PFRID != "" {
+			return lineup.Project(t.FantasyPPG, t.EWMA, t.Avg)
+		}
+		return 0
+	}
+	injuries, err := client.Injuries(ctx, e.Week)
+	if err != nil {
+		log.Printf("trade: WARN injuries: %v", err)
+	}
+	toPlayer := func(c waiver.Candidate, status string) trade.Player {
+		p := trade.Player{
+			ID: c.PlayerID, Name: c.Name, Position: c.Position, Team: mfl.NflverseTeam(c.Team),
+			PerGame: perGame(c), ByeWeek: c.ByeWeek, Injury: injuries[c.PlayerID].Status,
+		}
+		if status == "INJURED_RESERVE" {
+			p.Injury = "IR"
+		}
+		return p
+	}
+
+	rostered, err := ourRoster(ctx, client, ddb, e.FranchiseID, trends)
+	if err != nil {
+		return "", err
+	}
+	in := trade.Input{Give: e.Give, Weeks: weeks, Rules: rules, Replacement: map[string]float64{}}
+	for _, r := range rostered {
+		if r.Status == "TAXI_SQUAD" {
+			continue
+		}
+		in.Roster = append(in.Roster, toPlayer(r.Candidate, r.Status))
+	}
+	if len(e.Get) > 0 {
+		incoming, err := lookupPlayers(ctx, client, ddb, e.Get, trends)
+		if err != nil {
+			return "", err
+		}
+		if missing := unresolved(e.Get, incoming); len(missing) > 0 {
+			return "", fmt.Errorf("get players not found in MFL: %s", strings.Join(missing, ","))
+		}
+		for _, c := range incoming {
+			in.Get = append(in.Get, toPlayer(c, ""))
+		}
+	}
+	in.Matchup = func(p trade.Player, week int) float64 {
+		return projection.Matchup(env, p.Team, week, e.Week, starters.GroupOf(p.Position), projection.Options{})
+	}
+
+	faRows, err := store.LoadFreeAgents(ctx, ddb, envStr("FREE_AGENTS_TABLE_NAME", "mfl_free_agents"))
+	if err != nil {
+		return "", err
+	}
+	for _, fa := range faRows {
+		c := candidate(fa.PlayerID, fa.Name, fa.Position, fa.Team, fa.PFRID, fa.ByeWeek, trends)
+		if g := c.Group; g != "" {
+			in.Replacement[g] = max(in.Replacement[g], perGame(c))
+		}
+	}
+
+	res, err := trade.Evaluate(in, trade.Options{})
+	if err != nil {
+		return "", err
+	}
+	franchise := e.FranchiseID
+	for _, f := range league.Franchises {
+		if f.ID == e.FranchiseID && f.Name != "" {
+			franchise = f.Name
+		}
+	}
+	rep := trade.Report{League: league.Name, Franchise: franchise, Season: e.Season, Weeks: weeks, GeneratedAt: time.Now().UTC(), Result: res}
+
+	js, err := json.MarshalIndent(rep, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	name := tradeReportName(e.Give, e.Get)
+	where, err := writeOutputs(ctx, awsCfg, envStr("TRADE_PREFIX", "reports/trade"), name, e.Season, e.Week, js, []byte(rep.Markdown()))
+	if err != nil {
+		return "", err
+	}
+	log.Printf("OK trade: %s %+.2f rest-of-season starting points (weeks %d-%d) -> %s", res.Verdict, res.Delta, e.Week, e.LastWeek, strings.Join(where, ", "))
+	return fmt.Sprintf("verdict=%s delta=%.2f", res.Verdict, res.Delta), nil
+}
+
+// unresolved returns the ids with no player in cands, in request order.
+func unresolved(ids []string, cands []waiver.Candidate) []string {
+	have := map[string]bool{}
+	for _, c := range cands {
+		have[c.PlayerID] = true
+	}
+	var out []string
+	for _, id := range ids {
+		if !have[id] {
+			out = append(out, id)
+		}
+	}
+	return out
+}
+
+// tradeReportName is trade-<give>-for-<get> with each id reduced to [A-Za-z0-9_-],
+// since the name becomes an S3 key and a file path.
+func tradeReportName(give, get []string) string {
+	clean := func(ids []string) string {
+		out := make([]string, len(ids))
+		for i, id := range ids {
+			out[i] = strings.Map(func(r rune) rune {
+				switch {
+				case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_', r == '-':
+					return r
+				}
+				return -1
+			}, id)
+		}
+		return strings.Join(out, "_")
+	}
+	return "trade-" + clean(give) + "-for-" + clean(get)
+}
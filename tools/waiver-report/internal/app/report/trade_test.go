@@ -0,0 +1,25 @@
+package report
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/tyler180/fantasy-football-backends/internal/waiver"
+)
+
+func TestTradeReportName(t *testing.T) {
+	got := tradeReportName([]string{"13130", "../../etc"}, []string{"15 2/1"})
+	if got != "trade-13130_etc-for-1521" {
+		t.Fatalf("name = %q", got)
+	}
+}
+
+func TestUnresolved(t *testing.T) {
+	cands := []waiver.Candidate{{PlayerID: "13130"}}
+	if got := unresolved([]string{"13130", "99999", "88888"}, cands); !reflect.DeepEqual(got, []string{"99999", "88888"}) {
+		t.Fatalf("unresolved = %v", got)
+	}
+	if got := unresolved([]string{"13130"}, cands); got != nil {
+		t.Fatalf("unresolved = %v", got)
+	}
+}